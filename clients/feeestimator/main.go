@@ -0,0 +1,288 @@
+// Package feeestimator estimates the fees to bid for transactions, from the
+// fees of recent ledgers reported by Horizon or Stellar-RPC.
+//
+// An Estimator bids a percentile of the recent fees depending on the urgency
+// of a transaction, bids one urgency level higher when the network is
+// surging, and caps its estimates. Estimates can be applied to
+// txnbuild.TransactionParams, and used to wrap a transaction, or replace a
+// pending fee bump transaction, with a fee bump transaction bidding more.
+package feeestimator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/stellar/go/txnbuild"
+)
+
+// Urgency is how soon a transaction needs to be included in a ledger.
+type Urgency int
+
+const (
+	// Low bids the 30th percentile of the recent fees, for transactions which
+	// can wait for a quiet ledger.
+	Low Urgency = iota + 1
+	// Medium bids the median of the recent fees.
+	Medium
+	// High bids the 90th percentile of the recent fees.
+	High
+	// Critical bids the 99th percentile of the recent fees.
+	Critical
+)
+
+var percentiles = map[Urgency]int{
+	Low:      30,
+	Medium:   50,
+	High:     90,
+	Critical: 99,
+}
+
+func (u Urgency) String() string {
+	switch u {
+	case Low:
+		return "low"
+	case Medium:
+		return "medium"
+	case High:
+		return "high"
+	case Critical:
+		return "critical"
+	default:
+		return fmt.Sprintf("Urgency(%d)", int(u))
+	}
+}
+
+// The defaults of Estimator.
+const (
+	DefaultSurgeCapacity = 0.9
+	// DefaultMaxAge is about the time between two ledgers.
+	DefaultMaxAge = 5 * time.Second
+)
+
+// ReplaceByFeeMultiplier is the factor by which a transaction must outbid a
+// transaction of the same source and sequence number pending in the queue
+// of stellar-core to replace it.
+const ReplaceByFeeMultiplier = 10
+
+// ErrFeeCapped is returned when replacing a fee bump transaction requires a
+// fee above the cap of the Estimator.
+var ErrFeeCapped = errors.New("fee required is above the cap")
+
+// Estimate is a fee estimate.
+type Estimate struct {
+	// Urgency is the urgency the fees were estimated for, one level above the
+	// urgency requested when the network surges.
+	Urgency Urgency
+	// BaseFee is the fee to bid per operation of a classic transaction, in
+	// stroops.
+	BaseFee int64
+	// SorobanInclusionFee is the inclusion fee to bid for a Soroban
+	// transaction, in stroops, on top of its resource fee.
+	SorobanInclusionFee int64
+	// Surge reports whether the network is surging.
+	Surge bool
+	// Capped reports whether a fee was lowered to its cap.
+	Capped bool
+	// LastLedger is the last ledger of the statistics used.
+	LastLedger uint32
+}
+
+// Estimator estimates fees from the fee statistics of a Source. It caches
+// the statistics for MaxAge, and is safe for concurrent use.
+type Estimator struct {
+	Source Source
+	// Urgency is the urgency of BaseFee, Medium if 0.
+	Urgency Urgency
+	// MaxBaseFee caps the base fee of classic transactions, in stroops per
+	// operation. There is no cap if 0.
+	MaxBaseFee int64
+	// MaxSorobanInclusionFee caps the inclusion fee of Soroban transactions.
+	// There is no cap if 0.
+	MaxSorobanInclusionFee int64
+	// SurgeCapacity is the usage of the capacity of recent ledgers from which
+	// the network is considered surging, DefaultSurgeCapacity if 0.
+	SurgeCapacity float64
+	// MaxAge is how long statistics are reused, DefaultMaxAge if 0. They are
+	// fetched for every estimate if negative.
+	MaxAge time.Duration
+
+	lock    sync.Mutex
+	stats   Stats
+	fetched time.Time
+	// now is replaceable for tests.
+	now func() time.Time
+}
+
+// Estimate estimates the fees of a transaction of the given urgency.
+func (e *Estimator) Estimate(ctx context.Context, urgency Urgency) (Estimate, error) {
+	if _, ok := percentiles[urgency]; !ok {
+		return Estimate{}, fmt.Errorf("invalid urgency %d", int(urgency))
+	}
+	stats, err := e.feeStats(ctx)
+	if err != nil {
+		return Estimate{}, err
+	}
+
+	estimate := Estimate{
+		Urgency:    urgency,
+		Surge:      e.surging(stats),
+		LastLedger: stats.LastLedger,
+	}
+	if estimate.Surge && urgency < Critical {
+		estimate.Urgency++
+	}
+	percentile := percentiles[estimate.Urgency]
+	minimum := max(stats.BaseFee, txnbuild.MinBaseFee)
+
+	estimate.BaseFee = max(stats.InclusionFee.Percentile(percentile), minimum)
+	// without soroban statistics, soroban transactions compete with classic
+	// ones
+	soroban := stats.SorobanInclusionFee
+	if soroban.empty() {
+		soroban = stats.InclusionFee
+	}
+	estimate.SorobanInclusionFee = max(soroban.Percentile(percentile), minimum)
+
+	if e.MaxBaseFee > 0 && estimate.BaseFee > e.MaxBaseFee {
+		estimate.BaseFee, estimate.Capped = max(e.MaxBaseFee, minimum), true
+	}
+	if e.MaxSorobanInclusionFee > 0 && estimate.SorobanInclusionFee > e.MaxSorobanInclusionFee {
+		estimate.SorobanInclusionFee, estimate.Capped = max(e.MaxSorobanInclusionFee, minimum), true
+	}
+	return estimate, nil
+}
+
+// BaseFee estimates the base fee of a classic transaction of the urgency of
+// the Estimator.
+func (e *Estimator) BaseFee(ctx context.Context) (int64, error) {
+	urgency := e.Urgency
+	if urgency == 0 {
+		urgency = Medium
+	}
+	estimate, err := e.Estimate(ctx, urgency)
+	if err != nil {
+		return 0, err
+	}
+	return estimate.BaseFee, nil
+}
+
+// Apply sets the base fee of the parameters of a transaction, to the
+// inclusion fee of a Soroban transaction if it has a Soroban operation.
+func (e *Estimator) Apply(ctx context.Context, params *txnbuild.TransactionParams, urgency Urgency) error {
+	estimate, err := e.Estimate(ctx, urgency)
+	if err != nil {
+		return err
+	}
+	params.BaseFee = estimate.BaseFee
+	if isSoroban(params.Operations) {
+		params.BaseFee = estimate.SorobanInclusionFee
+	}
+	return nil
+}
+
+// FeeBump wraps a transaction in a fee bump transaction paid by feeAccount,
+// bidding the estimated fee, or the fee of the transaction if it is higher.
+// The fee bump transaction must be signed by feeAccount.
+func (e *Estimator) FeeBump(ctx context.Context, inner *txnbuild.Transaction, feeAccount string, urgency Urgency) (*txnbuild.FeeBumpTransaction, error) {
+	baseFee, err := e.feeBumpBaseFee(ctx, inner, urgency)
+	if err != nil {
+		return nil, err
+	}
+	return txnbuild.NewFeeBumpTransaction(txnbuild.FeeBumpTransactionParams{
+		Inner:      inner,
+		FeeAccount: feeAccount,
+		BaseFee:    max(baseFee, inner.BaseFee()),
+	})
+}
+
+// Rebump returns a fee bump transaction replacing a previous one which is
+// still pending, e.g. because the network surged since it was submitted. It
+// bids the estimated fee, and at least ReplaceByFeeMultiplier times the fee
+// of the previous transaction, as stellar-core requires, and returns
+// ErrFeeCapped if this is above the cap. The fee bump transaction must be
+// signed by its fee account.
+func (e *Estimator) Rebump(ctx context.Context, previous *txnbuild.FeeBumpTransaction, urgency Urgency) (*txnbuild.FeeBumpTransaction, error) {
+	inner := previous.InnerTransaction()
+	baseFee, err := e.feeBumpBaseFee(ctx, inner, urgency)
+	if err != nil {
+		return nil, err
+	}
+	required := previous.BaseFee() * ReplaceByFeeMultiplier
+	maximum := e.MaxBaseFee
+	if isSoroban(inner.Operations()) {
+		maximum = e.MaxSorobanInclusionFee
+	}
+	if maximum > 0 && required > maximum {
+		return nil, fmt.Errorf("replacing fee bump transaction requires a base fee of %d: %w", required, ErrFeeCapped)
+	}
+	return txnbuild.NewFeeBumpTransaction(txnbuild.FeeBumpTransactionParams{
+		Inner:      inner,
+		FeeAccount: previous.FeeAccount(),
+		BaseFee:    max(baseFee, required, inner.BaseFee()),
+	})
+}
+
+func (e *Estimator) feeBumpBaseFee(ctx context.Context, inner *txnbuild.Transaction, urgency Urgency) (int64, error) {
+	estimate, err := e.Estimate(ctx, urgency)
+	if err != nil {
+		return 0, err
+	}
+	if isSoroban(inner.Operations()) {
+		return estimate.SorobanInclusionFee, nil
+	}
+	return estimate.BaseFee, nil
+}
+
+// feeStats returns the cached statistics, fetching them if they are too old.
+func (e *Estimator) feeStats(ctx context.Context) (Stats, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.now == nil {
+		e.now = time.Now
+	}
+	maxAge := e.MaxAge
+	if maxAge == 0 {
+		maxAge = DefaultMaxAge
+	}
+	if !e.fetched.IsZero() && e.now().Sub(e.fetched) < maxAge {
+		return e.stats, nil
+	}
+
+	stats, err := e.Source.FeeStats(ctx)
+	if err != nil {
+		return Stats{}, fmt.Errorf("could not fetch fee stats: %w", err)
+	}
+	e.stats, e.fetched = stats, e.now()
+	return stats, nil
+}
+
+// surging reports whether the network is surging: either the capacity of
+// recent ledgers is used up, or most transactions paid more than the base
+// fee, which only happens when ledgers are full.
+func (e *Estimator) surging(stats Stats) bool {
+	capacity := e.SurgeCapacity
+	if capacity == 0 {
+		capacity = DefaultSurgeCapacity
+	}
+	if stats.CapacityUsage >= capacity {
+		return true
+	}
+	for _, d := range []Distribution{stats.InclusionFee, stats.SorobanInclusionFee} {
+		if !d.empty() && d.P10 > stats.BaseFee {
+			return true
+		}
+	}
+	return false
+}
+
+func isSoroban(operations []txnbuild.Operation) bool {
+	for _, op := range operations {
+		if _, ok := op.(txnbuild.SorobanOperation); ok {
+			return true
+		}
+	}
+	return false
+}
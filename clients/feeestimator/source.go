@@ -0,0 +1,145 @@
+package feeestimator
+
+import (
+	"context"
+
+	"github.com/stellar/go/clients/horizonclient"
+	rpcclient "github.com/stellar/go/clients/rpcclient"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	protocol "github.com/stellar/go/protocols/rpc"
+	"github.com/stellar/go/txnbuild"
+)
+
+// Distribution is the distribution of the inclusion fees of the transactions
+// of recent ledgers, in stroops per operation.
+type Distribution struct {
+	Min, Mode, Max                                        int64
+	P10, P20, P30, P40, P50, P60, P70, P80, P90, P95, P99 int64
+}
+
+// Percentile returns a percentile of the distribution, rounded up to the
+// closest percentile known.
+func (d Distribution) Percentile(p int) int64 {
+	switch {
+	case p <= 0:
+		return d.Min
+	case p <= 10:
+		return d.P10
+	case p <= 20:
+		return d.P20
+	case p <= 30:
+		return d.P30
+	case p <= 40:
+		return d.P40
+	case p <= 50:
+		return d.P50
+	case p <= 60:
+		return d.P60
+	case p <= 70:
+		return d.P70
+	case p <= 80:
+		return d.P80
+	case p <= 90:
+		return d.P90
+	case p <= 95:
+		return d.P95
+	case p <= 99:
+		return d.P99
+	default:
+		return d.Max
+	}
+}
+
+// empty reports whether the distribution is unknown, or there were no
+// transactions.
+func (d Distribution) empty() bool {
+	return d.Max == 0
+}
+
+// Stats are the fee statistics of recent ledgers.
+type Stats struct {
+	LastLedger uint32
+	// BaseFee is the minimum base fee of the network, in stroops per
+	// operation.
+	BaseFee int64
+	// CapacityUsage is the fraction of the capacity of recent ledgers used,
+	// between 0 and 1, and negative if unknown, as Stellar-RPC does not
+	// report it.
+	CapacityUsage float64
+	// InclusionFee is the distribution of the fees of classic transactions.
+	InclusionFee Distribution
+	// SorobanInclusionFee is the distribution of the inclusion fees of
+	// Soroban transactions, empty if unknown, as Horizon does not report it.
+	SorobanInclusionFee Distribution
+}
+
+// Source fetches the fee statistics of the network.
+type Source interface {
+	FeeStats(ctx context.Context) (Stats, error)
+}
+
+// HorizonSource is a Source using the fee_stats endpoint of Horizon.
+type HorizonSource struct {
+	Client horizonclient.ContextClientInterface
+}
+
+// FeeStats returns the fees charged in the last ledgers.
+func (s HorizonSource) FeeStats(ctx context.Context) (Stats, error) {
+	stats, err := s.Client.FeeStatsCtx(ctx)
+	if err != nil {
+		return Stats{}, err
+	}
+	return fromHorizon(stats), nil
+}
+
+func fromHorizon(stats hProtocol.FeeStats) Stats {
+	d := stats.FeeCharged
+	return Stats{
+		LastLedger:    stats.LastLedger,
+		BaseFee:       stats.LastLedgerBaseFee,
+		CapacityUsage: stats.LedgerCapacityUsage,
+		InclusionFee: Distribution{
+			Min: d.Min, Mode: d.Mode, Max: d.Max,
+			P10: d.P10, P20: d.P20, P30: d.P30, P40: d.P40, P50: d.P50, P60: d.P60,
+			P70: d.P70, P80: d.P80, P90: d.P90, P95: d.P95, P99: d.P99,
+		},
+	}
+}
+
+// RPCSource is a Source using the getFeeStats method of Stellar-RPC.
+type RPCSource struct {
+	Client *rpcclient.Client
+}
+
+// FeeStats returns the inclusion fees of the last ledgers.
+func (s RPCSource) FeeStats(ctx context.Context) (Stats, error) {
+	stats, err := s.Client.GetFeeStats(ctx)
+	if err != nil {
+		return Stats{}, err
+	}
+	return fromRPC(stats), nil
+}
+
+func fromRPC(stats protocol.GetFeeStatsResponse) Stats {
+	return Stats{
+		LastLedger: stats.LatestLedger,
+		// Stellar-RPC does not report the base fee of the network, which has
+		// always been the minimum
+		BaseFee:             txnbuild.MinBaseFee,
+		CapacityUsage:       -1,
+		InclusionFee:        fromRPCDistribution(stats.InclusionFee),
+		SorobanInclusionFee: fromRPCDistribution(stats.SorobanInclusionFee),
+	}
+}
+
+func fromRPCDistribution(d protocol.FeeDistribution) Distribution {
+	if d.TransactionCount == 0 {
+		return Distribution{}
+	}
+	return Distribution{
+		Min: int64(d.Min), Mode: int64(d.Mode), Max: int64(d.Max),
+		P10: int64(d.P10), P20: int64(d.P20), P30: int64(d.P30), P40: int64(d.P40),
+		P50: int64(d.P50), P60: int64(d.P60), P70: int64(d.P70), P80: int64(d.P80),
+		P90: int64(d.P90), P95: int64(d.P95), P99: int64(d.P99),
+	}
+}
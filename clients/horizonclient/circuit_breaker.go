@@ -0,0 +1,166 @@
+package horizonclient
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned instead of sending a request while the circuit
+// breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open: horizon is failing")
+
+// CircuitState is the state of a CircuitBreaker.
+type CircuitState int
+
+const (
+	// CircuitClosed lets every request through.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects every request.
+	CircuitOpen
+	// CircuitHalfOpen lets a single trial request through, which closes the
+	// circuit if it succeeds and opens it again otherwise.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreaker stops sending requests to Horizon after consecutive
+// failures, so that callers fail fast instead of piling up requests to a
+// server which is down. A request fails if it returns a network error or a
+// 5xx response.
+type CircuitBreaker struct {
+	// OnStateChange, if set, is called when the state of the breaker changes,
+	// e.g. to record metrics or alert.
+	OnStateChange func(from, to CircuitState)
+	// OnReject, if set, is called when a request is rejected.
+	OnReject func(req *http.Request)
+
+	failureThreshold int
+	openTimeout      time.Duration
+
+	lock     sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	trial    bool
+
+	now func() time.Time
+}
+
+// NewCircuitBreaker returns a CircuitBreaker which opens after
+// failureThreshold consecutive failures and lets a trial request through
+// openTimeout after opening.
+func NewCircuitBreaker(failureThreshold int, openTimeout time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		failureThreshold: max(failureThreshold, 1),
+		openTimeout:      openTimeout,
+		now:              time.Now,
+	}
+}
+
+// State returns the current state of the breaker.
+func (b *CircuitBreaker) State() CircuitState {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.state == CircuitOpen && b.now().Sub(b.openedAt) >= b.openTimeout {
+		return CircuitHalfOpen
+	}
+	return b.state
+}
+
+// allow reports whether a request can be sent, and whether it is the trial
+// request of a half-open breaker.
+func (b *CircuitBreaker) allow() (bool, bool) {
+	b.lock.Lock()
+	from := b.state
+	if b.state == CircuitOpen && b.now().Sub(b.openedAt) >= b.openTimeout {
+		b.state = CircuitHalfOpen
+	}
+	allowed, trial := true, false
+	if b.state == CircuitHalfOpen {
+		allowed, trial = !b.trial, !b.trial
+		b.trial = true
+	} else if b.state == CircuitOpen {
+		allowed = false
+	}
+	to := b.state
+	b.lock.Unlock()
+
+	b.changed(from, to)
+	return allowed, trial
+}
+
+// record updates the breaker with the outcome of a request.
+func (b *CircuitBreaker) record(trial, failed bool) {
+	b.lock.Lock()
+	from := b.state
+	if trial {
+		b.trial = false
+	}
+	switch {
+	case !failed:
+		b.failures = 0
+		if trial {
+			b.state = CircuitClosed
+		}
+	case trial:
+		b.state, b.openedAt = CircuitOpen, b.now()
+	case b.state == CircuitClosed:
+		b.failures++
+		if b.failures >= b.failureThreshold {
+			b.state, b.openedAt, b.failures = CircuitOpen, b.now(), 0
+		}
+	}
+	to := b.state
+	b.lock.Unlock()
+
+	b.changed(from, to)
+}
+
+func (b *CircuitBreaker) changed(from, to CircuitState) {
+	if from != to && b.OnStateChange != nil {
+		b.OnStateChange(from, to)
+	}
+}
+
+// Middleware returns a middleware rejecting requests with ErrCircuitOpen
+// while the breaker is open.
+func (b *CircuitBreaker) Middleware() Middleware {
+	return func(next HTTP) HTTP {
+		return DoFunc(func(req *http.Request) (*http.Response, error) {
+			allowed, trial := b.allow()
+			if !allowed {
+				if b.OnReject != nil {
+					b.OnReject(req)
+				}
+				return nil, ErrCircuitOpen
+			}
+
+			resp, err := next.Do(req)
+			if err != nil && req.Context().Err() != nil {
+				// canceled requests say nothing about the health of horizon
+				if trial {
+					b.lock.Lock()
+					b.trial = false
+					b.lock.Unlock()
+				}
+				return resp, err
+			}
+			b.record(trial, err != nil || resp.StatusCode >= http.StatusInternalServerError)
+			return resp, err
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package horizonclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	breaker := NewCircuitBreaker(2, time.Minute)
+	breaker.now = func() time.Time { return now }
+	var changes []string
+	breaker.OnStateChange = func(from, to CircuitState) {
+		changes = append(changes, from.String()+"->"+to.String())
+	}
+	rejected := 0
+	breaker.OnReject = func(*http.Request) { rejected++ }
+
+	next := &scriptedHTTP{}
+	client := WithMiddleware(next, breaker.Middleware())
+	expect := func(responses ...func(*http.Request) (*http.Response, error)) {
+		next.responses = append(next.responses, responses...)
+	}
+
+	// a success resets the count of consecutive failures
+	expect(
+		respond(http.StatusInternalServerError, ""),
+		respond(http.StatusNotFound, ""),
+		fail(errors.New("connection refused")),
+	)
+	for i := 0; i < 3; i++ {
+		send(t, client, http.MethodGet, "https://horizon/ledgers", "")
+	}
+	assert.Equal(t, CircuitClosed, breaker.State())
+
+	expect(respond(http.StatusBadGateway, ""))
+	send(t, client, http.MethodGet, "https://horizon/ledgers", "")
+	assert.Equal(t, CircuitOpen, breaker.State())
+
+	_, err := send(t, client, http.MethodGet, "https://horizon/ledgers", "")
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, 1, rejected)
+	assert.Empty(t, next.responses)
+
+	// a failed trial opens the breaker again
+	now = now.Add(time.Minute)
+	assert.Equal(t, CircuitHalfOpen, breaker.State())
+	expect(respond(http.StatusServiceUnavailable, ""))
+	send(t, client, http.MethodGet, "https://horizon/ledgers", "")
+	assert.Equal(t, CircuitOpen, breaker.State())
+
+	// a successful trial closes it
+	now = now.Add(time.Minute)
+	expect(respond(http.StatusOK, ""))
+	resp, err := send(t, client, http.MethodGet, "https://horizon/ledgers", "")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, CircuitClosed, breaker.State())
+
+	assert.Equal(t, []string{
+		"closed->open",
+		"open->half-open",
+		"half-open->open",
+		"open->half-open",
+		"half-open->closed",
+	}, changes)
+}
+
+func TestCircuitBreakerSingleTrial(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	breaker := NewCircuitBreaker(1, time.Minute)
+	breaker.now = func() time.Time { return now }
+	breaker.record(false, true)
+	now = now.Add(time.Minute)
+
+	allowed, trial := breaker.allow()
+	assert.True(t, allowed)
+	assert.True(t, trial)
+	// other requests are rejected while the trial is in flight
+	allowed, _ = breaker.allow()
+	assert.False(t, allowed)
+}
+
+func TestCircuitBreakerIgnoresCanceledRequests(t *testing.T) {
+	breaker := NewCircuitBreaker(1, time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	next := DoFunc(func(req *http.Request) (*http.Response, error) {
+		cancel()
+		return nil, req.Context().Err()
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://horizon/ledgers", nil)
+	require.NoError(t, err)
+
+	_, err = WithMiddleware(next, breaker.Middleware()).Do(req)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, CircuitClosed, breaker.State())
+}
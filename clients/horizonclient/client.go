@@ -1,12 +1,9 @@
 package horizonclient
 
 import (
-	"bufio"
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -16,8 +13,6 @@ import (
 	"github.com/stellar/go/txnbuild"
 	"github.com/stellar/go/xdr"
 
-	"github.com/manucorporat/sse"
-
 	hProtocol "github.com/stellar/go/protocols/horizon"
 	"github.com/stellar/go/protocols/horizon/effects"
 	"github.com/stellar/go/protocols/horizon/operations"
@@ -25,18 +20,18 @@ import (
 )
 
 // sendRequest builds the URL for the given horizon request and sends the url to a horizon server
-func (c *Client) sendRequest(hr HorizonRequest, resp interface{}) (err error) {
+func (c *Client) sendRequest(ctx context.Context, hr HorizonRequest, resp interface{}) (err error) {
 	req, err := hr.HTTPRequest(c.fixHorizonURL())
 	if err != nil {
 		return err
 	}
 
-	return c.sendHTTPRequest(req, resp)
+	return c.sendHTTPRequest(ctx, req, resp)
 }
 
 // checkMemoRequired implements a memo required check as defined in
 // https://github.com/stellar/stellar-protocol/blob/master/ecosystem/sep-0029.md
-func (c *Client) checkMemoRequired(transaction *txnbuild.Transaction) error {
+func (c *Client) checkMemoRequired(ctx context.Context, transaction *txnbuild.Transaction) error {
 	destinations := map[string]bool{}
 
 	for i, op := range transaction.Operations() {
@@ -77,7 +72,7 @@ func (c *Client) checkMemoRequired(transaction *txnbuild.Transaction) error {
 			DataKey:   "config.memo_required",
 		}
 
-		data, err := c.AccountData(request)
+		data, err := c.AccountDataCtx(ctx, request)
 		if err != nil {
 			horizonError := GetError(err)
 
@@ -101,22 +96,24 @@ func (c *Client) checkMemoRequired(transaction *txnbuild.Transaction) error {
 
 // sendGetRequest sends a HTTP GET request to a horizon server.
 // It can be used for requests that do not implement the HorizonRequest interface.
-func (c *Client) sendGetRequest(requestURL string, a interface{}) error {
+func (c *Client) sendGetRequest(ctx context.Context, requestURL string, a interface{}) error {
 	req, err := http.NewRequest("GET", requestURL, nil)
 	if err != nil {
 		return errors.Wrap(err, "error creating HTTP request")
 	}
-	return c.sendHTTPRequest(req, a)
+	return c.sendHTTPRequest(ctx, req, a)
 }
 
-func (c *Client) sendHTTPRequest(req *http.Request, a interface{}) error {
+// sendHTTPRequest sends a request bound to ctx, which is cancelled after the
+// horizon timeout at the latest.
+func (c *Client) sendHTTPRequest(ctx context.Context, req *http.Request, a interface{}) error {
 	c.setClientAppHeaders(req)
 	c.setDefaultClient()
 
 	if c.horizonTimeout == 0 {
 		c.horizonTimeout = HorizonTimeout
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), c.horizonTimeout)
+	ctx, cancel := context.WithTimeout(ctx, c.horizonTimeout)
 	defer cancel()
 
 	if resp, err := c.HTTP.Do(req.WithContext(ctx)); err != nil {
@@ -126,155 +123,6 @@ func (c *Client) sendHTTPRequest(req *http.Request, a interface{}) error {
 	}
 }
 
-// stream handles connections to endpoints that support streaming on a horizon server
-func (c *Client) stream(
-	ctx context.Context,
-	streamURL string,
-	handler func(data []byte) error,
-) error {
-	su, err := url.Parse(streamURL)
-	if err != nil {
-		return errors.Wrap(err, "error parsing stream url")
-	}
-
-	query := su.Query()
-	if query.Get("cursor") == "" {
-		query.Set("cursor", "now")
-	}
-
-	for {
-		// Check if ctx is not canceled
-		select {
-		case <-ctx.Done():
-			return nil
-		default:
-			// Continue
-		}
-
-		// If err is nil, reconnect and try again.
-		if err = c.streamConnection(ctx, su, query, handler); err != nil {
-			return err
-		}
-	}
-}
-
-func (c *Client) streamConnection(
-	ctx context.Context,
-	su *url.URL,
-	query url.Values,
-	handler func(data []byte) error,
-) error {
-	// updates the url with a new cursor
-	su.RawQuery = query.Encode()
-	req, err := http.NewRequest("GET", su.String(), nil)
-	if err != nil {
-		return errors.Wrap(err, "error creating HTTP request")
-	}
-	req.Header.Set("Accept", "text/event-stream")
-	c.setDefaultClient()
-	c.setClientAppHeaders(req)
-
-	// We can use c.HTTP here because we set Timeout per request not on the client. See sendRequest()
-	resp, err := c.HTTP.Do(req)
-	if err != nil {
-		return errors.Wrap(err, "error sending HTTP request")
-	}
-	defer resp.Body.Close()
-
-	// Expected statusCode are 200-299
-	if !(resp.StatusCode >= 200 && resp.StatusCode < 300) {
-		return fmt.Errorf("got bad HTTP status code %d", resp.StatusCode)
-	}
-
-	reader := bufio.NewReader(resp.Body)
-
-	// Read events one by one. Break this loop when there is no more data to be
-	// read from resp.Body (io.EOF).
-Events:
-	for {
-		// Read until empty line = event delimiter. The perfect solution would be to read
-		// as many bytes as possible and forward them to sse.Decode. However this
-		// requires much more complicated code.
-		// We could also write our own `sse` package that works fine with streams directly
-		// (github.com/manucorporat/sse is just using io/ioutils.ReadAll).
-		var buffer bytes.Buffer
-		nonEmptylinesRead := 0
-		for {
-			// Check if ctx is not canceled
-			select {
-			case <-ctx.Done():
-				return nil
-			default:
-				// Continue
-			}
-
-			line, err := reader.ReadString('\n')
-			if err != nil {
-				if err == io.EOF || err == io.ErrUnexpectedEOF {
-					// We catch EOF errors to handle two possible situations:
-					// - The last line before closing the stream was not empty. This should never
-					//   happen in Horizon as it always sends an empty line after each event.
-					// - The stream was closed by the server/proxy because the connection was idle.
-					//
-					// In the former case, that (again) should never happen in Horizon, we need to
-					// check if there are any events we need to decode. We do this in the `if`
-					// statement below just in case if Horizon behavior changes in a future.
-					//
-					// From spec:
-					// > Once the end of the file is reached, the user agent must dispatch the
-					// > event one final time, as defined below.
-					if nonEmptylinesRead == 0 {
-						break Events
-					}
-				} else {
-					return errors.Wrap(err, "error reading line")
-				}
-			}
-			buffer.WriteString(line)
-
-			if strings.TrimRight(line, "\n\r") == "" {
-				break
-			}
-
-			nonEmptylinesRead++
-		}
-
-		events, err := sse.Decode(strings.NewReader(buffer.String()))
-		if err != nil {
-			return errors.Wrap(err, "error decoding event")
-		}
-
-		// Right now len(events) should always be 1. This loop will be helpful after writing
-		// new SSE decoder that can handle io.Reader without using ioutils.ReadAll().
-		for _, event := range events {
-			if event.Event != "message" {
-				continue
-			}
-
-			// Update cursor with event ID
-			if event.Id != "" {
-				query.Set("cursor", event.Id)
-			}
-
-			switch data := event.Data.(type) {
-			case string:
-				err = handler([]byte(data))
-				err = errors.Wrap(err, "handler error")
-			case []byte:
-				err = handler(data)
-				err = errors.Wrap(err, "handler error")
-			default:
-				err = errors.New("invalid event.Data type")
-			}
-			if err != nil {
-				return err
-			}
-		}
-	}
-
-	return nil
-}
-
 func (c *Client) setClientAppHeaders(req *http.Request) {
 	for key, value := range c.Headers {
 		req.Header.Set(key, value)
@@ -320,13 +168,23 @@ func (c *Client) HorizonTimeout() time.Duration {
 // have a trustline to an asset.
 // See https://developers.stellar.org/api/resources/accounts/
 func (c *Client) Accounts(request AccountsRequest) (accounts hProtocol.AccountsPage, err error) {
-	err = c.sendRequest(request, &accounts)
+	return c.AccountsCtx(context.Background(), request)
+}
+
+// AccountsCtx is the context-aware version of Accounts.
+func (c *Client) AccountsCtx(ctx context.Context, request AccountsRequest) (accounts hProtocol.AccountsPage, err error) {
+	err = c.sendRequest(ctx, request, &accounts)
 	return
 }
 
 // AccountDetail returns information for a single account.
 // See https://developers.stellar.org/api/resources/accounts/single/
 func (c *Client) AccountDetail(request AccountRequest) (account hProtocol.Account, err error) {
+	return c.AccountDetailCtx(context.Background(), request)
+}
+
+// AccountDetailCtx is the context-aware version of AccountDetail.
+func (c *Client) AccountDetailCtx(ctx context.Context, request AccountRequest) (account hProtocol.Account, err error) {
 	if request.AccountID == "" {
 		err = errors.New("no account ID provided")
 	}
@@ -335,13 +193,18 @@ func (c *Client) AccountDetail(request AccountRequest) (account hProtocol.Accoun
 		return
 	}
 
-	err = c.sendRequest(request, &account)
+	err = c.sendRequest(ctx, request, &account)
 	return
 }
 
 // AccountData returns a single data associated with a given account
 // See https://developers.stellar.org/api/resources/accounts/data/
 func (c *Client) AccountData(request AccountRequest) (accountData hProtocol.AccountData, err error) {
+	return c.AccountDataCtx(context.Background(), request)
+}
+
+// AccountDataCtx is the context-aware version of AccountData.
+func (c *Client) AccountDataCtx(ctx context.Context, request AccountRequest) (accountData hProtocol.AccountData, err error) {
 	if request.AccountID == "" || request.DataKey == "" {
 		err = errors.New("too few parameters")
 	}
@@ -350,34 +213,54 @@ func (c *Client) AccountData(request AccountRequest) (accountData hProtocol.Acco
 		return
 	}
 
-	err = c.sendRequest(request, &accountData)
+	err = c.sendRequest(ctx, request, &accountData)
 	return
 }
 
 // Effects returns effects (https://developers.stellar.org/api/resources/effects/)
 // It can be used to return effects for an account, a ledger, an operation, a transaction and all effects on the network.
 func (c *Client) Effects(request EffectRequest) (effects effects.EffectsPage, err error) {
-	err = c.sendRequest(request, &effects)
+	return c.EffectsCtx(context.Background(), request)
+}
+
+// EffectsCtx is the context-aware version of Effects.
+func (c *Client) EffectsCtx(ctx context.Context, request EffectRequest) (effects effects.EffectsPage, err error) {
+	err = c.sendRequest(ctx, request, &effects)
 	return
 }
 
 // Assets returns asset information.
 // See https://developers.stellar.org/api/resources/assets/list/
 func (c *Client) Assets(request AssetRequest) (assets hProtocol.AssetsPage, err error) {
-	err = c.sendRequest(request, &assets)
+	return c.AssetsCtx(context.Background(), request)
+}
+
+// AssetsCtx is the context-aware version of Assets.
+func (c *Client) AssetsCtx(ctx context.Context, request AssetRequest) (assets hProtocol.AssetsPage, err error) {
+	err = c.sendRequest(ctx, request, &assets)
 	return
 }
 
 // Ledgers returns information about all ledgers.
 // See https://developers.stellar.org/api/resources/ledgers/list/
 func (c *Client) Ledgers(request LedgerRequest) (ledgers hProtocol.LedgersPage, err error) {
-	err = c.sendRequest(request, &ledgers)
+	return c.LedgersCtx(context.Background(), request)
+}
+
+// LedgersCtx is the context-aware version of Ledgers.
+func (c *Client) LedgersCtx(ctx context.Context, request LedgerRequest) (ledgers hProtocol.LedgersPage, err error) {
+	err = c.sendRequest(ctx, request, &ledgers)
 	return
 }
 
 // LedgerDetail returns information about a particular ledger for a given sequence number
 // See https://developers.stellar.org/api/resources/ledgers/single/
 func (c *Client) LedgerDetail(sequence uint32) (ledger hProtocol.Ledger, err error) {
+	return c.LedgerDetailCtx(context.Background(), sequence)
+}
+
+// LedgerDetailCtx is the context-aware version of LedgerDetail.
+func (c *Client) LedgerDetailCtx(ctx context.Context, sequence uint32) (ledger hProtocol.Ledger, err error) {
 	if sequence == 0 {
 		err = errors.New("invalid sequence number provided")
 	}
@@ -387,28 +270,43 @@ func (c *Client) LedgerDetail(sequence uint32) (ledger hProtocol.Ledger, err err
 	}
 
 	request := LedgerRequest{forSequence: sequence}
-	err = c.sendRequest(request, &ledger)
+	err = c.sendRequest(ctx, request, &ledger)
 	return
 }
 
 // FeeStats returns information about fees in the last 5 ledgers.
 // See https://developers.stellar.org/api/aggregations/fee-stats/
 func (c *Client) FeeStats() (feestats hProtocol.FeeStats, err error) {
+	return c.FeeStatsCtx(context.Background())
+}
+
+// FeeStatsCtx is the context-aware version of FeeStats.
+func (c *Client) FeeStatsCtx(ctx context.Context) (feestats hProtocol.FeeStats, err error) {
 	request := feeStatsRequest{endpoint: "fee_stats"}
-	err = c.sendRequest(request, &feestats)
+	err = c.sendRequest(ctx, request, &feestats)
 	return
 }
 
 // Offers returns information about offers made on the SDEX.
 // See https://developers.stellar.org/api/resources/offers/list/
 func (c *Client) Offers(request OfferRequest) (offers hProtocol.OffersPage, err error) {
-	err = c.sendRequest(request, &offers)
+	return c.OffersCtx(context.Background(), request)
+}
+
+// OffersCtx is the context-aware version of Offers.
+func (c *Client) OffersCtx(ctx context.Context, request OfferRequest) (offers hProtocol.OffersPage, err error) {
+	err = c.sendRequest(ctx, request, &offers)
 	return
 }
 
 // OfferDetails returns information for a single offer.
 // See https://developers.stellar.org/api/resources/offers/single/
 func (c *Client) OfferDetails(offerID string) (offer hProtocol.Offer, err error) {
+	return c.OfferDetailsCtx(context.Background(), offerID)
+}
+
+// OfferDetailsCtx is the context-aware version of OfferDetails.
+func (c *Client) OfferDetailsCtx(ctx context.Context, offerID string) (offer hProtocol.Offer, err error) {
 	if len(offerID) == 0 {
 		err = errors.New("no offer ID provided")
 		return
@@ -419,20 +317,30 @@ func (c *Client) OfferDetails(offerID string) (offer hProtocol.Offer, err error)
 		return
 	}
 
-	err = c.sendRequest(OfferRequest{OfferID: offerID}, &offer)
+	err = c.sendRequest(ctx, OfferRequest{OfferID: offerID}, &offer)
 	return
 }
 
 // Operations returns stellar operations (https://developers.stellar.org/api/resources/operations/list/)
 // It can be used to return operations for an account, a ledger, a transaction and all operations on the network.
 func (c *Client) Operations(request OperationRequest) (ops operations.OperationsPage, err error) {
-	err = c.sendRequest(request.SetOperationsEndpoint(), &ops)
+	return c.OperationsCtx(context.Background(), request)
+}
+
+// OperationsCtx is the context-aware version of Operations.
+func (c *Client) OperationsCtx(ctx context.Context, request OperationRequest) (ops operations.OperationsPage, err error) {
+	err = c.sendRequest(ctx, request.SetOperationsEndpoint(), &ops)
 	return
 }
 
 // OperationDetail returns a single stellar operation for a given operation id
 // See https://developers.stellar.org/api/resources/operations/single/
 func (c *Client) OperationDetail(id string) (ops operations.Operation, err error) {
+	return c.OperationDetailCtx(context.Background(), id)
+}
+
+// OperationDetailCtx is the context-aware version of OperationDetail.
+func (c *Client) OperationDetailCtx(ctx context.Context, id string) (ops operations.Operation, err error) {
 	if id == "" {
 		return ops, errors.New("invalid operation id provided")
 	}
@@ -441,7 +349,7 @@ func (c *Client) OperationDetail(id string) (ops operations.Operation, err error
 
 	var record interface{}
 
-	err = c.sendRequest(request, &record)
+	err = c.sendRequest(ctx, request, &record)
 	if err != nil {
 		return ops, errors.Wrap(err, "sending request to horizon")
 	}
@@ -464,10 +372,10 @@ func (c *Client) OperationDetail(id string) (ops operations.Operation, err error
 
 // validateFeeBumpTx checks if the inner transaction has a memo or not and converts the transaction object to
 // base64 string.
-func (c *Client) validateFeeBumpTx(transaction *txnbuild.FeeBumpTransaction, opts SubmitTxOpts) (string, error) {
+func (c *Client) validateFeeBumpTx(ctx context.Context, transaction *txnbuild.FeeBumpTransaction, opts SubmitTxOpts) (string, error) {
 	var err error
 	if inner := transaction.InnerTransaction(); !opts.SkipMemoRequiredCheck && inner.Memo() == nil {
-		err = c.checkMemoRequired(inner)
+		err = c.checkMemoRequired(ctx, inner)
 		if err != nil {
 			return "", err
 		}
@@ -483,10 +391,10 @@ func (c *Client) validateFeeBumpTx(transaction *txnbuild.FeeBumpTransaction, opt
 
 // validateTx checks if the transaction has a memo or not and converts the transaction object to
 // base64 string.
-func (c *Client) validateTx(transaction *txnbuild.Transaction, opts SubmitTxOpts) (string, error) {
+func (c *Client) validateTx(ctx context.Context, transaction *txnbuild.Transaction, opts SubmitTxOpts) (string, error) {
 	var err error
 	if !opts.SkipMemoRequiredCheck && transaction.Memo() == nil {
-		err = c.checkMemoRequired(transaction)
+		err = c.checkMemoRequired(ctx, transaction)
 		if err != nil {
 			return "", err
 		}
@@ -503,9 +411,15 @@ func (c *Client) validateTx(transaction *txnbuild.Transaction, opts SubmitTxOpts
 // SubmitTransactionXDR submits a transaction represented as a base64 XDR string to the network. err can be either error object or horizon.Error object.
 // See https://developers.stellar.org/api/resources/transactions/post/
 func (c *Client) SubmitTransactionXDR(transactionXdr string) (tx hProtocol.Transaction,
+	err error) {
+	return c.SubmitTransactionXDRCtx(context.Background(), transactionXdr)
+}
+
+// SubmitTransactionXDRCtx is the context-aware version of SubmitTransactionXDR.
+func (c *Client) SubmitTransactionXDRCtx(ctx context.Context, transactionXdr string) (tx hProtocol.Transaction,
 	err error) {
 	request := submitRequest{endpoint: "transactions", transactionXdr: transactionXdr}
-	err = c.sendRequest(request, &tx)
+	err = c.sendRequest(ctx, request, &tx)
 	return
 }
 
@@ -519,7 +433,12 @@ func (c *Client) SubmitTransactionXDR(transactionXdr string) (tx hProtocol.Trans
 //
 // See https://developers.stellar.org/api/resources/transactions/post/
 func (c *Client) SubmitFeeBumpTransaction(transaction *txnbuild.FeeBumpTransaction) (tx hProtocol.Transaction, err error) {
-	return c.SubmitFeeBumpTransactionWithOptions(transaction, SubmitTxOpts{})
+	return c.SubmitFeeBumpTransactionCtx(context.Background(), transaction)
+}
+
+// SubmitFeeBumpTransactionCtx is the context-aware version of SubmitFeeBumpTransaction.
+func (c *Client) SubmitFeeBumpTransactionCtx(ctx context.Context, transaction *txnbuild.FeeBumpTransaction) (tx hProtocol.Transaction, err error) {
+	return c.SubmitFeeBumpTransactionWithOptionsCtx(ctx, transaction, SubmitTxOpts{})
 }
 
 // SubmitFeeBumpTransactionWithOptions submits a fee bump transaction to the network, allowing
@@ -527,14 +446,19 @@ func (c *Client) SubmitFeeBumpTransaction(transaction *txnbuild.FeeBumpTransacti
 //
 // See https://developers.stellar.org/api/resources/transactions/post/
 func (c *Client) SubmitFeeBumpTransactionWithOptions(transaction *txnbuild.FeeBumpTransaction, opts SubmitTxOpts) (tx hProtocol.Transaction, err error) {
+	return c.SubmitFeeBumpTransactionWithOptionsCtx(context.Background(), transaction, opts)
+}
+
+// SubmitFeeBumpTransactionWithOptionsCtx is the context-aware version of SubmitFeeBumpTransactionWithOptions.
+func (c *Client) SubmitFeeBumpTransactionWithOptionsCtx(ctx context.Context, transaction *txnbuild.FeeBumpTransaction, opts SubmitTxOpts) (tx hProtocol.Transaction, err error) {
 	// only check if memo is required if skip is false and the inner transaction
 	// doesn't have a memo.
-	txeBase64, err := c.validateFeeBumpTx(transaction, opts)
+	txeBase64, err := c.validateFeeBumpTx(ctx, transaction, opts)
 	if err != nil {
 		return
 	}
 
-	return c.SubmitTransactionXDR(txeBase64)
+	return c.SubmitTransactionXDRCtx(ctx, txeBase64)
 }
 
 // SubmitTransaction submits a transaction to the network. err can be either an
@@ -547,7 +471,12 @@ func (c *Client) SubmitFeeBumpTransactionWithOptions(transaction *txnbuild.FeeBu
 //
 // See https://developers.stellar.org/api/resources/transactions/post/
 func (c *Client) SubmitTransaction(transaction *txnbuild.Transaction) (tx hProtocol.Transaction, err error) {
-	return c.SubmitTransactionWithOptions(transaction, SubmitTxOpts{})
+	return c.SubmitTransactionCtx(context.Background(), transaction)
+}
+
+// SubmitTransactionCtx is the context-aware version of SubmitTransaction.
+func (c *Client) SubmitTransactionCtx(ctx context.Context, transaction *txnbuild.Transaction) (tx hProtocol.Transaction, err error) {
+	return c.SubmitTransactionWithOptionsCtx(ctx, transaction, SubmitTxOpts{})
 }
 
 // SubmitTransactionWithOptions submits a transaction to the network, allowing
@@ -555,21 +484,32 @@ func (c *Client) SubmitTransaction(transaction *txnbuild.Transaction) (tx hProto
 //
 // See https://developers.stellar.org/api/resources/transactions/post/
 func (c *Client) SubmitTransactionWithOptions(transaction *txnbuild.Transaction, opts SubmitTxOpts) (tx hProtocol.Transaction, err error) {
+	return c.SubmitTransactionWithOptionsCtx(context.Background(), transaction, opts)
+}
+
+// SubmitTransactionWithOptionsCtx is the context-aware version of SubmitTransactionWithOptions.
+func (c *Client) SubmitTransactionWithOptionsCtx(ctx context.Context, transaction *txnbuild.Transaction, opts SubmitTxOpts) (tx hProtocol.Transaction, err error) {
 	// only check if memo is required if skip is false and the transaction
 	// doesn't have a memo.
-	txeBase64, err := c.validateTx(transaction, opts)
+	txeBase64, err := c.validateTx(ctx, transaction, opts)
 	if err != nil {
 		return
 	}
 
-	return c.SubmitTransactionXDR(txeBase64)
+	return c.SubmitTransactionXDRCtx(ctx, txeBase64)
 }
 
 // AsyncSubmitTransactionXDR submits a base64 XDR transaction using the transactions_async endpoint. err can be either error object or horizon.Error object.
 func (c *Client) AsyncSubmitTransactionXDR(transactionXdr string) (txResp hProtocol.AsyncTransactionSubmissionResponse,
+	err error) {
+	return c.AsyncSubmitTransactionXDRCtx(context.Background(), transactionXdr)
+}
+
+// AsyncSubmitTransactionXDRCtx is the context-aware version of AsyncSubmitTransactionXDR.
+func (c *Client) AsyncSubmitTransactionXDRCtx(ctx context.Context, transactionXdr string) (txResp hProtocol.AsyncTransactionSubmissionResponse,
 	err error) {
 	request := submitRequest{endpoint: "transactions_async", transactionXdr: transactionXdr}
-	err = c.sendRequest(request, &txResp)
+	err = c.sendRequest(ctx, request, &txResp)
 	return
 }
 
@@ -581,20 +521,30 @@ func (c *Client) AsyncSubmitTransactionXDR(transactionXdr string) (txResp hProto
 //
 // If you want to skip this check, use SubmitTransactionWithOptions.
 func (c *Client) AsyncSubmitFeeBumpTransaction(transaction *txnbuild.FeeBumpTransaction) (txResp hProtocol.AsyncTransactionSubmissionResponse, err error) {
-	return c.AsyncSubmitFeeBumpTransactionWithOptions(transaction, SubmitTxOpts{})
+	return c.AsyncSubmitFeeBumpTransactionCtx(context.Background(), transaction)
+}
+
+// AsyncSubmitFeeBumpTransactionCtx is the context-aware version of AsyncSubmitFeeBumpTransaction.
+func (c *Client) AsyncSubmitFeeBumpTransactionCtx(ctx context.Context, transaction *txnbuild.FeeBumpTransaction) (txResp hProtocol.AsyncTransactionSubmissionResponse, err error) {
+	return c.AsyncSubmitFeeBumpTransactionWithOptionsCtx(ctx, transaction, SubmitTxOpts{})
 }
 
 // AsyncSubmitFeeBumpTransactionWithOptions submits an async fee bump transaction to the network, allowing
 // you to pass SubmitTxOpts. err can be either an error object or a horizon.Error object.
 func (c *Client) AsyncSubmitFeeBumpTransactionWithOptions(transaction *txnbuild.FeeBumpTransaction, opts SubmitTxOpts) (txResp hProtocol.AsyncTransactionSubmissionResponse, err error) {
+	return c.AsyncSubmitFeeBumpTransactionWithOptionsCtx(context.Background(), transaction, opts)
+}
+
+// AsyncSubmitFeeBumpTransactionWithOptionsCtx is the context-aware version of AsyncSubmitFeeBumpTransactionWithOptions.
+func (c *Client) AsyncSubmitFeeBumpTransactionWithOptionsCtx(ctx context.Context, transaction *txnbuild.FeeBumpTransaction, opts SubmitTxOpts) (txResp hProtocol.AsyncTransactionSubmissionResponse, err error) {
 	// only check if memo is required if skip is false and the inner transaction
 	// doesn't have a memo.
-	txeBase64, err := c.validateFeeBumpTx(transaction, opts)
+	txeBase64, err := c.validateFeeBumpTx(ctx, transaction, opts)
 	if err != nil {
 		return
 	}
 
-	return c.AsyncSubmitTransactionXDR(txeBase64)
+	return c.AsyncSubmitTransactionXDRCtx(ctx, txeBase64)
 }
 
 // AsyncSubmitTransaction submits an async transaction to the network. err can be either an
@@ -605,93 +555,148 @@ func (c *Client) AsyncSubmitFeeBumpTransactionWithOptions(transaction *txnbuild.
 //
 // If you want to skip this check, use SubmitTransactionWithOptions.
 func (c *Client) AsyncSubmitTransaction(transaction *txnbuild.Transaction) (txResp hProtocol.AsyncTransactionSubmissionResponse, err error) {
-	return c.AsyncSubmitTransactionWithOptions(transaction, SubmitTxOpts{})
+	return c.AsyncSubmitTransactionCtx(context.Background(), transaction)
+}
+
+// AsyncSubmitTransactionCtx is the context-aware version of AsyncSubmitTransaction.
+func (c *Client) AsyncSubmitTransactionCtx(ctx context.Context, transaction *txnbuild.Transaction) (txResp hProtocol.AsyncTransactionSubmissionResponse, err error) {
+	return c.AsyncSubmitTransactionWithOptionsCtx(ctx, transaction, SubmitTxOpts{})
 }
 
 // AsyncSubmitTransactionWithOptions submits an async transaction to the network, allowing
 // you to pass SubmitTxOpts. err can be either an error object or a horizon.Error object.
 func (c *Client) AsyncSubmitTransactionWithOptions(transaction *txnbuild.Transaction, opts SubmitTxOpts) (txResp hProtocol.AsyncTransactionSubmissionResponse, err error) {
+	return c.AsyncSubmitTransactionWithOptionsCtx(context.Background(), transaction, opts)
+}
+
+// AsyncSubmitTransactionWithOptionsCtx is the context-aware version of AsyncSubmitTransactionWithOptions.
+func (c *Client) AsyncSubmitTransactionWithOptionsCtx(ctx context.Context, transaction *txnbuild.Transaction, opts SubmitTxOpts) (txResp hProtocol.AsyncTransactionSubmissionResponse, err error) {
 	// only check if memo is required if skip is false and the transaction
 	// doesn't have a memo.
-	txeBase64, err := c.validateTx(transaction, opts)
+	txeBase64, err := c.validateTx(ctx, transaction, opts)
 	if err != nil {
 		return
 	}
 
-	return c.AsyncSubmitTransactionXDR(txeBase64)
+	return c.AsyncSubmitTransactionXDRCtx(ctx, txeBase64)
 }
 
 // Transactions returns stellar transactions (https://developers.stellar.org/api/resources/transactions/list/)
 // It can be used to return transactions for an account, a ledger,and all transactions on the network.
 func (c *Client) Transactions(request TransactionRequest) (txs hProtocol.TransactionsPage, err error) {
-	err = c.sendRequest(request, &txs)
+	return c.TransactionsCtx(context.Background(), request)
+}
+
+// TransactionsCtx is the context-aware version of Transactions.
+func (c *Client) TransactionsCtx(ctx context.Context, request TransactionRequest) (txs hProtocol.TransactionsPage, err error) {
+	err = c.sendRequest(ctx, request, &txs)
 	return
 }
 
 // TransactionDetail returns information about a particular transaction for a given transaction hash
 // See https://developers.stellar.org/api/resources/transactions/single/
 func (c *Client) TransactionDetail(txHash string) (tx hProtocol.Transaction, err error) {
+	return c.TransactionDetailCtx(context.Background(), txHash)
+}
+
+// TransactionDetailCtx is the context-aware version of TransactionDetail.
+func (c *Client) TransactionDetailCtx(ctx context.Context, txHash string) (tx hProtocol.Transaction, err error) {
 	if txHash == "" {
 		return tx, errors.New("no transaction hash provided")
 	}
 
 	request := TransactionRequest{forTransactionHash: txHash}
-	err = c.sendRequest(request, &tx)
+	err = c.sendRequest(ctx, request, &tx)
 	return
 }
 
 // OrderBook returns the orderbook for an asset pair (https://developers.stellar.org/api/aggregations/order-books/single/)
 func (c *Client) OrderBook(request OrderBookRequest) (obs hProtocol.OrderBookSummary, err error) {
-	err = c.sendRequest(request, &obs)
+	return c.OrderBookCtx(context.Background(), request)
+}
+
+// OrderBookCtx is the context-aware version of OrderBook.
+func (c *Client) OrderBookCtx(ctx context.Context, request OrderBookRequest) (obs hProtocol.OrderBookSummary, err error) {
+	err = c.sendRequest(ctx, request, &obs)
 	return
 }
 
 // Paths returns the available paths to make a strict receive path payment. See https://developers.stellar.org/api/aggregations/paths/strict-receive/
 // This function is an alias for `client.StrictReceivePaths` and will be deprecated, use `client.StrictReceivePaths` instead.
 func (c *Client) Paths(request PathsRequest) (paths hProtocol.PathsPage, err error) {
-	paths, err = c.StrictReceivePaths(request)
+	return c.PathsCtx(context.Background(), request)
+}
+
+// PathsCtx is the context-aware version of Paths.
+func (c *Client) PathsCtx(ctx context.Context, request PathsRequest) (paths hProtocol.PathsPage, err error) {
+	paths, err = c.StrictReceivePathsCtx(ctx, request)
 	return
 }
 
 // StrictReceivePaths returns the available paths to make a strict receive path payment. See https://developers.stellar.org/api/aggregations/paths/strict-receive/
 func (c *Client) StrictReceivePaths(request PathsRequest) (paths hProtocol.PathsPage, err error) {
-	err = c.sendRequest(request, &paths)
+	return c.StrictReceivePathsCtx(context.Background(), request)
+}
+
+// StrictReceivePathsCtx is the context-aware version of StrictReceivePaths.
+func (c *Client) StrictReceivePathsCtx(ctx context.Context, request PathsRequest) (paths hProtocol.PathsPage, err error) {
+	err = c.sendRequest(ctx, request, &paths)
 	return
 }
 
 // StrictSendPaths returns the available paths to make a strict send path payment. See https://developers.stellar.org/api/aggregations/paths/strict-send/
 func (c *Client) StrictSendPaths(request StrictSendPathsRequest) (paths hProtocol.PathsPage, err error) {
-	err = c.sendRequest(request, &paths)
+	return c.StrictSendPathsCtx(context.Background(), request)
+}
+
+// StrictSendPathsCtx is the context-aware version of StrictSendPaths.
+func (c *Client) StrictSendPathsCtx(ctx context.Context, request StrictSendPathsRequest) (paths hProtocol.PathsPage, err error) {
+	err = c.sendRequest(ctx, request, &paths)
 	return
 }
 
 // Payments returns stellar account_merge, create_account, path payment and payment operations.
 // It can be used to return payments for an account, a ledger, a transaction and all payments on the network.
 func (c *Client) Payments(request OperationRequest) (ops operations.OperationsPage, err error) {
-	err = c.sendRequest(request.SetPaymentsEndpoint(), &ops)
+	return c.PaymentsCtx(context.Background(), request)
+}
+
+// PaymentsCtx is the context-aware version of Payments.
+func (c *Client) PaymentsCtx(ctx context.Context, request OperationRequest) (ops operations.OperationsPage, err error) {
+	err = c.sendRequest(ctx, request.SetPaymentsEndpoint(), &ops)
 	return
 }
 
 // Trades returns stellar trades (https://developers.stellar.org/api/resources/trades/list/)
 // It can be used to return trades for an account, an offer and all trades on the network.
 func (c *Client) Trades(request TradeRequest) (tds hProtocol.TradesPage, err error) {
-	err = c.sendRequest(request, &tds)
+	return c.TradesCtx(context.Background(), request)
+}
+
+// TradesCtx is the context-aware version of Trades.
+func (c *Client) TradesCtx(ctx context.Context, request TradeRequest) (tds hProtocol.TradesPage, err error) {
+	err = c.sendRequest(ctx, request, &tds)
 	return
 }
 
 // Fund creates a new account funded from friendbot. It only works on test networks. See
 // https://developers.stellar.org/docs/tutorials/create-account/ for more information.
 func (c *Client) Fund(addr string) (tx hProtocol.Transaction, err error) {
+	return c.FundCtx(context.Background(), addr)
+}
+
+// FundCtx is the context-aware version of Fund.
+func (c *Client) FundCtx(ctx context.Context, addr string) (tx hProtocol.Transaction, err error) {
 	friendbotURL := fmt.Sprintf("%sfriendbot?addr=%s", c.fixHorizonURL(), addr)
-	err = c.sendGetRequest(friendbotURL, &tx)
+	err = c.sendGetRequest(ctx, friendbotURL, &tx)
 	if IsNotFoundError(err) {
 		return tx, errors.Wrap(err, "funding is only available on test networks and may not be supported by "+c.fixHorizonURL())
 	}
 	return
 }
 
-// StreamTrades streams executed trades. It can be used to stream all trades, trades for an account and
-// trades for an offer. Use context.WithCancel to stop streaming or context.Background() if you want
+// StreamTrades streams executed trades. It can be used to stream all trades, trades for an account,
+// trades for an offer and trades for a liquidity pool. Use context.WithCancel to stop streaming or context.Background() if you want
 // to stream indefinitely. TradeHandler is a user-supplied function that is executed for each streamed trade received.
 func (c *Client) StreamTrades(ctx context.Context, request TradeRequest, handler TradeHandler) (err error) {
 	err = request.StreamTrades(ctx, c, handler)
@@ -700,18 +705,24 @@ func (c *Client) StreamTrades(ctx context.Context, request TradeRequest, handler
 
 // TradeAggregations returns stellar trade aggregations (https://developers.stellar.org/api/aggregations/trade-aggregations/list/)
 func (c *Client) TradeAggregations(request TradeAggregationRequest) (tds hProtocol.TradeAggregationsPage, err error) {
-	err = c.sendRequest(request, &tds)
+	return c.TradeAggregationsCtx(context.Background(), request)
+}
+
+// TradeAggregationsCtx is the context-aware version of TradeAggregations.
+func (c *Client) TradeAggregationsCtx(ctx context.Context, request TradeAggregationRequest) (tds hProtocol.TradeAggregationsPage, err error) {
+	err = c.sendRequest(ctx, request, &tds)
 	return
 }
 
 // StreamTransactions streams processed transactions. It can be used to stream all transactions and
-// transactions for an account. Use context.WithCancel to stop streaming or context.Background()
+// transactions for an account, a claimable balance or a liquidity pool. Use context.WithCancel to stop streaming or context.Background()
 // if you want to stream indefinitely. TransactionHandler is a user-supplied function that is executed for each streamed transaction received.
 func (c *Client) StreamTransactions(ctx context.Context, request TransactionRequest, handler TransactionHandler) error {
 	return request.StreamTransactions(ctx, c, handler)
 }
 
-// StreamEffects streams horizon effects. It can be used to stream all effects or account specific effects.
+// StreamEffects streams horizon effects. It can be used to stream all effects or the effects of an account
+// or a liquidity pool.
 // Use context.WithCancel to stop streaming or context.Background() if you want to stream indefinitely.
 // EffectHandler is a user-supplied function that is executed for each streamed transaction received.
 func (c *Client) StreamEffects(ctx context.Context, request EffectRequest, handler EffectHandler) error {
@@ -719,7 +730,7 @@ func (c *Client) StreamEffects(ctx context.Context, request EffectRequest, handl
 }
 
 // StreamOperations streams stellar operations. It can be used to stream all operations or operations
-// for an account. Use context.WithCancel to stop streaming or context.Background() if you want to
+// for an account, a claimable balance or a liquidity pool. Use context.WithCancel to stop streaming or context.Background() if you want to
 // stream indefinitely. OperationHandler is a user-supplied function that is executed for each streamed
 // operation received.
 func (c *Client) StreamOperations(ctx context.Context, request OperationRequest, handler OperationHandler) error {
@@ -777,7 +788,12 @@ func (c *Client) FetchTimebounds(seconds int64) (txnbuild.TimeBounds, error) {
 
 // Root loads the root endpoint of horizon
 func (c *Client) Root() (root hProtocol.Root, err error) {
-	err = c.sendGetRequest(c.fixHorizonURL(), &root)
+	return c.RootCtx(context.Background())
+}
+
+// RootCtx is the context-aware version of Root.
+func (c *Client) RootCtx(ctx context.Context) (root hProtocol.Root, err error) {
+	err = c.sendGetRequest(ctx, c.fixHorizonURL(), &root)
 	return
 }
 
@@ -788,111 +804,201 @@ func (c *Client) Version() string {
 
 // NextAccountsPage returns the next page of accounts.
 func (c *Client) NextAccountsPage(page hProtocol.AccountsPage) (accounts hProtocol.AccountsPage, err error) {
-	err = c.sendGetRequest(page.Links.Next.Href, &accounts)
+	return c.NextAccountsPageCtx(context.Background(), page)
+}
+
+// NextAccountsPageCtx is the context-aware version of NextAccountsPage.
+func (c *Client) NextAccountsPageCtx(ctx context.Context, page hProtocol.AccountsPage) (accounts hProtocol.AccountsPage, err error) {
+	err = c.sendGetRequest(ctx, page.Links.Next.Href, &accounts)
 	return
 }
 
 // NextAssetsPage returns the next page of assets.
 func (c *Client) NextAssetsPage(page hProtocol.AssetsPage) (assets hProtocol.AssetsPage, err error) {
-	err = c.sendGetRequest(page.Links.Next.Href, &assets)
+	return c.NextAssetsPageCtx(context.Background(), page)
+}
+
+// NextAssetsPageCtx is the context-aware version of NextAssetsPage.
+func (c *Client) NextAssetsPageCtx(ctx context.Context, page hProtocol.AssetsPage) (assets hProtocol.AssetsPage, err error) {
+	err = c.sendGetRequest(ctx, page.Links.Next.Href, &assets)
 	return
 }
 
 // PrevAssetsPage returns the previous page of assets.
 func (c *Client) PrevAssetsPage(page hProtocol.AssetsPage) (assets hProtocol.AssetsPage, err error) {
-	err = c.sendGetRequest(page.Links.Prev.Href, &assets)
+	return c.PrevAssetsPageCtx(context.Background(), page)
+}
+
+// PrevAssetsPageCtx is the context-aware version of PrevAssetsPage.
+func (c *Client) PrevAssetsPageCtx(ctx context.Context, page hProtocol.AssetsPage) (assets hProtocol.AssetsPage, err error) {
+	err = c.sendGetRequest(ctx, page.Links.Prev.Href, &assets)
 	return
 }
 
 // NextLedgersPage returns the next page of ledgers.
 func (c *Client) NextLedgersPage(page hProtocol.LedgersPage) (ledgers hProtocol.LedgersPage, err error) {
-	err = c.sendGetRequest(page.Links.Next.Href, &ledgers)
+	return c.NextLedgersPageCtx(context.Background(), page)
+}
+
+// NextLedgersPageCtx is the context-aware version of NextLedgersPage.
+func (c *Client) NextLedgersPageCtx(ctx context.Context, page hProtocol.LedgersPage) (ledgers hProtocol.LedgersPage, err error) {
+	err = c.sendGetRequest(ctx, page.Links.Next.Href, &ledgers)
 	return
 }
 
 // PrevLedgersPage returns the previous page of ledgers.
 func (c *Client) PrevLedgersPage(page hProtocol.LedgersPage) (ledgers hProtocol.LedgersPage, err error) {
-	err = c.sendGetRequest(page.Links.Prev.Href, &ledgers)
+	return c.PrevLedgersPageCtx(context.Background(), page)
+}
+
+// PrevLedgersPageCtx is the context-aware version of PrevLedgersPage.
+func (c *Client) PrevLedgersPageCtx(ctx context.Context, page hProtocol.LedgersPage) (ledgers hProtocol.LedgersPage, err error) {
+	err = c.sendGetRequest(ctx, page.Links.Prev.Href, &ledgers)
 	return
 }
 
 // NextEffectsPage returns the next page of effects.
 func (c *Client) NextEffectsPage(page effects.EffectsPage) (efp effects.EffectsPage, err error) {
-	err = c.sendGetRequest(page.Links.Next.Href, &efp)
+	return c.NextEffectsPageCtx(context.Background(), page)
+}
+
+// NextEffectsPageCtx is the context-aware version of NextEffectsPage.
+func (c *Client) NextEffectsPageCtx(ctx context.Context, page effects.EffectsPage) (efp effects.EffectsPage, err error) {
+	err = c.sendGetRequest(ctx, page.Links.Next.Href, &efp)
 	return
 }
 
 // PrevEffectsPage returns the previous page of effects.
 func (c *Client) PrevEffectsPage(page effects.EffectsPage) (efp effects.EffectsPage, err error) {
-	err = c.sendGetRequest(page.Links.Prev.Href, &efp)
+	return c.PrevEffectsPageCtx(context.Background(), page)
+}
+
+// PrevEffectsPageCtx is the context-aware version of PrevEffectsPage.
+func (c *Client) PrevEffectsPageCtx(ctx context.Context, page effects.EffectsPage) (efp effects.EffectsPage, err error) {
+	err = c.sendGetRequest(ctx, page.Links.Prev.Href, &efp)
 	return
 }
 
 // NextTransactionsPage returns the next page of transactions.
 func (c *Client) NextTransactionsPage(page hProtocol.TransactionsPage) (transactions hProtocol.TransactionsPage, err error) {
-	err = c.sendGetRequest(page.Links.Next.Href, &transactions)
+	return c.NextTransactionsPageCtx(context.Background(), page)
+}
+
+// NextTransactionsPageCtx is the context-aware version of NextTransactionsPage.
+func (c *Client) NextTransactionsPageCtx(ctx context.Context, page hProtocol.TransactionsPage) (transactions hProtocol.TransactionsPage, err error) {
+	err = c.sendGetRequest(ctx, page.Links.Next.Href, &transactions)
 	return
 }
 
 // PrevTransactionsPage returns the previous page of transactions.
 func (c *Client) PrevTransactionsPage(page hProtocol.TransactionsPage) (transactions hProtocol.TransactionsPage, err error) {
-	err = c.sendGetRequest(page.Links.Prev.Href, &transactions)
+	return c.PrevTransactionsPageCtx(context.Background(), page)
+}
+
+// PrevTransactionsPageCtx is the context-aware version of PrevTransactionsPage.
+func (c *Client) PrevTransactionsPageCtx(ctx context.Context, page hProtocol.TransactionsPage) (transactions hProtocol.TransactionsPage, err error) {
+	err = c.sendGetRequest(ctx, page.Links.Prev.Href, &transactions)
 	return
 }
 
 // NextOperationsPage returns the next page of operations.
 func (c *Client) NextOperationsPage(page operations.OperationsPage) (operations operations.OperationsPage, err error) {
-	err = c.sendGetRequest(page.Links.Next.Href, &operations)
+	return c.NextOperationsPageCtx(context.Background(), page)
+}
+
+// NextOperationsPageCtx is the context-aware version of NextOperationsPage.
+func (c *Client) NextOperationsPageCtx(ctx context.Context, page operations.OperationsPage) (operations operations.OperationsPage, err error) {
+	err = c.sendGetRequest(ctx, page.Links.Next.Href, &operations)
 	return
 }
 
 // PrevOperationsPage returns the previous page of operations.
 func (c *Client) PrevOperationsPage(page operations.OperationsPage) (operations operations.OperationsPage, err error) {
-	err = c.sendGetRequest(page.Links.Prev.Href, &operations)
+	return c.PrevOperationsPageCtx(context.Background(), page)
+}
+
+// PrevOperationsPageCtx is the context-aware version of PrevOperationsPage.
+func (c *Client) PrevOperationsPageCtx(ctx context.Context, page operations.OperationsPage) (operations operations.OperationsPage, err error) {
+	err = c.sendGetRequest(ctx, page.Links.Prev.Href, &operations)
 	return
 }
 
 // NextPaymentsPage returns the next page of payments.
 func (c *Client) NextPaymentsPage(page operations.OperationsPage) (operations.OperationsPage, error) {
-	return c.NextOperationsPage(page)
+	return c.NextPaymentsPageCtx(context.Background(), page)
+}
+
+// NextPaymentsPageCtx is the context-aware version of NextPaymentsPage.
+func (c *Client) NextPaymentsPageCtx(ctx context.Context, page operations.OperationsPage) (operations.OperationsPage, error) {
+	return c.NextOperationsPageCtx(ctx, page)
 }
 
 // PrevPaymentsPage returns the previous page of payments.
 func (c *Client) PrevPaymentsPage(page operations.OperationsPage) (operations.OperationsPage, error) {
-	return c.PrevOperationsPage(page)
+	return c.PrevPaymentsPageCtx(context.Background(), page)
+}
+
+// PrevPaymentsPageCtx is the context-aware version of PrevPaymentsPage.
+func (c *Client) PrevPaymentsPageCtx(ctx context.Context, page operations.OperationsPage) (operations.OperationsPage, error) {
+	return c.PrevOperationsPageCtx(ctx, page)
 }
 
 // NextOffersPage returns the next page of offers.
 func (c *Client) NextOffersPage(page hProtocol.OffersPage) (offers hProtocol.OffersPage, err error) {
-	err = c.sendGetRequest(page.Links.Next.Href, &offers)
+	return c.NextOffersPageCtx(context.Background(), page)
+}
+
+// NextOffersPageCtx is the context-aware version of NextOffersPage.
+func (c *Client) NextOffersPageCtx(ctx context.Context, page hProtocol.OffersPage) (offers hProtocol.OffersPage, err error) {
+	err = c.sendGetRequest(ctx, page.Links.Next.Href, &offers)
 	return
 }
 
 // PrevOffersPage returns the previous page of offers.
 func (c *Client) PrevOffersPage(page hProtocol.OffersPage) (offers hProtocol.OffersPage, err error) {
-	err = c.sendGetRequest(page.Links.Prev.Href, &offers)
+	return c.PrevOffersPageCtx(context.Background(), page)
+}
+
+// PrevOffersPageCtx is the context-aware version of PrevOffersPage.
+func (c *Client) PrevOffersPageCtx(ctx context.Context, page hProtocol.OffersPage) (offers hProtocol.OffersPage, err error) {
+	err = c.sendGetRequest(ctx, page.Links.Prev.Href, &offers)
 	return
 }
 
 // NextTradesPage returns the next page of trades.
 func (c *Client) NextTradesPage(page hProtocol.TradesPage) (trades hProtocol.TradesPage, err error) {
-	err = c.sendGetRequest(page.Links.Next.Href, &trades)
+	return c.NextTradesPageCtx(context.Background(), page)
+}
+
+// NextTradesPageCtx is the context-aware version of NextTradesPage.
+func (c *Client) NextTradesPageCtx(ctx context.Context, page hProtocol.TradesPage) (trades hProtocol.TradesPage, err error) {
+	err = c.sendGetRequest(ctx, page.Links.Next.Href, &trades)
 	return
 }
 
 // PrevTradesPage returns the previous page of trades.
 func (c *Client) PrevTradesPage(page hProtocol.TradesPage) (trades hProtocol.TradesPage, err error) {
-	err = c.sendGetRequest(page.Links.Prev.Href, &trades)
+	return c.PrevTradesPageCtx(context.Background(), page)
+}
+
+// PrevTradesPageCtx is the context-aware version of PrevTradesPage.
+func (c *Client) PrevTradesPageCtx(ctx context.Context, page hProtocol.TradesPage) (trades hProtocol.TradesPage, err error) {
+	err = c.sendGetRequest(ctx, page.Links.Prev.Href, &trades)
 	return
 }
 
 // HomeDomainForAccount returns the home domain for a single account.
 func (c *Client) HomeDomainForAccount(aid string) (string, error) {
+	return c.HomeDomainForAccountCtx(context.Background(), aid)
+}
+
+// HomeDomainForAccountCtx is the context-aware version of HomeDomainForAccount.
+func (c *Client) HomeDomainForAccountCtx(ctx context.Context, aid string) (string, error) {
 	if aid == "" {
 		return "", errors.New("no account ID provided")
 	}
 
-	accountDetail, err := c.AccountDetail(AccountRequest{AccountID: aid})
+	accountDetail, err := c.AccountDetailCtx(ctx, AccountRequest{AccountID: aid})
 	if err != nil {
 		return "", errors.Wrap(err, "get account detail failed")
 	}
@@ -903,50 +1009,93 @@ func (c *Client) HomeDomainForAccount(aid string) (string, error) {
 // NextTradeAggregationsPage returns the next page of trade aggregations from the current
 // trade aggregations response.
 func (c *Client) NextTradeAggregationsPage(page hProtocol.TradeAggregationsPage) (ta hProtocol.TradeAggregationsPage, err error) {
-	err = c.sendGetRequest(page.Links.Next.Href, &ta)
+	return c.NextTradeAggregationsPageCtx(context.Background(), page)
+}
+
+// NextTradeAggregationsPageCtx is the context-aware version of NextTradeAggregationsPage.
+func (c *Client) NextTradeAggregationsPageCtx(ctx context.Context, page hProtocol.TradeAggregationsPage) (ta hProtocol.TradeAggregationsPage, err error) {
+	err = c.sendGetRequest(ctx, page.Links.Next.Href, &ta)
 	return
 }
 
 // PrevTradeAggregationsPage returns the previous page of trade aggregations from the current
 // trade aggregations response.
 func (c *Client) PrevTradeAggregationsPage(page hProtocol.TradeAggregationsPage) (ta hProtocol.TradeAggregationsPage, err error) {
-	err = c.sendGetRequest(page.Links.Prev.Href, &ta)
+	return c.PrevTradeAggregationsPageCtx(context.Background(), page)
+}
+
+// PrevTradeAggregationsPageCtx is the context-aware version of PrevTradeAggregationsPage.
+func (c *Client) PrevTradeAggregationsPageCtx(ctx context.Context, page hProtocol.TradeAggregationsPage) (ta hProtocol.TradeAggregationsPage, err error) {
+	err = c.sendGetRequest(ctx, page.Links.Prev.Href, &ta)
 	return
 }
 
 // ClaimableBalances returns details about available claimable balances,
 // possibly filtered to a specific sponsor or other parameters.
 func (c *Client) ClaimableBalances(cbr ClaimableBalanceRequest) (cb hProtocol.ClaimableBalances, err error) {
-	err = c.sendRequest(cbr, &cb)
+	return c.ClaimableBalancesCtx(context.Background(), cbr)
+}
+
+// ClaimableBalancesCtx is the context-aware version of ClaimableBalances.
+func (c *Client) ClaimableBalancesCtx(ctx context.Context, cbr ClaimableBalanceRequest) (cb hProtocol.ClaimableBalances, err error) {
+	err = c.sendRequest(ctx, cbr, &cb)
 	return
 }
 
 // ClaimableBalance returns details about a *specific*, unique claimable balance.
 func (c *Client) ClaimableBalance(id string) (cb hProtocol.ClaimableBalance, err error) {
+	return c.ClaimableBalanceCtx(context.Background(), id)
+}
+
+// ClaimableBalanceCtx is the context-aware version of ClaimableBalance.
+func (c *Client) ClaimableBalanceCtx(ctx context.Context, id string) (cb hProtocol.ClaimableBalance, err error) {
 	cbr := ClaimableBalanceRequest{ID: id}
-	err = c.sendRequest(cbr, &cb)
+	err = c.sendRequest(ctx, cbr, &cb)
 	return
 }
 
 func (c *Client) LiquidityPoolDetail(request LiquidityPoolRequest) (lp hProtocol.LiquidityPool, err error) {
-	err = c.sendRequest(request, &lp)
+	return c.LiquidityPoolDetailCtx(context.Background(), request)
+}
+
+// LiquidityPoolDetailCtx is the context-aware version of LiquidityPoolDetail.
+func (c *Client) LiquidityPoolDetailCtx(ctx context.Context, request LiquidityPoolRequest) (lp hProtocol.LiquidityPool, err error) {
+	err = c.sendRequest(ctx, request, &lp)
 	return
 }
 
 func (c *Client) LiquidityPools(request LiquidityPoolsRequest) (lp hProtocol.LiquidityPoolsPage, err error) {
-	err = c.sendRequest(request, &lp)
+	return c.LiquidityPoolsCtx(context.Background(), request)
+}
+
+// LiquidityPoolsCtx is the context-aware version of LiquidityPools.
+func (c *Client) LiquidityPoolsCtx(ctx context.Context, request LiquidityPoolsRequest) (lp hProtocol.LiquidityPoolsPage, err error) {
+	err = c.sendRequest(ctx, request, &lp)
 	return
 }
 
 func (c *Client) NextLiquidityPoolsPage(page hProtocol.LiquidityPoolsPage) (lp hProtocol.LiquidityPoolsPage, err error) {
-	err = c.sendGetRequest(page.Links.Next.Href, &lp)
+	return c.NextLiquidityPoolsPageCtx(context.Background(), page)
+}
+
+// NextLiquidityPoolsPageCtx is the context-aware version of NextLiquidityPoolsPage.
+func (c *Client) NextLiquidityPoolsPageCtx(ctx context.Context, page hProtocol.LiquidityPoolsPage) (lp hProtocol.LiquidityPoolsPage, err error) {
+	err = c.sendGetRequest(ctx, page.Links.Next.Href, &lp)
 	return
 }
 
 func (c *Client) PrevLiquidityPoolsPage(page hProtocol.LiquidityPoolsPage) (lp hProtocol.LiquidityPoolsPage, err error) {
-	err = c.sendGetRequest(page.Links.Prev.Href, &lp)
+	return c.PrevLiquidityPoolsPageCtx(context.Background(), page)
+}
+
+// PrevLiquidityPoolsPageCtx is the context-aware version of PrevLiquidityPoolsPage.
+func (c *Client) PrevLiquidityPoolsPageCtx(ctx context.Context, page hProtocol.LiquidityPoolsPage) (lp hProtocol.LiquidityPoolsPage, err error) {
+	err = c.sendGetRequest(ctx, page.Links.Prev.Href, &lp)
 	return
 }
 
 // ensure that the horizon client implements ClientInterface
 var _ ClientInterface = &Client{}
+
+// ensure that the horizon client implements ContextClientInterface
+var _ ContextClientInterface = &Client{}
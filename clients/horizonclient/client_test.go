@@ -1,8 +1,16 @@
 package horizonclient
 
 import (
-	"github.com/stretchr/testify/assert"
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
 )
 
 func setupClient() *Client {
@@ -58,3 +66,72 @@ func TestSetClientAppHeaders_CustomHeadersLogic(t *testing.T) {
 	assert.Equal(t, "4.5.7", req.Header.Get("X-App-Version"))
 	assert.Equal(t, "abcde", req.Header.Get("X-Api-Key"))
 }
+
+// blockingServer returns a server whose handlers block until their request is
+// canceled, which is reported on the returned channel.
+func blockingServer(t *testing.T, stream bool) (*httptest.Server, <-chan struct{}) {
+	canceled := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if stream {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+		}
+		<-r.Context().Done()
+		select {
+		case canceled <- struct{}{}:
+		default:
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, canceled
+}
+
+func TestRequestCanceledByContext(t *testing.T) {
+	server, canceled := blockingServer(t, false)
+	client := &Client{HorizonURL: server.URL}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	_, err := client.AccountDetailCtx(ctx, AccountRequest{AccountID: "GCLWGQPMKXQSPF776IU33AH4PZNOOWNAWGGKVTBQMIC5IMKUNP3E6NVU"})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("request was not canceled on the server")
+	}
+}
+
+func TestRequestContextDeadline(t *testing.T) {
+	server, _ := blockingServer(t, false)
+	client := &Client{HorizonURL: server.URL}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := client.LedgerDetailCtx(ctx, 1)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// the client timeout still applies to requests without a deadline
+	client.SetHorizonTimeout(10 * time.Millisecond)
+	_, err = client.FeeStats()
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestStreamCanceledByContext(t *testing.T) {
+	server, canceled := blockingServer(t, true)
+	client := &Client{HorizonURL: server.URL}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	err := client.StreamLedgers(ctx, LedgerRequest{}, func(hProtocol.Ledger) {
+		t.Fatal("unexpected ledger")
+	})
+	require.NoError(t, err)
+
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("stream was not canceled on the server")
+	}
+}
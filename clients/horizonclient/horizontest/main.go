@@ -0,0 +1,525 @@
+// Package horizontest provides an in-process fake Horizon server, so that code
+// using horizonclient can be tested end to end over HTTP without a network.
+//
+// The server keeps accounts, transactions, operations and effects in memory
+// and renders them with the protocols/horizon types, in HAL pages which can be
+// followed with their links or streamed with server-sent events. Submitted
+// transactions are decoded with txnbuild, checked like stellar-core would
+// check them (sequence number, fee, time bounds and signatures) and applied.
+// Only the create_account, payment and bump_sequence operations are supported;
+// other operations fail with op_not_supported.
+package horizontest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/clients/horizonclient"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/protocols/horizon/effects"
+	"github.com/stellar/go/protocols/horizon/operations"
+	"github.com/stellar/go/support/errors"
+	supporthttptest "github.com/stellar/go/support/http/httptest"
+	"github.com/stellar/go/support/log"
+	"github.com/stellar/go/support/render/hal"
+	"github.com/stellar/go/support/render/problem"
+)
+
+const (
+	defaultLimit = 10
+	maxLimit     = 200
+)
+
+// transaction is a transaction stored by the server, with the accounts taking
+// part in it.
+type transaction struct {
+	hProtocol.Transaction
+	participants []string
+}
+
+// operation is an operation stored by the server, with the accounts taking
+// part in it.
+type operation struct {
+	operations.Operation
+	payment      bool
+	participants []string
+}
+
+// effect is an effect stored by the server, with the transaction it belongs
+// to.
+type effect struct {
+	effects.Effect
+	transactionHash string
+}
+
+// Server is a fake Horizon server. Its zero value is not usable, use
+// NewServer.
+type Server struct {
+	*httptest.Server
+	NetworkPassphrase string
+
+	problems *problem.Problem
+
+	lock         sync.Mutex
+	ledger       int32
+	closedAt     time.Time
+	accounts     map[string]*hProtocol.Account
+	transactions []transaction
+	operations   []operation
+	effects      []effect
+	// changed is closed, and replaced, when records are added, to wake up the
+	// streams.
+	changed chan struct{}
+	closed  chan struct{}
+
+	now func() time.Time
+}
+
+// NewServer starts a fake Horizon server for the given network.
+func NewServer(networkPassphrase string) *Server {
+	s := &Server{
+		NetworkPassphrase: networkPassphrase,
+		problems:          problem.New("https://stellar.org/horizon-errors/", log.DefaultLogger, problem.LogNoErrors),
+		ledger:            1,
+		accounts:          map[string]*hProtocol.Account{},
+		changed:           make(chan struct{}),
+		closed:            make(chan struct{}),
+		now:               time.Now,
+	}
+	s.closedAt = s.now().UTC().Truncate(time.Second)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.root)
+	mux.HandleFunc("GET /accounts/{account}", s.account)
+	mux.HandleFunc("GET /accounts/{account}/transactions", s.listTransactions(byTransactionParticipant))
+	mux.HandleFunc("GET /accounts/{account}/operations", s.listOperations(byOperationParticipant, false))
+	mux.HandleFunc("GET /accounts/{account}/payments", s.listOperations(byOperationParticipant, true))
+	mux.HandleFunc("GET /accounts/{account}/effects", s.listEffects(byEffectAccount))
+	mux.HandleFunc("GET /transactions", s.listTransactions(nil))
+	mux.HandleFunc("POST /transactions", s.submit)
+	mux.HandleFunc("GET /transactions/{hash}", s.transaction)
+	mux.HandleFunc("GET /transactions/{hash}/operations", s.listOperations(byOperationTransaction, false))
+	mux.HandleFunc("GET /transactions/{hash}/payments", s.listOperations(byOperationTransaction, true))
+	mux.HandleFunc("GET /transactions/{hash}/effects", s.listEffects(byEffectTransaction))
+	mux.HandleFunc("GET /operations", s.listOperations(nil, false))
+	mux.HandleFunc("GET /operations/{id}", s.operation)
+	mux.HandleFunc("GET /operations/{id}/effects", s.listEffects(byEffectOperation))
+	mux.HandleFunc("GET /payments", s.listOperations(nil, true))
+	mux.HandleFunc("GET /effects", s.listEffects(nil))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		s.problems.Render(r.Context(), w, problem.NotFound)
+	})
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Close ends the streams and shuts down the server.
+func (s *Server) Close() {
+	close(s.closed)
+	s.Server.CloseClientConnections()
+	s.Server.Close()
+}
+
+// Client returns a horizon client of the server.
+func (s *Server) Client() *horizonclient.Client {
+	return &horizonclient.Client{HorizonURL: s.URL, HTTP: s.Server.Client()}
+}
+
+// SetAccount adds or replaces an account. ID, AccountID and the paging token
+// are set from accountID and, if the account has no signers, its master key
+// is its only signer, with a weight of 1.
+func (s *Server) SetAccount(accountID string, account hProtocol.Account) {
+	account.ID, account.AccountID, account.PT = accountID, accountID, accountID
+	if len(account.Signers) == 0 {
+		account.Signers = []hProtocol.Signer{{Key: accountID, Weight: 1, Type: "ed25519_public_key"}}
+	}
+	if account.Data == nil {
+		account.Data = map[string]string{}
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	account.LastModifiedLedger = uint32(s.ledger)
+	s.accounts[accountID] = cloneAccount(&account)
+}
+
+// CreateAccount adds an account with a native balance, in lumens, and the
+// sequence number of an account created in the current ledger.
+func (s *Server) CreateAccount(accountID string, balance string) {
+	s.lock.Lock()
+	sequence := int64(s.ledger) << 32
+	s.lock.Unlock()
+
+	s.SetAccount(accountID, hProtocol.Account{
+		Sequence: sequence,
+		Balances: []hProtocol.Balance{nativeBalance(balance)},
+	})
+}
+
+// Account returns an account of the server.
+func (s *Server) Account(accountID string) (hProtocol.Account, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	account, ok := s.accounts[accountID]
+	if !ok {
+		return hProtocol.Account{}, false
+	}
+	return *cloneAccount(account), true
+}
+
+// Transactions returns the transactions applied by the server, successful or
+// not, in order.
+func (s *Server) Transactions() []hProtocol.Transaction {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	txs := make([]hProtocol.Transaction, len(s.transactions))
+	for i, tx := range s.transactions {
+		txs[i] = tx.Transaction
+	}
+	return txs
+}
+
+func (s *Server) root(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	hal.Render(w, hProtocol.Root{
+		HorizonVersion:        "horizontest",
+		IngestSequence:        uint32(s.ledger),
+		HorizonSequence:       s.ledger,
+		HorizonLatestClosedAt: s.closedAt,
+		HistoryElderSequence:  1,
+		CoreSequence:          s.ledger,
+		NetworkPassphrase:     s.NetworkPassphrase,
+	})
+}
+
+func (s *Server) account(w http.ResponseWriter, r *http.Request) {
+	account, ok := s.Account(r.PathValue("account"))
+	if !ok {
+		s.problems.Render(r.Context(), w, problem.NotFound)
+		return
+	}
+	self := s.URL + "/accounts/" + account.AccountID
+	account.Links.Self = hal.NewLink(self)
+	account.Links.Transactions = hal.NewLink(self + "/transactions{?cursor,limit,order}")
+	account.Links.Operations = hal.NewLink(self + "/operations{?cursor,limit,order}")
+	account.Links.Payments = hal.NewLink(self + "/payments{?cursor,limit,order}")
+	account.Links.Effects = hal.NewLink(self + "/effects{?cursor,limit,order}")
+	hal.Render(w, account)
+}
+
+func (s *Server) transaction(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, tx := range s.transactions {
+		if tx.Hash == r.PathValue("hash") {
+			hal.Render(w, tx.Transaction)
+			return
+		}
+	}
+	s.problems.Render(r.Context(), w, problem.NotFound)
+}
+
+func (s *Server) operation(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, op := range s.operations {
+		if op.GetID() == r.PathValue("id") {
+			hal.Render(w, op.Operation)
+			return
+		}
+	}
+	s.problems.Render(r.Context(), w, problem.NotFound)
+}
+
+// The filters of the collections, on the path parameters of the request.
+func byTransactionParticipant(r *http.Request, tx transaction) bool {
+	return slices.Contains(tx.participants, r.PathValue("account"))
+}
+
+func byOperationParticipant(r *http.Request, op operation) bool {
+	return slices.Contains(op.participants, r.PathValue("account"))
+}
+
+func byOperationTransaction(r *http.Request, op operation) bool {
+	return op.GetTransactionHash() == r.PathValue("hash")
+}
+
+func byEffectAccount(r *http.Request, e effect) bool {
+	return e.GetAccount() == r.PathValue("account")
+}
+
+func byEffectTransaction(r *http.Request, e effect) bool {
+	return e.transactionHash == r.PathValue("hash")
+}
+
+func byEffectOperation(r *http.Request, e effect) bool {
+	// the paging token of an effect is the id of its operation followed by
+	// its index
+	opID, _, _ := strings.Cut(e.PagingToken(), "-")
+	return opID == r.PathValue("id")
+}
+
+func (s *Server) listTransactions(filter func(*http.Request, transaction) bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.list(w, r, func() []hal.Pageable {
+			var records []hal.Pageable
+			for _, tx := range s.transactions {
+				if filter == nil || filter(r, tx) {
+					records = append(records, tx.Transaction)
+				}
+			}
+			return records
+		})
+	}
+}
+
+func (s *Server) listOperations(filter func(*http.Request, operation) bool, payments bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.list(w, r, func() []hal.Pageable {
+			var records []hal.Pageable
+			for _, op := range s.operations {
+				if (!payments || op.payment) && (filter == nil || filter(r, op)) {
+					records = append(records, op.Operation)
+				}
+			}
+			return records
+		})
+	}
+}
+
+func (s *Server) listEffects(filter func(*http.Request, effect) bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.list(w, r, func() []hal.Pageable {
+			var records []hal.Pageable
+			for _, e := range s.effects {
+				if filter == nil || filter(r, e) {
+					records = append(records, e.Effect)
+				}
+			}
+			return records
+		})
+	}
+}
+
+// list renders a page of a collection, or streams it. records returns the
+// records of the collection in ascending order, and is called with the lock
+// held.
+func (s *Server) list(w http.ResponseWriter, r *http.Request, records func() []hal.Pageable) {
+	query := r.URL.Query()
+	cursor := query.Get("cursor")
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		cursor = id
+	}
+	if cursor != "" && cursor != "now" {
+		if _, _, err := parsePagingToken(cursor); err != nil {
+			s.problems.Render(r.Context(), w, problem.MakeInvalidFieldProblem("cursor", err))
+			return
+		}
+	}
+
+	if r.Header.Get("Accept") == "text/event-stream" {
+		s.stream(w, r, cursor, records)
+		return
+	}
+
+	order := query.Get("order")
+	if order == "" {
+		order = "asc"
+	}
+	if order != "asc" && order != "desc" {
+		s.problems.Render(r.Context(), w, problem.MakeInvalidFieldProblem("order", errors.New("order must be asc or desc")))
+		return
+	}
+	limit := uint64(defaultLimit)
+	if value := query.Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.ParseUint(value, 10, 64)
+		if err != nil || limit == 0 || limit > maxLimit {
+			s.problems.Render(r.Context(), w, problem.MakeInvalidFieldProblem("limit", errors.Errorf("limit must be between 1 and %d", maxLimit)))
+			return
+		}
+	}
+
+	s.lock.Lock()
+	all := records()
+	s.lock.Unlock()
+
+	page := hal.Page{Order: order, Limit: limit, Cursor: cursor}
+	fullURL := *r.URL
+	page.FullURL = &fullURL
+	page.FullURL.Scheme, page.FullURL.Host = "http", r.Host
+	if order == "desc" {
+		for i := len(all) - 1; i >= 0 && uint64(len(page.Embedded.Records)) < limit; i-- {
+			if cursor == "" || before(all[i].PagingToken(), cursor) {
+				page.Add(all[i])
+			}
+		}
+	} else {
+		for _, record := range all {
+			if uint64(len(page.Embedded.Records)) == limit {
+				break
+			}
+			if cursor == "" || after(record.PagingToken(), cursor) {
+				page.Add(record)
+			}
+		}
+	}
+	page.PopulateLinks()
+	hal.Render(w, page)
+}
+
+// stream sends the records of a collection after cursor, and then the new
+// ones as they are added, like Horizon streams.
+func (s *Server) stream(w http.ResponseWriter, r *http.Request, cursor string, records func() []hal.Pageable) {
+	sse := supporthttptest.SSEWriter{ResponseWriter: w}
+	sse.Send(supporthttptest.SSEEvent{Event: "open", Data: `"hello"`, Retry: time.Second})
+
+	s.lock.Lock()
+	if cursor == "now" {
+		cursor = ""
+		if all := records(); len(all) > 0 {
+			cursor = all[len(all)-1].PagingToken()
+		}
+	}
+	s.lock.Unlock()
+
+	for {
+		s.lock.Lock()
+		var pending []hal.Pageable
+		for _, record := range records() {
+			if cursor == "" || after(record.PagingToken(), cursor) {
+				pending = append(pending, record)
+			}
+		}
+		changed := s.changed
+		s.lock.Unlock()
+
+		for _, record := range pending {
+			data, err := json.Marshal(record)
+			if err != nil {
+				return
+			}
+			cursor = record.PagingToken()
+			sse.Send(supporthttptest.SSEEvent{ID: cursor, Data: string(data)})
+		}
+
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		case <-s.closed:
+			return
+		}
+	}
+}
+
+// notify wakes up the streams, and must be called with the lock held.
+func (s *Server) notify() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// parsePagingToken parses the paging tokens of the server, which are toids
+// for transactions and operations, and toids followed by an index for
+// effects.
+func parsePagingToken(token string) (int64, int64, error) {
+	id, index, found := strings.Cut(token, "-")
+	major, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return 0, 0, errors.Errorf("invalid cursor %q", token)
+	}
+	minor := int64(0)
+	if found {
+		if minor, err = strconv.ParseInt(index, 10, 64); err != nil {
+			return 0, 0, errors.Errorf("invalid cursor %q", token)
+		}
+	}
+	return major, minor, nil
+}
+
+func compareTokens(a, b string) int {
+	aMajor, aMinor, _ := parsePagingToken(a)
+	bMajor, bMinor, _ := parsePagingToken(b)
+	switch {
+	case aMajor != bMajor:
+		return cmpInt64(aMajor, bMajor)
+	default:
+		return cmpInt64(aMinor, bMinor)
+	}
+}
+
+func cmpInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func after(token, cursor string) bool {
+	return compareTokens(token, cursor) > 0
+}
+
+func before(token, cursor string) bool {
+	return compareTokens(token, cursor) < 0
+}
+
+// nativeBalance returns a native balance, with the seven decimals of Horizon.
+func nativeBalance(balance string) hProtocol.Balance {
+	b := hProtocol.Balance{Balance: balance}
+	if value, err := amount.ParseInt64(balance); err == nil {
+		b.Balance = amount.StringFromInt64(value)
+	}
+	b.Type = "native"
+	return b
+}
+
+// cloneAccount returns a copy of an account which does not share its
+// balances, signers and data.
+func cloneAccount(account *hProtocol.Account) *hProtocol.Account {
+	clone := *account
+	clone.Balances = append([]hProtocol.Balance(nil), account.Balances...)
+	clone.Signers = append([]hProtocol.Signer(nil), account.Signers...)
+	clone.Data = make(map[string]string, len(account.Data))
+	for k, v := range account.Data {
+		clone.Data[k] = v
+	}
+	return &clone
+}
+
+// balance returns the balance of an asset held by an account, in stroops,
+// and whether the account holds the asset.
+func balance(account *hProtocol.Account, assetType, code, issuer string) (int64, bool) {
+	for _, b := range account.Balances {
+		if b.Type == assetType && b.Code == code && b.Issuer == issuer {
+			value, err := amount.ParseInt64(b.Balance)
+			if err != nil {
+				panic(fmt.Sprintf("invalid balance %q of %s", b.Balance, account.AccountID))
+			}
+			return value, true
+		}
+	}
+	return 0, false
+}
+
+// setBalance updates the balance of an asset held by an account.
+func setBalance(account *hProtocol.Account, assetType, code, issuer string, value int64) {
+	for i, b := range account.Balances {
+		if b.Type == assetType && b.Code == code && b.Issuer == issuer {
+			account.Balances[i].Balance = amount.StringFromInt64(value)
+			return
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package horizontest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/network"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/protocols/horizon/effects"
+	"github.com/stellar/go/protocols/horizon/operations"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTransaction builds a transaction of the source account, signed by the
+// signers, with the next sequence number of the account on the server.
+func newTransaction(t *testing.T, client *horizonclient.Client, source string, ops []txnbuild.Operation, signers ...*keypair.Full) *txnbuild.Transaction {
+	account, err := client.AccountDetail(horizonclient.AccountRequest{AccountID: source})
+	require.NoError(t, err)
+	tx, err := txnbuild.NewTransaction(txnbuild.TransactionParams{
+		SourceAccount:        &account,
+		IncrementSequenceNum: true,
+		Operations:           ops,
+		BaseFee:              txnbuild.MinBaseFee,
+		Preconditions:        txnbuild.Preconditions{TimeBounds: txnbuild.NewInfiniteTimeout()},
+	})
+	require.NoError(t, err)
+	tx, err = tx.Sign(network.TestNetworkPassphrase, signers...)
+	require.NoError(t, err)
+	return tx
+}
+
+func resultCodes(t *testing.T, err error) *hProtocol.TransactionResultCodes {
+	hError := horizonclient.GetError(err)
+	require.NotNil(t, hError, "%v", err)
+	codes, err := hError.ResultCodes()
+	require.NoError(t, err)
+	return codes
+}
+
+func TestAccountDetail(t *testing.T) {
+	server := NewServer(network.TestNetworkPassphrase)
+	defer server.Close()
+	client := server.Client()
+	kp := keypair.MustRandom()
+	server.CreateAccount(kp.Address(), "100")
+
+	account, err := client.AccountDetail(horizonclient.AccountRequest{AccountID: kp.Address()})
+	require.NoError(t, err)
+	assert.Equal(t, kp.Address(), account.AccountID)
+	assert.Equal(t, int64(1)<<32, account.Sequence)
+	balance, err := account.GetNativeBalance()
+	require.NoError(t, err)
+	assert.Equal(t, "100.0000000", balance)
+	assert.Equal(t, server.URL+"/accounts/"+kp.Address(), account.Links.Self.Href)
+
+	_, err = client.AccountDetail(horizonclient.AccountRequest{AccountID: keypair.MustRandom().Address()})
+	assert.True(t, horizonclient.IsNotFoundError(err))
+
+	root, err := client.Root()
+	require.NoError(t, err)
+	assert.Equal(t, network.TestNetworkPassphrase, root.NetworkPassphrase)
+}
+
+func TestSubmitTransaction(t *testing.T) {
+	server := NewServer(network.TestNetworkPassphrase)
+	defer server.Close()
+	client := server.Client()
+	alice, bob, carol := keypair.MustRandom(), keypair.MustRandom(), keypair.MustRandom()
+	server.CreateAccount(alice.Address(), "100")
+	server.CreateAccount(bob.Address(), "10")
+
+	tx := newTransaction(t, client, alice.Address(), []txnbuild.Operation{
+		&txnbuild.Payment{Destination: bob.Address(), Amount: "25", Asset: txnbuild.NativeAsset{}},
+		&txnbuild.CreateAccount{Destination: carol.Address(), Amount: "5"},
+	}, alice)
+	submitted, err := client.SubmitTransaction(tx)
+	require.NoError(t, err)
+	hash, err := tx.HashHex(network.TestNetworkPassphrase)
+	require.NoError(t, err)
+	assert.Equal(t, hash, submitted.Hash)
+	assert.True(t, submitted.Successful)
+	assert.Equal(t, int32(2), submitted.Ledger)
+	assert.Equal(t, int64(200), submitted.FeeCharged)
+	assert.NotEmpty(t, submitted.ResultXdr)
+
+	balance := func(accountID string) string {
+		account, ok := server.Account(accountID)
+		require.True(t, ok)
+		native, err := account.GetNativeBalance()
+		require.NoError(t, err)
+		return native
+	}
+	assert.Equal(t, "69.9999800", balance(alice.Address()))
+	assert.Equal(t, "35.0000000", balance(bob.Address()))
+	assert.Equal(t, "5.0000000", balance(carol.Address()))
+	account, _ := server.Account(alice.Address())
+	assert.Equal(t, tx.SequenceNumber(), account.Sequence)
+
+	payments, err := client.Payments(horizonclient.OperationRequest{ForAccount: bob.Address()})
+	require.NoError(t, err)
+	require.Len(t, payments.Embedded.Records, 1)
+	payment, ok := payments.Embedded.Records[0].(operations.Payment)
+	require.True(t, ok)
+	assert.Equal(t, alice.Address(), payment.From)
+	assert.Equal(t, "25.0000000", payment.Amount)
+	assert.Equal(t, hash, payment.TransactionHash)
+
+	page, err := client.Effects(horizonclient.EffectRequest{ForTransaction: hash})
+	require.NoError(t, err)
+	var types []string
+	for _, e := range page.Embedded.Records {
+		types = append(types, e.GetType())
+	}
+	assert.Equal(t, []string{"account_credited", "account_debited", "account_created", "account_debited"}, types)
+	created, ok := page.Embedded.Records[2].(effects.AccountCreated)
+	require.True(t, ok)
+	assert.Equal(t, carol.Address(), created.Account)
+}
+
+func TestSubmitTransactionFailures(t *testing.T) {
+	server := NewServer(network.TestNetworkPassphrase)
+	defer server.Close()
+	client := server.Client()
+	alice, bob := keypair.MustRandom(), keypair.MustRandom()
+	server.CreateAccount(alice.Address(), "100")
+	payment := []txnbuild.Operation{
+		&txnbuild.Payment{Destination: bob.Address(), Amount: "1", Asset: txnbuild.NativeAsset{}},
+	}
+
+	// rejected transactions are not applied
+	_, err := client.SubmitTransaction(newTransaction(t, client, alice.Address(), payment, bob))
+	assert.Equal(t, "tx_bad_auth", resultCodes(t, err).TransactionCode)
+	tx := newTransaction(t, client, alice.Address(), payment, alice)
+	_, err = client.SubmitTransaction(tx)
+	require.Error(t, err)
+	_, err = client.SubmitTransaction(tx)
+	assert.Equal(t, "tx_bad_seq", resultCodes(t, err).TransactionCode)
+
+	// failed transactions consume their fee and sequence number
+	codes := resultCodes(t, func() error {
+		_, err := client.SubmitTransaction(newTransaction(t, client, alice.Address(), payment, alice))
+		return err
+	}())
+	assert.Equal(t, "tx_failed", codes.TransactionCode)
+	assert.Equal(t, []string{"op_no_destination"}, codes.OperationCodes)
+
+	account, _ := server.Account(alice.Address())
+	native, err := account.GetNativeBalance()
+	require.NoError(t, err)
+	assert.Equal(t, "99.9999800", native)
+	txs := server.Transactions()
+	require.Len(t, txs, 2)
+	assert.False(t, txs[0].Successful)
+	assert.Equal(t, tx.SequenceNumber()+1, account.Sequence)
+
+	_, err = client.SubmitTransactionXDR("AAAA")
+	hError := horizonclient.GetError(err)
+	require.NotNil(t, hError)
+	assert.Equal(t, "https://stellar.org/horizon-errors/transaction_malformed", hError.Problem.Type)
+}
+
+func TestSubmitFeeBumpTransaction(t *testing.T) {
+	server := NewServer(network.TestNetworkPassphrase)
+	defer server.Close()
+	client := server.Client()
+	alice, sponsor := keypair.MustRandom(), keypair.MustRandom()
+	server.CreateAccount(alice.Address(), "0")
+	server.CreateAccount(sponsor.Address(), "100")
+
+	inner := newTransaction(t, client, alice.Address(), []txnbuild.Operation{
+		&txnbuild.BumpSequence{BumpTo: 100 << 32},
+	}, alice)
+	feeBump, err := txnbuild.NewFeeBumpTransaction(txnbuild.FeeBumpTransactionParams{
+		Inner:      inner,
+		FeeAccount: sponsor.Address(),
+		BaseFee:    txnbuild.MinBaseFee,
+	})
+	require.NoError(t, err)
+	feeBump, err = feeBump.Sign(network.TestNetworkPassphrase, sponsor)
+	require.NoError(t, err)
+
+	submitted, err := client.SubmitFeeBumpTransaction(feeBump)
+	require.NoError(t, err)
+	assert.Equal(t, sponsor.Address(), submitted.FeeAccount)
+	assert.Equal(t, int64(200), submitted.FeeCharged)
+	require.NotNil(t, submitted.InnerTransaction)
+	innerHash, err := inner.HashHex(network.TestNetworkPassphrase)
+	require.NoError(t, err)
+	assert.Equal(t, innerHash, submitted.InnerTransaction.Hash)
+
+	account, _ := server.Account(alice.Address())
+	assert.Equal(t, int64(100<<32), account.Sequence)
+	account, _ = server.Account(sponsor.Address())
+	native, err := account.GetNativeBalance()
+	require.NoError(t, err)
+	assert.Equal(t, "99.9999800", native)
+
+	// the inner transaction is checked too
+	feeBump, err = txnbuild.NewFeeBumpTransaction(txnbuild.FeeBumpTransactionParams{
+		Inner:      inner,
+		FeeAccount: sponsor.Address(),
+		BaseFee:    txnbuild.MinBaseFee,
+	})
+	require.NoError(t, err)
+	feeBump, err = feeBump.Sign(network.TestNetworkPassphrase, sponsor)
+	require.NoError(t, err)
+	_, err = client.SubmitFeeBumpTransaction(feeBump)
+	codes := resultCodes(t, err)
+	assert.Equal(t, "tx_fee_bump_inner_failed", codes.TransactionCode)
+	assert.Equal(t, "tx_bad_seq", codes.InnerTransactionCode)
+}
+
+func TestPaging(t *testing.T) {
+	server := NewServer(network.TestNetworkPassphrase)
+	defer server.Close()
+	client := server.Client()
+	alice := keypair.MustRandom()
+	server.CreateAccount(alice.Address(), "100")
+	for i := 0; i < 5; i++ {
+		_, err := client.SubmitTransaction(newTransaction(t, client, alice.Address(), []txnbuild.Operation{
+			&txnbuild.BumpSequence{BumpTo: 0},
+		}, alice))
+		require.NoError(t, err)
+	}
+
+	var ledgers []int32
+	request := horizonclient.TransactionRequest{ForAccount: alice.Address()}
+	for tx, err := range horizonclient.Records[hProtocol.Transaction](context.Background(), client, request, horizonclient.PageOptions{PageSize: 2}) {
+		require.NoError(t, err)
+		ledgers = append(ledgers, tx.Ledger)
+	}
+	assert.Equal(t, []int32{2, 3, 4, 5, 6}, ledgers)
+
+	page, err := client.Transactions(horizonclient.TransactionRequest{Order: horizonclient.OrderDesc, Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, page.Embedded.Records, 2)
+	assert.Equal(t, int32(6), page.Embedded.Records[0].Ledger)
+	page, err = client.NextTransactionsPage(page)
+	require.NoError(t, err)
+	require.Len(t, page.Embedded.Records, 2)
+	assert.Equal(t, int32(4), page.Embedded.Records[0].Ledger)
+
+	_, err = client.Transactions(horizonclient.TransactionRequest{Cursor: "invalid"})
+	hError := horizonclient.GetError(err)
+	require.NotNil(t, hError)
+	assert.Equal(t, 400, hError.Problem.Status)
+}
+
+func TestStreaming(t *testing.T) {
+	server := NewServer(network.TestNetworkPassphrase)
+	defer server.Close()
+	client := server.Client()
+	alice, bob := keypair.MustRandom(), keypair.MustRandom()
+	server.CreateAccount(alice.Address(), "100")
+	server.CreateAccount(bob.Address(), "100")
+	pay := func() {
+		_, err := client.SubmitTransaction(newTransaction(t, client, alice.Address(), []txnbuild.Operation{
+			&txnbuild.Payment{Destination: bob.Address(), Amount: "1", Asset: txnbuild.NativeAsset{}},
+		}, alice))
+		require.NoError(t, err)
+	}
+	pay()
+
+	connected := make(chan struct{})
+	client.StreamOptions.OnConnected = func(horizonclient.StreamConnected) { close(connected) }
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	received := make(chan operations.Operation, 2)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- client.StreamPayments(ctx, horizonclient.OperationRequest{ForAccount: bob.Address(), Cursor: "now"}, func(op operations.Operation) {
+			received <- op
+		})
+	}()
+
+	// the stream starts after the payment made before it, and sends the new
+	// ones as they are applied
+	<-connected
+	for i := 0; i < 2; i++ {
+		pay()
+		op := <-received
+		payment, ok := op.(operations.Payment)
+		require.True(t, ok)
+		assert.Equal(t, bob.Address(), payment.To)
+	}
+	cancel()
+	assert.NoError(t, <-errs)
+	txs := server.Transactions()
+	assert.Len(t, txs, 3)
+}
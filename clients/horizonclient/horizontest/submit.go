@@ -0,0 +1,635 @@
+package horizontest
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/keypair"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/protocols/horizon/base"
+	"github.com/stellar/go/protocols/horizon/effects"
+	"github.com/stellar/go/protocols/horizon/operations"
+	"github.com/stellar/go/support/render/hal"
+	"github.com/stellar/go/support/render/problem"
+	"github.com/stellar/go/toid"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/go/xdr"
+)
+
+// baseFee is the minimum fee per operation, in stroops.
+const baseFee = txnbuild.MinBaseFee
+
+var transactionCodes = map[xdr.TransactionResultCode]string{
+	xdr.TransactionResultCodeTxSuccess:             "tx_success",
+	xdr.TransactionResultCodeTxFailed:              "tx_failed",
+	xdr.TransactionResultCodeTxTooEarly:            "tx_too_early",
+	xdr.TransactionResultCodeTxTooLate:             "tx_too_late",
+	xdr.TransactionResultCodeTxMissingOperation:    "tx_missing_operation",
+	xdr.TransactionResultCodeTxBadSeq:              "tx_bad_seq",
+	xdr.TransactionResultCodeTxBadAuth:             "tx_bad_auth",
+	xdr.TransactionResultCodeTxInsufficientBalance: "tx_insufficient_balance",
+	xdr.TransactionResultCodeTxNoAccount:           "tx_no_source_account",
+	xdr.TransactionResultCodeTxInsufficientFee:     "tx_insufficient_fee",
+	xdr.TransactionResultCodeTxFeeBumpInnerSuccess: "tx_fee_bump_inner_success",
+	xdr.TransactionResultCodeTxFeeBumpInnerFailed:  "tx_fee_bump_inner_failed",
+}
+
+// operationCode returns the horizon result code of an operation result.
+func operationCode(result xdr.OperationResult) string {
+	switch result.Code {
+	case xdr.OperationResultCodeOpBadAuth:
+		return "op_bad_auth"
+	case xdr.OperationResultCodeOpNoAccount:
+		return "op_no_source_account"
+	case xdr.OperationResultCodeOpNotSupported:
+		return "op_not_supported"
+	}
+
+	switch result.Tr.Type {
+	case xdr.OperationTypeCreateAccount:
+		switch result.Tr.CreateAccountResult.Code {
+		case xdr.CreateAccountResultCodeCreateAccountMalformed:
+			return "op_malformed"
+		case xdr.CreateAccountResultCodeCreateAccountUnderfunded:
+			return "op_underfunded"
+		case xdr.CreateAccountResultCodeCreateAccountAlreadyExist:
+			return "op_already_exists"
+		}
+	case xdr.OperationTypePayment:
+		switch result.Tr.PaymentResult.Code {
+		case xdr.PaymentResultCodePaymentMalformed:
+			return "op_malformed"
+		case xdr.PaymentResultCodePaymentUnderfunded:
+			return "op_underfunded"
+		case xdr.PaymentResultCodePaymentSrcNoTrust:
+			return "op_src_no_trust"
+		case xdr.PaymentResultCodePaymentNoDestination:
+			return "op_no_destination"
+		case xdr.PaymentResultCodePaymentNoTrust:
+			return "op_no_trust"
+		}
+	case xdr.OperationTypeBumpSequence:
+		if result.Tr.BumpSeqResult.Code == xdr.BumpSequenceResultCodeBumpSequenceBadSeq {
+			return "op_bad_seq"
+		}
+	}
+	return "op_success"
+}
+
+// effectSpec is an effect of an operation, built once its id is known.
+type effectSpec struct {
+	account string
+	kind    effects.EffectType
+	build   func(effects.Base) effects.Effect
+}
+
+// operationSpec is an applied operation, built once its id is known.
+type operationSpec struct {
+	source       string
+	participants []string
+	payment      bool
+	build        func(operations.Base) operations.Operation
+	effects      []effectSpec
+}
+
+// submission is a transaction being applied.
+type submission struct {
+	envelope string
+	hash     string
+	tx       *txnbuild.Transaction
+	feeBump  *txnbuild.FeeBumpTransaction
+	source   string
+	// feeSource pays the fee, the fee account of a fee bump transaction.
+	feeSource string
+	fee       int64
+}
+
+func (s *Server) submit(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		s.problems.Render(r.Context(), w, problem.BadRequest)
+		return
+	}
+	envelope := r.PostForm.Get("tx")
+	parsed, err := txnbuild.TransactionFromXDR(envelope)
+	if err != nil {
+		s.problems.Render(r.Context(), w, problem.P{
+			Type:   "transaction_malformed",
+			Title:  "Transaction Malformed",
+			Status: http.StatusBadRequest,
+			Detail: "Horizon could not decode the transaction envelope in this request.",
+			Extras: map[string]interface{}{"envelope_xdr": envelope},
+		})
+		return
+	}
+
+	sub := submission{envelope: envelope}
+	if sub.hash, err = parsed.HashHex(s.NetworkPassphrase); err != nil {
+		s.problems.Render(r.Context(), w, err)
+		return
+	}
+	if feeBump, ok := parsed.FeeBump(); ok {
+		sub.feeBump, sub.tx = feeBump, feeBump.InnerTransaction()
+		sub.feeSource = accountID(feeBump.FeeAccount())
+		sub.fee = int64(len(sub.tx.Operations())+1) * baseFee
+	} else {
+		sub.tx, _ = parsed.Transaction()
+		sub.fee = int64(len(sub.tx.Operations())) * baseFee
+	}
+	sub.source = accountID(sub.tx.SourceAccount().AccountID)
+	if sub.feeSource == "" {
+		sub.feeSource = sub.source
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	result, tx := s.apply(sub)
+	resultXDR, err := xdr.MarshalBase64(result)
+	if err != nil {
+		s.problems.Render(r.Context(), w, err)
+		return
+	}
+	if tx != nil {
+		tx.ResultXdr = resultXDR
+	}
+
+	if result.Result.Code == xdr.TransactionResultCodeTxSuccess ||
+		result.Result.Code == xdr.TransactionResultCodeTxFeeBumpInnerSuccess {
+		hal.Render(w, tx.Transaction)
+		return
+	}
+
+	codes := hProtocol.TransactionResultCodes{TransactionCode: transactionCodes[result.Result.Code]}
+	results := result.Result.Results
+	if pair := result.Result.InnerResultPair; pair != nil {
+		codes.InnerTransactionCode = transactionCodes[pair.Result.Result.Code]
+		results = pair.Result.Result.Results
+	}
+	if results != nil {
+		for _, opResult := range *results {
+			codes.OperationCodes = append(codes.OperationCodes, operationCode(opResult))
+		}
+	}
+	s.problems.Render(r.Context(), w, problem.P{
+		Type:   "transaction_failed",
+		Title:  "Transaction Failed",
+		Status: http.StatusBadRequest,
+		Detail: "The transaction failed when submitted to the stellar network. " +
+			"The `extras.result_codes` field on this response contains further " +
+			"details.",
+		Extras: map[string]interface{}{
+			"envelope_xdr": sub.envelope,
+			"result_xdr":   resultXDR,
+			"result_codes": codes,
+		},
+	})
+}
+
+// apply validates and applies a transaction, and must be called with the lock
+// held. It returns the transaction stored if the transaction was applied,
+// successfully or not, and nil if it was rejected.
+func (s *Server) apply(sub submission) (xdr.TransactionResult, *transaction) {
+	now := s.now()
+	innerHash, err := sub.tx.Hash(s.NetworkPassphrase)
+	if err != nil {
+		panic(err)
+	}
+
+	reject := func(code xdr.TransactionResultCode, inner bool) (xdr.TransactionResult, *transaction) {
+		return transactionResult(sub, innerHash, 0, code, inner, nil), nil
+	}
+
+	// the checks of the outer transaction of a fee bump
+	feeSource, ok := s.accounts[sub.feeSource]
+	if sub.feeBump != nil {
+		switch {
+		case sub.feeBump.BaseFee() < baseFee:
+			return reject(xdr.TransactionResultCodeTxInsufficientFee, false)
+		case !ok:
+			return reject(xdr.TransactionResultCodeTxNoAccount, false)
+		case !s.authorized(sub.feeBump.Signatures(), sub.hashBytes(), feeSource, feeSource.Thresholds.LowThreshold):
+			return reject(xdr.TransactionResultCodeTxBadAuth, false)
+		}
+	}
+
+	// the checks of the transaction, which is the inner transaction of a fee
+	// bump
+	inner := sub.feeBump != nil
+	source, ok := s.accounts[sub.source]
+	tb := sub.tx.Timebounds()
+	switch {
+	case len(sub.tx.Operations()) == 0:
+		return reject(xdr.TransactionResultCodeTxMissingOperation, inner)
+	case tb.MinTime > 0 && now.Unix() < tb.MinTime:
+		return reject(xdr.TransactionResultCodeTxTooEarly, inner)
+	case tb.MaxTime > 0 && now.Unix() > tb.MaxTime:
+		return reject(xdr.TransactionResultCodeTxTooLate, inner)
+	case sub.feeBump == nil && sub.tx.BaseFee() < baseFee:
+		return reject(xdr.TransactionResultCodeTxInsufficientFee, inner)
+	case !ok:
+		return reject(xdr.TransactionResultCodeTxNoAccount, inner)
+	case sub.tx.SequenceNumber() != source.Sequence+1:
+		return reject(xdr.TransactionResultCodeTxBadSeq, inner)
+	case !s.authorized(sub.tx.Signatures(), innerHash, source, source.Thresholds.LowThreshold):
+		return reject(xdr.TransactionResultCodeTxBadAuth, inner)
+	}
+	feeSource = s.accounts[sub.feeSource]
+	if native, _ := balance(feeSource, "native", "", ""); native < sub.fee {
+		return reject(xdr.TransactionResultCodeTxInsufficientBalance, false)
+	}
+
+	// the fee is charged and the sequence number consumed, even if an
+	// operation fails
+	s.ledger++
+	s.closedAt = now.UTC().Truncate(time.Second)
+	native, _ := balance(feeSource, "native", "", "")
+	setBalance(feeSource, "native", "", "", native-sub.fee)
+	feeSource.LastModifiedLedger = uint32(s.ledger)
+	source.Sequence = sub.tx.SequenceNumber()
+	source.SequenceLedger = uint32(s.ledger)
+	source.LastModifiedLedger = uint32(s.ledger)
+
+	// the operations are applied to a copy of the accounts, which is kept
+	// only if they all succeed
+	state := make(map[string]*hProtocol.Account, len(s.accounts))
+	for id, account := range s.accounts {
+		state[id] = account
+	}
+	var opResults []xdr.OperationResult
+	var specs []operationSpec
+	successful := true
+	for _, op := range sub.tx.Operations() {
+		opResult, spec := s.applyOperation(state, sub, innerHash, op)
+		opResults = append(opResults, opResult)
+		specs = append(specs, spec)
+		successful = successful && operationCode(opResult) == "op_success"
+	}
+	if successful {
+		for id, account := range state {
+			if account != s.accounts[id] {
+				account.LastModifiedLedger = uint32(s.ledger)
+			}
+			s.accounts[id] = account
+		}
+	}
+
+	code := xdr.TransactionResultCodeTxSuccess
+	if !successful {
+		code = xdr.TransactionResultCodeTxFailed
+	}
+	result := transactionResult(sub, innerHash, sub.fee, code, inner, &opResults)
+	tx := s.record(sub, successful, specs)
+	return result, tx
+}
+
+// applyOperation applies an operation to state, replacing the accounts it
+// modifies with modified copies.
+func (s *Server) applyOperation(state map[string]*hProtocol.Account, sub submission, hash [32]byte, op txnbuild.Operation) (xdr.OperationResult, operationSpec) {
+	sourceID := sub.source
+	if op.GetSourceAccount() != "" {
+		sourceID = accountID(op.GetSourceAccount())
+	}
+	spec := operationSpec{source: sourceID, participants: []string{sourceID}}
+
+	source, ok := state[sourceID]
+	if !ok {
+		return xdr.OperationResult{Code: xdr.OperationResultCodeOpNoAccount}, spec
+	}
+	threshold := source.Thresholds.MedThreshold
+	if _, ok := op.(*txnbuild.BumpSequence); ok {
+		threshold = source.Thresholds.LowThreshold
+	}
+	if !s.authorized(sub.tx.Signatures(), hash, source, threshold) {
+		return xdr.OperationResult{Code: xdr.OperationResultCodeOpBadAuth}, spec
+	}
+	modify := func(id string) *hProtocol.Account {
+		account := cloneAccount(state[id])
+		state[id] = account
+		return account
+	}
+
+	switch op := op.(type) {
+	case *txnbuild.CreateAccount:
+		result := &xdr.CreateAccountResult{Code: xdr.CreateAccountResultCodeCreateAccountSuccess}
+		opResult := xdr.OperationResult{Code: xdr.OperationResultCodeOpInner, Tr: &xdr.OperationResultTr{
+			Type:                xdr.OperationTypeCreateAccount,
+			CreateAccountResult: result,
+		}}
+		destination := accountID(op.Destination)
+		spec.participants = append(spec.participants, destination)
+		starting, err := amount.ParseInt64(op.Amount)
+		available, _ := balance(source, "native", "", "")
+		switch {
+		case err != nil || starting < 0:
+			result.Code = xdr.CreateAccountResultCodeCreateAccountMalformed
+			return opResult, spec
+		case state[destination] != nil:
+			result.Code = xdr.CreateAccountResultCodeCreateAccountAlreadyExist
+			return opResult, spec
+		case available < starting:
+			result.Code = xdr.CreateAccountResultCodeCreateAccountUnderfunded
+			return opResult, spec
+		}
+
+		startingBalance := amount.StringFromInt64(starting)
+		setBalance(modify(sourceID), "native", "", "", available-starting)
+		state[destination] = &hProtocol.Account{
+			ID:        destination,
+			AccountID: destination,
+			PT:        destination,
+			Sequence:  int64(s.ledger) << 32,
+			Balances:  []hProtocol.Balance{nativeBalance(startingBalance)},
+			Signers:   []hProtocol.Signer{{Key: destination, Weight: 1, Type: "ed25519_public_key"}},
+			Data:      map[string]string{},
+		}
+		spec.build = func(b operations.Base) operations.Operation {
+			return operations.CreateAccount{Base: b, StartingBalance: startingBalance, Funder: sourceID, Account: destination}
+		}
+		spec.payment = true
+		spec.effects = []effectSpec{
+			{destination, effects.EffectAccountCreated, func(b effects.Base) effects.Effect {
+				return effects.AccountCreated{Base: b, StartingBalance: startingBalance}
+			}},
+			{sourceID, effects.EffectAccountDebited, func(b effects.Base) effects.Effect {
+				return effects.AccountDebited{Base: b, Asset: base.Asset{Type: "native"}, Amount: startingBalance}
+			}},
+		}
+		return opResult, spec
+
+	case *txnbuild.Payment:
+		result := &xdr.PaymentResult{Code: xdr.PaymentResultCodePaymentSuccess}
+		opResult := xdr.OperationResult{Code: xdr.OperationResultCodeOpInner, Tr: &xdr.OperationResultTr{
+			Type:          xdr.OperationTypePayment,
+			PaymentResult: result,
+		}}
+		destinationID := accountID(op.Destination)
+		spec.participants = append(spec.participants, destinationID)
+		value, err := amount.ParseInt64(op.Amount)
+		if err != nil || value <= 0 || op.Asset == nil {
+			result.Code = xdr.PaymentResultCodePaymentMalformed
+			return opResult, spec
+		}
+		asset := base.Asset{Type: "native"}
+		if !op.Asset.IsNative() {
+			asset = base.Asset{Type: "credit_alphanum4", Code: op.Asset.GetCode(), Issuer: op.Asset.GetIssuer()}
+			if len(asset.Code) > 4 {
+				asset.Type = "credit_alphanum12"
+			}
+		}
+		if state[destinationID] == nil {
+			result.Code = xdr.PaymentResultCodePaymentNoDestination
+			return opResult, spec
+		}
+
+		// the issuer of an asset mints and burns it, without a trustline
+		if sourceID != asset.Issuer {
+			available, trusted := balance(source, asset.Type, asset.Code, asset.Issuer)
+			switch {
+			case !trusted:
+				result.Code = xdr.PaymentResultCodePaymentSrcNoTrust
+				return opResult, spec
+			case available < value:
+				result.Code = xdr.PaymentResultCodePaymentUnderfunded
+				return opResult, spec
+			}
+			setBalance(modify(sourceID), asset.Type, asset.Code, asset.Issuer, available-value)
+		}
+		if destinationID != asset.Issuer {
+			held, trusted := balance(state[destinationID], asset.Type, asset.Code, asset.Issuer)
+			if !trusted {
+				result.Code = xdr.PaymentResultCodePaymentNoTrust
+				return opResult, spec
+			}
+			setBalance(modify(destinationID), asset.Type, asset.Code, asset.Issuer, held+value)
+		}
+
+		paid := amount.StringFromInt64(value)
+		spec.build = func(b operations.Base) operations.Operation {
+			return operations.Payment{Base: b, Asset: asset, From: sourceID, To: destinationID, Amount: paid}
+		}
+		spec.payment = true
+		spec.effects = []effectSpec{
+			{destinationID, effects.EffectAccountCredited, func(b effects.Base) effects.Effect {
+				return effects.AccountCredited{Base: b, Asset: asset, Amount: paid}
+			}},
+			{sourceID, effects.EffectAccountDebited, func(b effects.Base) effects.Effect {
+				return effects.AccountDebited{Base: b, Asset: asset, Amount: paid}
+			}},
+		}
+		return opResult, spec
+
+	case *txnbuild.BumpSequence:
+		result := &xdr.BumpSequenceResult{Code: xdr.BumpSequenceResultCodeBumpSequenceSuccess}
+		opResult := xdr.OperationResult{Code: xdr.OperationResultCodeOpInner, Tr: &xdr.OperationResultTr{
+			Type:          xdr.OperationTypeBumpSequence,
+			BumpSeqResult: result,
+		}}
+		if op.BumpTo < 0 {
+			result.Code = xdr.BumpSequenceResultCodeBumpSequenceBadSeq
+			return opResult, spec
+		}
+		spec.build = func(b operations.Base) operations.Operation {
+			return operations.BumpSequence{Base: b, BumpTo: strconv.FormatInt(op.BumpTo, 10)}
+		}
+		if op.BumpTo > source.Sequence {
+			modify(sourceID).Sequence = op.BumpTo
+			spec.effects = []effectSpec{
+				{sourceID, effects.EffectSequenceBumped, func(b effects.Base) effects.Effect {
+					return effects.SequenceBumped{Base: b, NewSeq: op.BumpTo}
+				}},
+			}
+		}
+		return opResult, spec
+
+	default:
+		return xdr.OperationResult{Code: xdr.OperationResultCodeOpNotSupported}, spec
+	}
+}
+
+// record stores an applied transaction, with its operations and, if it
+// succeeded, their effects. It must be called with the lock held.
+func (s *Server) record(sub submission, successful bool, specs []operationSpec) *transaction {
+	closedAt := s.closedAt
+	txID := toid.New(s.ledger, 1, 0)
+	tx := transaction{participants: []string{sub.source}}
+	if sub.feeSource != sub.source {
+		tx.participants = append(tx.participants, sub.feeSource)
+	}
+
+	tx.ID, tx.Hash, tx.PT = sub.hash, sub.hash, txID.String()
+	tx.Successful = successful
+	tx.Ledger = s.ledger
+	tx.LedgerCloseTime = closedAt
+	tx.Account = sub.source
+	tx.AccountSequence = sub.tx.SequenceNumber()
+	tx.FeeAccount = sub.feeSource
+	tx.FeeCharged = sub.fee
+	tx.MaxFee = sub.tx.MaxFee()
+	tx.OperationCount = int32(len(specs))
+	tx.EnvelopeXdr = sub.envelope
+	tx.MemoType, tx.Memo = memo(sub.tx.Memo())
+	tx.Signatures = signatures(sub.tx.Signatures())
+	if sub.feeBump != nil {
+		innerHash, _ := sub.tx.HashHex(s.NetworkPassphrase)
+		tx.MaxFee = sub.feeBump.MaxFee()
+		tx.FeeBumpTransaction = &hProtocol.FeeBumpTransaction{Hash: sub.hash, Signatures: signatures(sub.feeBump.Signatures())}
+		tx.InnerTransaction = &hProtocol.InnerTransaction{Hash: innerHash, Signatures: tx.Signatures, MaxFee: sub.tx.MaxFee()}
+		tx.Signatures = tx.FeeBumpTransaction.Signatures
+	}
+	self := s.URL + "/transactions/" + sub.hash
+	tx.Links.Self = hal.NewLink(self)
+	tx.Links.Transaction = tx.Links.Self
+	tx.Links.Account = hal.NewLink(s.URL + "/accounts/" + sub.source)
+	tx.Links.Operations = hal.NewLink(self + "/operations{?cursor,limit,order}")
+	tx.Links.Effects = hal.NewLink(self + "/effects{?cursor,limit,order}")
+
+	for i, spec := range specs {
+		opID := toid.New(s.ledger, 1, int32(i+1)).ToInt64()
+		b := operations.Base{
+			ID:                    strconv.FormatInt(opID, 10),
+			PT:                    strconv.FormatInt(opID, 10),
+			TransactionSuccessful: successful,
+			SourceAccount:         spec.source,
+			LedgerCloseTime:       closedAt,
+			TransactionHash:       sub.hash,
+		}
+		opType := sub.tx.Operations()[i]
+		if xdrOp, err := opType.BuildXDR(); err == nil {
+			b.TypeI = int32(xdrOp.Body.Type)
+			b.Type = operations.TypeNames[xdrOp.Body.Type]
+		}
+		b.Links.Self = hal.NewLink(fmt.Sprintf("%s/operations/%d", s.URL, opID))
+		b.Links.Transaction = hal.NewLink(self)
+		b.Links.Effects = hal.NewLink(fmt.Sprintf("%s/operations/%d/effects", s.URL, opID))
+
+		var op operations.Operation = b
+		if spec.build != nil {
+			op = spec.build(b)
+		}
+		s.operations = append(s.operations, operation{Operation: op, payment: spec.payment, participants: spec.participants})
+		for _, participant := range spec.participants {
+			if !slices.Contains(tx.participants, participant) {
+				tx.participants = append(tx.participants, participant)
+			}
+		}
+
+		if !successful {
+			continue
+		}
+		for j, e := range spec.effects {
+			eb := effects.Base{
+				ID:              fmt.Sprintf("%019d-%010d", opID, j+1),
+				PT:              fmt.Sprintf("%d-%d", opID, j+1),
+				Account:         e.account,
+				Type:            effects.EffectTypeNames[e.kind],
+				TypeI:           int32(e.kind),
+				LedgerCloseTime: closedAt,
+			}
+			eb.Links.Operation = hal.NewLink(fmt.Sprintf("%s/operations/%d", s.URL, opID))
+			s.effects = append(s.effects, effect{Effect: e.build(eb), transactionHash: sub.hash})
+		}
+	}
+
+	s.transactions = append(s.transactions, tx)
+	s.notify()
+	return &s.transactions[len(s.transactions)-1]
+}
+
+// authorized reports whether the signatures, of the transaction with the
+// given hash, reach the threshold of an account. Only ed25519 signers are
+// supported.
+func (s *Server) authorized(signatures []xdr.DecoratedSignature, hash [32]byte, account *hProtocol.Account, threshold byte) bool {
+	weight := int32(0)
+	for _, signer := range account.Signers {
+		if signer.Type != "ed25519_public_key" {
+			continue
+		}
+		kp, err := keypair.ParseAddress(signer.Key)
+		if err != nil {
+			continue
+		}
+		hint := kp.Hint()
+		for _, signature := range signatures {
+			if signature.Hint == xdr.SignatureHint(hint) && kp.Verify(hash[:], signature.Signature) == nil {
+				weight += signer.Weight
+				break
+			}
+		}
+	}
+	return weight > 0 && weight >= int32(threshold)
+}
+
+func (sub submission) hashBytes() [32]byte {
+	var hash [32]byte
+	decoded, err := hex.DecodeString(sub.hash)
+	if err == nil {
+		copy(hash[:], decoded)
+	}
+	return hash
+}
+
+// transactionResult returns the result of a transaction. inner is whether
+// the code is the one of the inner transaction of a fee bump.
+func transactionResult(sub submission, innerHash [32]byte, fee int64, code xdr.TransactionResultCode, inner bool, opResults *[]xdr.OperationResult) xdr.TransactionResult {
+	if sub.feeBump == nil || (!inner && opResults == nil) {
+		return xdr.TransactionResult{
+			FeeCharged: xdr.Int64(fee),
+			Result:     xdr.TransactionResultResult{Code: code, Results: opResults},
+		}
+	}
+
+	outer := xdr.TransactionResultCodeTxFeeBumpInnerFailed
+	if code == xdr.TransactionResultCodeTxSuccess {
+		outer = xdr.TransactionResultCodeTxFeeBumpInnerSuccess
+	}
+	return xdr.TransactionResult{
+		FeeCharged: xdr.Int64(fee),
+		Result: xdr.TransactionResultResult{
+			Code: outer,
+			InnerResultPair: &xdr.InnerTransactionResultPair{
+				TransactionHash: innerHash,
+				Result: xdr.InnerTransactionResult{
+					Result: xdr.InnerTransactionResultResult{Code: code, Results: opResults},
+				},
+			},
+		},
+	}
+}
+
+// accountID returns the account of an address, which may be muxed.
+func accountID(address string) string {
+	muxed, err := xdr.AddressToMuxedAccount(address)
+	if err != nil {
+		return address
+	}
+	return muxed.ToAccountId().Address()
+}
+
+func memo(m txnbuild.Memo) (string, string) {
+	switch m := m.(type) {
+	case txnbuild.MemoText:
+		return "text", string(m)
+	case txnbuild.MemoID:
+		return "id", strconv.FormatUint(uint64(m), 10)
+	case txnbuild.MemoHash:
+		return "hash", base64.StdEncoding.EncodeToString(m[:])
+	case txnbuild.MemoReturn:
+		return "return", base64.StdEncoding.EncodeToString(m[:])
+	default:
+		return "none", ""
+	}
+}
+
+func signatures(decorated []xdr.DecoratedSignature) []string {
+	encoded := make([]string, len(decorated))
+	for i, signature := range decorated {
+		encoded[i] = base64.StdEncoding.EncodeToString(signature.Signature)
+	}
+	return encoded
+}
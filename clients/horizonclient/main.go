@@ -148,6 +148,9 @@ type Client struct {
 	// Headers allows specifying additional HTTP headers for requests made by the client.
 	Headers map[string]string
 
+	// StreamOptions configures the reconnections of the streams.
+	StreamOptions StreamOptions
+
 	// clock is a Clock returning the current time.
 	clock *clock.Clock
 }
@@ -242,6 +245,82 @@ type ClientInterface interface {
 	PrevLiquidityPoolsPage(hProtocol.LiquidityPoolsPage) (hProtocol.LiquidityPoolsPage, error)
 }
 
+// ContextClientInterface contains the context-aware versions of the methods
+// of ClientInterface. Their requests are bound to the context they are given,
+// which can cancel them or carry request-scoped values, as well as to the
+// client timeout: a request ends at the deadline of its context or at the end
+// of the client timeout, whichever comes first. The methods of
+// ClientInterface call them with context.Background().
+type ContextClientInterface interface {
+	AccountsCtx(ctx context.Context, request AccountsRequest) (hProtocol.AccountsPage, error)
+	AccountDetailCtx(ctx context.Context, request AccountRequest) (hProtocol.Account, error)
+	AccountDataCtx(ctx context.Context, request AccountRequest) (hProtocol.AccountData, error)
+	EffectsCtx(ctx context.Context, request EffectRequest) (effects.EffectsPage, error)
+	AssetsCtx(ctx context.Context, request AssetRequest) (hProtocol.AssetsPage, error)
+	LedgersCtx(ctx context.Context, request LedgerRequest) (hProtocol.LedgersPage, error)
+	LedgerDetailCtx(ctx context.Context, sequence uint32) (hProtocol.Ledger, error)
+	FeeStatsCtx(ctx context.Context) (hProtocol.FeeStats, error)
+	OffersCtx(ctx context.Context, request OfferRequest) (hProtocol.OffersPage, error)
+	OfferDetailsCtx(ctx context.Context, offerID string) (hProtocol.Offer, error)
+	OperationsCtx(ctx context.Context, request OperationRequest) (operations.OperationsPage, error)
+	OperationDetailCtx(ctx context.Context, id string) (operations.Operation, error)
+	SubmitTransactionXDRCtx(ctx context.Context, transactionXdr string) (hProtocol.Transaction, error)
+	SubmitFeeBumpTransactionCtx(ctx context.Context, transaction *txnbuild.FeeBumpTransaction) (hProtocol.Transaction, error)
+	SubmitFeeBumpTransactionWithOptionsCtx(ctx context.Context, transaction *txnbuild.FeeBumpTransaction, opts SubmitTxOpts) (hProtocol.Transaction, error)
+	SubmitTransactionCtx(ctx context.Context, transaction *txnbuild.Transaction) (hProtocol.Transaction, error)
+	SubmitTransactionWithOptionsCtx(ctx context.Context, transaction *txnbuild.Transaction, opts SubmitTxOpts) (hProtocol.Transaction, error)
+	AsyncSubmitTransactionXDRCtx(ctx context.Context, transactionXdr string) (hProtocol.AsyncTransactionSubmissionResponse, error)
+	AsyncSubmitFeeBumpTransactionCtx(ctx context.Context, transaction *txnbuild.FeeBumpTransaction) (hProtocol.AsyncTransactionSubmissionResponse, error)
+	AsyncSubmitFeeBumpTransactionWithOptionsCtx(ctx context.Context, transaction *txnbuild.FeeBumpTransaction, opts SubmitTxOpts) (hProtocol.AsyncTransactionSubmissionResponse, error)
+	AsyncSubmitTransactionCtx(ctx context.Context, transaction *txnbuild.Transaction) (hProtocol.AsyncTransactionSubmissionResponse, error)
+	AsyncSubmitTransactionWithOptionsCtx(ctx context.Context, transaction *txnbuild.Transaction, opts SubmitTxOpts) (hProtocol.AsyncTransactionSubmissionResponse, error)
+	TransactionsCtx(ctx context.Context, request TransactionRequest) (hProtocol.TransactionsPage, error)
+	TransactionDetailCtx(ctx context.Context, txHash string) (hProtocol.Transaction, error)
+	OrderBookCtx(ctx context.Context, request OrderBookRequest) (hProtocol.OrderBookSummary, error)
+	PathsCtx(ctx context.Context, request PathsRequest) (hProtocol.PathsPage, error)
+	StrictReceivePathsCtx(ctx context.Context, request PathsRequest) (hProtocol.PathsPage, error)
+	StrictSendPathsCtx(ctx context.Context, request StrictSendPathsRequest) (hProtocol.PathsPage, error)
+	PaymentsCtx(ctx context.Context, request OperationRequest) (operations.OperationsPage, error)
+	TradesCtx(ctx context.Context, request TradeRequest) (hProtocol.TradesPage, error)
+	FundCtx(ctx context.Context, addr string) (hProtocol.Transaction, error)
+	TradeAggregationsCtx(ctx context.Context, request TradeAggregationRequest) (hProtocol.TradeAggregationsPage, error)
+	RootCtx(ctx context.Context) (hProtocol.Root, error)
+	NextAccountsPageCtx(ctx context.Context, page hProtocol.AccountsPage) (hProtocol.AccountsPage, error)
+	NextAssetsPageCtx(ctx context.Context, page hProtocol.AssetsPage) (hProtocol.AssetsPage, error)
+	PrevAssetsPageCtx(ctx context.Context, page hProtocol.AssetsPage) (hProtocol.AssetsPage, error)
+	NextLedgersPageCtx(ctx context.Context, page hProtocol.LedgersPage) (hProtocol.LedgersPage, error)
+	PrevLedgersPageCtx(ctx context.Context, page hProtocol.LedgersPage) (hProtocol.LedgersPage, error)
+	NextEffectsPageCtx(ctx context.Context, page effects.EffectsPage) (effects.EffectsPage, error)
+	PrevEffectsPageCtx(ctx context.Context, page effects.EffectsPage) (effects.EffectsPage, error)
+	NextTransactionsPageCtx(ctx context.Context, page hProtocol.TransactionsPage) (hProtocol.TransactionsPage, error)
+	PrevTransactionsPageCtx(ctx context.Context, page hProtocol.TransactionsPage) (hProtocol.TransactionsPage, error)
+	NextOperationsPageCtx(ctx context.Context, page operations.OperationsPage) (operations.OperationsPage, error)
+	PrevOperationsPageCtx(ctx context.Context, page operations.OperationsPage) (operations.OperationsPage, error)
+	NextPaymentsPageCtx(ctx context.Context, page operations.OperationsPage) (operations.OperationsPage, error)
+	PrevPaymentsPageCtx(ctx context.Context, page operations.OperationsPage) (operations.OperationsPage, error)
+	NextOffersPageCtx(ctx context.Context, page hProtocol.OffersPage) (hProtocol.OffersPage, error)
+	PrevOffersPageCtx(ctx context.Context, page hProtocol.OffersPage) (hProtocol.OffersPage, error)
+	NextTradesPageCtx(ctx context.Context, page hProtocol.TradesPage) (hProtocol.TradesPage, error)
+	PrevTradesPageCtx(ctx context.Context, page hProtocol.TradesPage) (hProtocol.TradesPage, error)
+	HomeDomainForAccountCtx(ctx context.Context, aid string) (string, error)
+	NextTradeAggregationsPageCtx(ctx context.Context, page hProtocol.TradeAggregationsPage) (hProtocol.TradeAggregationsPage, error)
+	PrevTradeAggregationsPageCtx(ctx context.Context, page hProtocol.TradeAggregationsPage) (hProtocol.TradeAggregationsPage, error)
+	ClaimableBalancesCtx(ctx context.Context, cbr ClaimableBalanceRequest) (hProtocol.ClaimableBalances, error)
+	ClaimableBalanceCtx(ctx context.Context, id string) (hProtocol.ClaimableBalance, error)
+	LiquidityPoolDetailCtx(ctx context.Context, request LiquidityPoolRequest) (hProtocol.LiquidityPool, error)
+	LiquidityPoolsCtx(ctx context.Context, request LiquidityPoolsRequest) (hProtocol.LiquidityPoolsPage, error)
+	NextLiquidityPoolsPageCtx(ctx context.Context, page hProtocol.LiquidityPoolsPage) (hProtocol.LiquidityPoolsPage, error)
+	PrevLiquidityPoolsPageCtx(ctx context.Context, page hProtocol.LiquidityPoolsPage) (hProtocol.LiquidityPoolsPage, error)
+	StreamTransactions(ctx context.Context, request TransactionRequest, handler TransactionHandler) error
+	StreamTrades(ctx context.Context, request TradeRequest, handler TradeHandler) error
+	StreamEffects(ctx context.Context, request EffectRequest, handler EffectHandler) error
+	StreamOperations(ctx context.Context, request OperationRequest, handler OperationHandler) error
+	StreamPayments(ctx context.Context, request OperationRequest, handler OperationHandler) error
+	StreamOffers(ctx context.Context, request OfferRequest, handler OfferHandler) error
+	StreamLedgers(ctx context.Context, request LedgerRequest, handler LedgerHandler) error
+	StreamOrderBooks(ctx context.Context, request OrderBookRequest, handler OrderBookHandler) error
+}
+
 // DefaultTestNetClient is a default client to connect to test network.
 var DefaultTestNetClient = &Client{
 	HorizonURL:     "https://horizon-testnet.stellar.org/",
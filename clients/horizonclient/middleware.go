@@ -0,0 +1,78 @@
+package horizonclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Middleware wraps the HTTP client used by a horizon client to add behavior
+// to every request, such as retries, rate limiting or circuit breaking.
+type Middleware func(next HTTP) HTTP
+
+// WithMiddleware wraps an HTTP client, http.DefaultClient if nil, with
+// middlewares. The first middleware is the outermost one, so that with
+//
+//	client.HTTP = WithMiddleware(http.DefaultClient,
+//		RetryPolicy{}.Middleware(),
+//		limiter.Middleware(),
+//		breaker.Middleware(),
+//	)
+//
+// every attempt of the retry middleware is rate limited and accounted for by
+// the circuit breaker.
+func WithMiddleware(client HTTP, middlewares ...Middleware) HTTP {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		client = middlewares[i](client)
+	}
+	return client
+}
+
+// DoFunc adapts a function sending requests to the HTTP interface, so that a
+// middleware only needs to implement Do.
+type DoFunc func(req *http.Request) (*http.Response, error)
+
+// Do sends the request.
+func (f DoFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Get sends a GET request to the url.
+func (f DoFunc) Get(url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return f(req)
+}
+
+// PostForm sends a POST request to the url with the form encoded data as
+// body.
+func (f DoFunc) PostForm(url string, data url.Values) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return f(req)
+}
+
+// sleepContext waits for the given duration or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -383,6 +383,359 @@ func (m *MockClient) PrevLiquidityPoolsPage(page hProtocol.LiquidityPoolsPage) (
 	return a.Get(0).(hProtocol.LiquidityPoolsPage), a.Error(1)
 }
 
+// AccountsCtx is a mocking method
+func (m *MockClient) AccountsCtx(ctx context.Context, request AccountsRequest) (hProtocol.AccountsPage, error) {
+	a := m.Called(ctx, request)
+	return a.Get(0).(hProtocol.AccountsPage), a.Error(1)
+}
+
+// AccountDetailCtx is a mocking method
+func (m *MockClient) AccountDetailCtx(ctx context.Context, request AccountRequest) (hProtocol.Account, error) {
+	a := m.Called(ctx, request)
+	return a.Get(0).(hProtocol.Account), a.Error(1)
+}
+
+// AccountDataCtx is a mocking method
+func (m *MockClient) AccountDataCtx(ctx context.Context, request AccountRequest) (hProtocol.AccountData, error) {
+	a := m.Called(ctx, request)
+	return a.Get(0).(hProtocol.AccountData), a.Error(1)
+}
+
+// EffectsCtx is a mocking method
+func (m *MockClient) EffectsCtx(ctx context.Context, request EffectRequest) (effects.EffectsPage, error) {
+	a := m.Called(ctx, request)
+	return a.Get(0).(effects.EffectsPage), a.Error(1)
+}
+
+// AssetsCtx is a mocking method
+func (m *MockClient) AssetsCtx(ctx context.Context, request AssetRequest) (hProtocol.AssetsPage, error) {
+	a := m.Called(ctx, request)
+	return a.Get(0).(hProtocol.AssetsPage), a.Error(1)
+}
+
+// LedgersCtx is a mocking method
+func (m *MockClient) LedgersCtx(ctx context.Context, request LedgerRequest) (hProtocol.LedgersPage, error) {
+	a := m.Called(ctx, request)
+	return a.Get(0).(hProtocol.LedgersPage), a.Error(1)
+}
+
+// LedgerDetailCtx is a mocking method
+func (m *MockClient) LedgerDetailCtx(ctx context.Context, sequence uint32) (hProtocol.Ledger, error) {
+	a := m.Called(ctx, sequence)
+	return a.Get(0).(hProtocol.Ledger), a.Error(1)
+}
+
+// FeeStatsCtx is a mocking method
+func (m *MockClient) FeeStatsCtx(ctx context.Context) (hProtocol.FeeStats, error) {
+	a := m.Called(ctx)
+	return a.Get(0).(hProtocol.FeeStats), a.Error(1)
+}
+
+// OffersCtx is a mocking method
+func (m *MockClient) OffersCtx(ctx context.Context, request OfferRequest) (hProtocol.OffersPage, error) {
+	a := m.Called(ctx, request)
+	return a.Get(0).(hProtocol.OffersPage), a.Error(1)
+}
+
+// OfferDetailsCtx is a mocking method
+func (m *MockClient) OfferDetailsCtx(ctx context.Context, offerID string) (hProtocol.Offer, error) {
+	a := m.Called(ctx, offerID)
+	return a.Get(0).(hProtocol.Offer), a.Error(1)
+}
+
+// OperationsCtx is a mocking method
+func (m *MockClient) OperationsCtx(ctx context.Context, request OperationRequest) (operations.OperationsPage, error) {
+	a := m.Called(ctx, request)
+	return a.Get(0).(operations.OperationsPage), a.Error(1)
+}
+
+// OperationDetailCtx is a mocking method
+func (m *MockClient) OperationDetailCtx(ctx context.Context, id string) (operations.Operation, error) {
+	a := m.Called(ctx, id)
+	return a.Get(0).(operations.Operation), a.Error(1)
+}
+
+// SubmitTransactionXDRCtx is a mocking method
+func (m *MockClient) SubmitTransactionXDRCtx(ctx context.Context, transactionXdr string) (hProtocol.Transaction, error) {
+	a := m.Called(ctx, transactionXdr)
+	return a.Get(0).(hProtocol.Transaction), a.Error(1)
+}
+
+// SubmitFeeBumpTransactionCtx is a mocking method
+func (m *MockClient) SubmitFeeBumpTransactionCtx(ctx context.Context, transaction *txnbuild.FeeBumpTransaction) (hProtocol.Transaction, error) {
+	a := m.Called(ctx, transaction)
+	return a.Get(0).(hProtocol.Transaction), a.Error(1)
+}
+
+// SubmitFeeBumpTransactionWithOptionsCtx is a mocking method
+func (m *MockClient) SubmitFeeBumpTransactionWithOptionsCtx(ctx context.Context, transaction *txnbuild.FeeBumpTransaction, opts SubmitTxOpts) (hProtocol.Transaction, error) {
+	a := m.Called(ctx, transaction, opts)
+	return a.Get(0).(hProtocol.Transaction), a.Error(1)
+}
+
+// SubmitTransactionCtx is a mocking method
+func (m *MockClient) SubmitTransactionCtx(ctx context.Context, transaction *txnbuild.Transaction) (hProtocol.Transaction, error) {
+	a := m.Called(ctx, transaction)
+	return a.Get(0).(hProtocol.Transaction), a.Error(1)
+}
+
+// SubmitTransactionWithOptionsCtx is a mocking method
+func (m *MockClient) SubmitTransactionWithOptionsCtx(ctx context.Context, transaction *txnbuild.Transaction, opts SubmitTxOpts) (hProtocol.Transaction, error) {
+	a := m.Called(ctx, transaction, opts)
+	return a.Get(0).(hProtocol.Transaction), a.Error(1)
+}
+
+// AsyncSubmitTransactionXDRCtx is a mocking method
+func (m *MockClient) AsyncSubmitTransactionXDRCtx(ctx context.Context, transactionXdr string) (hProtocol.AsyncTransactionSubmissionResponse, error) {
+	a := m.Called(ctx, transactionXdr)
+	return a.Get(0).(hProtocol.AsyncTransactionSubmissionResponse), a.Error(1)
+}
+
+// AsyncSubmitFeeBumpTransactionCtx is a mocking method
+func (m *MockClient) AsyncSubmitFeeBumpTransactionCtx(ctx context.Context, transaction *txnbuild.FeeBumpTransaction) (hProtocol.AsyncTransactionSubmissionResponse, error) {
+	a := m.Called(ctx, transaction)
+	return a.Get(0).(hProtocol.AsyncTransactionSubmissionResponse), a.Error(1)
+}
+
+// AsyncSubmitFeeBumpTransactionWithOptionsCtx is a mocking method
+func (m *MockClient) AsyncSubmitFeeBumpTransactionWithOptionsCtx(ctx context.Context, transaction *txnbuild.FeeBumpTransaction, opts SubmitTxOpts) (hProtocol.AsyncTransactionSubmissionResponse, error) {
+	a := m.Called(ctx, transaction, opts)
+	return a.Get(0).(hProtocol.AsyncTransactionSubmissionResponse), a.Error(1)
+}
+
+// AsyncSubmitTransactionCtx is a mocking method
+func (m *MockClient) AsyncSubmitTransactionCtx(ctx context.Context, transaction *txnbuild.Transaction) (hProtocol.AsyncTransactionSubmissionResponse, error) {
+	a := m.Called(ctx, transaction)
+	return a.Get(0).(hProtocol.AsyncTransactionSubmissionResponse), a.Error(1)
+}
+
+// AsyncSubmitTransactionWithOptionsCtx is a mocking method
+func (m *MockClient) AsyncSubmitTransactionWithOptionsCtx(ctx context.Context, transaction *txnbuild.Transaction, opts SubmitTxOpts) (hProtocol.AsyncTransactionSubmissionResponse, error) {
+	a := m.Called(ctx, transaction, opts)
+	return a.Get(0).(hProtocol.AsyncTransactionSubmissionResponse), a.Error(1)
+}
+
+// TransactionsCtx is a mocking method
+func (m *MockClient) TransactionsCtx(ctx context.Context, request TransactionRequest) (hProtocol.TransactionsPage, error) {
+	a := m.Called(ctx, request)
+	return a.Get(0).(hProtocol.TransactionsPage), a.Error(1)
+}
+
+// TransactionDetailCtx is a mocking method
+func (m *MockClient) TransactionDetailCtx(ctx context.Context, txHash string) (hProtocol.Transaction, error) {
+	a := m.Called(ctx, txHash)
+	return a.Get(0).(hProtocol.Transaction), a.Error(1)
+}
+
+// OrderBookCtx is a mocking method
+func (m *MockClient) OrderBookCtx(ctx context.Context, request OrderBookRequest) (hProtocol.OrderBookSummary, error) {
+	a := m.Called(ctx, request)
+	return a.Get(0).(hProtocol.OrderBookSummary), a.Error(1)
+}
+
+// PathsCtx is a mocking method
+func (m *MockClient) PathsCtx(ctx context.Context, request PathsRequest) (hProtocol.PathsPage, error) {
+	a := m.Called(ctx, request)
+	return a.Get(0).(hProtocol.PathsPage), a.Error(1)
+}
+
+// StrictReceivePathsCtx is a mocking method
+func (m *MockClient) StrictReceivePathsCtx(ctx context.Context, request PathsRequest) (hProtocol.PathsPage, error) {
+	a := m.Called(ctx, request)
+	return a.Get(0).(hProtocol.PathsPage), a.Error(1)
+}
+
+// StrictSendPathsCtx is a mocking method
+func (m *MockClient) StrictSendPathsCtx(ctx context.Context, request StrictSendPathsRequest) (hProtocol.PathsPage, error) {
+	a := m.Called(ctx, request)
+	return a.Get(0).(hProtocol.PathsPage), a.Error(1)
+}
+
+// PaymentsCtx is a mocking method
+func (m *MockClient) PaymentsCtx(ctx context.Context, request OperationRequest) (operations.OperationsPage, error) {
+	a := m.Called(ctx, request)
+	return a.Get(0).(operations.OperationsPage), a.Error(1)
+}
+
+// TradesCtx is a mocking method
+func (m *MockClient) TradesCtx(ctx context.Context, request TradeRequest) (hProtocol.TradesPage, error) {
+	a := m.Called(ctx, request)
+	return a.Get(0).(hProtocol.TradesPage), a.Error(1)
+}
+
+// FundCtx is a mocking method
+func (m *MockClient) FundCtx(ctx context.Context, addr string) (hProtocol.Transaction, error) {
+	a := m.Called(ctx, addr)
+	return a.Get(0).(hProtocol.Transaction), a.Error(1)
+}
+
+// TradeAggregationsCtx is a mocking method
+func (m *MockClient) TradeAggregationsCtx(ctx context.Context, request TradeAggregationRequest) (hProtocol.TradeAggregationsPage, error) {
+	a := m.Called(ctx, request)
+	return a.Get(0).(hProtocol.TradeAggregationsPage), a.Error(1)
+}
+
+// RootCtx is a mocking method
+func (m *MockClient) RootCtx(ctx context.Context) (hProtocol.Root, error) {
+	a := m.Called(ctx)
+	return a.Get(0).(hProtocol.Root), a.Error(1)
+}
+
+// NextAccountsPageCtx is a mocking method
+func (m *MockClient) NextAccountsPageCtx(ctx context.Context, page hProtocol.AccountsPage) (hProtocol.AccountsPage, error) {
+	a := m.Called(ctx, page)
+	return a.Get(0).(hProtocol.AccountsPage), a.Error(1)
+}
+
+// NextAssetsPageCtx is a mocking method
+func (m *MockClient) NextAssetsPageCtx(ctx context.Context, page hProtocol.AssetsPage) (hProtocol.AssetsPage, error) {
+	a := m.Called(ctx, page)
+	return a.Get(0).(hProtocol.AssetsPage), a.Error(1)
+}
+
+// PrevAssetsPageCtx is a mocking method
+func (m *MockClient) PrevAssetsPageCtx(ctx context.Context, page hProtocol.AssetsPage) (hProtocol.AssetsPage, error) {
+	a := m.Called(ctx, page)
+	return a.Get(0).(hProtocol.AssetsPage), a.Error(1)
+}
+
+// NextLedgersPageCtx is a mocking method
+func (m *MockClient) NextLedgersPageCtx(ctx context.Context, page hProtocol.LedgersPage) (hProtocol.LedgersPage, error) {
+	a := m.Called(ctx, page)
+	return a.Get(0).(hProtocol.LedgersPage), a.Error(1)
+}
+
+// PrevLedgersPageCtx is a mocking method
+func (m *MockClient) PrevLedgersPageCtx(ctx context.Context, page hProtocol.LedgersPage) (hProtocol.LedgersPage, error) {
+	a := m.Called(ctx, page)
+	return a.Get(0).(hProtocol.LedgersPage), a.Error(1)
+}
+
+// NextEffectsPageCtx is a mocking method
+func (m *MockClient) NextEffectsPageCtx(ctx context.Context, page effects.EffectsPage) (effects.EffectsPage, error) {
+	a := m.Called(ctx, page)
+	return a.Get(0).(effects.EffectsPage), a.Error(1)
+}
+
+// PrevEffectsPageCtx is a mocking method
+func (m *MockClient) PrevEffectsPageCtx(ctx context.Context, page effects.EffectsPage) (effects.EffectsPage, error) {
+	a := m.Called(ctx, page)
+	return a.Get(0).(effects.EffectsPage), a.Error(1)
+}
+
+// NextTransactionsPageCtx is a mocking method
+func (m *MockClient) NextTransactionsPageCtx(ctx context.Context, page hProtocol.TransactionsPage) (hProtocol.TransactionsPage, error) {
+	a := m.Called(ctx, page)
+	return a.Get(0).(hProtocol.TransactionsPage), a.Error(1)
+}
+
+// PrevTransactionsPageCtx is a mocking method
+func (m *MockClient) PrevTransactionsPageCtx(ctx context.Context, page hProtocol.TransactionsPage) (hProtocol.TransactionsPage, error) {
+	a := m.Called(ctx, page)
+	return a.Get(0).(hProtocol.TransactionsPage), a.Error(1)
+}
+
+// NextOperationsPageCtx is a mocking method
+func (m *MockClient) NextOperationsPageCtx(ctx context.Context, page operations.OperationsPage) (operations.OperationsPage, error) {
+	a := m.Called(ctx, page)
+	return a.Get(0).(operations.OperationsPage), a.Error(1)
+}
+
+// PrevOperationsPageCtx is a mocking method
+func (m *MockClient) PrevOperationsPageCtx(ctx context.Context, page operations.OperationsPage) (operations.OperationsPage, error) {
+	a := m.Called(ctx, page)
+	return a.Get(0).(operations.OperationsPage), a.Error(1)
+}
+
+// NextPaymentsPageCtx is a mocking method
+func (m *MockClient) NextPaymentsPageCtx(ctx context.Context, page operations.OperationsPage) (operations.OperationsPage, error) {
+	a := m.Called(ctx, page)
+	return a.Get(0).(operations.OperationsPage), a.Error(1)
+}
+
+// PrevPaymentsPageCtx is a mocking method
+func (m *MockClient) PrevPaymentsPageCtx(ctx context.Context, page operations.OperationsPage) (operations.OperationsPage, error) {
+	a := m.Called(ctx, page)
+	return a.Get(0).(operations.OperationsPage), a.Error(1)
+}
+
+// NextOffersPageCtx is a mocking method
+func (m *MockClient) NextOffersPageCtx(ctx context.Context, page hProtocol.OffersPage) (hProtocol.OffersPage, error) {
+	a := m.Called(ctx, page)
+	return a.Get(0).(hProtocol.OffersPage), a.Error(1)
+}
+
+// PrevOffersPageCtx is a mocking method
+func (m *MockClient) PrevOffersPageCtx(ctx context.Context, page hProtocol.OffersPage) (hProtocol.OffersPage, error) {
+	a := m.Called(ctx, page)
+	return a.Get(0).(hProtocol.OffersPage), a.Error(1)
+}
+
+// NextTradesPageCtx is a mocking method
+func (m *MockClient) NextTradesPageCtx(ctx context.Context, page hProtocol.TradesPage) (hProtocol.TradesPage, error) {
+	a := m.Called(ctx, page)
+	return a.Get(0).(hProtocol.TradesPage), a.Error(1)
+}
+
+// PrevTradesPageCtx is a mocking method
+func (m *MockClient) PrevTradesPageCtx(ctx context.Context, page hProtocol.TradesPage) (hProtocol.TradesPage, error) {
+	a := m.Called(ctx, page)
+	return a.Get(0).(hProtocol.TradesPage), a.Error(1)
+}
+
+// HomeDomainForAccountCtx is a mocking method
+func (m *MockClient) HomeDomainForAccountCtx(ctx context.Context, aid string) (string, error) {
+	a := m.Called(ctx, aid)
+	return a.Get(0).(string), a.Error(1)
+}
+
+// NextTradeAggregationsPageCtx is a mocking method
+func (m *MockClient) NextTradeAggregationsPageCtx(ctx context.Context, page hProtocol.TradeAggregationsPage) (hProtocol.TradeAggregationsPage, error) {
+	a := m.Called(ctx, page)
+	return a.Get(0).(hProtocol.TradeAggregationsPage), a.Error(1)
+}
+
+// PrevTradeAggregationsPageCtx is a mocking method
+func (m *MockClient) PrevTradeAggregationsPageCtx(ctx context.Context, page hProtocol.TradeAggregationsPage) (hProtocol.TradeAggregationsPage, error) {
+	a := m.Called(ctx, page)
+	return a.Get(0).(hProtocol.TradeAggregationsPage), a.Error(1)
+}
+
+// ClaimableBalancesCtx is a mocking method
+func (m *MockClient) ClaimableBalancesCtx(ctx context.Context, cbr ClaimableBalanceRequest) (hProtocol.ClaimableBalances, error) {
+	a := m.Called(ctx, cbr)
+	return a.Get(0).(hProtocol.ClaimableBalances), a.Error(1)
+}
+
+// ClaimableBalanceCtx is a mocking method
+func (m *MockClient) ClaimableBalanceCtx(ctx context.Context, id string) (hProtocol.ClaimableBalance, error) {
+	a := m.Called(ctx, id)
+	return a.Get(0).(hProtocol.ClaimableBalance), a.Error(1)
+}
+
+// LiquidityPoolDetailCtx is a mocking method
+func (m *MockClient) LiquidityPoolDetailCtx(ctx context.Context, request LiquidityPoolRequest) (hProtocol.LiquidityPool, error) {
+	a := m.Called(ctx, request)
+	return a.Get(0).(hProtocol.LiquidityPool), a.Error(1)
+}
+
+// LiquidityPoolsCtx is a mocking method
+func (m *MockClient) LiquidityPoolsCtx(ctx context.Context, request LiquidityPoolsRequest) (hProtocol.LiquidityPoolsPage, error) {
+	a := m.Called(ctx, request)
+	return a.Get(0).(hProtocol.LiquidityPoolsPage), a.Error(1)
+}
+
+// NextLiquidityPoolsPageCtx is a mocking method
+func (m *MockClient) NextLiquidityPoolsPageCtx(ctx context.Context, page hProtocol.LiquidityPoolsPage) (hProtocol.LiquidityPoolsPage, error) {
+	a := m.Called(ctx, page)
+	return a.Get(0).(hProtocol.LiquidityPoolsPage), a.Error(1)
+}
+
+// PrevLiquidityPoolsPageCtx is a mocking method
+func (m *MockClient) PrevLiquidityPoolsPageCtx(ctx context.Context, page hProtocol.LiquidityPoolsPage) (hProtocol.LiquidityPoolsPage, error) {
+	a := m.Called(ctx, page)
+	return a.Get(0).(hProtocol.LiquidityPoolsPage), a.Error(1)
+}
 func (m *MockAdminClient) GetIngestionAccountFilter() (hProtocol.AccountFilterConfig, error) {
 	a := m.Called()
 	return a.Get(0).(hProtocol.AccountFilterConfig), a.Error(1)
@@ -406,5 +759,8 @@ func (m *MockAdminClient) SetIngestionAssetFilter(resource hProtocol.AssetFilter
 // ensure that the MockClient implements ClientInterface
 var _ ClientInterface = &MockClient{}
 
+// ensure that the MockClient implements ContextClientInterface
+var _ ContextClientInterface = &MockClient{}
+
 // ensure that the MockClient implements ClientInterface
 var _ AdminClientInterface = &MockAdminClient{}
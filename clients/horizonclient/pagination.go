@@ -0,0 +1,170 @@
+package horizonclient
+
+import (
+	"context"
+	"encoding/json"
+	"iter"
+	"strconv"
+
+	"github.com/stellar/go/protocols/horizon/effects"
+	"github.com/stellar/go/protocols/horizon/operations"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/support/render/hal"
+)
+
+// Record is implemented by the records returned by horizon collection
+// endpoints, e.g. horizon.Ledger, effects.Effect or operations.Operation.
+type Record interface {
+	PagingToken() string
+}
+
+// PageOptions configures the iteration over a collection by Records. The
+// zero value walks every page of the request as is.
+type PageOptions struct {
+	// Cursor, if set, replaces the cursor of the request.
+	Cursor string
+	// Order, if set, replaces the order of the request.
+	Order Order
+	// PageSize, if set, replaces the limit of the request, i.e. the number of
+	// records fetched with every request.
+	PageSize uint
+	// MaxRecords, if positive, is the maximum number of records returned.
+	MaxRecords int
+	// StopAt, if set, ends the iteration before the record with this paging
+	// token, e.g. the last record processed by a previous iteration in
+	// descending order.
+	StopAt string
+}
+
+// page is a page of any horizon collection, with its records left undecoded.
+type page struct {
+	Links    hal.Links `json:"_links"`
+	Embedded struct {
+		Records []json.RawMessage `json:"records"`
+	} `json:"_embedded"`
+}
+
+// Records returns an iterator over all the records of a collection request,
+// such as LedgerRequest, EffectRequest or OperationRequest, following the
+// next links of the pages until an empty page. T is the type of the records
+// of the collection:
+//
+//	for ledger, err := range horizonclient.Records[hProtocol.Ledger](ctx, client, request, options) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+//
+// The iteration ends after the first error, which is returned with the zero
+// value of T. Requests are bound to ctx, so the iteration ends with ctx.Err()
+// once ctx is done.
+//
+// An OperationRequest must have its endpoint set, with SetOperationsEndpoint
+// or SetPaymentsEndpoint.
+func Records[T Record](ctx context.Context, client *Client, request HorizonRequest, options PageOptions) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		count := 0
+		next := ""
+		for first := true; ; first = false {
+			if err := ctx.Err(); err != nil {
+				yield(zero, err)
+				return
+			}
+
+			var p page
+			var err error
+			if first {
+				err = client.sendFirstPage(ctx, request, options, &p)
+			} else {
+				err = client.sendGetRequest(ctx, next, &p)
+			}
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			if len(p.Embedded.Records) == 0 {
+				return
+			}
+
+			for _, data := range p.Embedded.Records {
+				record, err := decodeRecord[T](data)
+				if err != nil {
+					yield(zero, err)
+					return
+				}
+				if options.StopAt != "" && record.PagingToken() == options.StopAt {
+					return
+				}
+				if !yield(record, nil) {
+					return
+				}
+				count++
+				if options.MaxRecords > 0 && count >= options.MaxRecords {
+					return
+				}
+			}
+
+			if p.Links.Next.Href == "" || p.Links.Next.Href == next {
+				return
+			}
+			next = p.Links.Next.Href
+		}
+	}
+}
+
+// sendFirstPage requests the first page of a collection, with the query
+// parameters overridden by options.
+func (c *Client) sendFirstPage(ctx context.Context, request HorizonRequest, options PageOptions, p *page) error {
+	req, err := request.HTTPRequest(c.fixHorizonURL())
+	if err != nil {
+		return err
+	}
+
+	query := req.URL.Query()
+	if options.Cursor != "" {
+		query.Set("cursor", options.Cursor)
+	}
+	if options.Order != "" {
+		query.Set("order", string(options.Order))
+	}
+	if options.PageSize > 0 {
+		query.Set("limit", strconv.FormatUint(uint64(options.PageSize), 10))
+	}
+	req.URL.RawQuery = query.Encode()
+
+	return c.sendHTTPRequest(ctx, req, p)
+}
+
+// decodeRecord decodes a record of a collection, using the type of the record
+// to find the concrete type of effects and operations.
+func decodeRecord[T Record](data json.RawMessage) (T, error) {
+	var record T
+	var decoded Record
+	var err error
+	switch any(&record).(type) {
+	case *effects.Effect:
+		var base effects.Base
+		if err = json.Unmarshal(data, &base); err != nil {
+			return record, errors.Wrap(err, "error decoding effect")
+		}
+		decoded, err = effects.UnmarshalEffect(base.Type, data)
+	case *operations.Operation:
+		var base operations.Base
+		if err = json.Unmarshal(data, &base); err != nil {
+			return record, errors.Wrap(err, "error decoding operation")
+		}
+		decoded, err = operations.UnmarshalOperation(base.TypeI, data)
+	default:
+		if err = json.Unmarshal(data, &record); err != nil {
+			return record, errors.Wrap(err, "error decoding record")
+		}
+		return record, nil
+	}
+	if err != nil {
+		return record, err
+	}
+	record, _ = decoded.(T)
+	return record, nil
+}
@@ -0,0 +1,159 @@
+package horizonclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/protocols/horizon/effects"
+	"github.com/stellar/go/protocols/horizon/operations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pagingServer serves a collection of numbered records, paged by cursor and
+// limit, and records the queries it receives.
+func pagingServer(t *testing.T, total int, record func(i int) string) (*httptest.Server, *[]url.Values) {
+	var queries []url.Values
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		queries = append(queries, query)
+		cursor, _ := strconv.Atoi(query.Get("cursor"))
+		limit, err := strconv.Atoi(query.Get("limit"))
+		if err != nil {
+			limit = 10
+		}
+		desc := query.Get("order") == "desc"
+		if desc && cursor == 0 {
+			cursor = total + 1
+		}
+
+		var records []string
+		for len(records) < limit {
+			if desc {
+				cursor--
+			} else {
+				cursor++
+			}
+			if cursor < 1 || cursor > total {
+				break
+			}
+			records = append(records, record(cursor))
+		}
+		next := fmt.Sprintf("%s%s?cursor=%d&limit=%d&order=%s", server.URL, r.URL.Path, cursor, limit, query.Get("order"))
+		fmt.Fprintf(w, `{"_links": {"next": {"href": %q}}, "_embedded": {"records": [%s]}}`, next, strings.Join(records, ","))
+	}))
+	t.Cleanup(server.Close)
+	return server, &queries
+}
+
+func ledgerRecord(i int) string {
+	return fmt.Sprintf(`{"id": "%d", "paging_token": "%d", "sequence": %d}`, i, i, i)
+}
+
+func collectLedgers(t *testing.T, seq func(func(hProtocol.Ledger, error) bool)) []int32 {
+	var sequences []int32
+	for ledger, err := range seq {
+		require.NoError(t, err)
+		sequences = append(sequences, ledger.Sequence)
+	}
+	return sequences
+}
+
+func TestRecordsWalksAllPages(t *testing.T) {
+	server, queries := pagingServer(t, 7, ledgerRecord)
+	client := &Client{HorizonURL: server.URL}
+
+	sequences := collectLedgers(t, Records[hProtocol.Ledger](context.Background(), client, LedgerRequest{Limit: 3}, PageOptions{}))
+	assert.Equal(t, []int32{1, 2, 3, 4, 5, 6, 7}, sequences)
+	// the last page is empty
+	assert.Len(t, *queries, 4)
+}
+
+func TestRecordsOptions(t *testing.T) {
+	server, queries := pagingServer(t, 7, ledgerRecord)
+	client := &Client{HorizonURL: server.URL}
+
+	options := PageOptions{Order: OrderDesc, Cursor: "6", PageSize: 2, MaxRecords: 3}
+	sequences := collectLedgers(t, Records[hProtocol.Ledger](context.Background(), client, LedgerRequest{Order: OrderAsc, Limit: 5}, options))
+	assert.Equal(t, []int32{5, 4, 3}, sequences)
+	assert.Len(t, *queries, 2)
+	assert.Equal(t, "desc", (*queries)[0].Get("order"))
+	assert.Equal(t, "6", (*queries)[0].Get("cursor"))
+	assert.Equal(t, "2", (*queries)[0].Get("limit"))
+
+	// the iteration ends before the StopAt record
+	options = PageOptions{Order: OrderDesc, StopAt: "4"}
+	sequences = collectLedgers(t, Records[hProtocol.Ledger](context.Background(), client, LedgerRequest{}, options))
+	assert.Equal(t, []int32{7, 6, 5}, sequences)
+}
+
+func TestRecordsBreak(t *testing.T) {
+	server, queries := pagingServer(t, 7, ledgerRecord)
+	client := &Client{HorizonURL: server.URL}
+
+	for ledger, err := range Records[hProtocol.Ledger](context.Background(), client, LedgerRequest{Limit: 2}, PageOptions{}) {
+		require.NoError(t, err)
+		if ledger.Sequence == 3 {
+			break
+		}
+	}
+	assert.Len(t, *queries, 2)
+}
+
+func TestRecordsInterfaces(t *testing.T) {
+	server, _ := pagingServer(t, 2, func(i int) string {
+		return fmt.Sprintf(`{"id": "%d", "paging_token": "%d", "type": "account_created", "type_i": 0, "account": "GA%d", "starting_balance": "10.0000000"}`, i, i, i)
+	})
+	client := &Client{HorizonURL: server.URL}
+
+	var effectRecords []effects.Effect
+	for effect, err := range Records[effects.Effect](context.Background(), client, EffectRequest{}, PageOptions{}) {
+		require.NoError(t, err)
+		effectRecords = append(effectRecords, effect)
+	}
+	require.Len(t, effectRecords, 2)
+	created, ok := effectRecords[1].(effects.AccountCreated)
+	require.True(t, ok)
+	assert.Equal(t, "10.0000000", created.StartingBalance)
+
+	var ops []operations.Operation
+	for op, err := range Records[operations.Operation](context.Background(), client, (&OperationRequest{}).SetOperationsEndpoint(), PageOptions{}) {
+		require.NoError(t, err)
+		ops = append(ops, op)
+	}
+	require.Len(t, ops, 2)
+	assert.IsType(t, operations.CreateAccount{}, ops[0])
+	assert.Equal(t, "1", ops[0].PagingToken())
+}
+
+func TestRecordsErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"type": "https://stellar.org/horizon-errors/not_found", "title": "Resource Missing", "status": 404}`))
+	}))
+	defer server.Close()
+	client := &Client{HorizonURL: server.URL}
+
+	count := 0
+	for _, err := range Records[hProtocol.Ledger](context.Background(), client, LedgerRequest{}, PageOptions{}) {
+		count++
+		var herr *Error
+		require.ErrorAs(t, err, &herr)
+		assert.Equal(t, http.StatusNotFound, herr.Problem.Status)
+	}
+	assert.Equal(t, 1, count)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, err := range Records[hProtocol.Ledger](ctx, client, LedgerRequest{}, PageOptions{}) {
+		assert.ErrorIs(t, err, context.Canceled)
+	}
+}
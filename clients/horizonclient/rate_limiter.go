@@ -0,0 +1,112 @@
+package horizonclient
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket limiting the rate of the requests sent to
+// Horizon, so that a client stays below the rate limit of the server instead
+// of being rejected with 429 responses.
+type RateLimiter struct {
+	// OnWait, if set, is called when a request is delayed by the limiter, e.g.
+	// to record metrics.
+	OnWait func(req *http.Request, delay time.Duration)
+
+	rate  float64
+	burst float64
+
+	lock   sync.Mutex
+	tokens float64
+	last   time.Time
+
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewRateLimiter returns a RateLimiter allowing requestsPerSecond requests per
+// second on average, and bursts of up to burst requests. Requests are not
+// limited if requestsPerSecond is not positive.
+func NewRateLimiter(requestsPerSecond float64, burst int) *RateLimiter {
+	burst = max(burst, 1)
+	return &RateLimiter{
+		rate:   requestsPerSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		now:    time.Now,
+		sleep:  sleepContext,
+	}
+}
+
+// reserve takes a token from the bucket and returns how long to wait before
+// it is available.
+func (l *RateLimiter) reserve() time.Duration {
+	if l.rate <= 0 {
+		return 0
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := l.now()
+	if !l.last.IsZero() {
+		l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	}
+	l.last = now
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// cancel gives back a token which was not used.
+func (l *RateLimiter) cancel() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.tokens = min(l.burst, l.tokens+1)
+}
+
+// Wait blocks until a request can be sent or ctx is done.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	_, err := l.wait(ctx)
+	return err
+}
+
+func (l *RateLimiter) wait(ctx context.Context) (time.Duration, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	delay := l.reserve()
+	if delay == 0 {
+		return 0, nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && deadline.Sub(l.now()) < delay {
+		// fail right away rather than when the context expires
+		l.cancel()
+		return delay, context.DeadlineExceeded
+	}
+	if err := l.sleep(ctx, delay); err != nil {
+		l.cancel()
+		return delay, err
+	}
+	return delay, nil
+}
+
+// Middleware returns a middleware delaying requests to respect the rate of
+// the limiter.
+func (l *RateLimiter) Middleware() Middleware {
+	return func(next HTTP) HTTP {
+		return DoFunc(func(req *http.Request) (*http.Response, error) {
+			delay, err := l.wait(req.Context())
+			if delay > 0 && l.OnWait != nil {
+				l.OnWait(req, delay)
+			}
+			if err != nil {
+				return nil, err
+			}
+			return next.Do(req)
+		})
+	}
+}
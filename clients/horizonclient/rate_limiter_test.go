@@ -0,0 +1,81 @@
+package horizonclient
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testLimiter returns a limiter whose clock only moves when it sleeps.
+func testLimiter(requestsPerSecond float64, burst int) (*RateLimiter, *[]time.Duration) {
+	var delays []time.Duration
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := NewRateLimiter(requestsPerSecond, burst)
+	l.now = func() time.Time { return now }
+	l.sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		now = now.Add(d)
+		return ctx.Err()
+	}
+	return l, &delays
+}
+
+func TestRateLimiterBurst(t *testing.T) {
+	l, delays := testLimiter(2, 3)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, l.Wait(context.Background()))
+	}
+	// the burst goes through, then requests are spaced by 1/rate
+	assert.Equal(t, []time.Duration{500 * time.Millisecond, 500 * time.Millisecond}, *delays)
+}
+
+func TestRateLimiterUnlimited(t *testing.T) {
+	l, delays := testLimiter(0, 1)
+	for i := 0; i < 100; i++ {
+		require.NoError(t, l.Wait(context.Background()))
+	}
+	assert.Empty(t, *delays)
+}
+
+func TestRateLimiterDeadline(t *testing.T) {
+	l, delays := testLimiter(1, 1)
+	require.NoError(t, l.Wait(context.Background()))
+
+	// the request fails right away if it cannot be sent before its deadline
+	ctx, cancel := context.WithDeadline(context.Background(), l.now().Add(100*time.Millisecond))
+	defer cancel()
+	assert.ErrorIs(t, l.Wait(ctx), context.DeadlineExceeded)
+	assert.Empty(t, *delays)
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, l.Wait(canceled), context.Canceled)
+
+	// the tokens of the failed requests were given back
+	require.NoError(t, l.Wait(context.Background()))
+	assert.Equal(t, []time.Duration{time.Second}, *delays)
+}
+
+func TestRateLimiterMiddleware(t *testing.T) {
+	l, _ := testLimiter(1, 1)
+	var waits []time.Duration
+	l.OnWait = func(req *http.Request, delay time.Duration) {
+		waits = append(waits, delay)
+	}
+	next := &scriptedHTTP{responses: []func(*http.Request) (*http.Response, error){
+		respond(http.StatusOK, ""),
+		respond(http.StatusOK, ""),
+	}}
+	client := WithMiddleware(next, l.Middleware())
+
+	for i := 0; i < 2; i++ {
+		resp, err := send(t, client, http.MethodGet, "https://horizon/ledgers", "")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	assert.Equal(t, []time.Duration{time.Second}, waits)
+}
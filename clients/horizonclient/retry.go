@@ -0,0 +1,239 @@
+package horizonclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stellar/go/support/render/problem"
+)
+
+// The defaults of RetryPolicy.
+const (
+	DefaultMaxRetries     = 3
+	DefaultInitialBackoff = 500 * time.Millisecond
+	DefaultMaxBackoff     = 30 * time.Second
+)
+
+// maxProblemSize bounds the size of the error responses read to find out
+// whether a transaction submission can be retried.
+const maxProblemSize = 1 << 20
+
+// RetryPolicy configures the retry middleware returned by Middleware().
+//
+// Failed requests are retried with an exponential backoff, unless Horizon
+// specifies when to retry with the Retry-After header or, when rate limited,
+// the X-Ratelimit-Reset header. Since the middleware runs inside the request
+// context, the client timeout bounds the total time spent retrying.
+type RetryPolicy struct {
+	// MaxRetries is the number of times a request is retried after the first
+	// attempt, DefaultMaxRetries if 0. Requests are not retried if negative.
+	MaxRetries int
+	// InitialBackoff is the delay before the first retry, DefaultInitialBackoff
+	// if 0. It doubles for every subsequent retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between retries, DefaultMaxBackoff if 0. The
+	// response is returned as is if Horizon asks to wait longer.
+	MaxBackoff time.Duration
+	// Retryable reports whether a request can be retried after the given
+	// response or error. It defaults to IsRetryable.
+	Retryable func(req *http.Request, resp *http.Response, err error) bool
+	// OnRetry, if set, is called before waiting to retry a request, e.g. to
+	// record metrics. attempt is the number of the attempt which failed,
+	// starting at 1.
+	OnRetry func(req *http.Request, attempt int, delay time.Duration, resp *http.Response, err error)
+
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxRetries == 0 {
+		p.MaxRetries = DefaultMaxRetries
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = DefaultInitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = DefaultMaxBackoff
+	}
+	if p.Retryable == nil {
+		p.Retryable = IsRetryable
+	}
+	if p.now == nil {
+		p.now = time.Now
+	}
+	if p.sleep == nil {
+		p.sleep = sleepContext
+	}
+	return p
+}
+
+// Middleware returns a middleware retrying requests according to the policy.
+func (p RetryPolicy) Middleware() Middleware {
+	p = p.withDefaults()
+	return func(next HTTP) HTTP {
+		return DoFunc(func(req *http.Request) (*http.Response, error) {
+			return p.do(next, req)
+		})
+	}
+}
+
+func (p RetryPolicy) do(next HTTP, req *http.Request) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := next.Do(req)
+		if attempt > p.MaxRetries || !p.Retryable(req, resp, err) {
+			return resp, err
+		}
+
+		delay := p.backoff(attempt)
+		if resp != nil {
+			if after, ok := retryAfter(resp, p.now()); ok {
+				if after > p.MaxBackoff {
+					return resp, err
+				}
+				delay = after
+			}
+		}
+
+		// the body of the request has been consumed and must be sent again
+		if req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return resp, err
+			}
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return resp, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		if p.OnRetry != nil {
+			p.OnRetry(req, attempt, delay, resp, err)
+		}
+		if resp != nil {
+			// drain the body so that the connection can be reused
+			io.Copy(io.Discard, io.LimitReader(resp.Body, maxProblemSize))
+			resp.Body.Close()
+		}
+		if err := p.sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// backoff returns the jittered delay before retrying a failed attempt.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.InitialBackoff
+	for i := 1; i < attempt && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	delay = min(delay, p.MaxBackoff)
+	// spread the retries of concurrent requests
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// retryAfter returns the delay after which Horizon asks to retry a request.
+func retryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if value := resp.Header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second, true
+		}
+		if date, err := http.ParseTime(value); err == nil {
+			return max(date.Sub(now), 0), true
+		}
+	}
+	// the rate limit headers are included in every response, but only matter
+	// once the limit is reached
+	if resp.StatusCode == http.StatusTooManyRequests {
+		if value := resp.Header.Get("X-Ratelimit-Reset"); value != "" {
+			if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+				return time.Duration(seconds) * time.Second, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// IsRetryable is the default RetryPolicy.Retryable. It only retries requests
+// which are safe to send again:
+//
+//   - requests rejected by rate limiting or while Horizon is unavailable (429
+//     and 503), since they were not processed
+//   - idempotent requests, such as GET requests, after a network error or a
+//     500, 502 or 504 response
+//   - transaction submissions after a timeout (504), since a transaction
+//     can only be applied once, or after failing with tx_insufficient_fee,
+//     which may succeed once the surge pricing is over
+//
+// Transaction submissions are not retried after a network error: the
+// transaction may have been applied, in which case submitting it again would
+// fail with tx_bad_seq and hide the outcome of the first submission.
+func IsRetryable(req *http.Request, resp *http.Response, err error) bool {
+	if err != nil {
+		if errors.Is(err, ErrCircuitOpen) || req.Context().Err() != nil {
+			return false
+		}
+		return isIdempotent(req)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusInternalServerError, http.StatusBadGateway:
+		return isIdempotent(req)
+	case http.StatusGatewayTimeout:
+		return isIdempotent(req) || isTransactionSubmission(req)
+	case http.StatusBadRequest:
+		return isTransactionSubmission(req) && transactionResultCode(resp) == "tx_insufficient_fee"
+	default:
+		return false
+	}
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+		http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+func isTransactionSubmission(req *http.Request) bool {
+	return req.Method == http.MethodPost &&
+		(strings.HasSuffix(req.URL.Path, "/transactions") || strings.HasSuffix(req.URL.Path, "/transactions_async"))
+}
+
+// transactionResultCode returns the transaction result code of an error
+// response, which is left unread.
+func transactionResultCode(resp *http.Response) string {
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxProblemSize))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+	if err != nil {
+		return ""
+	}
+
+	var p problem.P
+	if err := json.Unmarshal(body, &p); err != nil {
+		return ""
+	}
+	herr := Error{Problem: p}
+	codes, err := herr.ResultCodes()
+	if err != nil {
+		return ""
+	}
+	return codes.TransactionCode
+}
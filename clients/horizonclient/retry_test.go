@@ -0,0 +1,253 @@
+package horizonclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedHTTP answers the requests it receives with the given responses, in
+// order, and records the bodies of the requests.
+type scriptedHTTP struct {
+	responses []func(req *http.Request) (*http.Response, error)
+	bodies    []string
+}
+
+func (s *scriptedHTTP) Do(req *http.Request) (*http.Response, error) {
+	body := ""
+	if req.Body != nil {
+		b, _ := io.ReadAll(req.Body)
+		body = string(b)
+	}
+	s.bodies = append(s.bodies, body)
+	next := s.responses[0]
+	s.responses = s.responses[1:]
+	return next(req)
+}
+
+func (s *scriptedHTTP) Get(url string) (*http.Response, error) {
+	return DoFunc(s.Do).Get(url)
+}
+
+func (s *scriptedHTTP) PostForm(url string, data url.Values) (*http.Response, error) {
+	return DoFunc(s.Do).PostForm(url, data)
+}
+
+func respond(status int, body string, headers ...string) func(req *http.Request) (*http.Response, error) {
+	return func(req *http.Request) (*http.Response, error) {
+		resp := &http.Response{
+			StatusCode: status,
+			Header:     http.Header{},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}
+		for i := 0; i+1 < len(headers); i += 2 {
+			resp.Header.Set(headers[i], headers[i+1])
+		}
+		return resp, nil
+	}
+}
+
+func fail(err error) func(req *http.Request) (*http.Response, error) {
+	return func(*http.Request) (*http.Response, error) {
+		return nil, err
+	}
+}
+
+// testPolicy returns a policy which records its delays instead of sleeping.
+func testPolicy(delays *[]time.Duration) RetryPolicy {
+	return RetryPolicy{
+		InitialBackoff: time.Second,
+		MaxBackoff:     10 * time.Second,
+		now:            func() time.Time { return time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC) },
+		sleep: func(ctx context.Context, d time.Duration) error {
+			*delays = append(*delays, d)
+			return ctx.Err()
+		},
+	}
+}
+
+func send(t *testing.T, client HTTP, method, url, body string) (*http.Response, error) {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req, err := http.NewRequest(method, url, reader)
+	require.NoError(t, err)
+	return client.Do(req)
+}
+
+const insufficientFeeProblem = `{
+	"type": "https://stellar.org/horizon-errors/transaction_failed",
+	"title": "Transaction Failed",
+	"status": 400,
+	"extras": {"result_codes": {"transaction": "tx_insufficient_fee"}}
+}`
+
+func TestRetryBackoff(t *testing.T) {
+	var delays []time.Duration
+	next := &scriptedHTTP{responses: []func(*http.Request) (*http.Response, error){
+		respond(http.StatusBadGateway, ""),
+		fail(errors.New("connection reset")),
+		respond(http.StatusInternalServerError, ""),
+		respond(http.StatusOK, "ok"),
+	}}
+	var attempts []int
+	policy := testPolicy(&delays)
+	policy.OnRetry = func(req *http.Request, attempt int, delay time.Duration, resp *http.Response, err error) {
+		attempts = append(attempts, attempt)
+	}
+
+	resp, err := send(t, WithMiddleware(next, policy.Middleware()), http.MethodGet, "https://horizon/ledgers", "")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []int{1, 2, 3}, attempts)
+	require.Len(t, delays, 3)
+	for i, max := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		assert.GreaterOrEqual(t, delays[i], max/2)
+		assert.LessOrEqual(t, delays[i], max)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	var delays []time.Duration
+	next := &scriptedHTTP{responses: []func(*http.Request) (*http.Response, error){
+		respond(http.StatusServiceUnavailable, ""),
+		respond(http.StatusServiceUnavailable, ""),
+		respond(http.StatusServiceUnavailable, "last"),
+	}}
+	policy := testPolicy(&delays)
+	policy.MaxRetries = 2
+
+	resp, err := send(t, WithMiddleware(next, policy.Middleware()), http.MethodGet, "https://horizon/ledgers", "")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "last", string(body))
+	assert.Len(t, delays, 2)
+
+	// requests are not retried with a negative MaxRetries
+	next = &scriptedHTTP{responses: []func(*http.Request) (*http.Response, error){
+		respond(http.StatusServiceUnavailable, ""),
+	}}
+	policy.MaxRetries = -1
+	resp, err = send(t, WithMiddleware(next, policy.Middleware()), http.MethodGet, "https://horizon/ledgers", "")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
+
+func TestRetryHonorsServerDelays(t *testing.T) {
+	var delays []time.Duration
+	policy := testPolicy(&delays)
+	next := &scriptedHTTP{responses: []func(*http.Request) (*http.Response, error){
+		respond(http.StatusTooManyRequests, "", "Retry-After", "3"),
+		respond(http.StatusServiceUnavailable, "", "Retry-After", "Mon, 01 Jan 2024 00:00:05 GMT"),
+		respond(http.StatusTooManyRequests, "", "X-Ratelimit-Reset", "7"),
+		respond(http.StatusOK, ""),
+	}}
+
+	resp, err := send(t, WithMiddleware(next, policy.Middleware()), http.MethodGet, "https://horizon/ledgers", "")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []time.Duration{3 * time.Second, 5 * time.Second, 7 * time.Second}, delays)
+
+	// the response is returned if horizon asks to wait longer than MaxBackoff
+	delays = nil
+	next = &scriptedHTTP{responses: []func(*http.Request) (*http.Response, error){
+		respond(http.StatusTooManyRequests, "", "X-Ratelimit-Reset", "60"),
+	}}
+	resp, err = send(t, WithMiddleware(next, policy.Middleware()), http.MethodGet, "https://horizon/ledgers", "")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Empty(t, delays)
+}
+
+func TestRetryTransactionSubmission(t *testing.T) {
+	var delays []time.Duration
+	policy := testPolicy(&delays)
+	next := &scriptedHTTP{responses: []func(*http.Request) (*http.Response, error){
+		respond(http.StatusGatewayTimeout, ""),
+		respond(http.StatusBadRequest, insufficientFeeProblem),
+		respond(http.StatusOK, "{}"),
+	}}
+
+	resp, err := send(t, WithMiddleware(next, policy.Middleware()), http.MethodPost, "https://horizon/transactions", "tx=AAAA")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	// the body is sent again with every attempt
+	assert.Equal(t, []string{"tx=AAAA", "tx=AAAA", "tx=AAAA"}, next.bodies)
+
+	// submissions which may have been processed are not retried
+	for _, response := range []func(*http.Request) (*http.Response, error){
+		fail(errors.New("connection reset")),
+		respond(http.StatusInternalServerError, ""),
+		respond(http.StatusBadRequest, strings.Replace(insufficientFeeProblem, "tx_insufficient_fee", "tx_bad_seq", 1)),
+	} {
+		next = &scriptedHTTP{responses: []func(*http.Request) (*http.Response, error){response}}
+		_, _ = send(t, WithMiddleware(next, policy.Middleware()), http.MethodPost, "https://horizon/transactions", "tx=AAAA")
+		assert.Len(t, next.bodies, 1)
+	}
+}
+
+func TestRetryableKeepsResponseBody(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, "https://horizon/transactions", strings.NewReader("tx=AAAA"))
+	require.NoError(t, err)
+	resp, err := respond(http.StatusBadRequest, insufficientFeeProblem)(req)
+	require.NoError(t, err)
+
+	assert.True(t, IsRetryable(req, resp, nil))
+	// the error can still be decoded by the client
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, insufficientFeeProblem, string(body))
+}
+
+func TestRetryCanceled(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: time.Hour, MaxBackoff: time.Hour}
+	next := &scriptedHTTP{responses: []func(*http.Request) (*http.Response, error){
+		respond(http.StatusServiceUnavailable, ""),
+	}}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://horizon/ledgers", nil)
+	require.NoError(t, err)
+
+	_, err = WithMiddleware(next, policy.Middleware()).Do(req)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestClientWithMiddleware(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"type": "rate_limit_exceeded", "status": 429}`))
+			return
+		}
+		w.Write([]byte(`{"id": "GAAA", "sequence": "1"}`))
+	}))
+	defer server.Close()
+
+	limiter := NewRateLimiter(100, 10)
+	breaker := NewCircuitBreaker(5, time.Second)
+	client := &Client{
+		HorizonURL: server.URL,
+		HTTP:       WithMiddleware(nil, RetryPolicy{}.Middleware(), limiter.Middleware(), breaker.Middleware()),
+	}
+	account, err := client.AccountDetail(AccountRequest{AccountID: "GAAA"})
+	require.NoError(t, err)
+	assert.Equal(t, "GAAA", account.ID)
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, CircuitClosed, breaker.State())
+}
@@ -0,0 +1,361 @@
+package horizonclient
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/stellar/go/support/errors"
+)
+
+// The defaults of StreamOptions.
+const (
+	DefaultStreamInitialBackoff = time.Second
+	DefaultStreamMaxBackoff     = time.Minute
+)
+
+// ErrStreamIdle is the error of a stream connection on which nothing was
+// received for StreamOptions.IdleTimeout.
+var ErrStreamIdle = errors.New("stream connection idle")
+
+// StreamConnected is passed to StreamOptions.OnConnected.
+type StreamConnected struct {
+	// URL is the URL of the stream, including the cursor it resumes from.
+	URL string
+	// LastEventID is the ID of the last event received before connecting,
+	// sent as the Last-Event-ID header.
+	LastEventID string
+}
+
+// StreamRetrying is passed to StreamOptions.OnRetrying.
+type StreamRetrying struct {
+	URL string
+	// Attempt is the number of consecutive connections which failed or were
+	// closed without receiving any event, starting at 1.
+	Attempt int
+	// Delay is the time to wait before reconnecting.
+	Delay time.Duration
+	// Err is the error of the connection, nil if the server closed it.
+	Err error
+}
+
+// StreamGaveUp is passed to StreamOptions.OnGaveUp.
+type StreamGaveUp struct {
+	URL string
+	// Attempts is the number of consecutive failed connections.
+	Attempts int
+	// Err is the error returned by the stream.
+	Err error
+}
+
+// StreamOptions configures how streams reconnect. Streams always reconnect
+// when Horizon closes the connection, resuming from the last event received.
+// The zero value returns the first connection error, as streams always did.
+type StreamOptions struct {
+	// MaxRetries is the number of consecutive failed connections after which
+	// the stream gives up and returns the error. Failed connections are not
+	// retried if 0 or negative. Requests rejected with a 4xx status other than
+	// 429 and handler errors are never retried.
+	MaxRetries int
+	// InitialBackoff is the delay before reconnecting after the first failed
+	// or empty connection, DefaultStreamInitialBackoff if 0. It doubles for
+	// every subsequent one.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between connections, DefaultStreamMaxBackoff
+	// if 0.
+	MaxBackoff time.Duration
+	// IdleTimeout, if positive, reconnects when nothing, not even a comment,
+	// is received for this long, so that a stalled connection is detected.
+	IdleTimeout time.Duration
+
+	// OnConnected, if set, is called when a connection is established.
+	OnConnected func(StreamConnected)
+	// OnRetrying, if set, is called before waiting to reconnect.
+	OnRetrying func(StreamRetrying)
+	// OnGaveUp, if set, is called when the stream returns an error.
+	OnGaveUp func(StreamGaveUp)
+}
+
+func (o StreamOptions) withDefaults() StreamOptions {
+	if o.InitialBackoff <= 0 {
+		o.InitialBackoff = DefaultStreamInitialBackoff
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = DefaultStreamMaxBackoff
+	}
+	return o
+}
+
+// streamError is the error of a connection, which may be retried.
+type streamError struct {
+	err       error
+	retryable bool
+}
+
+// eventStream consumes a server-sent events stream of a horizon server,
+// reconnecting from the last event received.
+type eventStream struct {
+	client  *Client
+	url     *url.URL
+	query   url.Values
+	options StreamOptions
+	handler func(data []byte) error
+
+	lastEventID string
+	// retry is the reconnection delay sent by the server.
+	retry time.Duration
+}
+
+// stream handles connections to endpoints that support streaming on a horizon
+// server. It returns nil once ctx is done.
+func (c *Client) stream(
+	ctx context.Context,
+	streamURL string,
+	handler func(data []byte) error,
+) error {
+	su, err := url.Parse(streamURL)
+	if err != nil {
+		return errors.Wrap(err, "error parsing stream url")
+	}
+
+	query := su.Query()
+	if query.Get("cursor") == "" {
+		query.Set("cursor", "now")
+	}
+
+	s := &eventStream{
+		client:  c,
+		url:     su,
+		query:   query,
+		options: c.StreamOptions.withDefaults(),
+		handler: handler,
+	}
+	return s.run(ctx)
+}
+
+func (s *eventStream) run(ctx context.Context) error {
+	// failures counts the consecutive connections which failed, and attempts
+	// also the ones closed before receiving any event.
+	failures, attempts := 0, 0
+	for {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		received, err := s.connect(ctx)
+		// the connection is aborted when ctx is canceled
+		if ctx.Err() != nil {
+			return nil
+		}
+		if received {
+			failures, attempts = 0, 0
+		}
+		attempts++
+
+		if err != nil {
+			failures++
+			if !err.retryable || failures > s.options.MaxRetries {
+				if s.options.OnGaveUp != nil {
+					s.options.OnGaveUp(StreamGaveUp{URL: s.url.String(), Attempts: failures, Err: err.err})
+				}
+				return err.err
+			}
+		}
+
+		delay := s.backoff(attempts, received)
+		if s.options.OnRetrying != nil {
+			retrying := StreamRetrying{URL: s.url.String(), Attempt: attempts, Delay: delay}
+			if err != nil {
+				retrying.Err = err.err
+			}
+			s.options.OnRetrying(retrying)
+		}
+		if sleepContext(ctx, delay) != nil {
+			return nil
+		}
+	}
+}
+
+// backoff returns the delay before reconnecting. A connection which received
+// events is resumed after the delay requested by the server, if any, while
+// failed and empty connections back off exponentially, so that a server
+// closing connections right away is not flooded with requests.
+func (s *eventStream) backoff(attempts int, received bool) time.Duration {
+	if received {
+		return s.retry
+	}
+	delay := s.options.InitialBackoff
+	for i := 1; i < attempts && delay < s.options.MaxBackoff; i++ {
+		delay *= 2
+	}
+	delay = min(delay, s.options.MaxBackoff)
+	// spread the reconnections of concurrent streams
+	delay = delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+	return max(delay, s.retry)
+}
+
+// connect consumes a single connection of the stream, and reports whether it
+// received any event.
+func (s *eventStream) connect(ctx context.Context) (bool, *streamError) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// the connection is aborted if nothing is received for IdleTimeout
+	var idle atomic.Bool
+	active := func() {}
+	if s.options.IdleTimeout > 0 {
+		timer := time.AfterFunc(s.options.IdleTimeout, func() {
+			idle.Store(true)
+			cancel()
+		})
+		defer timer.Stop()
+		active = func() { timer.Reset(s.options.IdleTimeout) }
+	}
+	connectionError := func(err error) *streamError {
+		if idle.Load() {
+			return &streamError{err: ErrStreamIdle, retryable: true}
+		}
+		return &streamError{err: err, retryable: true}
+	}
+
+	// updates the url with a new cursor
+	s.url.RawQuery = s.query.Encode()
+	req, err := http.NewRequestWithContext(ctx, "GET", s.url.String(), nil)
+	if err != nil {
+		return false, &streamError{err: errors.Wrap(err, "error creating HTTP request")}
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if s.lastEventID != "" {
+		req.Header.Set("Last-Event-ID", s.lastEventID)
+	}
+	s.client.setDefaultClient()
+	s.client.setClientAppHeaders(req)
+
+	// We can use c.HTTP here because we set Timeout per request not on the client. See sendRequest()
+	resp, err := s.client.HTTP.Do(req)
+	if err != nil {
+		return false, connectionError(errors.Wrap(err, "error sending HTTP request"))
+	}
+	defer resp.Body.Close()
+
+	// Expected statusCode are 200-299
+	if !(resp.StatusCode >= 200 && resp.StatusCode < 300) {
+		return false, &streamError{
+			err: fmt.Errorf("got bad HTTP status code %d", resp.StatusCode),
+			// requests rejected by horizon will be rejected again
+			retryable: resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500,
+		}
+	}
+	if s.options.OnConnected != nil {
+		s.options.OnConnected(StreamConnected{URL: s.url.String(), LastEventID: s.lastEventID})
+	}
+
+	received := false
+	var event sseEvent
+	reader := bufio.NewReader(resp.Body)
+	for {
+		line, readErr := reader.ReadString('\n')
+		if len(line) > 0 {
+			active()
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		// From spec: once the end of the file is reached, the user agent must
+		// dispatch the event one final time.
+		if line == "" || readErr != nil {
+			if line != "" {
+				event.parseLine(line)
+			}
+			dispatched, err := s.dispatch(event)
+			if err != nil {
+				return received, err
+			}
+			received = received || dispatched
+			event = sseEvent{}
+		} else {
+			event.parseLine(line)
+		}
+
+		if readErr != nil {
+			if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+				// the stream was closed by the server or a proxy, e.g. because the
+				// connection was idle
+				return received, nil
+			}
+			return received, connectionError(errors.Wrap(readErr, "error reading line"))
+		}
+	}
+}
+
+// dispatch hands a message event to the handler, and reports whether it did.
+func (s *eventStream) dispatch(event sseEvent) (bool, *streamError) {
+	if event.retry > 0 {
+		s.retry = event.retry
+	}
+	if !event.hasData {
+		return false, nil
+	}
+	// Update cursor with event ID
+	if event.hasID {
+		s.lastEventID = event.id
+		if event.id != "" {
+			s.query.Set("cursor", event.id)
+		}
+	}
+	if event.event != "" && event.event != "message" {
+		return false, nil
+	}
+
+	if err := s.handler([]byte(event.data)); err != nil {
+		return false, &streamError{err: errors.Wrap(err, "handler error")}
+	}
+	return true, nil
+}
+
+// sseEvent is an event being read from a server-sent events stream.
+type sseEvent struct {
+	id      string
+	hasID   bool
+	event   string
+	data    string
+	hasData bool
+	retry   time.Duration
+}
+
+// parseLine parses a non empty line of an event, see
+// https://html.spec.whatwg.org/multipage/server-sent-events.html#event-stream-interpretation
+func (e *sseEvent) parseLine(line string) {
+	if strings.HasPrefix(line, ":") {
+		// comments are used as heartbeats
+		return
+	}
+	field, value, _ := strings.Cut(line, ":")
+	value = strings.TrimPrefix(value, " ")
+
+	switch field {
+	case "id":
+		if !strings.Contains(value, "\x00") {
+			e.id, e.hasID = value, true
+		}
+	case "event":
+		e.event = value
+	case "data":
+		if e.hasData {
+			e.data += "\n"
+		}
+		e.data += value
+		e.hasData = true
+	case "retry":
+		if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+			e.retry = time.Duration(ms) * time.Millisecond
+		}
+	}
+}
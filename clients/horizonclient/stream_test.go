@@ -0,0 +1,221 @@
+package horizonclient
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/protocols/horizon/operations"
+	"github.com/stellar/go/support/http/httptest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ledgerEvent(id string) httptest.SSEEvent {
+	return httptest.SSEEvent{ID: id, Data: `{"paging_token": "` + id + `", "sequence": ` + id + `}`}
+}
+
+// streamLedgers streams ledgers until count ledgers are received or the
+// stream returns.
+func streamLedgers(client *Client, count int) ([]int32, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var sequences []int32
+	err := client.StreamLedgers(ctx, LedgerRequest{Cursor: "0"}, func(ledger hProtocol.Ledger) {
+		sequences = append(sequences, ledger.Sequence)
+		if len(sequences) == count {
+			cancel()
+		}
+	})
+	return sequences, err
+}
+
+func TestStreamResumesFromLastEvent(t *testing.T) {
+	server := httptest.NewSSEServer(
+		httptest.SSEEvents(
+			httptest.SSEEvent{Event: "open", Data: `"hello"`, Retry: time.Millisecond},
+			ledgerEvent("1"),
+			ledgerEvent("2"),
+		),
+		httptest.SSEEvents(ledgerEvent("3")),
+	)
+	defer server.Close()
+	var connected []StreamConnected
+	client := &Client{
+		HorizonURL: server.URL,
+		StreamOptions: StreamOptions{
+			OnConnected: func(c StreamConnected) { connected = append(connected, c) },
+		},
+	}
+
+	sequences, err := streamLedgers(client, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []int32{1, 2, 3}, sequences)
+
+	requests := server.Requests()
+	require.Len(t, requests, 2)
+	assert.Equal(t, "0", requests[0].URL.Query().Get("cursor"))
+	assert.Empty(t, requests[0].Header.Get("Last-Event-ID"))
+	assert.Equal(t, "text/event-stream", requests[0].Header.Get("Accept"))
+	assert.Equal(t, "2", requests[1].URL.Query().Get("cursor"))
+	assert.Equal(t, "2", requests[1].Header.Get("Last-Event-ID"))
+	require.Len(t, connected, 2)
+	assert.Equal(t, "2", connected[1].LastEventID)
+}
+
+func TestStreamRetriesFailedConnections(t *testing.T) {
+	server := httptest.NewSSEServer(
+		httptest.SSEStatus(http.StatusServiceUnavailable, ""),
+		httptest.SSEEvents(),
+		httptest.SSEStatus(http.StatusTooManyRequests, ""),
+		httptest.SSEEvents(ledgerEvent("1")),
+	)
+	defer server.Close()
+	var retries []StreamRetrying
+	client := &Client{
+		HorizonURL: server.URL,
+		StreamOptions: StreamOptions{
+			MaxRetries:     2,
+			InitialBackoff: time.Millisecond,
+			MaxBackoff:     10 * time.Millisecond,
+			OnRetrying:     func(r StreamRetrying) { retries = append(retries, r) },
+		},
+	}
+
+	sequences, err := streamLedgers(client, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []int32{1}, sequences)
+
+	require.Len(t, retries, 3)
+	for i, r := range retries {
+		assert.Equal(t, i+1, r.Attempt)
+		assert.LessOrEqual(t, r.Delay, 10*time.Millisecond)
+	}
+	assert.EqualError(t, retries[0].Err, "got bad HTTP status code 503")
+	// an empty connection is not a failure, but is backed off
+	assert.NoError(t, retries[1].Err)
+	assert.GreaterOrEqual(t, retries[1].Delay, time.Millisecond)
+	assert.EqualError(t, retries[2].Err, "got bad HTTP status code 429")
+}
+
+func TestStreamGivesUp(t *testing.T) {
+	server := httptest.NewSSEServer(
+		httptest.SSEStatus(http.StatusInternalServerError, ""),
+		httptest.SSEStatus(http.StatusInternalServerError, ""),
+		httptest.SSEStatus(http.StatusBadRequest, ""),
+	)
+	defer server.Close()
+	var gaveUp []StreamGaveUp
+	client := &Client{
+		HorizonURL: server.URL,
+		StreamOptions: StreamOptions{
+			MaxRetries:     1,
+			InitialBackoff: time.Millisecond,
+			OnGaveUp:       func(g StreamGaveUp) { gaveUp = append(gaveUp, g) },
+		},
+	}
+
+	_, err := streamLedgers(client, 1)
+	assert.EqualError(t, err, "got bad HTTP status code 500")
+	require.Len(t, gaveUp, 1)
+	assert.Equal(t, 2, gaveUp[0].Attempts)
+
+	// requests rejected by horizon are not retried
+	client.StreamOptions.MaxRetries = 5
+	_, err = streamLedgers(client, 1)
+	assert.EqualError(t, err, "got bad HTTP status code 400")
+	require.Len(t, gaveUp, 2)
+	assert.Equal(t, 1, gaveUp[1].Attempts)
+	assert.Len(t, server.Requests(), 3)
+}
+
+func TestStreamHandlerErrorsAreNotRetried(t *testing.T) {
+	server := httptest.NewSSEServer(httptest.SSEEvents(httptest.SSEEvent{ID: "1", Data: "not json"}))
+	defer server.Close()
+	client := &Client{HorizonURL: server.URL, StreamOptions: StreamOptions{MaxRetries: 5}}
+
+	_, err := streamLedgers(client, 1)
+	assert.ErrorContains(t, err, "handler error")
+	assert.Len(t, server.Requests(), 1)
+}
+
+func TestStreamIdleTimeout(t *testing.T) {
+	heartbeats := func(w httptest.SSEWriter, r *http.Request) {
+		for i := 0; i < 5; i++ {
+			w.Comment("heartbeat")
+			time.Sleep(20 * time.Millisecond)
+		}
+		w.Send(ledgerEvent("2"))
+	}
+	server := httptest.NewSSEServer(
+		httptest.SSEStall(ledgerEvent("1")),
+		heartbeats,
+	)
+	defer server.Close()
+	var lock sync.Mutex
+	var retries []StreamRetrying
+	client := &Client{
+		HorizonURL: server.URL,
+		StreamOptions: StreamOptions{
+			MaxRetries:  1,
+			IdleTimeout: 50 * time.Millisecond,
+			OnRetrying: func(r StreamRetrying) {
+				lock.Lock()
+				defer lock.Unlock()
+				retries = append(retries, r)
+			},
+		},
+	}
+
+	sequences, err := streamLedgers(client, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []int32{1, 2}, sequences)
+	// the stalled connection was aborted, while the heartbeats kept the
+	// second one open
+	require.Len(t, retries, 1)
+	assert.ErrorIs(t, retries[0].Err, ErrStreamIdle)
+	assert.Equal(t, "1", server.Requests()[1].Header.Get("Last-Event-ID"))
+}
+
+func TestStreamMultilineData(t *testing.T) {
+	server := httptest.NewSSEServer(httptest.SSEEvents(
+		httptest.SSEEvent{ID: "5", Data: "{\n\"sequence\": 5\n}"},
+	))
+	defer server.Close()
+	client := &Client{HorizonURL: server.URL}
+
+	sequences, err := streamLedgers(client, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []int32{5}, sequences)
+}
+
+func TestStreamClaimableBalancesAndLiquidityPools(t *testing.T) {
+	server := httptest.NewSSEServer(
+		httptest.SSEEvents(httptest.SSEEvent{ID: "1", Data: `{"paging_token": "1", "type_i": 0, "type": "create_account"}`}),
+		httptest.SSEEvents(httptest.SSEEvent{ID: "2", Data: `{"paging_token": "2", "trade_type": "liquidity_pool"}`}),
+	)
+	defer server.Close()
+	client := &Client{HorizonURL: server.URL}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err := client.StreamOperations(ctx, OperationRequest{ForClaimableBalance: "00000000abcd"}, func(op operations.Operation) {
+		assert.Equal(t, "1", op.PagingToken())
+		cancel()
+	})
+	require.NoError(t, err)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	err = client.StreamTrades(ctx, TradeRequest{ForLiquidityPool: "abcd"}, func(trade hProtocol.Trade) {
+		assert.Equal(t, "liquidity_pool", trade.TradeType)
+		cancel()
+	})
+	require.NoError(t, err)
+
+	requests := server.Requests()
+	require.Len(t, requests, 2)
+	assert.Equal(t, "/claimable_balances/00000000abcd/operations", requests[0].URL.Path)
+	assert.Equal(t, "/liquidity_pools/abcd/trades", requests[1].URL.Path)
+}
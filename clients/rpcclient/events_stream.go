@@ -0,0 +1,285 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/stellar/go/protocols/rpc"
+)
+
+const (
+	defaultEventsPollInterval = 5 * time.Second
+	defaultEventsBufferSize   = 100
+	defaultEventsMaxErrors    = 5
+)
+
+// EventGapError reports that events were skipped because the stream position
+// fell out of Stellar-RPC's retention window. The stream resumes from
+// OldestLedger.
+type EventGapError struct {
+	// Ledger is the ledger the stream attempted to resume from.
+	Ledger uint32
+	// OldestLedger is the oldest ledger retained by Stellar-RPC.
+	OldestLedger uint32
+}
+
+func (e *EventGapError) Error() string {
+	return fmt.Sprintf("events between ledgers %d and %d are no longer retained", e.Ledger, e.OldestLedger-1)
+}
+
+// CursorStore persists the position of an event stream so that it can be
+// resumed after a restart.
+type CursorStore interface {
+	// Load returns the persisted cursor or an empty string if there is none.
+	Load(ctx context.Context) (string, error)
+	// Save persists cursor. It is called once every event preceding the
+	// cursor was handled successfully.
+	Save(ctx context.Context, cursor string) error
+}
+
+// MemoryCursorStore is a CursorStore which keeps the cursor in memory.
+type MemoryCursorStore struct {
+	mx     sync.Mutex
+	cursor string
+}
+
+func (m *MemoryCursorStore) Load(context.Context) (string, error) {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	return m.cursor, nil
+}
+
+func (m *MemoryCursorStore) Save(_ context.Context, cursor string) error {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+	m.cursor = cursor
+	return nil
+}
+
+// EventHandler is invoked for every event delivered by StreamEvents. Returning
+// an error stops the stream.
+type EventHandler func(ctx context.Context, event protocol.EventInfo) error
+
+// StreamEventsOptions configures StreamEvents.
+type StreamEventsOptions struct {
+	Filters []protocol.EventFilter
+	// StartLedger is the ledger to stream from when CursorStore has no
+	// persisted cursor. If zero, the stream starts at the latest ledger.
+	StartLedger uint32
+	// CursorStore persists the stream position. If nil, the position is only
+	// kept in memory.
+	CursorStore CursorStore
+	// Limit is the page size requested from getEvents. If zero, the server's
+	// default is used and every page with events is followed by another
+	// request without waiting for PollInterval.
+	Limit uint
+	// PollInterval is the delay between requests once the stream has caught
+	// up with the latest ledger.
+	PollInterval time.Duration
+	// BufferSize is the number of fetched events which may be waiting for the
+	// handler before fetching is paused.
+	BufferSize int
+	// MaxConsecutiveErrors is the number of consecutive getEvents failures
+	// which are retried before StreamEvents gives up.
+	MaxConsecutiveErrors int
+	// OnGap is called when the stream had to skip ledgers which fell out of
+	// the retention window. Returning an error stops the stream.
+	OnGap func(gap *EventGapError) error
+	// Format is the xdrFormat requested from getEvents.
+	Format string
+
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+func (o StreamEventsOptions) withDefaults() StreamEventsOptions {
+	if o.CursorStore == nil {
+		o.CursorStore = &MemoryCursorStore{}
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = defaultEventsPollInterval
+	}
+	if o.BufferSize <= 0 {
+		o.BufferSize = defaultEventsBufferSize
+	}
+	if o.MaxConsecutiveErrors <= 0 {
+		o.MaxConsecutiveErrors = defaultEventsMaxErrors
+	}
+	if o.sleep == nil {
+		o.sleep = sleepContext
+	}
+	return o
+}
+
+// streamItem is either an event to hand to the handler or, when event is nil,
+// a cursor to persist once every preceding event has been handled.
+type streamItem struct {
+	event  *protocol.EventInfo
+	cursor string
+}
+
+// StreamEvents continuously pages through getEvents and invokes handler for
+// every event matching opts.Filters, in order and at most once per event ID.
+// It blocks until ctx is done, handler returns an error, or Stellar-RPC keeps
+// failing.
+func (c *Client) StreamEvents(ctx context.Context, opts StreamEventsOptions, handler EventHandler) error {
+	opts = opts.withDefaults()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	items := make(chan streamItem, opts.BufferSize)
+	fetchErr := make(chan error, 1)
+	go func() {
+		defer close(items)
+		fetchErr <- c.fetchEvents(ctx, opts, items)
+	}()
+
+	var handleErr error
+	for item := range items {
+		if handleErr != nil {
+			// drain so the fetcher is not blocked
+			continue
+		}
+		if item.event != nil {
+			handleErr = handler(ctx, *item.event)
+		} else {
+			handleErr = opts.CursorStore.Save(ctx, item.cursor)
+		}
+		if handleErr != nil {
+			cancel()
+		}
+	}
+
+	if handleErr != nil {
+		return handleErr
+	}
+	return <-fetchErr
+}
+
+func (c *Client) fetchEvents(ctx context.Context, opts StreamEventsOptions, items chan<- streamItem) error {
+	var (
+		cursor      *protocol.Cursor
+		startLedger = opts.StartLedger
+		last        protocol.Cursor
+		delivered   bool
+	)
+
+	persisted, err := opts.CursorStore.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("could not load cursor: %w", err)
+	}
+	if persisted != "" {
+		parsed, err := protocol.ParseCursor(persisted)
+		if err != nil {
+			return fmt.Errorf("could not parse persisted cursor: %w", err)
+		}
+		cursor, last, delivered = &parsed, parsed, true
+	} else if startLedger == 0 {
+		latest, err := c.GetLatestLedger(ctx)
+		if err != nil {
+			return fmt.Errorf("could not get latest ledger: %w", err)
+		}
+		startLedger = latest.Sequence
+	}
+
+	failures := 0
+	for {
+		req := protocol.GetEventsRequest{
+			Filters:    opts.Filters,
+			Pagination: &protocol.PaginationOptions{Cursor: cursor, Limit: opts.Limit},
+			Format:     opts.Format,
+		}
+		if cursor == nil {
+			req.StartLedger = startLedger
+		}
+
+		resp, err := c.GetEvents(ctx, req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			position := startLedger
+			if cursor != nil {
+				position = cursor.Ledger
+			}
+			if gap := c.checkRetention(ctx, position); gap != nil {
+				if opts.OnGap != nil {
+					if err := opts.OnGap(gap); err != nil {
+						return err
+					}
+				}
+				cursor, startLedger, failures = nil, gap.OldestLedger, 0
+				continue
+			}
+			failures++
+			if failures >= opts.MaxConsecutiveErrors {
+				return fmt.Errorf("could not get events: %w", err)
+			}
+			if err := opts.sleep(ctx, time.Duration(failures)*opts.PollInterval); err != nil {
+				return err
+			}
+			continue
+		}
+		failures = 0
+
+		for i := range resp.Events {
+			event := resp.Events[i]
+			position, err := protocol.ParseCursor(event.ID)
+			if err != nil {
+				return fmt.Errorf("could not parse event id %s: %w", event.ID, err)
+			}
+			if delivered && position.Cmp(last) <= 0 {
+				continue
+			}
+			last, delivered = position, true
+			select {
+			case items <- streamItem{event: &event}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		if resp.Cursor != "" {
+			next, err := protocol.ParseCursor(resp.Cursor)
+			if err != nil {
+				return fmt.Errorf("could not parse page cursor %s: %w", resp.Cursor, err)
+			}
+			cursor = &next
+			select {
+			case items <- streamItem{cursor: resp.Cursor}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		// A full page means there may be more events available right away.
+		// The page size picked by the server when Limit is zero is unknown, so
+		// any page with events is then treated as full and polling only
+		// resumes once a page comes back empty.
+		if len(resp.Events) > 0 && (opts.Limit == 0 || uint(len(resp.Events)) >= opts.Limit) {
+			continue
+		}
+		if err := opts.sleep(ctx, opts.PollInterval); err != nil {
+			return err
+		}
+	}
+}
+
+// checkRetention returns a gap error if ledger is older than the oldest
+// ledger retained by Stellar-RPC.
+func (c *Client) checkRetention(ctx context.Context, ledger uint32) *EventGapError {
+	health, err := c.GetHealth(ctx)
+	if err != nil || ledger >= health.OldestLedger {
+		return nil
+	}
+	return &EventGapError{Ledger: ledger, OldestLedger: health.OldestLedger}
+}
+
+// IsEventGap reports whether err is, or wraps, an *EventGapError.
+func IsEventGap(err error) bool {
+	var gap *EventGapError
+	return errors.As(err, &gap)
+}
@@ -0,0 +1,259 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/protocols/rpc"
+)
+
+var errStop = errors.New("stop")
+
+func eventAt(ledger, tx uint32) protocol.EventInfo {
+	id := protocol.Cursor{Ledger: ledger, Tx: tx}.String()
+	return protocol.EventInfo{EventType: protocol.EventTypeContract, Ledger: int32(ledger), ID: id}
+}
+
+// eventsRequests records the getEvents requests received by the fake server.
+// The server runs in its own goroutine, so decoding failures are kept to be
+// asserted from the test goroutine.
+type eventsRequests struct {
+	mx       sync.Mutex
+	requests []protocol.GetEventsRequest
+	errs     []error
+}
+
+// record decodes and records a request, returning it along with the number
+// of requests received so far.
+func (r *eventsRequests) record(params json.RawMessage) (protocol.GetEventsRequest, int, error) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	var req protocol.GetEventsRequest
+	if err := json.Unmarshal(params, &req); err != nil {
+		r.errs = append(r.errs, err)
+		return req, len(r.requests), err
+	}
+	r.requests = append(r.requests, req)
+	return req, len(r.requests), nil
+}
+
+func (r *eventsRequests) received(t *testing.T) []protocol.GetEventsRequest {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	require.Empty(t, r.errs)
+	return append([]protocol.GetEventsRequest(nil), r.requests...)
+}
+
+func noSleepStreamOptions() StreamEventsOptions {
+	return StreamEventsOptions{
+		sleep: func(ctx context.Context, _ time.Duration) error { return ctx.Err() },
+	}
+}
+
+func TestStreamEventsPagesAndDeduplicates(t *testing.T) {
+	f, client := newFakeRPC(t)
+
+	var recorder eventsRequests
+	pages := []protocol.GetEventsResponse{
+		{
+			Events: []protocol.EventInfo{eventAt(10, 1), eventAt(10, 2)},
+			Cursor: eventAt(10, 2).ID,
+		},
+		{
+			// the first event was already delivered and must be skipped
+			Events: []protocol.EventInfo{eventAt(10, 2), eventAt(11, 1)},
+			Cursor: protocol.Cursor{Ledger: 12}.String(),
+		},
+	}
+	f.queue(protocol.GetEventsMethodName, rpcFunc(func(params json.RawMessage) (any, error) {
+		_, count, err := recorder.record(params)
+		if err != nil {
+			return nil, err
+		}
+		return pages[min(count, len(pages))-1], nil
+	}))
+
+	store := &MemoryCursorStore{}
+	opts := noSleepStreamOptions()
+	opts.StartLedger = 10
+	opts.Limit = 2
+	opts.CursorStore = store
+
+	var received []string
+	err := client.StreamEvents(context.Background(), opts, func(_ context.Context, event protocol.EventInfo) error {
+		received = append(received, event.ID)
+		if len(received) == 3 {
+			return errStop
+		}
+		return nil
+	})
+	require.ErrorIs(t, err, errStop)
+	assert.Equal(t, []string{eventAt(10, 1).ID, eventAt(10, 2).ID, eventAt(11, 1).ID}, received)
+
+	requests := recorder.received(t)
+	require.GreaterOrEqual(t, len(requests), 2)
+	assert.Equal(t, uint32(10), requests[0].StartLedger)
+	assert.Nil(t, requests[0].Pagination.Cursor)
+	assert.Equal(t, uint32(0), requests[1].StartLedger)
+	assert.Equal(t, eventAt(10, 2).ID, requests[1].Pagination.Cursor.String())
+
+	cursor, err := store.Load(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, eventAt(10, 2).ID, cursor)
+}
+
+func TestStreamEventsServerDefaultLimit(t *testing.T) {
+	f, client := newFakeRPC(t)
+
+	var recorder eventsRequests
+	pages := []protocol.GetEventsResponse{
+		{
+			Events: []protocol.EventInfo{eventAt(10, 1), eventAt(10, 2)},
+			Cursor: eventAt(10, 2).ID,
+		},
+		{
+			Events: []protocol.EventInfo{eventAt(11, 1)},
+			Cursor: protocol.Cursor{Ledger: 12}.String(),
+		},
+		{Cursor: protocol.Cursor{Ledger: 12}.String()},
+	}
+	f.queue(protocol.GetEventsMethodName, rpcFunc(func(params json.RawMessage) (any, error) {
+		_, count, err := recorder.record(params)
+		if err != nil {
+			return nil, err
+		}
+		return pages[min(count, len(pages))-1], nil
+	}))
+
+	// the stream stops at the first sleep, which must follow the empty page
+	opts := noSleepStreamOptions()
+	opts.StartLedger = 10
+	opts.sleep = func(context.Context, time.Duration) error { return errStop }
+
+	var received []string
+	err := client.StreamEvents(context.Background(), opts, func(_ context.Context, event protocol.EventInfo) error {
+		received = append(received, event.ID)
+		return nil
+	})
+	require.ErrorIs(t, err, errStop)
+	assert.Equal(t, []string{eventAt(10, 1).ID, eventAt(10, 2).ID, eventAt(11, 1).ID}, received)
+
+	requests := recorder.received(t)
+	require.Len(t, requests, 3)
+	for _, req := range requests {
+		assert.Zero(t, req.Pagination.Limit)
+	}
+}
+
+func TestStreamEventsResumesFromPersistedCursor(t *testing.T) {
+	f, client := newFakeRPC(t)
+
+	persisted := eventAt(20, 3)
+	var recorder eventsRequests
+	f.queue(protocol.GetEventsMethodName, rpcFunc(func(params json.RawMessage) (any, error) {
+		if _, _, err := recorder.record(params); err != nil {
+			return nil, err
+		}
+		return protocol.GetEventsResponse{
+			Events: []protocol.EventInfo{persisted, eventAt(21, 0)},
+		}, nil
+	}))
+
+	store := &MemoryCursorStore{}
+	require.NoError(t, store.Save(context.Background(), persisted.ID))
+	opts := noSleepStreamOptions()
+	opts.CursorStore = store
+
+	var received []string
+	err := client.StreamEvents(context.Background(), opts, func(_ context.Context, event protocol.EventInfo) error {
+		received = append(received, event.ID)
+		return errStop
+	})
+	require.ErrorIs(t, err, errStop)
+	assert.Equal(t, []string{eventAt(21, 0).ID}, received)
+	requests := recorder.received(t)
+	require.NotEmpty(t, requests)
+	assert.Equal(t, persisted.ID, requests[0].Pagination.Cursor.String())
+}
+
+func TestStreamEventsRetentionGap(t *testing.T) {
+	f, client := newFakeRPC(t)
+
+	var recorder eventsRequests
+	f.queue(protocol.GetEventsMethodName, rpcFunc(func(params json.RawMessage) (any, error) {
+		req, _, err := recorder.record(params)
+		if err != nil {
+			return nil, err
+		}
+		if req.StartLedger < 200 {
+			return nil, errors.New("startLedger must be between the oldest ledger and the latest ledger")
+		}
+		return protocol.GetEventsResponse{Events: []protocol.EventInfo{eventAt(200, 0)}}, nil
+	}))
+	f.queue(protocol.GetHealthMethodName, protocol.GetHealthResponse{OldestLedger: 200, LatestLedger: 300})
+
+	var gaps []*EventGapError
+	opts := noSleepStreamOptions()
+	opts.StartLedger = 100
+	opts.OnGap = func(gap *EventGapError) error {
+		gaps = append(gaps, gap)
+		return nil
+	}
+
+	var received []string
+	err := client.StreamEvents(context.Background(), opts, func(_ context.Context, event protocol.EventInfo) error {
+		received = append(received, event.ID)
+		return errStop
+	})
+	require.ErrorIs(t, err, errStop)
+	assert.Equal(t, []string{eventAt(200, 0).ID}, received)
+	require.Len(t, gaps, 1)
+	assert.Equal(t, &EventGapError{Ledger: 100, OldestLedger: 200}, gaps[0])
+	requests := recorder.received(t)
+	require.GreaterOrEqual(t, len(requests), 2)
+	assert.Equal(t, uint32(200), requests[1].StartLedger)
+}
+
+func TestStreamEventsGapAbort(t *testing.T) {
+	f, client := newFakeRPC(t)
+
+	f.queue(protocol.GetEventsMethodName, rpcFunc(func(json.RawMessage) (any, error) {
+		return nil, errors.New("out of range")
+	}))
+	f.queue(protocol.GetHealthMethodName, protocol.GetHealthResponse{OldestLedger: 200})
+
+	opts := noSleepStreamOptions()
+	opts.StartLedger = 100
+	opts.OnGap = func(gap *EventGapError) error { return gap }
+
+	err := client.StreamEvents(context.Background(), opts, func(context.Context, protocol.EventInfo) error {
+		return nil
+	})
+	assert.True(t, IsEventGap(err))
+}
+
+func TestStreamEventsGivesUpAfterErrors(t *testing.T) {
+	f, client := newFakeRPC(t)
+
+	f.queue(protocol.GetEventsMethodName, rpcFunc(func(json.RawMessage) (any, error) {
+		return nil, errors.New("boom")
+	}))
+	f.queue(protocol.GetHealthMethodName, protocol.GetHealthResponse{OldestLedger: 1})
+
+	opts := noSleepStreamOptions()
+	opts.StartLedger = 100
+	opts.MaxConsecutiveErrors = 3
+
+	err := client.StreamEvents(context.Background(), opts, func(context.Context, protocol.EventInfo) error {
+		return nil
+	})
+	require.ErrorContains(t, err, "boom")
+	assert.Equal(t, 3, f.callCount(protocol.GetEventsMethodName))
+}
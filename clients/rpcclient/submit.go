@@ -0,0 +1,317 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/stellar/go/protocols/stellarcore"
+	"github.com/stellar/go/xdr"
+
+	"github.com/stellar/go/protocols/rpc"
+)
+
+const (
+	defaultPollInterval     = time.Second
+	defaultMaxPollInterval  = 10 * time.Second
+	defaultRetryBackoff     = time.Second
+	defaultMaxRetryBackoff  = 30 * time.Second
+	defaultMaxTryAgainLater = 10
+	defaultWaitTimeout      = 5 * time.Minute
+)
+
+var (
+	// ErrTransactionExpired is returned by SubmitTransactionAndWait when the
+	// transaction was not found before its time bounds elapsed.
+	ErrTransactionExpired = errors.New("transaction expired before being included in a ledger")
+	// ErrTryAgainLater is returned by SubmitTransactionAndWait when Stellar-RPC
+	// kept answering TRY_AGAIN_LATER after all resubmission attempts.
+	ErrTryAgainLater = errors.New("transaction submission was throttled: TRY_AGAIN_LATER")
+)
+
+// SubmitOptions configures SubmitTransactionAndWait. The zero value is valid
+// and uses sensible defaults for every field.
+type SubmitOptions struct {
+	// PollInterval is the initial delay between getTransaction calls. It
+	// doubles after each NOT_FOUND response up to MaxPollInterval.
+	PollInterval    time.Duration
+	MaxPollInterval time.Duration
+	// RetryBackoff is the initial delay before resubmitting a transaction that
+	// was rejected with TRY_AGAIN_LATER. It doubles after each attempt up to
+	// MaxRetryBackoff.
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+	// MaxTryAgainLater is the maximum number of submissions which may return
+	// TRY_AGAIN_LATER before giving up with ErrTryAgainLater.
+	MaxTryAgainLater int
+	// Timeout bounds the total time spent waiting for the transaction when the
+	// envelope has no upper time bound.
+	Timeout time.Duration
+
+	// now and sleep are replaceable for tests.
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+func (o SubmitOptions) withDefaults() SubmitOptions {
+	if o.PollInterval <= 0 {
+		o.PollInterval = defaultPollInterval
+	}
+	if o.MaxPollInterval <= 0 {
+		o.MaxPollInterval = defaultMaxPollInterval
+	}
+	if o.RetryBackoff <= 0 {
+		o.RetryBackoff = defaultRetryBackoff
+	}
+	if o.MaxRetryBackoff <= 0 {
+		o.MaxRetryBackoff = defaultMaxRetryBackoff
+	}
+	if o.MaxTryAgainLater <= 0 {
+		o.MaxTryAgainLater = defaultMaxTryAgainLater
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = defaultWaitTimeout
+	}
+	if o.now == nil {
+		o.now = time.Now
+	}
+	if o.sleep == nil {
+		o.sleep = sleepContext
+	}
+	return o
+}
+
+// TransactionResult is the parsed outcome of a transaction which was
+// successfully included in a ledger.
+type TransactionResult struct {
+	Hash            string
+	Ledger          uint32
+	LedgerCloseTime int64
+	Envelope        xdr.TransactionEnvelope
+	Result          xdr.TransactionResult
+	Meta            xdr.TransactionMeta
+	// ReturnValue is the value returned by the invoked contract function. It
+	// is nil for transactions which are not Soroban invocations.
+	ReturnValue *xdr.ScVal
+}
+
+// TransactionError describes a transaction which was either rejected by
+// stellar-core on submission (Status is ERROR) or was included in a ledger but
+// failed (Status is FAILED).
+type TransactionError struct {
+	Hash   string
+	Status string
+	Result xdr.TransactionResult
+	// Meta is only populated for FAILED transactions.
+	Meta             *xdr.TransactionMeta
+	DiagnosticEvents []xdr.DiagnosticEvent
+}
+
+func (e *TransactionError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "transaction %s %s: %s", e.Hash, strings.ToLower(e.Status), e.Result.Result.Code.String())
+	if opResults, ok := e.Result.OperationResults(); ok {
+		codes := make([]string, 0, len(opResults))
+		for _, opResult := range opResults {
+			codes = append(codes, operationResultCode(opResult))
+		}
+		if len(codes) > 0 {
+			fmt.Fprintf(&sb, " [%s]", strings.Join(codes, ", "))
+		}
+	}
+	return sb.String()
+}
+
+// ResultCode returns the transaction result code.
+func (e *TransactionError) ResultCode() xdr.TransactionResultCode {
+	return e.Result.Result.Code
+}
+
+func operationResultCode(opResult xdr.OperationResult) string {
+	if opResult.Code != xdr.OperationResultCodeOpInner || opResult.Tr == nil {
+		return opResult.Code.String()
+	}
+	code, err := opResult.Tr.MapOperationResultTr()
+	if err != nil {
+		return opResult.Tr.Type.String()
+	}
+	return code
+}
+
+// SubmitTransactionAndWait submits the base64 encoded transaction envelope and
+// blocks until it is included in a ledger, it fails, its time bounds expire,
+// or ctx is done.
+//
+// Submissions rejected with TRY_AGAIN_LATER are retried with exponential
+// backoff. Submissions rejected with ERROR, and transactions which were
+// included but FAILED, are returned as a *TransactionError.
+func (c *Client) SubmitTransactionAndWait(ctx context.Context,
+	envelopeXDR string,
+	opts SubmitOptions,
+) (TransactionResult, error) {
+	opts = opts.withDefaults()
+
+	var envelope xdr.TransactionEnvelope
+	if err := xdr.SafeUnmarshalBase64(envelopeXDR, &envelope); err != nil {
+		return TransactionResult{}, fmt.Errorf("could not decode transaction envelope: %w", err)
+	}
+	deadline := opts.now().Add(opts.Timeout)
+	if tb := envelope.TimeBounds(); tb != nil && tb.MaxTime != 0 {
+		// allow one ledger past the upper bound for the network to catch up
+		deadline = time.Unix(int64(tb.MaxTime), 0).Add(6 * time.Second)
+	}
+
+	sendResp, err := c.sendWithRetry(ctx, envelopeXDR, opts)
+	if err != nil {
+		return TransactionResult{}, err
+	}
+
+	interval := opts.PollInterval
+	for {
+		txResp, err := c.GetTransaction(ctx, protocol.GetTransactionRequest{Hash: sendResp.Hash})
+		if err != nil {
+			return TransactionResult{}, fmt.Errorf("could not poll transaction %s: %w", sendResp.Hash, err)
+		}
+
+		switch txResp.Status {
+		case protocol.TransactionStatusSuccess:
+			return parseTransactionResult(txResp)
+		case protocol.TransactionStatusFailed:
+			return TransactionResult{}, parseFailedTransaction(txResp)
+		case protocol.TransactionStatusNotFound:
+		default:
+			return TransactionResult{}, fmt.Errorf("unexpected getTransaction status %q", txResp.Status)
+		}
+
+		if !opts.now().Before(deadline) {
+			return TransactionResult{}, fmt.Errorf("transaction %s: %w", sendResp.Hash, ErrTransactionExpired)
+		}
+		if err := opts.sleep(ctx, interval); err != nil {
+			return TransactionResult{}, err
+		}
+		interval = min(2*interval, opts.MaxPollInterval)
+	}
+}
+
+func (c *Client) sendWithRetry(ctx context.Context,
+	envelopeXDR string,
+	opts SubmitOptions,
+) (protocol.SendTransactionResponse, error) {
+	backoff := opts.RetryBackoff
+	for attempt := 1; ; attempt++ {
+		resp, err := c.SendTransaction(ctx, protocol.SendTransactionRequest{Transaction: envelopeXDR})
+		if err != nil {
+			return protocol.SendTransactionResponse{}, fmt.Errorf("could not submit transaction: %w", err)
+		}
+
+		switch resp.Status {
+		case stellarcore.TXStatusPending, stellarcore.TXStatusDuplicate:
+			return resp, nil
+		case stellarcore.TXStatusError:
+			return protocol.SendTransactionResponse{}, parseSubmissionError(resp)
+		case stellarcore.TXStatusTryAgainLater:
+			if attempt >= opts.MaxTryAgainLater {
+				return protocol.SendTransactionResponse{}, fmt.Errorf("transaction %s: %w", resp.Hash, ErrTryAgainLater)
+			}
+		default:
+			return protocol.SendTransactionResponse{}, fmt.Errorf("unexpected sendTransaction status %q", resp.Status)
+		}
+
+		if err := opts.sleep(ctx, backoff); err != nil {
+			return protocol.SendTransactionResponse{}, err
+		}
+		backoff = min(2*backoff, opts.MaxRetryBackoff)
+	}
+}
+
+func parseSubmissionError(resp protocol.SendTransactionResponse) error {
+	txErr := &TransactionError{Hash: resp.Hash, Status: resp.Status}
+	if resp.ErrorResultXDR != "" {
+		if err := xdr.SafeUnmarshalBase64(resp.ErrorResultXDR, &txErr.Result); err != nil {
+			return fmt.Errorf("could not decode errorResultXdr: %w", err)
+		}
+	}
+	events, err := decodeDiagnosticEvents(resp.DiagnosticEventsXDR)
+	if err != nil {
+		return err
+	}
+	txErr.DiagnosticEvents = events
+	return txErr
+}
+
+func parseFailedTransaction(resp protocol.GetTransactionResponse) error {
+	result, err := parseTransactionResult(resp)
+	if err != nil {
+		return err
+	}
+	events, err := decodeDiagnosticEvents(resp.DiagnosticEventsXDR)
+	if err != nil {
+		return err
+	}
+	return &TransactionError{
+		Hash:             resp.TransactionHash,
+		Status:           resp.Status,
+		Result:           result.Result,
+		Meta:             &result.Meta,
+		DiagnosticEvents: events,
+	}
+}
+
+func parseTransactionResult(resp protocol.GetTransactionResponse) (TransactionResult, error) {
+	result := TransactionResult{
+		Hash:            resp.TransactionHash,
+		Ledger:          resp.Ledger,
+		LedgerCloseTime: resp.LedgerCloseTime,
+	}
+	if err := xdr.SafeUnmarshalBase64(resp.EnvelopeXDR, &result.Envelope); err != nil {
+		return TransactionResult{}, fmt.Errorf("could not decode envelopeXdr: %w", err)
+	}
+	if err := xdr.SafeUnmarshalBase64(resp.ResultXDR, &result.Result); err != nil {
+		return TransactionResult{}, fmt.Errorf("could not decode resultXdr: %w", err)
+	}
+	if err := xdr.SafeUnmarshalBase64(resp.ResultMetaXDR, &result.Meta); err != nil {
+		return TransactionResult{}, fmt.Errorf("could not decode resultMetaXdr: %w", err)
+	}
+	result.ReturnValue = returnValue(result.Meta)
+	return result, nil
+}
+
+func returnValue(meta xdr.TransactionMeta) *xdr.ScVal {
+	switch meta.V {
+	case 3:
+		if meta.V3.SorobanMeta != nil {
+			return &meta.V3.SorobanMeta.ReturnValue
+		}
+	case 4:
+		if meta.V4.SorobanMeta != nil {
+			return meta.V4.SorobanMeta.ReturnValue
+		}
+	}
+	return nil
+}
+
+func decodeDiagnosticEvents(encoded []string) ([]xdr.DiagnosticEvent, error) {
+	if len(encoded) == 0 {
+		return nil, nil
+	}
+	events := make([]xdr.DiagnosticEvent, len(encoded))
+	for i, e := range encoded {
+		if err := xdr.SafeUnmarshalBase64(e, &events[i]); err != nil {
+			return nil, fmt.Errorf("could not decode diagnostic event %d: %w", i, err)
+		}
+	}
+	return events, nil
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,270 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/protocols/stellarcore"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/go/xdr"
+
+	"github.com/stellar/go/protocols/rpc"
+)
+
+// fakeRPC is a minimal JSON-RPC server standing in for Stellar-RPC. Each
+// method replies with the next queued response, repeating the last one once
+// the queue is drained. A queued rpcFunc is called with the request params
+// to compute the response.
+type fakeRPC struct {
+	mx        sync.Mutex
+	responses map[string][]any
+	calls     map[string]int
+}
+
+type rpcFunc func(params json.RawMessage) (any, error)
+
+func newFakeRPC(t *testing.T) (*fakeRPC, *Client) {
+	f := &fakeRPC{responses: map[string][]any{}, calls: map[string]int{}}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	client := NewClient(server.URL, nil)
+	t.Cleanup(func() { client.Close() })
+	return f, client
+}
+
+func (f *fakeRPC) queue(method string, responses ...any) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	f.responses[method] = append(f.responses[method], responses...)
+}
+
+func (f *fakeRPC) callCount(method string) int {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	return f.calls[method]
+}
+
+func (f *fakeRPC) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	f.mx.Lock()
+	queued := f.responses[req.Method]
+	idx := min(f.calls[req.Method], len(queued)-1)
+	f.calls[req.Method]++
+	f.mx.Unlock()
+
+	resp := map[string]any{"jsonrpc": "2.0", "id": req.ID}
+	if idx < 0 {
+		resp["error"] = map[string]any{"code": -32601, "message": "method not found"}
+	} else if fn, ok := queued[idx].(rpcFunc); ok {
+		result, err := fn(req.Params)
+		if err != nil {
+			resp["error"] = map[string]any{"code": -32600, "message": err.Error()}
+		} else {
+			resp["result"] = result
+		}
+	} else {
+		resp["result"] = queued[idx]
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func noSleepOptions() SubmitOptions {
+	return SubmitOptions{
+		sleep: func(ctx context.Context, d time.Duration) error { return ctx.Err() },
+	}
+}
+
+func buildEnvelope(t *testing.T, maxTime int64) string {
+	kp := keypair.MustRandom()
+	tx, err := txnbuild.NewTransaction(txnbuild.TransactionParams{
+		SourceAccount:        &txnbuild.SimpleAccount{AccountID: kp.Address(), Sequence: 1},
+		IncrementSequenceNum: true,
+		BaseFee:              txnbuild.MinBaseFee,
+		Preconditions:        txnbuild.Preconditions{TimeBounds: txnbuild.NewTimebounds(0, maxTime)},
+		Operations: []txnbuild.Operation{
+			&txnbuild.BumpSequence{BumpTo: 10},
+		},
+	})
+	require.NoError(t, err)
+	tx, err = tx.Sign(network.TestNetworkPassphrase, kp)
+	require.NoError(t, err)
+	envelope, err := tx.Base64()
+	require.NoError(t, err)
+	return envelope
+}
+
+func encode(t *testing.T, v any) string {
+	encoded, err := xdr.MarshalBase64(v)
+	require.NoError(t, err)
+	return encoded
+}
+
+func transactionResult(code xdr.TransactionResultCode, opResults []xdr.OperationResult) xdr.TransactionResult {
+	return xdr.TransactionResult{
+		FeeCharged: 100,
+		Result: xdr.TransactionResultResult{
+			Code:    code,
+			Results: &opResults,
+		},
+	}
+}
+
+func TestSubmitTransactionAndWaitSuccess(t *testing.T) {
+	f, client := newFakeRPC(t)
+	envelope := buildEnvelope(t, 0)
+
+	retVal := xdr.ScVal{Type: xdr.ScValTypeScvU32, U32: func() *xdr.Uint32 { v := xdr.Uint32(7); return &v }()}
+	meta := xdr.TransactionMeta{
+		V: 3,
+		V3: &xdr.TransactionMetaV3{
+			SorobanMeta: &xdr.SorobanTransactionMeta{ReturnValue: retVal},
+		},
+	}
+	result := transactionResult(xdr.TransactionResultCodeTxSuccess, []xdr.OperationResult{})
+
+	f.queue(protocol.SendTransactionMethodName,
+		protocol.SendTransactionResponse{Status: stellarcore.TXStatusTryAgainLater, Hash: "abc"},
+		protocol.SendTransactionResponse{Status: stellarcore.TXStatusPending, Hash: "abc"},
+	)
+	f.queue(protocol.GetTransactionMethodName,
+		protocol.GetTransactionResponse{TransactionDetails: protocol.TransactionDetails{
+			Status: protocol.TransactionStatusNotFound,
+		}},
+		protocol.GetTransactionResponse{
+			TransactionDetails: protocol.TransactionDetails{
+				Status:          protocol.TransactionStatusSuccess,
+				TransactionHash: "abc",
+				EnvelopeXDR:     envelope,
+				ResultXDR:       encode(t, result),
+				ResultMetaXDR:   encode(t, meta),
+				Ledger:          123,
+			},
+			LedgerCloseTime: 1000,
+		},
+	)
+
+	res, err := client.SubmitTransactionAndWait(context.Background(), envelope, noSleepOptions())
+	require.NoError(t, err)
+	assert.Equal(t, "abc", res.Hash)
+	assert.Equal(t, uint32(123), res.Ledger)
+	assert.Equal(t, int64(1000), res.LedgerCloseTime)
+	assert.True(t, res.Result.Successful())
+	require.NotNil(t, res.ReturnValue)
+	assert.Equal(t, xdr.Uint32(7), res.ReturnValue.MustU32())
+	assert.Equal(t, 2, f.callCount(protocol.SendTransactionMethodName))
+	assert.Equal(t, 2, f.callCount(protocol.GetTransactionMethodName))
+}
+
+func TestSubmitTransactionAndWaitSubmissionError(t *testing.T) {
+	f, client := newFakeRPC(t)
+	envelope := buildEnvelope(t, 0)
+
+	result := transactionResult(xdr.TransactionResultCodeTxBadSeq, nil)
+	f.queue(protocol.SendTransactionMethodName, protocol.SendTransactionResponse{
+		Status:         stellarcore.TXStatusError,
+		Hash:           "abc",
+		ErrorResultXDR: encode(t, result),
+	})
+
+	_, err := client.SubmitTransactionAndWait(context.Background(), envelope, noSleepOptions())
+	var txErr *TransactionError
+	require.ErrorAs(t, err, &txErr)
+	assert.Equal(t, stellarcore.TXStatusError, txErr.Status)
+	assert.Equal(t, xdr.TransactionResultCodeTxBadSeq, txErr.ResultCode())
+	assert.Equal(t, 0, f.callCount(protocol.GetTransactionMethodName))
+}
+
+func TestSubmitTransactionAndWaitFailed(t *testing.T) {
+	f, client := newFakeRPC(t)
+	envelope := buildEnvelope(t, 0)
+
+	result := transactionResult(xdr.TransactionResultCodeTxFailed, []xdr.OperationResult{{
+		Code: xdr.OperationResultCodeOpInner,
+		Tr: &xdr.OperationResultTr{
+			Type:          xdr.OperationTypePayment,
+			PaymentResult: &xdr.PaymentResult{Code: xdr.PaymentResultCodePaymentUnderfunded},
+		},
+	}})
+	meta := xdr.TransactionMeta{V: 3, V3: &xdr.TransactionMetaV3{}}
+	f.queue(protocol.SendTransactionMethodName, protocol.SendTransactionResponse{
+		Status: stellarcore.TXStatusPending,
+		Hash:   "abc",
+	})
+	f.queue(protocol.GetTransactionMethodName, protocol.GetTransactionResponse{
+		TransactionDetails: protocol.TransactionDetails{
+			Status:          protocol.TransactionStatusFailed,
+			TransactionHash: "abc",
+			EnvelopeXDR:     envelope,
+			ResultXDR:       encode(t, result),
+			ResultMetaXDR:   encode(t, meta),
+		},
+	})
+
+	_, err := client.SubmitTransactionAndWait(context.Background(), envelope, noSleepOptions())
+	var txErr *TransactionError
+	require.ErrorAs(t, err, &txErr)
+	assert.Equal(t, protocol.TransactionStatusFailed, txErr.Status)
+	assert.NotNil(t, txErr.Meta)
+	assert.Equal(t, "transaction abc failed: TransactionResultCodeTxFailed [PaymentResultCodePaymentUnderfunded]", txErr.Error())
+}
+
+func TestSubmitTransactionAndWaitTryAgainLaterExhausted(t *testing.T) {
+	f, client := newFakeRPC(t)
+	envelope := buildEnvelope(t, 0)
+
+	f.queue(protocol.SendTransactionMethodName, protocol.SendTransactionResponse{
+		Status: stellarcore.TXStatusTryAgainLater,
+		Hash:   "abc",
+	})
+
+	opts := noSleepOptions()
+	opts.MaxTryAgainLater = 3
+	_, err := client.SubmitTransactionAndWait(context.Background(), envelope, opts)
+	require.ErrorIs(t, err, ErrTryAgainLater)
+	assert.Equal(t, 3, f.callCount(protocol.SendTransactionMethodName))
+}
+
+func TestSubmitTransactionAndWaitExpired(t *testing.T) {
+	f, client := newFakeRPC(t)
+	now := time.Unix(1000, 0)
+	envelope := buildEnvelope(t, now.Unix()+10)
+
+	f.queue(protocol.SendTransactionMethodName, protocol.SendTransactionResponse{
+		Status: stellarcore.TXStatusPending,
+		Hash:   "abc",
+	})
+	f.queue(protocol.GetTransactionMethodName, protocol.GetTransactionResponse{
+		TransactionDetails: protocol.TransactionDetails{Status: protocol.TransactionStatusNotFound},
+	})
+
+	opts := SubmitOptions{
+		now: func() time.Time { return now },
+		sleep: func(_ context.Context, d time.Duration) error {
+			now = now.Add(d)
+			return nil
+		},
+	}
+	_, err := client.SubmitTransactionAndWait(context.Background(), envelope, opts)
+	require.ErrorIs(t, err, ErrTransactionExpired)
+	assert.Greater(t, f.callCount(protocol.GetTransactionMethodName), 1)
+}
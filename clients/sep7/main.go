@@ -0,0 +1,428 @@
+// Package sep7 builds and parses the web+stellar: URIs of SEP-7, which ask a
+// wallet to sign a transaction (tx operation) or to pay an account (pay
+// operation).
+//
+// A request can be signed by the URI_REQUEST_SIGNING_KEY of the stellar.toml
+// of its origin domain, so that wallets can show the domain the request comes
+// from. See
+// https://github.com/stellar/stellar-protocol/blob/master/ecosystem/sep-0007.md
+package sep7
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/txnbuild"
+)
+
+// Scheme is the scheme of SEP-7 URIs.
+const Scheme = "web+stellar"
+
+// The operations of SEP-7 URIs.
+const (
+	OperationTransaction = "tx"
+	OperationPayment     = "pay"
+)
+
+// The memo types of payment requests.
+const (
+	MemoTypeText   = "MEMO_TEXT"
+	MemoTypeID     = "MEMO_ID"
+	MemoTypeHash   = "MEMO_HASH"
+	MemoTypeReturn = "MEMO_RETURN"
+)
+
+// MaxMessageLength is the maximum length of the message of a request.
+const MaxMessageLength = 300
+
+// callbackPrefix prefixes the callback parameter, which is a URL.
+const callbackPrefix = "url:"
+
+// Request is a SEP-7 request, a *TransactionRequest or a *PaymentRequest.
+type Request interface {
+	// Operation returns the operation of the request, OperationTransaction or
+	// OperationPayment.
+	Operation() string
+	// String returns the URI of the request, with its signature if it is
+	// signed.
+	String() string
+
+	// params returns the parameters common to all requests.
+	params() *Params
+	// query returns the query of the request, without its common parameters.
+	query() []param
+}
+
+// Params are the parameters common to all requests.
+type Params struct {
+	// Callback is the URL to POST the signed transaction to, instead of
+	// submitting it to the network.
+	Callback string
+	// Message is shown to the user, up to MaxMessageLength characters.
+	Message string
+	// NetworkPassphrase is the network of the request, the public network if
+	// empty.
+	NetworkPassphrase string
+	// OriginDomain is the domain the request comes from, which must be
+	// verified with the signature of the request.
+	OriginDomain string
+	// Signature is the signature of the request, in base64, by the
+	// URI_REQUEST_SIGNING_KEY of OriginDomain.
+	Signature string
+
+	// unsigned is the URI parsed, without its signature, as it is signed.
+	unsigned string
+}
+
+// Network returns the network passphrase of the request.
+func (p *Params) Network() string {
+	if p.NetworkPassphrase == "" {
+		return network.PublicNetworkPassphrase
+	}
+	return p.NetworkPassphrase
+}
+
+func (p *Params) params() *Params {
+	return p
+}
+
+// Replacement identifies a field of the transaction of a request which the
+// wallet must replace, e.g. with an account of the user, as in SEP-11
+// (Txrep) paths.
+type Replacement struct {
+	// ID identifies the value of the field, so that several fields can be
+	// replaced with the same value.
+	ID string
+	// Path is the Txrep path of the field, e.g. "sourceAccount".
+	Path string
+	// Hint describes the value expected.
+	Hint string
+}
+
+// TransactionRequest asks a wallet to sign a transaction, and to submit it or
+// POST it to the callback.
+type TransactionRequest struct {
+	// XDR is the transaction envelope, in base64.
+	XDR string
+	// Replace are the fields of the transaction to replace.
+	Replace []Replacement
+	// PublicKey is the account which should sign the transaction.
+	PublicKey string
+	// Chain is a signed SEP-7 request which led to this request.
+	Chain string
+	Params
+}
+
+// Operation returns OperationTransaction.
+func (r *TransactionRequest) Operation() string {
+	return OperationTransaction
+}
+
+// Transaction returns the transaction of the request.
+func (r *TransactionRequest) Transaction() (*txnbuild.GenericTransaction, error) {
+	return txnbuild.TransactionFromXDR(r.XDR)
+}
+
+func (r *TransactionRequest) String() string {
+	return encode(r)
+}
+
+func (r *TransactionRequest) query() []param {
+	return []param{
+		{"xdr", r.XDR},
+		{"replace", encodeReplace(r.Replace)},
+		{"pubkey", r.PublicKey},
+		{"chain", r.Chain},
+	}
+}
+
+// PaymentRequest asks a wallet to pay an account.
+type PaymentRequest struct {
+	// Destination is the account or contract to pay.
+	Destination string
+	// Amount is the amount to pay, chosen by the user if empty.
+	Amount string
+	// AssetCode and AssetIssuer are the asset to pay, lumens if AssetCode is
+	// empty.
+	AssetCode   string
+	AssetIssuer string
+	// Memo is the memo of the transaction, in base64 for MemoTypeHash and
+	// MemoTypeReturn memos.
+	Memo string
+	// MemoType is the type of Memo, MemoTypeText if empty.
+	MemoType string
+	Params
+}
+
+// Operation returns OperationPayment.
+func (r *PaymentRequest) Operation() string {
+	return OperationPayment
+}
+
+// Asset returns the asset to pay.
+func (r *PaymentRequest) Asset() txnbuild.Asset {
+	if r.AssetCode == "" {
+		return txnbuild.NativeAsset{}
+	}
+	return txnbuild.CreditAsset{Code: r.AssetCode, Issuer: r.AssetIssuer}
+}
+
+// TransactionMemo returns the memo of the transaction of the payment, nil if
+// the request has no memo.
+func (r *PaymentRequest) TransactionMemo() (txnbuild.Memo, error) {
+	if r.Memo == "" {
+		return nil, nil
+	}
+	switch r.MemoType {
+	case "", MemoTypeText:
+		return txnbuild.MemoText(r.Memo), nil
+	case MemoTypeID:
+		id, err := strconv.ParseUint(r.Memo, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid memo id %q", r.Memo)
+		}
+		return txnbuild.MemoID(id), nil
+	case MemoTypeHash, MemoTypeReturn:
+		hash, err := base64.StdEncoding.DecodeString(r.Memo)
+		if err != nil || len(hash) != 32 {
+			return nil, fmt.Errorf("invalid memo hash %q", r.Memo)
+		}
+		if r.MemoType == MemoTypeHash {
+			return txnbuild.MemoHash(hash), nil
+		}
+		return txnbuild.MemoReturn(hash), nil
+	default:
+		return nil, fmt.Errorf("invalid memo type %q", r.MemoType)
+	}
+}
+
+func (r *PaymentRequest) String() string {
+	return encode(r)
+}
+
+func (r *PaymentRequest) query() []param {
+	return []param{
+		{"destination", r.Destination},
+		{"amount", r.Amount},
+		{"asset_code", r.AssetCode},
+		{"asset_issuer", r.AssetIssuer},
+		{"memo", r.Memo},
+		{"memo_type", r.MemoType},
+	}
+}
+
+type param struct {
+	key, value string
+}
+
+// encode returns the URI of a request, with its signature if it is signed.
+func encode(r Request) string {
+	uri := unsignedURI(r)
+	if signature := r.params().Signature; signature != "" {
+		uri += "&signature=" + escape(signature)
+	}
+	return uri
+}
+
+// unsignedURI returns the URI of a request without its signature: the URI
+// parsed, or its encoding if it was built.
+func unsignedURI(r Request) string {
+	p := r.params()
+	if p.unsigned != "" {
+		return p.unsigned
+	}
+	callback := p.Callback
+	if callback != "" {
+		callback = callbackPrefix + callback
+	}
+	query := append(r.query(),
+		param{"callback", callback},
+		param{"msg", p.Message},
+		param{"network_passphrase", p.NetworkPassphrase},
+		param{"origin_domain", p.OriginDomain},
+	)
+
+	var b strings.Builder
+	b.WriteString(Scheme + ":" + r.Operation())
+	separator := "?"
+	for _, param := range query {
+		if param.value == "" {
+			continue
+		}
+		b.WriteString(separator + param.key + "=" + escape(param.value))
+		separator = "&"
+	}
+	return b.String()
+}
+
+// escape escapes a value of the query of a URI as JavaScript's
+// encodeURIComponent does, encoding spaces as "%20", which all wallets decode.
+func escape(value string) string {
+	return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+}
+
+func encodeReplace(replace []Replacement) string {
+	if len(replace) == 0 {
+		return ""
+	}
+	var paths, hints []string
+	hinted := map[string]bool{}
+	for _, r := range replace {
+		paths = append(paths, r.Path+":"+r.ID)
+		if !hinted[r.ID] {
+			hinted[r.ID] = true
+			hints = append(hints, r.ID+":"+r.Hint)
+		}
+	}
+	return strings.Join(paths, ",") + ";" + strings.Join(hints, ",")
+}
+
+func parseReplace(value string) ([]Replacement, error) {
+	if value == "" {
+		return nil, nil
+	}
+	pathList, hintList, _ := strings.Cut(value, ";")
+	hints := map[string]string{}
+	if hintList != "" {
+		for _, hint := range strings.Split(hintList, ",") {
+			id, description, ok := strings.Cut(hint, ":")
+			if !ok {
+				return nil, fmt.Errorf("invalid replace hint %q", hint)
+			}
+			hints[id] = description
+		}
+	}
+	var replace []Replacement
+	for _, path := range strings.Split(pathList, ",") {
+		i := strings.LastIndex(path, ":")
+		if i <= 0 || i == len(path)-1 {
+			return nil, fmt.Errorf("invalid replace path %q", path)
+		}
+		id := path[i+1:]
+		replace = append(replace, Replacement{ID: id, Path: path[:i], Hint: hints[id]})
+	}
+	return replace, nil
+}
+
+// Parse parses a SEP-7 URI, and validates its parameters. The request keeps
+// the URI parsed, which String returns and signatures are verified against, so
+// that it must not be modified.
+func Parse(uri string) (Request, error) {
+	rest, ok := cutPrefixFold(uri, Scheme+":")
+	if !ok {
+		return nil, fmt.Errorf("uri scheme is not %s", Scheme)
+	}
+	operation, rawQuery, _ := strings.Cut(rest, "?")
+
+	// the signature is the last parameter, and signs the URI before it
+	unsigned := uri
+	if i := strings.LastIndex(uri, "&signature="); i >= 0 {
+		if strings.Contains(uri[i+1:], "&") {
+			return nil, errors.New("signature must be the last parameter")
+		}
+		unsigned = uri[:i]
+	}
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+
+	var request Request
+	switch operation {
+	case OperationTransaction:
+		request, err = parseTransaction(query)
+	case OperationPayment:
+		request, err = parsePayment(query)
+	default:
+		return nil, fmt.Errorf("invalid operation %q", operation)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p := request.params()
+	if callback := query.Get("callback"); callback != "" {
+		if p.Callback, ok = strings.CutPrefix(callback, callbackPrefix); !ok {
+			return nil, fmt.Errorf("callback must start with %q", callbackPrefix)
+		}
+		if _, err = url.ParseRequestURI(p.Callback); err != nil {
+			return nil, fmt.Errorf("invalid callback: %w", err)
+		}
+	}
+	p.Message = query.Get("msg")
+	if len(p.Message) > MaxMessageLength {
+		return nil, fmt.Errorf("message is longer than %d characters", MaxMessageLength)
+	}
+	p.NetworkPassphrase = query.Get("network_passphrase")
+	p.OriginDomain = query.Get("origin_domain")
+	p.Signature = query.Get("signature")
+	if p.Signature != "" && unsigned == uri {
+		return nil, errors.New("signature must be the last parameter")
+	}
+	p.unsigned = unsigned
+	return request, nil
+}
+
+func parseTransaction(query url.Values) (*TransactionRequest, error) {
+	r := &TransactionRequest{
+		XDR:       query.Get("xdr"),
+		PublicKey: query.Get("pubkey"),
+		Chain:     query.Get("chain"),
+	}
+	if r.XDR == "" {
+		return nil, errors.New("xdr is required")
+	}
+	if _, err := r.Transaction(); err != nil {
+		return nil, fmt.Errorf("invalid xdr: %w", err)
+	}
+	if r.PublicKey != "" && !strkey.IsValidEd25519PublicKey(r.PublicKey) {
+		return nil, fmt.Errorf("invalid pubkey %q", r.PublicKey)
+	}
+	var err error
+	if r.Replace, err = parseReplace(query.Get("replace")); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func parsePayment(query url.Values) (*PaymentRequest, error) {
+	r := &PaymentRequest{
+		Destination: query.Get("destination"),
+		Amount:      query.Get("amount"),
+		AssetCode:   query.Get("asset_code"),
+		AssetIssuer: query.Get("asset_issuer"),
+		Memo:        query.Get("memo"),
+		MemoType:    query.Get("memo_type"),
+	}
+	if !strkey.IsValidEd25519PublicKey(r.Destination) &&
+		!strkey.IsValidMuxedAccountEd25519PublicKey(r.Destination) &&
+		!strkey.IsValidContractAddress(r.Destination) {
+		return nil, fmt.Errorf("invalid destination %q", r.Destination)
+	}
+	if r.Amount != "" {
+		if _, err := amount.Parse(r.Amount); err != nil {
+			return nil, fmt.Errorf("invalid amount %q", r.Amount)
+		}
+	}
+	if r.AssetCode != "" && !strkey.IsValidEd25519PublicKey(r.AssetIssuer) {
+		return nil, fmt.Errorf("invalid asset_issuer %q", r.AssetIssuer)
+	}
+	if _, err := r.TransactionMemo(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func cutPrefixFold(s, prefix string) (string, bool) {
+	if len(s) < len(prefix) || !strings.EqualFold(s[:len(prefix)], prefix) {
+		return s, false
+	}
+	return s[len(prefix):], true
+}
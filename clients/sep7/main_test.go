@@ -0,0 +1,176 @@
+package sep7
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stellar/go/clients/stellartoml"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func transactionXDR(t *testing.T) string {
+	source := keypair.MustRandom()
+	tx, err := txnbuild.NewTransaction(txnbuild.TransactionParams{
+		SourceAccount: &txnbuild.SimpleAccount{AccountID: source.Address(), Sequence: 1},
+		Operations: []txnbuild.Operation{&txnbuild.ChangeTrust{
+			Line: txnbuild.CreditAsset{Code: "USD", Issuer: source.Address()}.MustToChangeTrustAsset(),
+		}},
+		BaseFee:       txnbuild.MinBaseFee,
+		Preconditions: txnbuild.Preconditions{TimeBounds: txnbuild.NewInfiniteTimeout()},
+	})
+	require.NoError(t, err)
+	envelope, err := tx.Base64()
+	require.NoError(t, err)
+	return envelope
+}
+
+func TestParsePayment(t *testing.T) {
+	request, err := Parse("web+stellar:pay?destination=GCALNQQBXAPZ2WIRSDDBMSTAKCUH5SG6U76YBFLQLIXJTF7FE5AX7AOO&amount=120.123&asset_code=USD&asset_issuer=GCRCUE2C5TBNIPYHMEP7NK5RWTT2WBSZ75CMARH7GDOHDDCQH3XANFOB&memo=hasysda987fs&callback=url%3Ahttps%3A%2F%2FsomeSigningService.com%2Fhasysda987fs%3Fasset%3DUSD&msg=pay%20me%20with%20lumens")
+	require.NoError(t, err)
+	payment, ok := request.(*PaymentRequest)
+	require.True(t, ok)
+	assert.Equal(t, OperationPayment, payment.Operation())
+	assert.Equal(t, "GCALNQQBXAPZ2WIRSDDBMSTAKCUH5SG6U76YBFLQLIXJTF7FE5AX7AOO", payment.Destination)
+	assert.Equal(t, "120.123", payment.Amount)
+	assert.Equal(t, txnbuild.CreditAsset{Code: "USD", Issuer: "GCRCUE2C5TBNIPYHMEP7NK5RWTT2WBSZ75CMARH7GDOHDDCQH3XANFOB"}, payment.Asset())
+	memo, err := payment.TransactionMemo()
+	require.NoError(t, err)
+	assert.Equal(t, txnbuild.MemoText("hasysda987fs"), memo)
+	assert.Equal(t, "https://someSigningService.com/hasysda987fs?asset=USD", payment.Callback)
+	assert.Equal(t, "pay me with lumens", payment.Message)
+	assert.Equal(t, network.PublicNetworkPassphrase, payment.Network())
+}
+
+func TestPaymentRoundTrip(t *testing.T) {
+	payment := &PaymentRequest{
+		Destination: "GCALNQQBXAPZ2WIRSDDBMSTAKCUH5SG6U76YBFLQLIXJTF7FE5AX7AOO",
+		Amount:      "10",
+		Memo:        "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAE=",
+		MemoType:    MemoTypeHash,
+		Params: Params{
+			Message:           "pay me with lumens",
+			NetworkPassphrase: network.TestNetworkPassphrase,
+		},
+	}
+	uri := payment.String()
+	assert.Equal(t, "web+stellar:pay?destination=GCALNQQBXAPZ2WIRSDDBMSTAKCUH5SG6U76YBFLQLIXJTF7FE5AX7AOO&amount=10&memo=AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAE%3D&memo_type=MEMO_HASH&msg=pay%20me%20with%20lumens&network_passphrase=Test%20SDF%20Network%20%3B%20September%202015", uri)
+
+	request, err := Parse(uri)
+	require.NoError(t, err)
+	parsed := request.(*PaymentRequest)
+	assert.Equal(t, network.TestNetworkPassphrase, parsed.Network())
+	assert.Equal(t, uri, parsed.String())
+	memo, err := parsed.TransactionMemo()
+	require.NoError(t, err)
+	assert.Equal(t, txnbuild.MemoHash{31: 1}, memo)
+}
+
+func TestTransactionRoundTrip(t *testing.T) {
+	envelope := transactionXDR(t)
+	transaction := &TransactionRequest{
+		XDR: envelope,
+		Replace: []Replacement{
+			{ID: "X", Path: "sourceAccount", Hint: "account on which to create the trustline"},
+			{ID: "X", Path: "operations[0].sourceAccount", Hint: "account on which to create the trustline"},
+		},
+		Params: Params{Callback: "https://example.com/sign"},
+	}
+	uri := transaction.String()
+	assert.True(t, strings.HasPrefix(uri, "web+stellar:tx?xdr="))
+	assert.Contains(t, uri, "&replace=sourceAccount%3AX%2Coperations%5B0%5D.sourceAccount%3AX%3BX%3Aaccount%20on%20which%20to%20create%20the%20trustline&callback=url%3Ahttps%3A%2F%2Fexample.com%2Fsign")
+
+	request, err := Parse(uri)
+	require.NoError(t, err)
+	parsed := request.(*TransactionRequest)
+	assert.Equal(t, envelope, parsed.XDR)
+	assert.Equal(t, transaction.Replace, parsed.Replace)
+	assert.Equal(t, "https://example.com/sign", parsed.Callback)
+	tx, err := parsed.Transaction()
+	require.NoError(t, err)
+	_, ok := tx.Transaction()
+	assert.True(t, ok)
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, testCase := range []struct {
+		uri string
+		err string
+	}{
+		{"https://example.com", "uri scheme is not web+stellar"},
+		{"web+stellar:sign?xdr=AAAA", `invalid operation "sign"`},
+		{"web+stellar:tx?pubkey=GCALNQQBXAPZ2WIRSDDBMSTAKCUH5SG6U76YBFLQLIXJTF7FE5AX7AOO", "xdr is required"},
+		{"web+stellar:tx?xdr=AAAA", "invalid xdr"},
+		{"web+stellar:pay?destination=GABC", `invalid destination "GABC"`},
+		{"web+stellar:pay?destination=GCALNQQBXAPZ2WIRSDDBMSTAKCUH5SG6U76YBFLQLIXJTF7FE5AX7AOO&amount=ten", `invalid amount "ten"`},
+		{"web+stellar:pay?destination=GCALNQQBXAPZ2WIRSDDBMSTAKCUH5SG6U76YBFLQLIXJTF7FE5AX7AOO&asset_code=USD", `invalid asset_issuer ""`},
+		{"web+stellar:pay?destination=GCALNQQBXAPZ2WIRSDDBMSTAKCUH5SG6U76YBFLQLIXJTF7FE5AX7AOO&memo=1&memo_type=MEMO_NONE", `invalid memo type "MEMO_NONE"`},
+		{"web+stellar:pay?destination=GCALNQQBXAPZ2WIRSDDBMSTAKCUH5SG6U76YBFLQLIXJTF7FE5AX7AOO&callback=https%3A%2F%2Fexample.com", `callback must start with "url:"`},
+		{"web+stellar:pay?destination=GCALNQQBXAPZ2WIRSDDBMSTAKCUH5SG6U76YBFLQLIXJTF7FE5AX7AOO&msg=" + strings.Repeat("a", 301), "message is longer than 300 characters"},
+		{"web+stellar:pay?signature=AAAA&destination=GCALNQQBXAPZ2WIRSDDBMSTAKCUH5SG6U76YBFLQLIXJTF7FE5AX7AOO", "signature must be the last parameter"},
+	} {
+		_, err := Parse(testCase.uri)
+		if assert.Error(t, err, testCase.uri) {
+			assert.Contains(t, err.Error(), testCase.err)
+		}
+	}
+}
+
+func TestSignature(t *testing.T) {
+	signingKey := keypair.MustRandom()
+	payment := &PaymentRequest{
+		Destination: "GCALNQQBXAPZ2WIRSDDBMSTAKCUH5SG6U76YBFLQLIXJTF7FE5AX7AOO",
+		Params:      Params{Message: "order 42", OriginDomain: "example.com"},
+	}
+	assert.ErrorIs(t, Verify(payment, signingKey.Address()), ErrNotSigned)
+	require.NoError(t, Sign(payment, signingKey))
+	require.NoError(t, Verify(payment, signingKey.Address()))
+	assert.ErrorIs(t, Verify(payment, keypair.MustRandom().Address()), keypair.ErrInvalidSignature)
+
+	// the signature is verified against the URI as it was signed
+	request, err := Parse(payment.String())
+	require.NoError(t, err)
+	require.NoError(t, Verify(request, signingKey.Address()))
+	tampered, err := Parse(strings.Replace(payment.String(), "order%2042", "order%2043", 1))
+	require.NoError(t, err)
+	assert.ErrorIs(t, Verify(tampered, signingKey.Address()), keypair.ErrInvalidSignature)
+
+	assert.EqualError(t, Sign(&PaymentRequest{}, signingKey), "origin_domain is required to sign a request")
+}
+
+func TestVerifyOriginDomain(t *testing.T) {
+	signingKey := keypair.MustRandom()
+	transaction := &TransactionRequest{
+		XDR:    transactionXDR(t),
+		Params: Params{OriginDomain: "example.com"},
+	}
+	client := &stellartoml.MockClient{}
+	assert.ErrorIs(t, VerifyOriginDomain(transaction, client), ErrNotSigned)
+	require.NoError(t, Sign(transaction, signingKey))
+	request, err := Parse(transaction.String())
+	require.NoError(t, err)
+
+	client.On("GetStellarToml", "example.com").
+		Return(&stellartoml.Response{UriRequestSigningKey: signingKey.Address()}, nil).Once()
+	assert.NoError(t, VerifyOriginDomain(request, client))
+
+	client.On("GetStellarToml", "example.com").
+		Return(&stellartoml.Response{UriRequestSigningKey: keypair.MustRandom().Address()}, nil).Once()
+	assert.ErrorIs(t, VerifyOriginDomain(request, client), keypair.ErrInvalidSignature)
+
+	client.On("GetStellarToml", "example.com").
+		Return(&stellartoml.Response{}, nil).Once()
+	assert.EqualError(t, VerifyOriginDomain(request, client), "stellar.toml of example.com has no URI_REQUEST_SIGNING_KEY")
+
+	client.On("GetStellarToml", "example.com").
+		Return(&stellartoml.Response{}, errors.New("not found")).Once()
+	assert.EqualError(t, VerifyOriginDomain(request, client), "could not get stellar.toml of example.com: not found")
+	client.AssertExpectations(t)
+
+	// requests without origin domain are not verified
+	assert.NoError(t, VerifyOriginDomain(&TransactionRequest{XDR: transaction.XDR}, client))
+}
@@ -0,0 +1,75 @@
+package sep7
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/stellar/go/clients/stellartoml"
+	"github.com/stellar/go/keypair"
+)
+
+// signaturePrefix prefixes the payload of the signature of a request: 35 zero
+// bytes, the byte 4, and the name of SEP-7.
+var signaturePrefix = append(append(make([]byte, 35), 4), "stellar.sep.7 - URI Scheme"...)
+
+// ErrNotSigned is returned when verifying a request which is not signed.
+var ErrNotSigned = errors.New("request is not signed")
+
+func signaturePayload(r Request) []byte {
+	return append(append([]byte{}, signaturePrefix...), unsignedURI(r)...)
+}
+
+// Sign signs a request with the URI_REQUEST_SIGNING_KEY of its origin
+// domain, replacing its signature.
+func Sign(r Request, kp *keypair.Full) error {
+	p := r.params()
+	if p.OriginDomain == "" {
+		return errors.New("origin_domain is required to sign a request")
+	}
+	signature, err := kp.Sign(signaturePayload(r))
+	if err != nil {
+		return err
+	}
+	p.Signature = base64.StdEncoding.EncodeToString(signature)
+	return nil
+}
+
+// Verify verifies the signature of a request by a signing key (G...).
+func Verify(r Request, signingKey string) error {
+	p := r.params()
+	if p.Signature == "" {
+		return ErrNotSigned
+	}
+	kp, err := keypair.ParseAddress(signingKey)
+	if err != nil {
+		return fmt.Errorf("invalid signing key: %w", err)
+	}
+	signature, err := base64.StdEncoding.DecodeString(p.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+	return kp.Verify(signaturePayload(r), signature)
+}
+
+// VerifyOriginDomain verifies that a request comes from its origin domain:
+// that it is signed by the URI_REQUEST_SIGNING_KEY of the stellar.toml of
+// the domain, fetched with the client. Requests without origin domain are
+// not verified.
+func VerifyOriginDomain(r Request, client stellartoml.ClientInterface) error {
+	p := r.params()
+	if p.OriginDomain == "" {
+		return nil
+	}
+	if p.Signature == "" {
+		return fmt.Errorf("origin_domain %s is set: %w", p.OriginDomain, ErrNotSigned)
+	}
+	toml, err := client.GetStellarToml(p.OriginDomain)
+	if err != nil {
+		return fmt.Errorf("could not get stellar.toml of %s: %w", p.OriginDomain, err)
+	}
+	if toml.UriRequestSigningKey == "" {
+		return fmt.Errorf("stellar.toml of %s has no URI_REQUEST_SIGNING_KEY", p.OriginDomain)
+	}
+	return Verify(r, toml.UriRequestSigningKey)
+}
@@ -0,0 +1,113 @@
+package txsubmitter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/go/xdr"
+)
+
+// FakeSubmitter is a Submitter and AccountLoader keeping the sequence numbers
+// of accounts in memory, to test code submitting transactions without a
+// network. A transaction is applied if its sequence number is the next one
+// of its source account, and rejected with tx_bad_seq otherwise. Fee bump
+// transactions are applied as their inner transaction.
+type FakeSubmitter struct {
+	// Result, if set, returns the result code of the transactions with a
+	// valid sequence number, tx_success otherwise. Transactions with the
+	// tx_success and tx_failed codes are applied, consuming their sequence
+	// number, while the others are rejected.
+	Result func(tx *txnbuild.Transaction) xdr.TransactionResultCode
+
+	lock      sync.Mutex
+	sequences map[string]int64
+	submitted []*txnbuild.GenericTransaction
+}
+
+// NewFakeSubmitter returns a FakeSubmitter without any account.
+func NewFakeSubmitter() *FakeSubmitter {
+	return &FakeSubmitter{sequences: map[string]int64{}}
+}
+
+// SetSequence adds an account, or changes its sequence number, e.g. to
+// simulate a transaction submitted by another process.
+func (f *FakeSubmitter) SetSequence(address string, sequence int64) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.sequences[address] = sequence
+}
+
+// Sequence returns the sequence number of an account.
+func (f *FakeSubmitter) Sequence(address string) int64 {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.sequences[address]
+}
+
+// Submitted returns the transactions submitted so far, applied or not.
+func (f *FakeSubmitter) Submitted() []*txnbuild.GenericTransaction {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return append([]*txnbuild.GenericTransaction(nil), f.submitted...)
+}
+
+// LoadAccount returns an account added with SetSequence.
+func (f *FakeSubmitter) LoadAccount(ctx context.Context, address string) (txnbuild.Account, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	sequence, ok := f.sequences[address]
+	if !ok {
+		return nil, fmt.Errorf("account %s not found", address)
+	}
+	return &txnbuild.SimpleAccount{AccountID: address, Sequence: sequence}, nil
+}
+
+// Submit applies or rejects a transaction.
+func (f *FakeSubmitter) Submit(ctx context.Context, envelope string) error {
+	parsed, err := txnbuild.TransactionFromXDR(envelope)
+	if err != nil {
+		return fmt.Errorf("could not decode transaction: %w", err)
+	}
+	tx, ok := parsed.Transaction()
+	feeBump, isFeeBump := parsed.FeeBump()
+	if !ok {
+		tx = feeBump.InnerTransaction()
+	}
+	source := tx.SourceAccount().AccountID
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.submitted = append(f.submitted, parsed)
+
+	sequence, ok := f.sequences[source]
+	code := xdr.TransactionResultCodeTxSuccess
+	switch {
+	case !ok:
+		code = xdr.TransactionResultCodeTxNoAccount
+	case tx.SequenceNumber() != sequence+1:
+		code = xdr.TransactionResultCodeTxBadSeq
+	case f.Result != nil:
+		code = f.Result(tx)
+	}
+	if code == xdr.TransactionResultCodeTxSuccess || code == xdr.TransactionResultCodeTxFailed {
+		f.sequences[source] = tx.SequenceNumber()
+	}
+	if code == xdr.TransactionResultCodeTxSuccess {
+		return nil
+	}
+
+	result := xdr.TransactionResult{Result: xdr.TransactionResultResult{Code: code}}
+	if isFeeBump {
+		result.Result = xdr.TransactionResultResult{
+			Code: xdr.TransactionResultCodeTxFeeBumpInnerFailed,
+			InnerResultPair: &xdr.InnerTransactionResultPair{
+				Result: xdr.InnerTransactionResult{
+					Result: xdr.InnerTransactionResultResult{Code: code},
+				},
+			},
+		}
+	}
+	return &TransactionError{Result: result}
+}
@@ -0,0 +1,256 @@
+// Package txsubmitter submits transactions at a high throughput, from a pool
+// of channel accounts.
+//
+// A source account can only have one transaction applied per sequence
+// number, so that a single account serializes its transactions. A Pool uses
+// channel accounts, accounts whose only purpose is to be the source of
+// transactions, as many as the transactions submitted concurrently, while the
+// operations of the transactions have the actual accounts as their source.
+// The sequence numbers of the channels are tracked locally with a
+// SequenceManager, and recovered when a transaction is rejected with
+// tx_bad_seq. The fees can be paid by a single account, by wrapping the
+// transactions in fee bump transactions, so that channels do not need to be
+// funded beyond their reserve.
+package txsubmitter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/go/xdr"
+)
+
+// The defaults of Config.
+const (
+	DefaultMaxAttempts = 3
+	DefaultTimeout     = 5 * time.Minute
+)
+
+// Config configures a Pool.
+type Config struct {
+	NetworkPassphrase string
+	// Channels are the channel accounts, the source accounts of the
+	// transactions. A channel submits one transaction at a time.
+	Channels []*keypair.Full
+	// FeeAccount, if set, pays the fees of the transactions, which are wrapped
+	// in fee bump transactions.
+	FeeAccount *keypair.Full
+	// BaseFee is the maximum fee per operation, txnbuild.MinBaseFee if 0.
+	BaseFee int64
+	// FeeEstimator, if set, estimates the base fee of every transaction
+	// instead of BaseFee, and transactions rejected with tx_insufficient_fee
+	// are submitted again with a new estimate, up to MaxAttempts times.
+	FeeEstimator FeeEstimator
+	// Timeout is the validity of the transactions, from the time they are
+	// built, DefaultTimeout if 0.
+	Timeout time.Duration
+	// MaxAttempts is the number of times a transaction is built and submitted
+	// when rejected with tx_bad_seq or, with a FeeEstimator,
+	// tx_insufficient_fee, DefaultMaxAttempts if 0.
+	MaxAttempts int
+
+	// Loader loads the sequence numbers of the channels.
+	Loader AccountLoader
+	// Submitter submits the transactions.
+	Submitter Submitter
+}
+
+// FeeEstimator estimates the base fee of transactions, e.g. a
+// *feeestimator.Estimator.
+type FeeEstimator interface {
+	BaseFee(ctx context.Context) (int64, error)
+}
+
+// Result is a transaction submitted by a Pool.
+type Result struct {
+	// Hash is the hash of the transaction submitted last, the one of the fee
+	// bump transaction if the transaction was wrapped.
+	Hash string
+	// Channel is the address of the channel account used.
+	Channel string
+	// Sequence is the sequence number of the transaction submitted last.
+	Sequence int64
+	// Attempts is the number of times the transaction was submitted.
+	Attempts int
+}
+
+// Pool submits transactions from a pool of channel accounts. It is safe for
+// concurrent use.
+type Pool struct {
+	config    Config
+	sequences *SequenceManager
+	// channels holds the channels which are not in use.
+	channels chan *keypair.Full
+}
+
+// NewPool returns a Pool using the channels of config.
+func NewPool(config Config) (*Pool, error) {
+	switch {
+	case config.NetworkPassphrase == "":
+		return nil, errors.New("network passphrase is required")
+	case len(config.Channels) == 0:
+		return nil, errors.New("at least one channel account is required")
+	case config.Loader == nil:
+		return nil, errors.New("account loader is required")
+	case config.Submitter == nil:
+		return nil, errors.New("submitter is required")
+	}
+	if config.BaseFee == 0 {
+		config.BaseFee = txnbuild.MinBaseFee
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = DefaultMaxAttempts
+	}
+
+	p := &Pool{
+		config:    config,
+		sequences: NewSequenceManager(config.Loader),
+		channels:  make(chan *keypair.Full, len(config.Channels)),
+	}
+	for _, channel := range config.Channels {
+		p.channels <- channel
+	}
+	return p, nil
+}
+
+// Submit builds a transaction of the operations on a free channel, signs it
+// with the channel and signers, and submits it, waiting for a channel to be
+// free if they are all in use. Operations are applied to the channel unless
+// they set their source account, and signers must sign for the source
+// accounts of the operations.
+//
+// A transaction rejected with tx_bad_seq is built again with the sequence
+// number of the channel reloaded, up to MaxAttempts times, as well as a
+// transaction rejected with tx_insufficient_fee if the Pool has a
+// FeeEstimator. Other rejections and failures are returned as a
+// *TransactionError.
+func (p *Pool) Submit(ctx context.Context, operations []txnbuild.Operation, signers ...*keypair.Full) (Result, error) {
+	var channel *keypair.Full
+	select {
+	case channel = <-p.channels:
+	case <-ctx.Done():
+		return Result{}, ctx.Err()
+	}
+	defer func() { p.channels <- channel }()
+
+	result := Result{Channel: channel.Address()}
+	for {
+		result.Attempts++
+		baseFee := p.config.BaseFee
+		if p.config.FeeEstimator != nil {
+			var err error
+			if baseFee, err = p.config.FeeEstimator.BaseFee(ctx); err != nil {
+				return result, fmt.Errorf("could not estimate fee: %w", err)
+			}
+		}
+		reservation, err := p.sequences.Reserve(ctx, channel.Address())
+		if err != nil {
+			return result, err
+		}
+		result.Sequence = reservation.Sequence
+
+		var envelope string
+		envelope, result.Hash, err = p.build(reservation, channel, baseFee, operations, signers)
+		if err != nil {
+			p.sequences.Release(reservation, false)
+			return result, err
+		}
+
+		err = p.config.Submitter.Submit(ctx, envelope)
+		var txErr *TransactionError
+		switch {
+		case err == nil:
+			p.sequences.Release(reservation, true)
+			return result, nil
+		case errors.As(err, &txErr):
+			p.sequences.Release(reservation, txErr.SequenceConsumed())
+			switch txErr.Code() {
+			case xdr.TransactionResultCodeTxBadSeq:
+				p.sequences.Invalidate(channel.Address())
+			case xdr.TransactionResultCodeTxInsufficientFee:
+				if p.config.FeeEstimator == nil {
+					return result, err
+				}
+			default:
+				return result, err
+			}
+			if result.Attempts >= p.config.MaxAttempts {
+				return result, err
+			}
+		default:
+			// the transaction may have been applied, so the sequence number of
+			// the channel is unknown
+			p.sequences.Release(reservation, true)
+			p.sequences.Invalidate(channel.Address())
+			return result, err
+		}
+	}
+}
+
+// build builds and signs the transaction of a reservation, and returns its
+// envelope and hash.
+func (p *Pool) build(reservation Reservation, channel *keypair.Full, baseFee int64, operations []txnbuild.Operation, signers []*keypair.Full) (string, string, error) {
+	// the fee of a transaction wrapped in a fee bump transaction is paid by
+	// the fee account
+	innerFee := baseFee
+	if p.config.FeeAccount != nil {
+		innerFee = txnbuild.MinBaseFee
+	}
+	tx, err := txnbuild.NewTransaction(txnbuild.TransactionParams{
+		SourceAccount:        reservation.Account(),
+		IncrementSequenceNum: true,
+		Operations:           operations,
+		BaseFee:              innerFee,
+		Preconditions: txnbuild.Preconditions{
+			TimeBounds: txnbuild.NewTimeout(int64(p.config.Timeout / time.Second)),
+		},
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("could not build transaction: %w", err)
+	}
+	tx, err = tx.Sign(p.config.NetworkPassphrase, append([]*keypair.Full{channel}, signers...)...)
+	if err != nil {
+		return "", "", fmt.Errorf("could not sign transaction: %w", err)
+	}
+	if p.config.FeeAccount == nil {
+		return encode(tx, p.config.NetworkPassphrase)
+	}
+
+	feeBump, err := txnbuild.NewFeeBumpTransaction(txnbuild.FeeBumpTransactionParams{
+		Inner:      tx,
+		FeeAccount: p.config.FeeAccount.Address(),
+		BaseFee:    baseFee,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("could not build fee bump transaction: %w", err)
+	}
+	feeBump, err = feeBump.Sign(p.config.NetworkPassphrase, p.config.FeeAccount)
+	if err != nil {
+		return "", "", fmt.Errorf("could not sign fee bump transaction: %w", err)
+	}
+	return encode(feeBump, p.config.NetworkPassphrase)
+}
+
+type encodable interface {
+	Base64() (string, error)
+	HashHex(network string) (string, error)
+}
+
+func encode(tx encodable, networkPassphrase string) (string, string, error) {
+	envelope, err := tx.Base64()
+	if err != nil {
+		return "", "", fmt.Errorf("could not encode transaction: %w", err)
+	}
+	hash, err := tx.HashHex(networkPassphrase)
+	if err != nil {
+		return "", "", fmt.Errorf("could not hash transaction: %w", err)
+	}
+	return envelope, hash, nil
+}
@@ -0,0 +1,297 @@
+package txsubmitter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/clients/horizonclient/horizontest"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFakePool(t *testing.T, fake *FakeSubmitter, channels int, configure func(*Config)) (*Pool, []*keypair.Full) {
+	config := Config{
+		NetworkPassphrase: network.TestNetworkPassphrase,
+		Loader:            fake,
+		Submitter:         fake,
+	}
+	for i := 0; i < channels; i++ {
+		channel := keypair.MustRandom()
+		fake.SetSequence(channel.Address(), int64(i+1)<<32)
+		config.Channels = append(config.Channels, channel)
+	}
+	if configure != nil {
+		configure(&config)
+	}
+	pool, err := NewPool(config)
+	require.NoError(t, err)
+	return pool, config.Channels
+}
+
+func bumpSequence() []txnbuild.Operation {
+	return []txnbuild.Operation{&txnbuild.BumpSequence{BumpTo: 0}}
+}
+
+func TestPoolSubmitsConcurrently(t *testing.T) {
+	fake := NewFakeSubmitter()
+	pool, channels := newFakePool(t, fake, 3, nil)
+
+	var wg sync.WaitGroup
+	results := make([]Result, 30)
+	errs := make([]error, 30)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = pool.Submit(context.Background(), bumpSequence())
+		}(i)
+	}
+	wg.Wait()
+
+	perChannel := map[string]int{}
+	for i, result := range results {
+		require.NoError(t, errs[i])
+		assert.Equal(t, 1, result.Attempts)
+		perChannel[result.Channel]++
+	}
+	assert.Len(t, fake.Submitted(), 30)
+	for i, channel := range channels {
+		assert.Equal(t, int64(i+1)<<32+int64(perChannel[channel.Address()]), fake.Sequence(channel.Address()))
+	}
+}
+
+func TestPoolRecoversBadSequence(t *testing.T) {
+	fake := NewFakeSubmitter()
+	pool, channels := newFakePool(t, fake, 1, nil)
+	address := channels[0].Address()
+
+	_, err := pool.Submit(context.Background(), bumpSequence())
+	require.NoError(t, err)
+	// another process uses the channel
+	fake.SetSequence(address, 100)
+
+	result, err := pool.Submit(context.Background(), bumpSequence())
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Attempts)
+	assert.Equal(t, int64(101), result.Sequence)
+	assert.Equal(t, int64(101), fake.Sequence(address))
+
+	// the channel keeps being out of sync
+	attempts := 0
+	fake.Result = func(tx *txnbuild.Transaction) xdr.TransactionResultCode {
+		attempts++
+		return xdr.TransactionResultCodeTxBadSeq
+	}
+	result, err = pool.Submit(context.Background(), bumpSequence())
+	var txErr *TransactionError
+	require.ErrorAs(t, err, &txErr)
+	assert.Equal(t, xdr.TransactionResultCodeTxBadSeq, txErr.Code())
+	assert.Equal(t, DefaultMaxAttempts, result.Attempts)
+	assert.Equal(t, DefaultMaxAttempts, attempts)
+}
+
+func TestPoolFailures(t *testing.T) {
+	fake := NewFakeSubmitter()
+	pool, channels := newFakePool(t, fake, 1, nil)
+	address := channels[0].Address()
+	start := fake.Sequence(address)
+
+	// a failed transaction consumes its sequence number, unlike a rejected one
+	fake.Result = func(tx *txnbuild.Transaction) xdr.TransactionResultCode {
+		return xdr.TransactionResultCodeTxFailed
+	}
+	_, err := pool.Submit(context.Background(), bumpSequence())
+	var txErr *TransactionError
+	require.ErrorAs(t, err, &txErr)
+	assert.True(t, txErr.SequenceConsumed())
+	fake.Result = func(tx *txnbuild.Transaction) xdr.TransactionResultCode {
+		return xdr.TransactionResultCodeTxInsufficientFee
+	}
+	_, err = pool.Submit(context.Background(), bumpSequence())
+	require.ErrorAs(t, err, &txErr)
+	assert.False(t, txErr.SequenceConsumed())
+
+	fake.Result = nil
+	result, err := pool.Submit(context.Background(), bumpSequence())
+	require.NoError(t, err)
+	assert.Equal(t, start+2, result.Sequence)
+}
+
+func TestPoolUnknownOutcome(t *testing.T) {
+	fake := NewFakeSubmitter()
+	timeout := errors.New("timeout")
+	failing := true
+	pool, channels := newFakePool(t, fake, 1, func(config *Config) {
+		config.Submitter = SubmitterFunc(func(ctx context.Context, envelope string) error {
+			err := fake.Submit(ctx, envelope)
+			if failing {
+				// the transaction was applied, but the response was lost
+				return timeout
+			}
+			return err
+		})
+	})
+	address := channels[0].Address()
+
+	_, err := pool.Submit(context.Background(), bumpSequence())
+	assert.ErrorIs(t, err, timeout)
+	failing = false
+	result, err := pool.Submit(context.Background(), bumpSequence())
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Attempts)
+	assert.Equal(t, result.Sequence, fake.Sequence(address))
+}
+
+func TestPoolFeeBump(t *testing.T) {
+	fake := NewFakeSubmitter()
+	feeAccount := keypair.MustRandom()
+	pool, channels := newFakePool(t, fake, 1, func(config *Config) {
+		config.FeeAccount = feeAccount
+		config.BaseFee = 1000
+	})
+
+	result, err := pool.Submit(context.Background(), bumpSequence())
+	require.NoError(t, err)
+	submitted := fake.Submitted()
+	require.Len(t, submitted, 1)
+	feeBump, ok := submitted[0].FeeBump()
+	require.True(t, ok)
+	assert.Equal(t, feeAccount.Address(), feeBump.FeeAccount())
+	assert.Equal(t, int64(1000), feeBump.BaseFee())
+	assert.Equal(t, int64(txnbuild.MinBaseFee), feeBump.InnerTransaction().BaseFee())
+	assert.Equal(t, channels[0].Address(), feeBump.InnerTransaction().SourceAccount().AccountID)
+	hash, err := feeBump.HashHex(network.TestNetworkPassphrase)
+	require.NoError(t, err)
+	assert.Equal(t, hash, result.Hash)
+
+	// the code of the inner transaction is used
+	fake.SetSequence(channels[0].Address(), 0)
+	result, err = pool.Submit(context.Background(), bumpSequence())
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Attempts)
+}
+
+// risingFees estimates a higher fee every time.
+type risingFees struct {
+	fee int64
+}
+
+func (f *risingFees) BaseFee(ctx context.Context) (int64, error) {
+	f.fee *= 2
+	return f.fee, nil
+}
+
+func TestPoolFeeEstimator(t *testing.T) {
+	fake := NewFakeSubmitter()
+	fake.Result = func(tx *txnbuild.Transaction) xdr.TransactionResultCode {
+		if tx.BaseFee() < 400 {
+			return xdr.TransactionResultCodeTxInsufficientFee
+		}
+		return xdr.TransactionResultCodeTxSuccess
+	}
+	pool, _ := newFakePool(t, fake, 1, func(config *Config) {
+		config.FeeEstimator = &risingFees{fee: 100}
+	})
+
+	// estimated at 200, and then 400
+	result, err := pool.Submit(context.Background(), bumpSequence())
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Attempts)
+	submitted := fake.Submitted()
+	require.Len(t, submitted, 2)
+	tx, _ := submitted[1].Transaction()
+	assert.Equal(t, int64(400), tx.BaseFee())
+	first, _ := submitted[0].Transaction()
+	assert.Equal(t, first.SequenceNumber(), tx.SequenceNumber())
+}
+
+func TestPoolWaitsForChannels(t *testing.T) {
+	fake := NewFakeSubmitter()
+	started, blocked := make(chan struct{}), make(chan struct{})
+	pool, _ := newFakePool(t, fake, 1, func(config *Config) {
+		config.Submitter = SubmitterFunc(func(ctx context.Context, envelope string) error {
+			close(started)
+			<-blocked
+			return fake.Submit(ctx, envelope)
+		})
+	})
+
+	done := make(chan error)
+	go func() {
+		_, err := pool.Submit(context.Background(), bumpSequence())
+		done <- err
+	}()
+	// the only channel is in use
+	<-started
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := pool.Submit(ctx, bumpSequence())
+	assert.ErrorIs(t, err, context.Canceled)
+	close(blocked)
+	assert.NoError(t, <-done)
+}
+
+func TestPoolHorizon(t *testing.T) {
+	server := horizontest.NewServer(network.TestNetworkPassphrase)
+	defer server.Close()
+	// the client sets its timeout on first use otherwise, which races
+	client := server.Client().SetHorizonTimeout(horizonclient.HorizonTimeout)
+	source, feeAccount, destination := keypair.MustRandom(), keypair.MustRandom(), keypair.MustRandom()
+	server.CreateAccount(source.Address(), "1000")
+	server.CreateAccount(feeAccount.Address(), "100")
+	server.CreateAccount(destination.Address(), "0")
+	channels := []*keypair.Full{keypair.MustRandom(), keypair.MustRandom()}
+	for _, channel := range channels {
+		server.CreateAccount(channel.Address(), "0")
+	}
+
+	pool, err := NewPool(Config{
+		NetworkPassphrase: network.TestNetworkPassphrase,
+		Channels:          channels,
+		FeeAccount:        feeAccount,
+		Loader:            HorizonLoader{Client: client},
+		Submitter:         HorizonSubmitter{Client: client},
+	})
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := pool.Submit(context.Background(), []txnbuild.Operation{&txnbuild.Payment{
+				SourceAccount: source.Address(),
+				Destination:   destination.Address(),
+				Amount:        "10",
+				Asset:         txnbuild.NativeAsset{},
+			}}, source)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	account, ok := server.Account(destination.Address())
+	require.True(t, ok)
+	balance, err := account.GetNativeBalance()
+	require.NoError(t, err)
+	assert.Equal(t, "100.0000000", balance)
+
+	// a failed payment is returned with its result
+	_, err = pool.Submit(context.Background(), []txnbuild.Operation{&txnbuild.Payment{
+		SourceAccount: source.Address(),
+		Destination:   keypair.MustRandom().Address(),
+		Amount:        "10",
+		Asset:         txnbuild.NativeAsset{},
+	}}, source)
+	var txErr *TransactionError
+	require.ErrorAs(t, err, &txErr)
+	assert.Equal(t, xdr.TransactionResultCodeTxFailed, txErr.Code())
+	assert.Len(t, server.Transactions(), 11)
+}
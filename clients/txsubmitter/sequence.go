@@ -0,0 +1,117 @@
+package txsubmitter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/stellar/go/txnbuild"
+)
+
+// SequenceManager hands out the sequence numbers of source accounts to
+// concurrent transactions. It loads the sequence number of an account from
+// the network the first time it is used, and again once it is known to be
+// out of sync, after a tx_bad_seq or a gap left by a transaction which did
+// not consume its sequence number.
+type SequenceManager struct {
+	loader AccountLoader
+
+	lock     sync.Mutex
+	accounts map[string]*sequence
+}
+
+// sequence is the state of the sequence number of an account.
+type sequence struct {
+	// last is the last sequence number reserved, or consumed on the network.
+	last   int64
+	loaded bool
+}
+
+// Reservation is a sequence number reserved for a transaction. It must be
+// released with SequenceManager.Release once the transaction was submitted.
+type Reservation struct {
+	Address  string
+	Sequence int64
+}
+
+// Account returns the source account of the transaction using the
+// reservation, to be built with txnbuild.TransactionParams.IncrementSequenceNum.
+func (r Reservation) Account() *txnbuild.SimpleAccount {
+	return &txnbuild.SimpleAccount{AccountID: r.Address, Sequence: r.Sequence - 1}
+}
+
+// NewSequenceManager returns a SequenceManager loading accounts with loader.
+func NewSequenceManager(loader AccountLoader) *SequenceManager {
+	return &SequenceManager{
+		loader:   loader,
+		accounts: map[string]*sequence{},
+	}
+}
+
+// Reserve reserves the next sequence number of an account.
+func (m *SequenceManager) Reserve(ctx context.Context, address string) (Reservation, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	state, ok := m.accounts[address]
+	if !ok {
+		state = &sequence{}
+		m.accounts[address] = state
+	}
+
+	if !state.loaded {
+		// the account is loaded without holding the lock, so that the other
+		// accounts are not blocked by the request
+		m.lock.Unlock()
+		account, err := m.loader.LoadAccount(ctx, address)
+		var loaded int64
+		if err == nil {
+			loaded, err = account.GetSequenceNumber()
+		}
+		m.lock.Lock()
+		if err != nil {
+			return Reservation{}, fmt.Errorf("could not load account %s: %w", address, err)
+		}
+		// another reservation may have loaded the account in the meantime
+		if !state.loaded {
+			state.last, state.loaded = loaded, true
+		}
+	}
+
+	state.last++
+	return Reservation{Address: address, Sequence: state.last}, nil
+}
+
+// Release releases a reservation once its transaction was submitted.
+// consumed is whether the transaction was applied, successfully or not,
+// consuming its sequence number. The sequence number of a transaction which
+// was not applied is reused if it was the last one reserved, and otherwise
+// the account is reloaded, as the transactions reserved after it will fail
+// with tx_bad_seq.
+func (m *SequenceManager) Release(r Reservation, consumed bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	state, ok := m.accounts[r.Address]
+	if !ok || !state.loaded {
+		return
+	}
+
+	switch {
+	case consumed:
+		state.last = max(state.last, r.Sequence)
+	case r.Sequence == state.last:
+		state.last--
+	case r.Sequence < state.last:
+		state.loaded = false
+	}
+}
+
+// Invalidate reloads the sequence number of an account on its next
+// reservation, e.g. after a tx_bad_seq or when the outcome of a transaction
+// is unknown.
+func (m *SequenceManager) Invalidate(address string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if state, ok := m.accounts[address]; ok {
+		state.loaded = false
+	}
+}
@@ -0,0 +1,68 @@
+package txsubmitter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingLoader counts the accounts loaded.
+type countingLoader struct {
+	*FakeSubmitter
+	loads int
+}
+
+func (l *countingLoader) LoadAccount(ctx context.Context, address string) (txnbuild.Account, error) {
+	l.loads++
+	return l.FakeSubmitter.LoadAccount(ctx, address)
+}
+
+func TestSequenceManager(t *testing.T) {
+	ctx := context.Background()
+	address := keypair.MustRandom().Address()
+	loader := &countingLoader{FakeSubmitter: NewFakeSubmitter()}
+	loader.SetSequence(address, 100)
+	m := NewSequenceManager(loader)
+
+	first, err := m.Reserve(ctx, address)
+	require.NoError(t, err)
+	second, err := m.Reserve(ctx, address)
+	require.NoError(t, err)
+	assert.Equal(t, Reservation{Address: address, Sequence: 101}, first)
+	assert.Equal(t, int64(102), second.Sequence)
+	assert.Equal(t, int64(101), second.Account().Sequence)
+	assert.Equal(t, 1, loader.loads)
+
+	// the last sequence number is reused if its transaction was not applied
+	m.Release(first, true)
+	m.Release(second, false)
+	third, err := m.Reserve(ctx, address)
+	require.NoError(t, err)
+	assert.Equal(t, int64(102), third.Sequence)
+	assert.Equal(t, 1, loader.loads)
+
+	// a gap reloads the account
+	fourth, err := m.Reserve(ctx, address)
+	require.NoError(t, err)
+	m.Release(third, false)
+	m.Release(fourth, false)
+	loader.SetSequence(address, 101)
+	fifth, err := m.Reserve(ctx, address)
+	require.NoError(t, err)
+	assert.Equal(t, int64(102), fifth.Sequence)
+	assert.Equal(t, 2, loader.loads)
+
+	loader.SetSequence(address, 200)
+	m.Invalidate(address)
+	sixth, err := m.Reserve(ctx, address)
+	require.NoError(t, err)
+	assert.Equal(t, int64(201), sixth.Sequence)
+	assert.Equal(t, 3, loader.loads)
+
+	_, err = m.Reserve(ctx, keypair.MustRandom().Address())
+	assert.ErrorContains(t, err, "could not load account")
+}
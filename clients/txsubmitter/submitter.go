@@ -0,0 +1,121 @@
+package txsubmitter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/stellar/go/clients/horizonclient"
+	rpcclient "github.com/stellar/go/clients/rpcclient"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/go/xdr"
+)
+
+// AccountLoader loads the sequence number of an account. It is implemented
+// by the Stellar-RPC client, *rpcclient.Client, and by HorizonLoader.
+type AccountLoader interface {
+	LoadAccount(ctx context.Context, address string) (txnbuild.Account, error)
+}
+
+// HorizonLoader is an AccountLoader loading accounts from Horizon.
+type HorizonLoader struct {
+	Client horizonclient.ContextClientInterface
+}
+
+// LoadAccount loads an account with AccountDetailCtx.
+func (l HorizonLoader) LoadAccount(ctx context.Context, address string) (txnbuild.Account, error) {
+	account, err := l.Client.AccountDetailCtx(ctx, horizonclient.AccountRequest{AccountID: address})
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// Submitter submits a base64 encoded transaction envelope, and returns once
+// the transaction was applied. A transaction which was rejected, or applied
+// but failed, must be returned as a *TransactionError, so that the
+// submission can be recovered; any other error leaves the outcome of the
+// transaction unknown.
+type Submitter interface {
+	Submit(ctx context.Context, envelope string) error
+}
+
+// SubmitterFunc is an adapter to use a function as a Submitter.
+type SubmitterFunc func(ctx context.Context, envelope string) error
+
+// Submit calls f(ctx, envelope).
+func (f SubmitterFunc) Submit(ctx context.Context, envelope string) error {
+	return f(ctx, envelope)
+}
+
+// TransactionError is a transaction which was rejected, or applied but
+// failed.
+type TransactionError struct {
+	Result xdr.TransactionResult
+	// Err is the error returned by the server.
+	Err error
+}
+
+func (e *TransactionError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("transaction failed: %s", e.Code())
+}
+
+func (e *TransactionError) Unwrap() error {
+	return e.Err
+}
+
+// Code returns the result code of the transaction, the one of the inner
+// transaction of a fee bump transaction.
+func (e *TransactionError) Code() xdr.TransactionResultCode {
+	if pair, ok := e.Result.Result.GetInnerResultPair(); ok {
+		return pair.Result.Result.Code
+	}
+	return e.Result.Result.Code
+}
+
+// SequenceConsumed reports whether the transaction was applied, consuming its
+// sequence number.
+func (e *TransactionError) SequenceConsumed() bool {
+	code := e.Code()
+	return code == xdr.TransactionResultCodeTxSuccess || code == xdr.TransactionResultCodeTxFailed
+}
+
+// HorizonSubmitter is a Submitter submitting transactions to Horizon.
+type HorizonSubmitter struct {
+	Client horizonclient.ContextClientInterface
+}
+
+// Submit submits a transaction with SubmitTransactionXDRCtx.
+func (s HorizonSubmitter) Submit(ctx context.Context, envelope string) error {
+	_, err := s.Client.SubmitTransactionXDRCtx(ctx, envelope)
+	if hError := horizonclient.GetError(err); hError != nil {
+		if resultXDR, resultErr := hError.ResultString(); resultErr == nil {
+			txErr := &TransactionError{Err: err}
+			if xdr.SafeUnmarshalBase64(resultXDR, &txErr.Result) == nil {
+				return txErr
+			}
+		}
+	}
+	return err
+}
+
+// RPCSubmitter is a Submitter submitting transactions to Stellar-RPC and
+// waiting for them to be applied.
+type RPCSubmitter struct {
+	Client  *rpcclient.Client
+	Options rpcclient.SubmitOptions
+}
+
+// Submit submits a transaction with SubmitTransactionAndWait.
+func (s RPCSubmitter) Submit(ctx context.Context, envelope string) error {
+	_, err := s.Client.SubmitTransactionAndWait(ctx, envelope, s.Options)
+	var rpcErr *rpcclient.TransactionError
+	// a rejection without a result does not tell what happened
+	if errors.As(err, &rpcErr) && rpcErr.Result.Result.Code != xdr.TransactionResultCodeTxSuccess {
+		return &TransactionError{Result: rpcErr.Result, Err: err}
+	}
+	return err
+}
@@ -0,0 +1,188 @@
+package orderbook
+
+import (
+	"context"
+	"io"
+
+	"github.com/stellar/go/historyarchive"
+	"github.com/stellar/go/ingest"
+	"github.com/stellar/go/ingest/ledgerbackend"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/xdr"
+)
+
+// DefaultVerifyFrequency is the number of ledgers after which an Ingester
+// checks the consistency of its graph, unless configured otherwise.
+const DefaultVerifyFrequency = 64
+
+var (
+	errNotBootstrapped = errors.New("order book graph has not been bootstrapped")
+	errLedgerGap       = errors.New("ledger is not the successor of the last ingested ledger")
+)
+
+// Ingester keeps an OrderBookGraph in sync with the Stellar ledger. The graph
+// is first bootstrapped from the ledger entries of a checkpoint and then
+// updated with the changes of every subsequent ledger.
+type Ingester struct {
+	graph             *OrderBookGraph
+	networkPassphrase string
+	// VerifyFrequency is the number of ledgers after which the consistency of
+	// the graph is checked with Verify(). Verification only happens during
+	// bootstrapping when it is 0.
+	VerifyFrequency uint32
+}
+
+// NewIngester constructs an Ingester which maintains the given graph.
+func NewIngester(graph *OrderBookGraph, networkPassphrase string) *Ingester {
+	return &Ingester{
+		graph:             graph,
+		networkPassphrase: networkPassphrase,
+		VerifyFrequency:   DefaultVerifyFrequency,
+	}
+}
+
+// LastLedger returns the sequence of the ledger up to which the graph is
+// accurate, or 0 if the graph has not been bootstrapped.
+func (i *Ingester) LastLedger() uint32 {
+	i.graph.lock.RLock()
+	defer i.graph.lock.RUnlock()
+	return i.graph.lastLedger
+}
+
+// BootstrapFromArchive replaces the contents of the graph with the offers and
+// liquidity pools of the given checkpoint ledger.
+func (i *Ingester) BootstrapFromArchive(ctx context.Context, archive historyarchive.ArchiveInterface, checkpointLedger uint32) error {
+	reader, err := ingest.NewCheckpointChangeReader(ctx, archive, checkpointLedger)
+	if err != nil {
+		return errors.Wrap(err, "could not create checkpoint change reader")
+	}
+	return i.Bootstrap(reader, checkpointLedger)
+}
+
+// Bootstrap replaces the contents of the graph with the offers and liquidity
+// pools read from a reader of the ledger entries at the given checkpoint, such
+// as a CheckpointChangeReader. The reader is closed once consumed.
+func (i *Ingester) Bootstrap(reader ingest.ChangeReader, checkpointLedger uint32) error {
+	defer reader.Close()
+
+	i.graph.Clear()
+	if err := i.addChanges(reader); err != nil {
+		i.graph.Discard()
+		return errors.Wrap(err, "could not read checkpoint entries")
+	}
+	if err := i.graph.Apply(checkpointLedger); err != nil {
+		i.graph.Discard()
+		return errors.Wrap(err, "could not apply checkpoint entries")
+	}
+	if _, _, err := i.graph.Verify(); err != nil {
+		return errors.Wrap(err, "order book graph is inconsistent after bootstrapping")
+	}
+	return nil
+}
+
+// ProcessLedger applies the offer and liquidity pool changes of a ledger to
+// the graph.
+//
+// Ledgers which were already applied are ignored, so a ledger can safely be
+// processed again, e.g. when a LedgerBackend is restarted. Ledgers must
+// otherwise be processed in order and a ledger which does not immediately
+// follow the last one results in an error, in which case the graph is left
+// untouched.
+func (i *Ingester) ProcessLedger(ledger xdr.LedgerCloseMeta) error {
+	sequence := ledger.LedgerSequence()
+	last := i.LastLedger()
+	switch {
+	case last == 0:
+		return errNotBootstrapped
+	case sequence <= last:
+		return nil
+	case sequence != last+1:
+		return errors.Wrapf(errLedgerGap, "ledger %d cannot follow ledger %d", sequence, last)
+	}
+
+	reader, err := ingest.NewLedgerChangeReaderFromLedgerCloseMeta(i.networkPassphrase, ledger)
+	if err != nil {
+		return errors.Wrapf(err, "could not create change reader for ledger %d", sequence)
+	}
+	defer reader.Close()
+
+	if err := i.addChanges(reader); err != nil {
+		i.graph.Discard()
+		return errors.Wrapf(err, "could not read changes of ledger %d", sequence)
+	}
+	if err := i.graph.Apply(sequence); err != nil {
+		i.graph.Discard()
+		return errors.Wrapf(err, "could not apply changes of ledger %d", sequence)
+	}
+
+	if i.VerifyFrequency > 0 && sequence%i.VerifyFrequency == 0 {
+		if _, _, err := i.graph.Verify(); err != nil {
+			return errors.Wrapf(err, "order book graph is inconsistent at ledger %d", sequence)
+		}
+	}
+	return nil
+}
+
+// Run keeps the graph current with the ledgers of the backend, starting right
+// after the last ledger of the graph, until the context is cancelled or an
+// error occurs. The graph must have been bootstrapped beforehand.
+func (i *Ingester) Run(ctx context.Context, backend ledgerbackend.LedgerBackend) error {
+	last := i.LastLedger()
+	if last == 0 {
+		return errNotBootstrapped
+	}
+
+	ledgerRange := ledgerbackend.UnboundedRange(last + 1)
+	prepared, err := backend.IsPrepared(ctx, ledgerRange)
+	if err != nil {
+		return errors.Wrap(err, "could not check if ledger range is prepared")
+	}
+	if !prepared {
+		if err := backend.PrepareRange(ctx, ledgerRange); err != nil {
+			return errors.Wrap(err, "could not prepare ledger range")
+		}
+	}
+
+	for sequence := last + 1; ; sequence++ {
+		ledger, err := backend.GetLedger(ctx, sequence)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return errors.Wrapf(err, "could not get ledger %d", sequence)
+		}
+		if err := i.ProcessLedger(ledger); err != nil {
+			return err
+		}
+	}
+}
+
+// addChanges queues the offer and liquidity pool changes of the reader in the
+// batch of the graph.
+func (i *Ingester) addChanges(reader ingest.ChangeReader) error {
+	for {
+		change, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch change.Type {
+		case xdr.LedgerEntryTypeOffer:
+			if change.Post == nil {
+				i.graph.RemoveOffer(change.Pre.Data.MustOffer().OfferId)
+			} else {
+				// adding an offer replaces any previous version of it
+				i.graph.AddOffers(change.Post.Data.MustOffer())
+			}
+		case xdr.LedgerEntryTypeLiquidityPool:
+			if change.Post == nil {
+				i.graph.RemoveLiquidityPool(change.Pre.Data.MustLiquidityPool())
+			} else {
+				i.graph.AddLiquidityPools(change.Post.Data.MustLiquidityPool())
+			}
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package orderbook
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/ingest"
+	"github.com/stellar/go/ingest/ledgerbackend"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/xdr"
+)
+
+func offerEntry(offer xdr.OfferEntry) *xdr.LedgerEntry {
+	return &xdr.LedgerEntry{Data: xdr.LedgerEntryData{Type: xdr.LedgerEntryTypeOffer, Offer: &offer}}
+}
+
+func poolLedgerEntry(pool xdr.LiquidityPoolEntry) *xdr.LedgerEntry {
+	return &xdr.LedgerEntry{Data: xdr.LedgerEntryData{Type: xdr.LedgerEntryTypeLiquidityPool, LiquidityPool: &pool}}
+}
+
+func bootstrappedIngester(t *testing.T) *Ingester {
+	reader := &ingest.MockChangeReader{}
+	for _, entry := range []*xdr.LedgerEntry{
+		offerEntry(fiftyCentsOffer),
+		offerEntry(eurOffer),
+		poolLedgerEntry(eurUsdLiquidityPool),
+		{Data: xdr.LedgerEntryData{Type: xdr.LedgerEntryTypeAccount, Account: &xdr.AccountEntry{AccountId: issuer}}},
+	} {
+		reader.On("Read").Return(ingest.Change{Type: entry.Data.Type, Post: entry}, nil).Once()
+	}
+	reader.On("Read").Return(ingest.Change{}, io.EOF).Once()
+	reader.On("Close").Return(nil).Once()
+
+	ingester := NewIngester(NewOrderBookGraph(), network.TestNetworkPassphrase)
+	require.NoError(t, ingester.Bootstrap(reader, 63))
+	reader.AssertExpectations(t)
+	return ingester
+}
+
+// ledger returns a ledger with the given entry changes. Upgrade changes are
+// used as they are read like the changes of transactions.
+func ledger(sequence uint32, changes ...xdr.LedgerEntryChange) xdr.LedgerCloseMeta {
+	return xdr.LedgerCloseMeta{V: 0, V0: &xdr.LedgerCloseMetaV0{
+		LedgerHeader: xdr.LedgerHeaderHistoryEntry{Header: xdr.LedgerHeader{LedgerSeq: xdr.Uint32(sequence)}},
+		UpgradesProcessing: []xdr.UpgradeEntryMeta{{
+			Upgrade: xdr.LedgerUpgrade{Type: xdr.LedgerUpgradeTypeLedgerUpgradeBaseFee, NewBaseFee: new(xdr.Uint32)},
+			Changes: changes,
+		}},
+	}}
+}
+
+func updatedEntry(pre, post *xdr.LedgerEntry) []xdr.LedgerEntryChange {
+	return []xdr.LedgerEntryChange{
+		{Type: xdr.LedgerEntryChangeTypeLedgerEntryState, State: pre},
+		{Type: xdr.LedgerEntryChangeTypeLedgerEntryUpdated, Updated: post},
+	}
+}
+
+func removedEntry(entry *xdr.LedgerEntry) []xdr.LedgerEntryChange {
+	key, err := entry.LedgerKey()
+	if err != nil {
+		panic(err)
+	}
+	return []xdr.LedgerEntryChange{
+		{Type: xdr.LedgerEntryChangeTypeLedgerEntryState, State: entry},
+		{Type: xdr.LedgerEntryChangeTypeLedgerEntryRemoved, Removed: &key},
+	}
+}
+
+func TestIngesterBootstrap(t *testing.T) {
+	ingester := bootstrappedIngester(t)
+	assert.Equal(t, uint32(63), ingester.LastLedger())
+	assert.ElementsMatch(t, []xdr.OfferEntry{fiftyCentsOffer, eurOffer}, ingester.graph.Offers())
+	assert.ElementsMatch(t, []xdr.LiquidityPoolEntry{eurUsdLiquidityPool}, ingester.graph.LiquidityPools())
+}
+
+func TestIngesterProcessLedger(t *testing.T) {
+	ingester := bootstrappedIngester(t)
+
+	updatedOffer := eurOffer
+	updatedOffer.Amount = 100
+	updatedPool := makePool(eurAsset, usdAsset, 2000, 500)
+
+	var changes []xdr.LedgerEntryChange
+	changes = append(changes, updatedEntry(offerEntry(eurOffer), offerEntry(updatedOffer))...)
+	changes = append(changes, removedEntry(offerEntry(fiftyCentsOffer))...)
+	changes = append(changes, xdr.LedgerEntryChange{Type: xdr.LedgerEntryChangeTypeLedgerEntryCreated, Created: offerEntry(dollarOffer)})
+	changes = append(changes, updatedEntry(poolLedgerEntry(eurUsdLiquidityPool), poolLedgerEntry(updatedPool))...)
+	changes = append(changes, xdr.LedgerEntryChange{Type: xdr.LedgerEntryChangeTypeLedgerEntryCreated, Created: poolLedgerEntry(nativeEurPool)})
+	require.NoError(t, ingester.ProcessLedger(ledger(64, changes...)))
+
+	assert.Equal(t, uint32(64), ingester.LastLedger())
+	assert.ElementsMatch(t, []xdr.OfferEntry{updatedOffer, dollarOffer}, ingester.graph.Offers())
+	assert.ElementsMatch(t, []xdr.LiquidityPoolEntry{updatedPool, nativeEurPool}, ingester.graph.LiquidityPools())
+
+	require.NoError(t, ingester.ProcessLedger(ledger(65, removedEntry(poolLedgerEntry(updatedPool))...)))
+	assert.ElementsMatch(t, []xdr.LiquidityPoolEntry{nativeEurPool}, ingester.graph.LiquidityPools())
+	_, _, err := ingester.graph.Verify()
+	assert.NoError(t, err)
+}
+
+func TestIngesterProcessLedgerOutOfOrder(t *testing.T) {
+	ingester := bootstrappedIngester(t)
+	created := xdr.LedgerEntryChange{Type: xdr.LedgerEntryChangeTypeLedgerEntryCreated, Created: offerEntry(dollarOffer)}
+
+	// ledgers which were already applied are ignored
+	require.NoError(t, ingester.ProcessLedger(ledger(63, created)))
+	assert.ElementsMatch(t, []xdr.OfferEntry{fiftyCentsOffer, eurOffer}, ingester.graph.Offers())
+
+	// gaps are rejected and leave the graph usable
+	assert.ErrorIs(t, ingester.ProcessLedger(ledger(65, created)), errLedgerGap)
+	assert.Equal(t, uint32(63), ingester.LastLedger())
+	require.NoError(t, ingester.ProcessLedger(ledger(64, created)))
+	assert.ElementsMatch(t, []xdr.OfferEntry{fiftyCentsOffer, eurOffer, dollarOffer}, ingester.graph.Offers())
+
+	assert.ErrorIs(t, NewIngester(NewOrderBookGraph(), network.TestNetworkPassphrase).ProcessLedger(ledger(64)), errNotBootstrapped)
+}
+
+func TestIngesterRun(t *testing.T) {
+	ingester := bootstrappedIngester(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	backend := &ledgerbackend.MockDatabaseBackend{}
+	backend.On("IsPrepared", ctx, ledgerbackend.UnboundedRange(64)).Return(false, nil).Once()
+	backend.On("PrepareRange", ctx, ledgerbackend.UnboundedRange(64)).Return(nil).Once()
+	backend.On("GetLedger", ctx, uint32(64)).
+		Return(ledger(64, removedEntry(offerEntry(eurOffer))...), nil).Once()
+	backend.On("GetLedger", ctx, uint32(65)).
+		Return(xdr.LedgerCloseMeta{}, context.Canceled).
+		Run(func(mock.Arguments) { cancel() }).Once()
+
+	assert.ErrorIs(t, ingester.Run(ctx, backend), context.Canceled)
+	assert.Equal(t, uint32(64), ingester.LastLedger())
+	assert.ElementsMatch(t, []xdr.OfferEntry{fiftyCentsOffer}, ingester.graph.Offers())
+	backend.AssertExpectations(t)
+}
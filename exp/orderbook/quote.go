@@ -0,0 +1,535 @@
+package orderbook
+
+import (
+	"context"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/xdr"
+)
+
+// DefaultQuoteSplits is the number of chunks in which a quoted amount is
+// divided when the caller does not specify it.
+const DefaultQuoteSplits = 10
+
+// ErrInsufficientLiquidity is returned when the order book graph does not
+// have enough liquidity to exchange the quoted amount.
+var ErrInsufficientLiquidity = errors.New("not enough liquidity to quote the payment")
+
+// Quote is a payment split across several path payments, or legs, which
+// together make a better use of the order book graph liquidity than any
+// single path.
+type Quote struct {
+	SourceAsset       string
+	SourceAmount      xdr.Int64
+	DestinationAsset  string
+	DestinationAmount xdr.Int64
+
+	// Legs are the path payments making up the quote. They must be submitted
+	// in order since the expected amounts of a leg account for the offers and
+	// pool reserves consumed by the previous legs.
+	Legs []Path
+}
+
+// quoteOverlay records the offers and liquidity pools consumed by simulated
+// trades without modifying the graph, so that the liquidity shared by
+// several paths is only counted once.
+type quoteOverlay struct {
+	graph *OrderBookGraph
+	// offers maps the ids of the offers which were traded with to their
+	// remaining amount
+	offers map[xdr.Int64]xdr.Int64
+	// pairs contains the trading pairs of the offers which were traded with
+	pairs map[tradingPair]bool
+	// pools maps the ids of the liquidity pools which were traded with to
+	// their updated reserves
+	pools map[xdr.PoolId]xdr.LiquidityPoolEntryConstantProduct
+}
+
+func newQuoteOverlay(graph *OrderBookGraph) *quoteOverlay {
+	return &quoteOverlay{
+		graph:  graph,
+		offers: map[xdr.Int64]xdr.Int64{},
+		pairs:  map[tradingPair]bool{},
+		pools:  map[xdr.PoolId]xdr.LiquidityPoolEntryConstantProduct{},
+	}
+}
+
+// edges returns the venues of an edge set of the graph as they are after the
+// simulated trades. The edge set is copied only if it was traded with.
+// buying is true for the edge sets of venuesForBuyingAsset.
+func (o *quoteOverlay) edges(edges edgeSet, asset int32, buying bool) edgeSet {
+	result, copied := edges, false
+	for i, e := range edges {
+		pair := tradingPair{buyingAsset: e.key, sellingAsset: asset}
+		if buying {
+			pair = tradingPair{buyingAsset: asset, sellingAsset: e.key}
+		}
+		pool := e.value.pool
+		cp, poolTraded := xdr.LiquidityPoolEntryConstantProduct{}, false
+		if pool.Body.ConstantProduct != nil {
+			cp, poolTraded = o.pools[pool.LiquidityPoolId]
+		}
+		if !o.pairs[pair] && !poolTraded {
+			continue
+		}
+
+		if !copied {
+			result, copied = slices.Clone(edges), true
+		}
+		if o.pairs[pair] {
+			offers := make([]xdr.OfferEntry, 0, len(e.value.offers))
+			for _, offer := range e.value.offers {
+				if remaining, ok := o.offers[offer.OfferId]; ok {
+					if remaining == 0 {
+						continue
+					}
+					offer.Amount = remaining
+				}
+				offers = append(offers, offer)
+			}
+			result[i].value.offers = offers
+		}
+		if poolTraded {
+			pool.Body.ConstantProduct = &cp
+			result[i].value.pool = pool
+		}
+	}
+	return result
+}
+
+// sell simulates trading amount of the asset along a path of asset ids which
+// starts with it and returns the amount of the last asset received, along
+// with whether each hop was exchanged with a liquidity pool.
+func (o *quoteOverlay) sell(path []int32, amount xdr.Int64, includePools bool) (xdr.Int64, []bool, error) {
+	usedPools := make([]bool, len(path)-1)
+	for i := 0; i+1 < len(path); i++ {
+		current, next := path[i], path[i+1]
+		edges := o.edges(o.graph.venuesForBuyingAsset[current], current, true)
+		j := edges.find(next)
+		if j < 0 {
+			return 0, nil, ErrInsufficientLiquidity
+		}
+		venues := edges[j].value
+
+		poolAmount := xdr.Int64(0)
+		if includePools && venues.pool.Body.ConstantProduct != nil {
+			if payout, err := makeTrade(venues.pool, current, tradeTypeDeposit, amount); err == nil {
+				poolAmount = payout
+			}
+		}
+		offersAmount := xdr.Int64(-1)
+		sold := make([]xdr.Int64, len(venues.offers))
+		if len(venues.offers) > 0 {
+			var err error
+			offersAmount, err = fillOffersForBuyingAsset(venues.offers, amount, sold)
+			if err != nil && poolAmount == 0 {
+				return 0, nil, err
+			}
+		}
+
+		// like processVenues, prefer the pool if it performs as well as the offers
+		switch {
+		case poolAmount <= 0 && offersAmount <= 0:
+			return 0, nil, ErrInsufficientLiquidity
+		case poolAmount >= offersAmount:
+			o.tradeWithPool(venues.pool, current, amount, poolAmount)
+			amount, usedPools[i] = poolAmount, true
+		default:
+			o.tradeWithOffers(venues.offers, sold, tradingPair{buyingAsset: current, sellingAsset: next})
+			amount = offersAmount
+		}
+	}
+	return amount, usedPools, nil
+}
+
+// buy simulates receiving amount of the last asset of a path of asset ids and
+// returns the amount of the first asset which needs to be sent, along with
+// whether each hop was exchanged with a liquidity pool.
+func (o *quoteOverlay) buy(
+	path []int32,
+	amount xdr.Int64,
+	ignoreOffersFrom *xdr.AccountId,
+	includePools bool,
+) (xdr.Int64, []bool, error) {
+	usedPools := make([]bool, len(path)-1)
+	for i := len(path) - 1; i > 0; i-- {
+		current, previous := path[i], path[i-1]
+		edges := o.edges(o.graph.venuesForSellingAsset[current], current, false)
+		j := edges.find(previous)
+		if j < 0 {
+			return 0, nil, ErrInsufficientLiquidity
+		}
+		venues := edges[j].value
+
+		poolAmount := xdr.Int64(0)
+		if includePools && venues.pool.Body.ConstantProduct != nil {
+			if deposit, err := makeTrade(venues.pool, previous, tradeTypeExpectation, amount); err == nil {
+				poolAmount = deposit
+			}
+		}
+		offersAmount := xdr.Int64(-1)
+		sold := make([]xdr.Int64, len(venues.offers))
+		if len(venues.offers) > 0 {
+			var err error
+			offersAmount, err = fillOffersForSellingAsset(venues.offers, ignoreOffersFrom, amount, 0, sold)
+			if err != nil && poolAmount == 0 {
+				return 0, nil, err
+			}
+		}
+
+		// like processVenues, prefer the pool if it performs as well as the offers
+		switch best := positiveMin(poolAmount, offersAmount); {
+		case best <= 0:
+			return 0, nil, ErrInsufficientLiquidity
+		case best == poolAmount:
+			o.tradeWithPool(venues.pool, previous, poolAmount, amount)
+			amount, usedPools[i-1] = poolAmount, true
+		default:
+			o.tradeWithOffers(venues.offers, sold, tradingPair{buyingAsset: previous, sellingAsset: current})
+			amount = offersAmount
+		}
+	}
+	return amount, usedPools, nil
+}
+
+// tradeWithPool records a deposit of the given asset into a pool in exchange
+// for paidOut of the other asset.
+func (o *quoteOverlay) tradeWithPool(pool liquidityPool, deposited int32, depositedAmount, paidOut xdr.Int64) {
+	cp := *pool.Body.ConstantProduct
+	if pool.assetA == deposited {
+		cp.ReserveA += depositedAmount
+		cp.ReserveB -= paidOut
+	} else {
+		cp.ReserveB += depositedAmount
+		cp.ReserveA -= paidOut
+	}
+	o.pools[pool.LiquidityPoolId] = cp
+}
+
+// tradeWithOffers records that offers[i] sold sold[i].
+func (o *quoteOverlay) tradeWithOffers(offers []xdr.OfferEntry, sold []xdr.Int64, pair tradingPair) {
+	for i, offer := range offers {
+		if sold[i] > 0 {
+			o.offers[offer.OfferId] = offer.Amount - sold[i]
+			o.pairs[pair] = true
+		}
+	}
+}
+
+// quoteBuyingState searches strict send paths through the venues left by the
+// trades simulated in the overlay.
+type quoteBuyingState struct {
+	*buyingGraphSearchState
+	overlay *quoteOverlay
+}
+
+func (state quoteBuyingState) venues(currentAsset int32) edgeSet {
+	return state.overlay.edges(state.graph.venuesForBuyingAsset[currentAsset], currentAsset, true)
+}
+
+// quoteSellingState searches strict receive paths through the venues left by
+// the trades simulated in the overlay.
+type quoteSellingState struct {
+	*sellingGraphSearchState
+	overlay *quoteOverlay
+}
+
+func (state quoteSellingState) venues(currentAsset int32) edgeSet {
+	return state.overlay.edges(state.graph.venuesForSellingAsset[currentAsset], currentAsset, false)
+}
+
+// allocation is the amount assigned to a path of asset ids, from source to
+// destination, and whether each of its hops is exchanged with a liquidity
+// pool.
+type allocation struct {
+	path      []int32
+	usedPools []bool
+	amount    xdr.Int64
+}
+
+// allocate assigns an amount to a path. Consecutive chunks exchanged with
+// the same venues are merged: the network picks either the offers or the
+// pool of a hop for the whole amount of a path payment, so merging chunks
+// which used different venues could result in a worse exchange.
+func allocate(allocations []allocation, path []int32, usedPools []bool, amount xdr.Int64) []allocation {
+	if n := len(allocations); n > 0 &&
+		slices.Equal(allocations[n-1].path, path) && slices.Equal(allocations[n-1].usedPools, usedPools) {
+		allocations[n-1].amount += amount
+		return allocations
+	}
+	return append(allocations, allocation{path: path, usedPools: usedPools, amount: amount})
+}
+
+// splitAmount divides amount in at most splits chunks of equal size, the last
+// one including the remainder.
+func splitAmount(amount xdr.Int64, splits int) []xdr.Int64 {
+	if splits <= 0 {
+		splits = DefaultQuoteSplits
+	}
+	chunk := amount / xdr.Int64(splits)
+	if chunk == 0 {
+		return []xdr.Int64{amount}
+	}
+	chunks := make([]xdr.Int64, splits)
+	for i := range chunks {
+		chunks[i] = chunk
+	}
+	chunks[splits-1] += amount % xdr.Int64(splits)
+	return chunks
+}
+
+// splitChunk puts back the two halves of a chunk which no single path can
+// exchange anymore at the front of the remaining chunks, so that the rest of
+// the liquidity of the paths can still be used. It returns false if the chunk
+// cannot be split.
+func splitChunk(chunks []xdr.Int64, chunk xdr.Int64) ([]xdr.Int64, bool) {
+	if chunk < 2 {
+		return chunks, false
+	}
+	half := chunk / 2
+	return append([]xdr.Int64{half, chunk - half}, chunks...), true
+}
+
+// pathAssetIDs returns the asset ids of a path found by a search, from source
+// to destination.
+func (graph *OrderBookGraph) pathAssetIDs(path Path) []int32 {
+	ids := make([]int32, 0, len(path.InteriorNodes)+2)
+	ids = append(ids, graph.assetStringToID[path.SourceAsset])
+	for _, asset := range path.InteriorNodes {
+		ids = append(ids, graph.assetStringToID[asset])
+	}
+	return append(ids, graph.assetStringToID[path.DestinationAsset])
+}
+
+func (graph *OrderBookGraph) pathInteriorNodes(path []int32) []string {
+	return assetIDsToAssetStrings(graph, path[1:len(path)-1])
+}
+
+// QuoteStrictSend returns the best way found to exchange amountToSpend of the
+// source asset for the destination asset, along with the last ledger of the
+// graph.
+//
+// The amount is divided in the given number of chunks, DefaultQuoteSplits if
+// it is not positive, which are halved when no path can exchange them
+// anymore. Each chunk is greedily sent along the path which yields the most
+// of the destination asset once the previous chunks have been traded. The
+// trades are simulated as the network would execute them, so offers and
+// liquidity pools shared by several paths are not counted twice. Consecutive
+// chunks sent through the same venues are merged into a single leg.
+//
+// ErrInsufficientLiquidity is returned if some chunk cannot be exchanged.
+func (graph *OrderBookGraph) QuoteStrictSend(
+	ctx context.Context,
+	maxPathLength int,
+	sourceAsset xdr.Asset,
+	amountToSpend xdr.Int64,
+	destinationAsset xdr.Asset,
+	splits int,
+	includePools bool,
+) (Quote, uint32, error) {
+	graph.lock.RLock()
+	defer graph.lock.RUnlock()
+
+	if amountToSpend <= 0 {
+		return Quote{}, graph.lastLedger, errBadAmount
+	}
+	quote := Quote{
+		SourceAsset:      sourceAsset.String(),
+		DestinationAsset: destinationAsset.String(),
+	}
+	if quote.SourceAsset == quote.DestinationAsset {
+		return directQuote(quote, amountToSpend), graph.lastLedger, nil
+	}
+	sourceAssetID, ok := graph.assetStringToID[quote.SourceAsset]
+	destinationAssetID, destinationOK := graph.assetStringToID[quote.DestinationAsset]
+	if !ok || !destinationOK {
+		return Quote{}, graph.lastLedger, ErrInsufficientLiquidity
+	}
+
+	var allocations []allocation
+	overlay := newQuoteOverlay(graph)
+	chunks := splitAmount(amountToSpend, splits)
+	for len(chunks) > 0 {
+		chunk := chunks[0]
+		chunks = chunks[1:]
+		state := quoteBuyingState{
+			buyingGraphSearchState: &buyingGraphSearchState{
+				graph:             graph,
+				sourceAssetString: quote.SourceAsset,
+				sourceAssetAmount: chunk,
+				targetAssets:      map[int32]bool{destinationAssetID: true},
+				paths:             []Path{},
+				includePools:      includePools,
+			},
+			overlay: overlay,
+		}
+		if err := search(ctx, state, maxPathLength, sourceAssetID, chunk); err != nil {
+			return Quote{}, graph.lastLedger, errors.Wrap(err, "could not determine paths")
+		}
+
+		var best *Path
+		for i, path := range state.paths {
+			if best == nil || path.DestinationAmount > best.DestinationAmount ||
+				(path.DestinationAmount == best.DestinationAmount && len(path.InteriorNodes) < len(best.InteriorNodes)) {
+				best = &state.paths[i]
+			}
+		}
+		if best == nil || best.DestinationAmount <= 0 {
+			if chunks, ok = splitChunk(chunks, chunk); !ok {
+				return Quote{}, graph.lastLedger, ErrInsufficientLiquidity
+			}
+			continue
+		}
+		path := graph.pathAssetIDs(*best)
+		_, usedPools, err := overlay.sell(path, chunk, includePools)
+		if err != nil {
+			return Quote{}, graph.lastLedger, errors.Wrap(err, "could not simulate path payment")
+		}
+		allocations = allocate(allocations, path, usedPools, chunk)
+	}
+
+	// The legs are simulated again since merged chunks are exchanged at once.
+	overlay = newQuoteOverlay(graph)
+	for _, a := range allocations {
+		received, _, err := overlay.sell(a.path, a.amount, includePools)
+		if err != nil {
+			return Quote{}, graph.lastLedger, errors.Wrap(err, "could not simulate path payment")
+		}
+		quote.Legs = append(quote.Legs, Path{
+			SourceAsset:       quote.SourceAsset,
+			SourceAmount:      a.amount,
+			DestinationAsset:  quote.DestinationAsset,
+			DestinationAmount: received,
+			InteriorNodes:     graph.pathInteriorNodes(a.path),
+		})
+		quote.SourceAmount += a.amount
+		quote.DestinationAmount += received
+	}
+	return quote, graph.lastLedger, nil
+}
+
+// QuoteStrictReceive returns the best way found to receive destinationAmount
+// of the destination asset in exchange for the source asset, along with the
+// last ledger of the graph. Offers created by sourceAccountID, if not nil,
+// are not used.
+//
+// The amount is divided in the given number of chunks, DefaultQuoteSplits if
+// it is not positive, which are halved when no path can exchange them
+// anymore. Each chunk is greedily received along the path which costs the
+// least of the source asset once the previous chunks have been traded. The
+// trades are simulated as the network would execute them, so offers and
+// liquidity pools shared by several paths are not counted twice. Consecutive
+// chunks received through the same venues are merged into a single leg.
+//
+// ErrInsufficientLiquidity is returned if some chunk cannot be exchanged.
+func (graph *OrderBookGraph) QuoteStrictReceive(
+	ctx context.Context,
+	maxPathLength int,
+	sourceAsset xdr.Asset,
+	destinationAsset xdr.Asset,
+	destinationAmount xdr.Int64,
+	sourceAccountID *xdr.AccountId,
+	splits int,
+	includePools bool,
+) (Quote, uint32, error) {
+	graph.lock.RLock()
+	defer graph.lock.RUnlock()
+
+	if destinationAmount <= 0 {
+		return Quote{}, graph.lastLedger, errBadAmount
+	}
+	quote := Quote{
+		SourceAsset:      sourceAsset.String(),
+		DestinationAsset: destinationAsset.String(),
+	}
+	if quote.SourceAsset == quote.DestinationAsset {
+		return directQuote(quote, destinationAmount), graph.lastLedger, nil
+	}
+	sourceAssetID, ok := graph.assetStringToID[quote.SourceAsset]
+	destinationAssetID, destinationOK := graph.assetStringToID[quote.DestinationAsset]
+	if !ok || !destinationOK {
+		return Quote{}, graph.lastLedger, ErrInsufficientLiquidity
+	}
+
+	var allocations []allocation
+	overlay := newQuoteOverlay(graph)
+	chunks := splitAmount(destinationAmount, splits)
+	for len(chunks) > 0 {
+		chunk := chunks[0]
+		chunks = chunks[1:]
+		state := quoteSellingState{
+			sellingGraphSearchState: &sellingGraphSearchState{
+				graph:                  graph,
+				destinationAssetString: quote.DestinationAsset,
+				destinationAssetAmount: chunk,
+				ignoreOffersFrom:       sourceAccountID,
+				targetAssets:           map[int32]xdr.Int64{sourceAssetID: 0},
+				paths:                  []Path{},
+				includePools:           includePools,
+			},
+			overlay: overlay,
+		}
+		if err := search(ctx, state, maxPathLength, destinationAssetID, chunk); err != nil {
+			return Quote{}, graph.lastLedger, errors.Wrap(err, "could not determine paths")
+		}
+
+		var best *Path
+		for i, path := range state.paths {
+			if path.SourceAmount <= 0 {
+				continue
+			}
+			if best == nil || path.SourceAmount < best.SourceAmount ||
+				(path.SourceAmount == best.SourceAmount && len(path.InteriorNodes) < len(best.InteriorNodes)) {
+				best = &state.paths[i]
+			}
+		}
+		if best == nil {
+			if chunks, ok = splitChunk(chunks, chunk); !ok {
+				return Quote{}, graph.lastLedger, ErrInsufficientLiquidity
+			}
+			continue
+		}
+		path := graph.pathAssetIDs(*best)
+		_, usedPools, err := overlay.buy(path, chunk, sourceAccountID, includePools)
+		if err != nil {
+			return Quote{}, graph.lastLedger, errors.Wrap(err, "could not simulate path payment")
+		}
+		allocations = allocate(allocations, path, usedPools, chunk)
+	}
+
+	// The legs are simulated again since merged chunks are exchanged at once.
+	overlay = newQuoteOverlay(graph)
+	for _, a := range allocations {
+		sent, _, err := overlay.buy(a.path, a.amount, sourceAccountID, includePools)
+		if err != nil {
+			return Quote{}, graph.lastLedger, errors.Wrap(err, "could not simulate path payment")
+		}
+		quote.Legs = append(quote.Legs, Path{
+			SourceAsset:       quote.SourceAsset,
+			SourceAmount:      sent,
+			DestinationAsset:  quote.DestinationAsset,
+			DestinationAmount: a.amount,
+			InteriorNodes:     graph.pathInteriorNodes(a.path),
+		})
+		quote.SourceAmount += sent
+		quote.DestinationAmount += a.amount
+	}
+	return quote, graph.lastLedger, nil
+}
+
+// directQuote returns the quote of a payment which does not need any
+// exchange.
+func directQuote(quote Quote, amount xdr.Int64) Quote {
+	quote.SourceAmount = amount
+	quote.DestinationAmount = amount
+	quote.Legs = []Path{{
+		SourceAsset:       quote.SourceAsset,
+		SourceAmount:      amount,
+		DestinationAsset:  quote.DestinationAsset,
+		DestinationAmount: amount,
+		InteriorNodes:     []string{},
+	}}
+	return quote
+}
@@ -0,0 +1,176 @@
+package orderbook
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/xdr"
+)
+
+func quoteOffer(id xdr.Int64, selling, buying xdr.Asset, amount xdr.Int64) xdr.OfferEntry {
+	return xdr.OfferEntry{
+		SellerId: issuer,
+		OfferId:  id,
+		Selling:  selling,
+		Buying:   buying,
+		Price:    xdr.Price{N: 1, D: 1},
+		Amount:   amount,
+	}
+}
+
+// splitGraph can exchange usd for native either directly or through eur,
+// but neither path can exchange more than 100 usd.
+func splitGraph(t *testing.T) *OrderBookGraph {
+	graph := NewOrderBookGraph()
+	graph.AddOffers(
+		quoteOffer(1, nativeAsset, usdAsset, 100),
+		quoteOffer(2, eurAsset, usdAsset, 1000),
+		quoteOffer(3, nativeAsset, eurAsset, 100),
+	)
+	require.NoError(t, graph.Apply(1))
+	return graph
+}
+
+func TestQuoteStrictSendSplitsAcrossPaths(t *testing.T) {
+	graph := splitGraph(t)
+	offers := graph.Offers()
+
+	paths, _, err := graph.FindFixedPaths(context.Background(), 3, usdAsset, 200, []xdr.Asset{nativeAsset}, 5, true)
+	require.NoError(t, err)
+	assert.Empty(t, paths)
+
+	quote, lastLedger, err := graph.QuoteStrictSend(context.Background(), 3, usdAsset, 200, nativeAsset, 0, true)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), lastLedger)
+	assert.Equal(t, Quote{
+		SourceAsset:       usdAsset.String(),
+		SourceAmount:      200,
+		DestinationAsset:  nativeAsset.String(),
+		DestinationAmount: 200,
+		Legs: []Path{
+			{
+				SourceAsset:       usdAsset.String(),
+				SourceAmount:      100,
+				DestinationAsset:  nativeAsset.String(),
+				DestinationAmount: 100,
+				InteriorNodes:     []string{},
+			},
+			{
+				SourceAsset:       usdAsset.String(),
+				SourceAmount:      100,
+				DestinationAsset:  nativeAsset.String(),
+				DestinationAmount: 100,
+				InteriorNodes:     []string{eurAsset.String()},
+			},
+		},
+	}, quote)
+
+	// quoting does not modify the graph
+	assert.ElementsMatch(t, offers, graph.Offers())
+	_, _, err = graph.Verify()
+	assert.NoError(t, err)
+}
+
+func TestQuoteStrictSendSharedLiquidity(t *testing.T) {
+	// both usd -> eur -> native and usd -> chf -> eur -> native go through the
+	// only 50 native offered for eur
+	graph := NewOrderBookGraph()
+	graph.AddOffers(
+		quoteOffer(1, nativeAsset, usdAsset, 100),
+		quoteOffer(2, eurAsset, usdAsset, 1000),
+		quoteOffer(3, chfAsset, usdAsset, 1000),
+		quoteOffer(4, eurAsset, chfAsset, 1000),
+		quoteOffer(5, nativeAsset, eurAsset, 50),
+	)
+	require.NoError(t, graph.Apply(1))
+
+	quote, _, err := graph.QuoteStrictSend(context.Background(), 4, usdAsset, 150, nativeAsset, 10, true)
+	require.NoError(t, err)
+	assert.Equal(t, xdr.Int64(150), quote.DestinationAmount)
+
+	_, _, err = graph.QuoteStrictSend(context.Background(), 4, usdAsset, 200, nativeAsset, 10, true)
+	assert.Equal(t, ErrInsufficientLiquidity, errors.Cause(err))
+}
+
+func TestQuoteStrictSendOffersAndPool(t *testing.T) {
+	graph := NewOrderBookGraph()
+	graph.AddOffers(quoteOffer(1, nativeAsset, usdAsset, 50))
+	graph.AddLiquidityPools(makePool(nativeAsset, usdAsset, 1000, 1000))
+	require.NoError(t, graph.Apply(1))
+
+	paths, _, err := graph.FindFixedPaths(context.Background(), 3, usdAsset, 100, []xdr.Asset{nativeAsset}, 5, true)
+	require.NoError(t, err)
+	require.Len(t, paths, 1)
+
+	// the offers are consumed first, then the rest goes to the untouched pool
+	poolPayout, _, ok := CalculatePoolPayout(1000, 1000, 50, xdr.LiquidityPoolFeeV18, false)
+	require.True(t, ok)
+
+	quote, _, err := graph.QuoteStrictSend(context.Background(), 3, usdAsset, 100, nativeAsset, 10, true)
+	require.NoError(t, err)
+	require.Len(t, quote.Legs, 2)
+	assert.Equal(t, xdr.Int64(50), quote.Legs[0].DestinationAmount)
+	assert.Equal(t, poolPayout, quote.Legs[1].DestinationAmount)
+	assert.Equal(t, 50+poolPayout, quote.DestinationAmount)
+	assert.Greater(t, quote.DestinationAmount, paths[0].DestinationAmount)
+
+	_, _, err = graph.QuoteStrictSend(context.Background(), 3, usdAsset, 100, nativeAsset, 10, false)
+	assert.Equal(t, ErrInsufficientLiquidity, errors.Cause(err))
+}
+
+func TestQuoteStrictReceive(t *testing.T) {
+	graph := splitGraph(t)
+
+	quote, lastLedger, err := graph.QuoteStrictReceive(context.Background(), 3, usdAsset, nativeAsset, 200, nil, 4, true)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), lastLedger)
+	assert.Equal(t, xdr.Int64(200), quote.SourceAmount)
+	assert.Equal(t, xdr.Int64(200), quote.DestinationAmount)
+	require.Len(t, quote.Legs, 2)
+	assert.Empty(t, quote.Legs[0].InteriorNodes)
+	assert.Equal(t, xdr.Int64(100), quote.Legs[0].SourceAmount)
+	assert.Equal(t, []string{eurAsset.String()}, quote.Legs[1].InteriorNodes)
+	assert.Equal(t, xdr.Int64(100), quote.Legs[1].SourceAmount)
+
+	_, _, err = graph.QuoteStrictReceive(context.Background(), 3, usdAsset, nativeAsset, 201, nil, 4, true)
+	assert.Equal(t, ErrInsufficientLiquidity, errors.Cause(err))
+
+	// the offers of the source account are ignored
+	quote, _, err = graph.QuoteStrictReceive(context.Background(), 3, usdAsset, nativeAsset, 100, &issuer, 4, true)
+	assert.Equal(t, ErrInsufficientLiquidity, errors.Cause(err))
+
+	other := xdr.MustAddress(keypair.MustRandom().Address())
+	quote, _, err = graph.QuoteStrictReceive(context.Background(), 3, usdAsset, nativeAsset, 100, &other, 4, true)
+	require.NoError(t, err)
+	assert.Equal(t, xdr.Int64(100), quote.SourceAmount)
+}
+
+func TestQuoteWithoutExchange(t *testing.T) {
+	graph := splitGraph(t)
+
+	quote, _, err := graph.QuoteStrictSend(context.Background(), 3, chfAsset, 10, chfAsset, 0, true)
+	require.NoError(t, err)
+	assert.Equal(t, Quote{
+		SourceAsset:       chfAsset.String(),
+		SourceAmount:      10,
+		DestinationAsset:  chfAsset.String(),
+		DestinationAmount: 10,
+		Legs: []Path{{
+			SourceAsset:       chfAsset.String(),
+			SourceAmount:      10,
+			DestinationAsset:  chfAsset.String(),
+			DestinationAmount: 10,
+			InteriorNodes:     []string{},
+		}},
+	}, quote)
+
+	_, _, err = graph.QuoteStrictSend(context.Background(), 3, usdAsset, 0, nativeAsset, 0, true)
+	assert.Equal(t, errBadAmount, err)
+	_, _, err = graph.QuoteStrictReceive(context.Background(), 3, chfAsset, nativeAsset, 10, nil, 0, true)
+	assert.Equal(t, ErrInsufficientLiquidity, err)
+}
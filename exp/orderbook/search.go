@@ -419,6 +419,18 @@ func consumeOffersForSellingAsset(
 	ignoreOffersFrom *xdr.AccountId,
 	currentAssetAmount xdr.Int64,
 	currentBestAmount xdr.Int64,
+) (xdr.Int64, error) {
+	return fillOffersForSellingAsset(offers, ignoreOffersFrom, currentAssetAmount, currentBestAmount, nil)
+}
+
+// fillOffersForSellingAsset is consumeOffersForSellingAsset which, if sold is
+// not nil, also records in sold[i] the amount sold by offers[i].
+func fillOffersForSellingAsset(
+	offers []xdr.OfferEntry,
+	ignoreOffersFrom *xdr.AccountId,
+	currentAssetAmount xdr.Int64,
+	currentBestAmount xdr.Int64,
+	sold []xdr.Int64,
 ) (xdr.Int64, error) {
 	if len(offers) == 0 {
 		return 0, errEmptyOffers
@@ -449,6 +461,9 @@ func consumeOffersForSellingAsset(
 		}
 
 		totalConsumed += xdr.Int64(buyingUnitsFromOffer)
+		if sold != nil {
+			sold[i] = xdr.Int64(sellingUnitsFromOffer)
+		}
 
 		// For sell-state, we are aiming to *minimize* the amount of the source
 		// assets we need to get to the destination, so if we exceed the best
@@ -475,6 +490,16 @@ func consumeOffersForSellingAsset(
 func consumeOffersForBuyingAsset(
 	offers []xdr.OfferEntry,
 	currentAssetAmount xdr.Int64,
+) (xdr.Int64, error) {
+	return fillOffersForBuyingAsset(offers, currentAssetAmount, nil)
+}
+
+// fillOffersForBuyingAsset is consumeOffersForBuyingAsset which, if sold is
+// not nil, also records in sold[i] the amount sold by offers[i].
+func fillOffersForBuyingAsset(
+	offers []xdr.OfferEntry,
+	currentAssetAmount xdr.Int64,
+	sold []xdr.Int64,
 ) (xdr.Int64, error) {
 	if len(offers) == 0 {
 		return 0, errEmptyOffers
@@ -504,6 +529,9 @@ func consumeOffersForBuyingAsset(
 			amountSoldXDR := xdr.Int64(amountSold)
 			if amountSoldXDR <= offers[i].Amount {
 				totalConsumed += amountSoldXDR
+				if sold != nil {
+					sold[i] = amountSoldXDR
+				}
 				return totalConsumed, nil
 			}
 		} else if err != price.ErrOverflow {
@@ -525,6 +553,9 @@ func consumeOffersForBuyingAsset(
 		}
 
 		totalConsumed += xdr.Int64(sellingUnitsFromOffer)
+		if sold != nil {
+			sold[i] = xdr.Int64(sellingUnitsFromOffer)
+		}
 		currentAssetAmount -= xdr.Int64(buyingUnitsFromOffer)
 
 		if currentAssetAmount == 0 {
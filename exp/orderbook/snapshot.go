@@ -0,0 +1,297 @@
+package orderbook
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+
+	xdr3 "github.com/stellar/go-xdr/xdr3"
+	"golang.org/x/exp/slices"
+
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/xdr"
+)
+
+// snapshotVersion is the version of the format written by Snapshot. It must
+// be incremented whenever the format changes.
+const snapshotVersion = 1
+
+// maxAssetStringLength bounds the length of the asset strings of a snapshot,
+// the longest being "credit_alphanum12/<12 characters code>/<issuer>".
+const maxAssetStringLength = 128
+
+var (
+	snapshotMagic = []byte("OBGS")
+
+	errInvalidSnapshot = errors.New("invalid order book graph snapshot")
+)
+
+// Snapshot writes the offers, liquidity pools, asset id tables and last
+// ledger of the graph to w. Pending updates which have not been applied are
+// not included.
+//
+// The snapshot is made of the magic bytes "OBGS" followed by these XDR
+// encoded fields:
+//
+//	uint32 version
+//	uint32 lastLedger
+//	string idToAssetString<>   // "" for vacant ids
+//	int32 vacantIDs<>
+//	// for every asset id, in order, the offers selling the asset grouped by
+//	// buying asset id and sorted by price
+//	struct { int32 buyingAsset; OfferEntry offers<>; } sellingEdges<>[len(idToAssetString)]
+//	LiquidityPoolEntry pools<>
+func (graph *OrderBookGraph) Snapshot(w io.Writer) error {
+	graph.lock.RLock()
+	defer graph.lock.RUnlock()
+
+	buffered := bufio.NewWriter(w)
+	if _, err := buffered.Write(snapshotMagic); err != nil {
+		return errors.Wrap(err, "could not write snapshot header")
+	}
+	enc := xdr3.NewEncoder(buffered)
+	if err := graph.encodeSnapshot(enc); err != nil {
+		return errors.Wrap(err, "could not write snapshot")
+	}
+	return errors.Wrap(buffered.Flush(), "could not write snapshot")
+}
+
+func (graph *OrderBookGraph) encodeSnapshot(enc *xdr3.Encoder) error {
+	if _, err := enc.EncodeUint(snapshotVersion); err != nil {
+		return err
+	}
+	if _, err := enc.EncodeUint(graph.lastLedger); err != nil {
+		return err
+	}
+
+	if _, err := enc.EncodeUint(uint32(len(graph.idToAssetString))); err != nil {
+		return err
+	}
+	for _, asset := range graph.idToAssetString {
+		if _, err := enc.EncodeString(asset); err != nil {
+			return err
+		}
+	}
+	if _, err := enc.EncodeUint(uint32(len(graph.vacantIDs))); err != nil {
+		return err
+	}
+	for _, id := range graph.vacantIDs {
+		if _, err := enc.EncodeInt(id); err != nil {
+			return err
+		}
+	}
+
+	for _, edges := range graph.venuesForSellingAsset {
+		var offerEdges []edge
+		for _, e := range edges {
+			if len(e.value.offers) > 0 {
+				offerEdges = append(offerEdges, e)
+			}
+		}
+		if _, err := enc.EncodeUint(uint32(len(offerEdges))); err != nil {
+			return err
+		}
+		for _, e := range offerEdges {
+			if _, err := enc.EncodeInt(e.key); err != nil {
+				return err
+			}
+			if _, err := enc.EncodeUint(uint32(len(e.value.offers))); err != nil {
+				return err
+			}
+			for i := range e.value.offers {
+				if err := e.value.offers[i].EncodeTo(enc); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if _, err := enc.EncodeUint(uint32(len(graph.liquidityPools))); err != nil {
+		return err
+	}
+	for _, pool := range graph.liquidityPools {
+		if err := pool.EncodeTo(enc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Restore replaces the contents of the graph, including any pending update,
+// with a snapshot written by Snapshot. The graph is left untouched if the
+// snapshot cannot be read.
+//
+// Offers are restored in the order they were written, which is much faster
+// than adding them one by one. Restore only checks that the snapshot is well
+// formed: Verify() can be called afterwards to check the consistency of the
+// restored graph.
+func (graph *OrderBookGraph) Restore(r io.Reader) error {
+	buffered := bufio.NewReader(r)
+	magic := make([]byte, len(snapshotMagic))
+	if _, err := io.ReadFull(buffered, magic); err != nil {
+		return errors.Wrap(err, "could not read snapshot header")
+	}
+	if !bytes.Equal(magic, snapshotMagic) {
+		return errors.Wrap(errInvalidSnapshot, "unexpected header")
+	}
+
+	restored := NewOrderBookGraph()
+	if err := restored.decodeSnapshot(xdr3.NewDecoder(buffered)); err != nil {
+		return errors.Wrap(err, "could not read snapshot")
+	}
+
+	graph.lock.Lock()
+	defer graph.lock.Unlock()
+	graph.idToAssetString = restored.idToAssetString
+	graph.assetStringToID = restored.assetStringToID
+	graph.vacantIDs = restored.vacantIDs
+	graph.venuesForSellingAsset = restored.venuesForSellingAsset
+	graph.venuesForBuyingAsset = restored.venuesForBuyingAsset
+	graph.liquidityPools = restored.liquidityPools
+	graph.tradingPairForOffer = restored.tradingPairForOffer
+	graph.lastLedger = restored.lastLedger
+	graph.batchedUpdates = graph.batch()
+	return nil
+}
+
+// decodeSnapshot populates an empty graph from a snapshot.
+func (graph *OrderBookGraph) decodeSnapshot(dec *xdr3.Decoder) error {
+	version, _, err := dec.DecodeUint()
+	if err != nil {
+		return err
+	}
+	if version != snapshotVersion {
+		return errors.Wrapf(errInvalidSnapshot, "unsupported version %d", version)
+	}
+	if graph.lastLedger, _, err = dec.DecodeUint(); err != nil {
+		return err
+	}
+
+	numAssets, _, err := dec.DecodeUint()
+	if err != nil {
+		return err
+	}
+	for id := uint32(0); id < numAssets; id++ {
+		asset, _, err := dec.DecodeString(maxAssetStringLength)
+		if err != nil {
+			return err
+		}
+		if asset != "" {
+			if _, ok := graph.assetStringToID[asset]; ok {
+				return errors.Wrapf(errInvalidSnapshot, "duplicate asset %s", asset)
+			}
+			graph.assetStringToID[asset] = int32(id)
+		}
+		graph.idToAssetString = append(graph.idToAssetString, asset)
+	}
+	// each asset has both a selling and a buying edge set
+	graph.venuesForSellingAsset = make([]edgeSet, len(graph.idToAssetString))
+	graph.venuesForBuyingAsset = make([]edgeSet, len(graph.idToAssetString))
+	validID := func(id int32) bool {
+		return id >= 0 && int(id) < len(graph.idToAssetString) && graph.idToAssetString[id] != ""
+	}
+
+	numVacant, _, err := dec.DecodeUint()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < numVacant; i++ {
+		id, _, err := dec.DecodeInt()
+		if err != nil {
+			return err
+		}
+		if id < 0 || int(id) >= len(graph.idToAssetString) || graph.idToAssetString[id] != "" {
+			return errors.Wrapf(errInvalidSnapshot, "invalid vacant id %d", id)
+		}
+		graph.vacantIDs = append(graph.vacantIDs, id)
+	}
+
+	for selling := range graph.venuesForSellingAsset {
+		numEdges, _, err := dec.DecodeUint()
+		if err != nil {
+			return err
+		}
+		if numEdges > 0 && !validID(int32(selling)) {
+			return errors.Wrapf(errInvalidSnapshot, "offers selling vacant asset id %d", selling)
+		}
+		for i := uint32(0); i < numEdges; i++ {
+			if err := graph.decodeOfferEdge(dec, int32(selling), validID); err != nil {
+				return err
+			}
+		}
+	}
+
+	numPools, _, err := dec.DecodeUint()
+	if err != nil {
+		return err
+	}
+	for i := uint32(0); i < numPools; i++ {
+		var pool xdr.LiquidityPoolEntry
+		if _, err := pool.DecodeFrom(dec, xdr3.DecodeDefaultMaxDepth); err != nil {
+			return err
+		}
+		if pool.Body.ConstantProduct == nil {
+			return errors.Wrapf(errInvalidSnapshot, "unsupported liquidity pool type %s", pool.Body.Type)
+		}
+		graph.addPool(pool)
+	}
+	return nil
+}
+
+// decodeOfferEdge reads the offers exchanging the selling asset for a buying
+// asset and adds them to both the selling and buying edge sets.
+func (graph *OrderBookGraph) decodeOfferEdge(dec *xdr3.Decoder, selling int32, validID func(int32) bool) error {
+	buying, _, err := dec.DecodeInt()
+	if err != nil {
+		return err
+	}
+	if !validID(buying) || buying == selling {
+		return errors.Wrapf(errInvalidSnapshot, "invalid buying asset id %d", buying)
+	}
+	if graph.venuesForSellingAsset[selling].find(buying) >= 0 {
+		return errors.Wrapf(errInvalidSnapshot, "duplicate edge %d -> %d", selling, buying)
+	}
+
+	numOffers, _, err := dec.DecodeUint()
+	if err != nil {
+		return err
+	}
+	if numOffers == 0 {
+		return errors.Wrapf(errInvalidSnapshot, "empty edge %d -> %d", selling, buying)
+	}
+	// the number of offers is only trusted once they have been read
+	offers := make([]xdr.OfferEntry, 0, min(numOffers, 1024))
+	pair := tradingPair{buyingAsset: buying, sellingAsset: selling}
+	for j := uint32(0); j < numOffers; j++ {
+		var offer xdr.OfferEntry
+		if _, err := offer.DecodeFrom(dec, xdr3.DecodeDefaultMaxDepth); err != nil {
+			return err
+		}
+		if j == 0 {
+			if offer.Selling.String() != graph.idToAssetString[selling] || offer.Buying.String() != graph.idToAssetString[buying] {
+				return errors.Wrapf(errInvalidSnapshot, "offer %d does not belong to edge %d -> %d", offer.OfferId, selling, buying)
+			}
+		} else {
+			// comparing with the previous offer is cheaper than formatting assets
+			previous := offers[j-1]
+			if !offer.Selling.Equals(previous.Selling) || !offer.Buying.Equals(previous.Buying) {
+				return errors.Wrapf(errInvalidSnapshot, "offer %d does not belong to edge %d -> %d", offer.OfferId, selling, buying)
+			}
+			if offer.Price.Cheaper(previous.Price) {
+				return errors.Wrapf(errInvalidSnapshot, "offers of edge %d -> %d are not sorted by price", selling, buying)
+			}
+		}
+		if _, ok := graph.tradingPairForOffer[offer.OfferId]; ok {
+			return errors.Wrapf(errInvalidSnapshot, "duplicate offer %d", offer.OfferId)
+		}
+		graph.tradingPairForOffer[offer.OfferId] = pair
+		offers = append(offers, offer)
+	}
+
+	graph.venuesForSellingAsset[selling] = append(graph.venuesForSellingAsset[selling],
+		edge{key: buying, value: Venues{offers: offers}})
+	// the edge sets must not share the offer slices as they are updated in place
+	graph.venuesForBuyingAsset[buying] = append(graph.venuesForBuyingAsset[buying],
+		edge{key: selling, value: Venues{offers: slices.Clone(offers)}})
+	return nil
+}
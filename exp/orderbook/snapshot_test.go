@@ -0,0 +1,173 @@
+package orderbook
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/xdr"
+)
+
+func snapshotGraph(t *testing.T) *OrderBookGraph {
+	graph := NewOrderBookGraph()
+	chfOffer := eurOffer
+	chfOffer.OfferId = 100
+	chfOffer.Buying = chfAsset
+	graph.AddOffers(dollarOffer, threeEurOffer, eurOffer, twoEurOffer, quarterOffer, fiftyCentsOffer, chfOffer)
+	graph.AddLiquidityPools(eurUsdLiquidityPool, nativeEurPool)
+	require.NoError(t, graph.Apply(10))
+
+	// leave a vacant asset id behind
+	graph.RemoveOffer(chfOffer.OfferId)
+	require.NoError(t, graph.Apply(11))
+	require.NotEmpty(t, graph.vacantIDs)
+	return graph
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	graph := snapshotGraph(t)
+	var snapshot bytes.Buffer
+	require.NoError(t, graph.Snapshot(&snapshot))
+
+	restored := NewOrderBookGraph()
+	restored.AddOffers(eurOffer)
+	require.NoError(t, restored.Restore(bytes.NewReader(snapshot.Bytes())))
+
+	offers, pools, err := restored.Verify()
+	require.NoError(t, err)
+	expectedOffers, expectedPools, err := graph.Verify()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, expectedOffers, offers)
+	assert.ElementsMatch(t, expectedPools, pools)
+	assert.Equal(t, graph.idToAssetString, restored.idToAssetString)
+	assert.Equal(t, graph.assetStringToID, restored.assetStringToID)
+	assert.Equal(t, graph.vacantIDs, restored.vacantIDs)
+	assert.Equal(t, graph.tradingPairForOffer, restored.tradingPairForOffer)
+	assert.Equal(t, uint32(11), restored.lastLedger)
+	// pending updates are discarded
+	assert.Empty(t, restored.batchedUpdates.operations)
+
+	// both graphs find the same paths
+	expectedPaths, _, err := graph.FindPaths(context.Background(), 3, usdAsset, 100, nil, []xdr.Asset{nativeAsset, eurAsset}, []xdr.Int64{1000, 1000}, true, 5, true)
+	require.NoError(t, err)
+	paths, _, err := restored.FindPaths(context.Background(), 3, usdAsset, 100, nil, []xdr.Asset{nativeAsset, eurAsset}, []xdr.Int64{1000, 1000}, true, 5, true)
+	require.NoError(t, err)
+	assert.Equal(t, expectedPaths, paths)
+
+	// the restored graph can be updated
+	restored.RemoveOffer(quarterOffer.OfferId)
+	restored.AddOffers(xdr.OfferEntry{
+		SellerId: issuer,
+		OfferId:  200,
+		Buying:   yenAsset,
+		Selling:  usdAsset,
+		Price:    xdr.Price{N: 1, D: 1},
+		Amount:   100,
+	})
+	require.NoError(t, restored.Apply(12))
+	_, _, err = restored.Verify()
+	assert.NoError(t, err)
+
+	// a snapshot of the restored graph restores the same graph
+	var other bytes.Buffer
+	require.NoError(t, restored.Snapshot(&other))
+	again := NewOrderBookGraph()
+	require.NoError(t, again.Restore(&other))
+	offers, _, err = again.Verify()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, restored.Offers(), offers)
+}
+
+func TestSnapshotRestoreEmpty(t *testing.T) {
+	var snapshot bytes.Buffer
+	require.NoError(t, NewOrderBookGraph().Snapshot(&snapshot))
+
+	restored := snapshotGraph(t)
+	require.NoError(t, restored.Restore(&snapshot))
+	assert.True(t, restored.IsEmpty())
+	_, _, err := restored.Verify()
+	assert.NoError(t, err)
+}
+
+func TestRestoreInvalidSnapshot(t *testing.T) {
+	var snapshot bytes.Buffer
+	require.NoError(t, snapshotGraph(t).Snapshot(&snapshot))
+	valid := snapshot.Bytes()
+
+	for _, testCase := range []struct {
+		name     string
+		snapshot []byte
+	}{
+		{"empty", nil},
+		{"magic", append([]byte("XXXX"), valid[4:]...)},
+		{"version", append(append([]byte("OBGS"), 0, 0, 0, 2), valid[8:]...)},
+		{"truncated", valid[:len(valid)-10]},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			graph := NewOrderBookGraph()
+			graph.AddOffers(eurOffer)
+			require.NoError(t, graph.Apply(5))
+
+			assert.Error(t, graph.Restore(bytes.NewReader(testCase.snapshot)))
+			assert.Equal(t, []xdr.OfferEntry{eurOffer}, graph.Offers())
+			assert.Equal(t, uint32(5), graph.lastLedger)
+		})
+	}
+}
+
+// benchmarkOffers returns 100k offers spread over the pairs of 5 assets.
+func benchmarkOffers() []xdr.OfferEntry {
+	var offers []xdr.OfferEntry
+	assets := []xdr.Asset{nativeAsset, usdAsset, eurAsset, chfAsset, yenAsset}
+	for i := 0; i < 100000; i++ {
+		selling := assets[i%len(assets)]
+		buying := assets[(i/len(assets)+i+1)%len(assets)]
+		if selling.Equals(buying) {
+			buying = assets[(i+1)%len(assets)]
+		}
+		offers = append(offers, xdr.OfferEntry{
+			SellerId: issuer,
+			OfferId:  xdr.Int64(i + 1),
+			Selling:  selling,
+			Buying:   buying,
+			Price:    xdr.Price{N: xdr.Int32(i%997 + 1), D: 100},
+			Amount:   100,
+		})
+	}
+	return offers
+}
+
+func BenchmarkRestore(b *testing.B) {
+	graph := NewOrderBookGraph()
+	graph.AddOffers(benchmarkOffers()...)
+	if err := graph.Apply(1); err != nil {
+		b.Fatal(err)
+	}
+	var snapshot bytes.Buffer
+	if err := graph.Snapshot(&snapshot); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := NewOrderBookGraph().Restore(bytes.NewReader(snapshot.Bytes())); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkAddOffers(b *testing.B) {
+	offers := benchmarkOffers()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		graph := NewOrderBookGraph()
+		graph.AddOffers(offers...)
+		if err := graph.Apply(1); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package ingest
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/stellar/go/protocols/rpc"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/toid"
+	"github.com/stellar/go/xdr"
+)
+
+// TransactionEventsOperationIndex is the operation index assigned to
+// transaction-level events (e.g. fee events emitted from protocol 23
+// onwards). It is the largest operation index representable in a cursor, so
+// transaction-level events sort after every operation-level event of the same
+// transaction and never collide with them.
+const TransactionEventsOperationIndex = toid.OperationMask
+
+// EventExtractor extracts contract and system events from ledgers and
+// returns the ones matching a set of Stellar-RPC getEvents filters, shaped
+// exactly like the events returned by getEvents.
+type EventExtractor struct {
+	networkPassphrase string
+	request           protocol.GetEventsRequest
+}
+
+// NewEventExtractor creates an EventExtractor which matches events using the
+// getEvents filter semantics. An empty filter list matches every event.
+func NewEventExtractor(networkPassphrase string, filters []protocol.EventFilter) (*EventExtractor, error) {
+	if len(filters) > protocol.MaxFiltersLimit {
+		return nil, fmt.Errorf("maximum %d filters allowed", protocol.MaxFiltersLimit)
+	}
+	for i := range filters {
+		if err := filters[i].Valid(); err != nil {
+			return nil, errors.Wrapf(err, "filter %d invalid", i+1)
+		}
+	}
+	return &EventExtractor{
+		networkPassphrase: networkPassphrase,
+		request:           protocol.GetEventsRequest{Filters: filters},
+	}, nil
+}
+
+// EventsFromLedger returns the matching events of every transaction in the
+// ledger, in cursor order.
+func (e *EventExtractor) EventsFromLedger(lcm xdr.LedgerCloseMeta) ([]protocol.EventInfo, error) {
+	reader, err := NewLedgerTransactionReaderFromLedgerCloseMeta(e.networkPassphrase, lcm)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating transaction reader")
+	}
+	defer reader.Close()
+
+	var events []protocol.EventInfo
+	for {
+		tx, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "error reading transaction")
+		}
+		txEvents, err := e.EventsFromTransaction(tx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error extracting events from transaction %d", tx.Index)
+		}
+		events = append(events, txEvents...)
+	}
+	return events, nil
+}
+
+// EventsFromTransaction returns the matching events of a single transaction,
+// in cursor order. Operation-level events come first, ordered by operation,
+// followed by transaction-level events which are assigned
+// TransactionEventsOperationIndex. Diagnostic events are never returned.
+func (e *EventExtractor) EventsFromTransaction(tx LedgerTransaction) ([]protocol.EventInfo, error) {
+	txEvents, err := tx.GetTransactionEvents()
+	if err != nil {
+		return nil, err
+	}
+
+	var events []protocol.EventInfo
+	for opIndex, opEvents := range txEvents.OperationEvents {
+		for eventIndex, event := range opEvents {
+			info, ok, err := e.eventInfo(tx, uint32(opIndex), uint32(eventIndex), event)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				events = append(events, info)
+			}
+		}
+	}
+	for eventIndex, event := range txEvents.TransactionEvents {
+		info, ok, err := e.eventInfo(tx, TransactionEventsOperationIndex, uint32(eventIndex), event.Event)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			events = append(events, info)
+		}
+	}
+	return events, nil
+}
+
+func (e *EventExtractor) eventInfo(
+	tx LedgerTransaction,
+	opIndex, eventIndex uint32,
+	event xdr.ContractEvent,
+) (protocol.EventInfo, bool, error) {
+	successful := tx.Successful()
+	if !e.request.Matches(xdr.DiagnosticEvent{InSuccessfulContractCall: successful, Event: event}) {
+		return protocol.EventInfo{}, false, nil
+	}
+
+	eventType, ok := protocol.GetEventTypeFromEventTypeXDR()[event.Type]
+	if !ok {
+		return protocol.EventInfo{}, false, fmt.Errorf("unknown contract event type %d", event.Type)
+	}
+	if event.Type == xdr.ContractEventTypeDiagnostic {
+		return protocol.EventInfo{}, false, nil
+	}
+
+	ledger := tx.Ledger.LedgerSequence()
+	cursor := protocol.Cursor{Ledger: ledger, Tx: tx.Index, Op: opIndex, Event: eventIndex}
+	info := protocol.EventInfo{
+		EventType:                eventType,
+		Ledger:                   int32(ledger),
+		LedgerClosedAt:           tx.Ledger.ClosedAt().UTC().Format(time.RFC3339),
+		ID:                       cursor.String(),
+		OpIndex:                  opIndex,
+		TxIndex:                  tx.Index,
+		TransactionHash:          hex.EncodeToString(tx.Hash[:]),
+		InSuccessfulContractCall: successful,
+	}
+	if event.ContractId != nil {
+		contractID, err := strkey.Encode(strkey.VersionByteContract, event.ContractId[:])
+		if err != nil {
+			return protocol.EventInfo{}, false, errors.Wrap(err, "error encoding contract id")
+		}
+		info.ContractID = contractID
+	}
+
+	v0, ok := event.Body.GetV0()
+	if !ok {
+		return protocol.EventInfo{}, false, fmt.Errorf("unsupported contract event body version %d", event.Body.V)
+	}
+	info.TopicXDR = make([]string, 0, len(v0.Topics))
+	for _, topic := range v0.Topics {
+		encoded, err := xdr.MarshalBase64(topic)
+		if err != nil {
+			return protocol.EventInfo{}, false, errors.Wrap(err, "error encoding event topic")
+		}
+		info.TopicXDR = append(info.TopicXDR, encoded)
+	}
+	value, err := xdr.MarshalBase64(v0.Data)
+	if err != nil {
+		return protocol.EventInfo{}, false, errors.Wrap(err, "error encoding event value")
+	}
+	info.ValueXDR = value
+
+	return info, true, nil
+}
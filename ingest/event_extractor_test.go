@@ -0,0 +1,313 @@
+package ingest
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/protocols/rpc"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/xdr"
+)
+
+func symbolEvent(contractID xdr.ContractId, eventType xdr.ContractEventType, topics ...string) xdr.ContractEvent {
+	scTopics := make([]xdr.ScVal, 0, len(topics))
+	for _, topic := range topics {
+		sym := xdr.ScSymbol(topic)
+		scTopics = append(scTopics, xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &sym})
+	}
+	return xdr.ContractEvent{
+		Type:       eventType,
+		ContractId: &contractID,
+		Body: xdr.ContractEventBody{
+			V: 0,
+			V0: &xdr.ContractEventV0{
+				Topics: scTopics,
+				Data:   xdr.ScVal{Type: xdr.ScValTypeScvVoid},
+			},
+		},
+	}
+}
+
+func eventsLedger() (xdr.LedgerCloseMeta, []xdr.ContractEvent, [][32]byte) {
+	envs, hashes, metas := makeTransactions(2)
+
+	transfer := symbolEvent(xdr.ContractId{1}, xdr.ContractEventTypeContract, "transfer", "alice")
+	mint := symbolEvent(xdr.ContractId{2}, xdr.ContractEventTypeContract, "mint")
+	fee := symbolEvent(xdr.ContractId{3}, xdr.ContractEventTypeContract, "fee")
+
+	success := xdr.TransactionResult{
+		Result: xdr.TransactionResultResult{
+			Code:    xdr.TransactionResultCodeTxSuccess,
+			Results: &[]xdr.OperationResult{},
+		},
+	}
+	metas[0].Result.Result = success
+	metas[0].TxApplyProcessing = xdr.TransactionMeta{
+		V: 4,
+		V4: &xdr.TransactionMetaV4{
+			Operations: []xdr.OperationMetaV2{
+				{Events: []xdr.ContractEvent{transfer}},
+				{Events: []xdr.ContractEvent{mint}},
+			},
+			Events: []xdr.TransactionEvent{{
+				Stage: xdr.TransactionEventStageTransactionEventStageBeforeAllTxs,
+				Event: fee,
+			}},
+			DiagnosticEvents: []xdr.DiagnosticEvent{{
+				InSuccessfulContractCall: true,
+				Event:                    symbolEvent(xdr.ContractId{4}, xdr.ContractEventTypeDiagnostic, "debug"),
+			}},
+		},
+	}
+	metas[1].Result.Result = success
+
+	lcm := xdr.LedgerCloseMeta{
+		V: 1,
+		V1: &xdr.LedgerCloseMetaV1{
+			LedgerHeader: xdr.LedgerHeaderHistoryEntry{
+				Header: xdr.LedgerHeader{
+					LedgerSeq:     100,
+					LedgerVersion: 23,
+					ScpValue:      xdr.StellarValue{CloseTime: 1700000000},
+				},
+			},
+			TxProcessing: metas,
+			TxSet: xdr.GeneralizedTransactionSet{
+				V: 1,
+				V1TxSet: &xdr.TransactionSetV1{
+					Phases: []xdr.TransactionPhase{{
+						V: 0,
+						V0Components: &[]xdr.TxSetComponent{{
+							TxsMaybeDiscountedFee: &xdr.TxSetComponentTxsMaybeDiscountedFee{Txs: envs},
+						}},
+					}},
+				},
+			},
+		},
+	}
+	return lcm, []xdr.ContractEvent{transfer, mint, fee}, hashes
+}
+
+func TestEventExtractorAllEvents(t *testing.T) {
+	lcm, contractEvents, hashes := eventsLedger()
+
+	extractor, err := NewEventExtractor(passphrase, nil)
+	require.NoError(t, err)
+	events, err := extractor.EventsFromLedger(lcm)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+
+	expectedCursors := []protocol.Cursor{
+		{Ledger: 100, Tx: 1, Op: 0, Event: 0},
+		{Ledger: 100, Tx: 1, Op: 1, Event: 0},
+		{Ledger: 100, Tx: 1, Op: TransactionEventsOperationIndex, Event: 0},
+	}
+	for i, event := range events {
+		assert.Equal(t, expectedCursors[i].String(), event.ID)
+		assert.Equal(t, protocol.EventTypeContract, event.EventType)
+		assert.Equal(t, int32(100), event.Ledger)
+		assert.Equal(t, "2023-11-14T22:13:20Z", event.LedgerClosedAt)
+		assert.Equal(t, uint32(1), event.TxIndex)
+		assert.Equal(t, expectedCursors[i].Op, event.OpIndex)
+		assert.Equal(t, hex.EncodeToString(hashes[0][:]), event.TransactionHash)
+		assert.True(t, event.InSuccessfulContractCall)
+
+		contractID := strkey.MustEncode(strkey.VersionByteContract, contractEvents[i].ContractId[:])
+		assert.Equal(t, contractID, event.ContractID)
+
+		v0 := contractEvents[i].Body.MustV0()
+		require.Len(t, event.TopicXDR, len(v0.Topics))
+		for j, topic := range v0.Topics {
+			var decoded xdr.ScVal
+			require.NoError(t, xdr.SafeUnmarshalBase64(event.TopicXDR[j], &decoded))
+			assert.True(t, topic.Equals(decoded))
+		}
+	}
+
+	// cursors must be strictly increasing
+	for i := 1; i < len(events); i++ {
+		prev, err := protocol.ParseCursor(events[i-1].ID)
+		require.NoError(t, err)
+		cur, err := protocol.ParseCursor(events[i].ID)
+		require.NoError(t, err)
+		assert.Equal(t, -1, prev.Cmp(cur))
+	}
+}
+
+func TestEventExtractorFilters(t *testing.T) {
+	lcm, contractEvents, _ := eventsLedger()
+
+	transferID := strkey.MustEncode(strkey.VersionByteContract, contractEvents[0].ContractId[:])
+	wildcard := protocol.WildCardExactOne
+	transferSym := contractEvents[0].Body.MustV0().Topics[0]
+
+	for _, testCase := range []struct {
+		name     string
+		filters  []protocol.EventFilter
+		expected int
+	}{
+		{
+			name:     "contract id",
+			filters:  []protocol.EventFilter{{ContractIDs: []string{transferID}}},
+			expected: 1,
+		},
+		{
+			name: "topic with wildcard",
+			filters: []protocol.EventFilter{{Topics: []protocol.TopicFilter{{
+				{ScVal: &transferSym},
+				{Wildcard: &wildcard},
+			}}}},
+			expected: 1,
+		},
+		{
+			name:     "system events only",
+			filters:  []protocol.EventFilter{{EventType: protocol.EventTypeSet{protocol.EventTypeSystem: nil}}},
+			expected: 0,
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			extractor, err := NewEventExtractor(passphrase, testCase.filters)
+			require.NoError(t, err)
+			events, err := extractor.EventsFromLedger(lcm)
+			require.NoError(t, err)
+			assert.Len(t, events, testCase.expected)
+		})
+	}
+}
+
+func TestEventExtractorInvalidFilter(t *testing.T) {
+	_, err := NewEventExtractor(passphrase, []protocol.EventFilter{{ContractIDs: []string{"invalid"}}})
+	require.Error(t, err)
+}
+
+// sorobanEnvelope marks the transaction as a Soroban transaction and returns
+// its new hash.
+func sorobanEnvelope(t *testing.T, env *xdr.TransactionEnvelope) xdr.Hash {
+	env.V1.Tx.Ext = xdr.TransactionExt{V: 1, SorobanData: &xdr.SorobanTransactionData{}}
+	hash, err := network.HashTransactionInEnvelope(*env, passphrase)
+	require.NoError(t, err)
+	return hash
+}
+
+// ledgerWithVersion returns a ledger with the given LedgerCloseMeta version
+// holding the transactions.
+func ledgerWithVersion(version int32, envs []xdr.TransactionEnvelope, metas []xdr.TransactionResultMeta) xdr.LedgerCloseMeta {
+	header := xdr.LedgerHeaderHistoryEntry{Header: xdr.LedgerHeader{
+		LedgerSeq:     100,
+		LedgerVersion: 21,
+		ScpValue:      xdr.StellarValue{CloseTime: 1700000000},
+	}}
+	txSet := xdr.GeneralizedTransactionSet{
+		V: 1,
+		V1TxSet: &xdr.TransactionSetV1{
+			Phases: []xdr.TransactionPhase{{
+				V: 0,
+				V0Components: &[]xdr.TxSetComponent{{
+					TxsMaybeDiscountedFee: &xdr.TxSetComponentTxsMaybeDiscountedFee{Txs: envs},
+				}},
+			}},
+		},
+	}
+	switch version {
+	case 0:
+		return xdr.LedgerCloseMeta{V: 0, V0: &xdr.LedgerCloseMetaV0{
+			LedgerHeader: header,
+			TxSet:        xdr.TransactionSet{Txs: envs},
+			TxProcessing: metas,
+		}}
+	case 1:
+		return xdr.LedgerCloseMeta{V: 1, V1: &xdr.LedgerCloseMetaV1{
+			LedgerHeader: header,
+			TxSet:        txSet,
+			TxProcessing: metas,
+		}}
+	default:
+		var metasV1 []xdr.TransactionResultMetaV1
+		for _, meta := range metas {
+			metasV1 = append(metasV1, xdr.TransactionResultMetaV1{
+				Result:            meta.Result,
+				FeeProcessing:     meta.FeeProcessing,
+				TxApplyProcessing: meta.TxApplyProcessing,
+			})
+		}
+		return xdr.LedgerCloseMeta{V: 2, V2: &xdr.LedgerCloseMetaV2{
+			LedgerHeader: header,
+			TxSet:        txSet,
+			TxProcessing: metasV1,
+		}}
+	}
+}
+
+func TestEventExtractorSorobanMeta(t *testing.T) {
+	envs, hashes, metas := makeTransactions(3)
+	success := xdr.TransactionResult{
+		Result: xdr.TransactionResultResult{
+			Code:    xdr.TransactionResultCodeTxSuccess,
+			Results: &[]xdr.OperationResult{},
+		},
+	}
+	transfer := symbolEvent(xdr.ContractId{1}, xdr.ContractEventTypeContract, "transfer", "alice")
+
+	// a Soroban transaction with events and diagnostic events, one of which
+	// duplicates the contract event as core does when diagnostics are enabled
+	hashes[0] = sorobanEnvelope(t, &envs[0])
+	metas[0].Result = xdr.TransactionResultPair{TransactionHash: hashes[0], Result: success}
+	metas[0].TxApplyProcessing = xdr.TransactionMeta{V: 3, V3: &xdr.TransactionMetaV3{
+		Operations: []xdr.OperationMeta{{}},
+		SorobanMeta: &xdr.SorobanTransactionMeta{
+			Events: []xdr.ContractEvent{transfer},
+			DiagnosticEvents: []xdr.DiagnosticEvent{
+				{InSuccessfulContractCall: true, Event: transfer},
+				{InSuccessfulContractCall: true, Event: symbolEvent(xdr.ContractId{4}, xdr.ContractEventTypeDiagnostic, "debug")},
+			},
+		},
+	}}
+	// a Soroban transaction without Soroban meta
+	hashes[1] = sorobanEnvelope(t, &envs[1])
+	metas[1].Result = xdr.TransactionResultPair{TransactionHash: hashes[1], Result: success}
+	// a classic transaction, whose meta never has Soroban meta
+	metas[2].Result.Result = success
+
+	for _, version := range []int32{1, 2} {
+		t.Run(fmt.Sprintf("LedgerCloseMetaV%d", version), func(t *testing.T) {
+			extractor, err := NewEventExtractor(passphrase, nil)
+			require.NoError(t, err)
+			events, err := extractor.EventsFromLedger(ledgerWithVersion(version, envs, metas))
+			require.NoError(t, err)
+			require.Len(t, events, 1)
+
+			event := events[0]
+			assert.Equal(t, protocol.Cursor{Ledger: 100, Tx: 1}.String(), event.ID)
+			assert.Equal(t, protocol.EventTypeContract, event.EventType)
+			assert.Equal(t, hex.EncodeToString(hashes[0][:]), event.TransactionHash)
+			assert.Equal(t, strkey.MustEncode(strkey.VersionByteContract, transfer.ContractId[:]), event.ContractID)
+			assert.True(t, event.InSuccessfulContractCall)
+			require.Len(t, event.TopicXDR, 2)
+			topic, err := xdr.MarshalBase64(transfer.Body.MustV0().Topics[1])
+			require.NoError(t, err)
+			assert.Equal(t, topic, event.TopicXDR[1])
+		})
+	}
+}
+
+func TestEventExtractorClassicMeta(t *testing.T) {
+	envs, _, metas := makeTransactions(3)
+	metas[0].TxApplyProcessing = xdr.TransactionMeta{V: 0, Operations: &[]xdr.OperationMeta{}}
+	metas[1].TxApplyProcessing = xdr.TransactionMeta{V: 1, V1: &xdr.TransactionMetaV1{}}
+	metas[2].TxApplyProcessing = xdr.TransactionMeta{V: 2, V2: &xdr.TransactionMetaV2{}}
+
+	for _, version := range []int32{0, 1, 2} {
+		t.Run(fmt.Sprintf("LedgerCloseMetaV%d", version), func(t *testing.T) {
+			extractor, err := NewEventExtractor(passphrase, nil)
+			require.NoError(t, err)
+			events, err := extractor.EventsFromLedger(ledgerWithVersion(version, envs, metas))
+			require.NoError(t, err)
+			assert.Empty(t, events)
+		})
+	}
+}
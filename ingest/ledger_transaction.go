@@ -278,7 +278,7 @@ func (t *LedgerTransaction) GetDiagnosticEvents() ([]xdr.DiagnosticEvent, error)
 func (t *LedgerTransaction) GetTransactionEvents() (TransactionEvents, error) {
 	txEvents := TransactionEvents{}
 	switch t.UnsafeMeta.V {
-	case 1, 2:
+	case 0, 1, 2:
 		return txEvents, nil
 	case 3:
 		// There wont be any events for classic operations in TxMetaV3
@@ -259,6 +259,89 @@ func ContractBalanceFromContractData(ledgerEntry xdr.LedgerEntry, passphrase str
 	return holder, amt, true
 }
 
+// TokenMetadata is the SEP-41 token metadata kept in contract instance
+// storage under the METADATA key.
+type TokenMetadata struct {
+	Decimal uint32
+	Name    string
+	Symbol  string
+}
+
+// MetadataFromContractData takes a ledger entry and, if it is a contract
+// instance entry holding token metadata, returns that metadata.
+//
+// Both the Stellar Asset Contract and tokens built with the soroban-token-sdk
+// store the metadata in the instance storage in the following form:
+//
+//	ScMapEntry{
+//	  Key: ScVal{ Sym: ScSymbol("METADATA") },
+//	  Val: ScVal{ Map: ScMap(
+//	    { ScVal{ Sym: ScSymbol("decimal") } -> ScVal{ U32: ... } },
+//	    { ScVal{ Sym: ScSymbol("name") } -> ScVal{ Str: ScString(...) } },
+//	    { ScVal{ Sym: ScSymbol("symbol") } -> ScVal{ Str: ScString(...) } }
+//	  )}
+//	}
+//
+// Unlike AssetFromContractData, the metadata of custom tokens cannot be
+// verified and is returned as written by the contract.
+func MetadataFromContractData(ledgerEntry xdr.LedgerEntry) (TokenMetadata, bool) {
+	contractData, ok := ledgerEntry.Data.GetContractData()
+	if !ok {
+		return TokenMetadata{}, false
+	}
+	if contractData.Key.Type != xdr.ScValTypeScvLedgerKeyContractInstance ||
+		contractData.Durability != xdr.ContractDataDurabilityPersistent {
+		return TokenMetadata{}, false
+	}
+	instance, ok := contractData.Val.GetInstance()
+	if !ok || instance.Storage == nil {
+		return TokenMetadata{}, false
+	}
+
+	for _, storageEntry := range *instance.Storage {
+		if sym, ok := storageEntry.Key.GetSym(); !ok || sym != metadataSym {
+			continue
+		}
+		metadataMap, ok := storageEntry.Val.GetMap()
+		if !ok || metadataMap == nil {
+			return TokenMetadata{}, false
+		}
+
+		var (
+			metadata                    TokenMetadata
+			hasDecimal, hasName, hasSym bool
+		)
+		for _, entry := range *metadataMap {
+			key, ok := entry.Key.GetSym()
+			if !ok {
+				continue
+			}
+			switch key {
+			case decimalSym:
+				var decimal xdr.Uint32
+				if decimal, hasDecimal = entry.Val.GetU32(); hasDecimal {
+					metadata.Decimal = uint32(decimal)
+				}
+			case metadataNameSym:
+				var name xdr.ScString
+				if name, hasName = entry.Val.GetStr(); hasName {
+					metadata.Name = string(name)
+				}
+			case metadataSymbolSym:
+				var symbol xdr.ScString
+				if symbol, hasSym = entry.Val.GetStr(); hasSym {
+					metadata.Symbol = string(symbol)
+				}
+			}
+		}
+		if !hasDecimal || !hasName || !hasSym {
+			return TokenMetadata{}, false
+		}
+		return metadata, true
+	}
+	return TokenMetadata{}, false
+}
+
 func metadataObjFromAsset(isNative bool, code, issuer string) (*xdr.ScMap, error) {
 	assetInfoVecKey := &xdr.ScVec{
 		xdr.ScVal{
@@ -0,0 +1,87 @@
+// Package hsm implements a keypair.Signer which signs with an ed25519 key held
+// by a hardware security module, or any token following the PKCS#11 model:
+// keys are objects of the token, found by their label, which sign data with
+// the CKM_EDDSA mechanism without ever leaving the token.
+//
+// Token abstracts the operations of a token used for signing, and can be
+// implemented on top of a PKCS#11 library. SoftToken is a token keeping its
+// keys in memory, to use in development and tests.
+package hsm
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/xdr"
+)
+
+var (
+	// ErrKeyNotFound is returned when a token has no key of a label.
+	ErrKeyNotFound = errors.New("key not found")
+	// ErrNotLoggedIn is returned when a token is used before logging in.
+	ErrNotLoggedIn = errors.New("user not logged in")
+	// ErrPinIncorrect is returned when logging in with an incorrect PIN.
+	ErrPinIncorrect = errors.New("pin incorrect")
+)
+
+// ObjectHandle identifies an object of a token, in a session.
+type ObjectHandle uint
+
+// Token is a session with a token holding ed25519 keys, in which the user is
+// logged in.
+type Token interface {
+	// FindKey returns the private key object of a label, and its public key.
+	FindKey(label string) (ObjectHandle, ed25519.PublicKey, error)
+	// Sign signs data with a private key object, with the CKM_EDDSA
+	// mechanism.
+	Sign(key ObjectHandle, data []byte) ([]byte, error)
+}
+
+// Signer signs with a key of a token.
+type Signer struct {
+	token   Token
+	key     ObjectHandle
+	address string
+	kp      *keypair.FromAddress
+}
+
+// NewSigner returns a Signer signing with the key of a label of the token.
+func NewSigner(token Token, label string) (*Signer, error) {
+	key, publicKey, err := token.FindKey(label)
+	if err != nil {
+		return nil, fmt.Errorf("could not find key %q: %w", label, err)
+	}
+	address, err := strkey.Encode(strkey.VersionByteAccountID, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	kp, err := keypair.ParseAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	return &Signer{token: token, key: key, address: address, kp: kp}, nil
+}
+
+// PublicKey returns the address of the key.
+func (s *Signer) PublicKey() string {
+	return s.address
+}
+
+// SignDecorated signs the payload with the token.
+func (s *Signer) SignDecorated(ctx context.Context, payload []byte) (xdr.DecoratedSignature, error) {
+	if err := ctx.Err(); err != nil {
+		return xdr.DecoratedSignature{}, err
+	}
+	signature, err := s.token.Sign(s.key, payload)
+	if err != nil {
+		return xdr.DecoratedSignature{}, fmt.Errorf("could not sign: %w", err)
+	}
+	return xdr.DecoratedSignature{
+		Hint:      xdr.SignatureHint(s.kp.Hint()),
+		Signature: signature,
+	}, nil
+}
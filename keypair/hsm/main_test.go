@@ -0,0 +1,61 @@
+package hsm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigner(t *testing.T) {
+	token := NewSoftToken("1234")
+	require.NoError(t, token.Login("1234"))
+	kp := keypair.MustRandom()
+	require.NoError(t, token.ImportKey("imported", kp))
+
+	signer, err := NewSigner(token, "imported")
+	require.NoError(t, err)
+	assert.Equal(t, kp.Address(), signer.PublicKey())
+
+	payload := []byte("payload")
+	signature, err := signer.SignDecorated(context.Background(), payload)
+	require.NoError(t, err)
+	expected, err := kp.SignDecorated(payload)
+	require.NoError(t, err)
+	assert.Equal(t, expected, signature)
+
+	address, err := token.GenerateKey("generated")
+	require.NoError(t, err)
+	signer, err = NewSigner(token, "generated")
+	require.NoError(t, err)
+	assert.Equal(t, address, signer.PublicKey())
+	signature, err = signer.SignDecorated(context.Background(), payload)
+	require.NoError(t, err)
+	assert.NoError(t, keypair.VerifyDecorated(signer, payload, signature))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = signer.SignDecorated(ctx, payload)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestSoftTokenSession(t *testing.T) {
+	token := NewSoftToken("1234")
+	assert.ErrorIs(t, token.Login("0000"), ErrPinIncorrect)
+	_, err := token.GenerateKey("key")
+	assert.ErrorIs(t, err, ErrNotLoggedIn)
+
+	require.NoError(t, token.Login("1234"))
+	_, err = token.GenerateKey("key")
+	require.NoError(t, err)
+	signer, err := NewSigner(token, "key")
+	require.NoError(t, err)
+	_, err = NewSigner(token, "missing")
+	assert.EqualError(t, err, `could not find key "missing": key not found`)
+
+	token.Logout()
+	_, err = signer.SignDecorated(context.Background(), []byte("payload"))
+	assert.ErrorIs(t, err, ErrNotLoggedIn)
+}
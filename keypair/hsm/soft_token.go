@@ -0,0 +1,115 @@
+package hsm
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/subtle"
+	"sync"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/strkey"
+)
+
+// SoftToken is a Token keeping its keys in memory. Like a hardware token, it
+// requires the user to log in with its PIN, and never exposes its private
+// keys. It is safe for concurrent use.
+type SoftToken struct {
+	pin string
+
+	lock     sync.Mutex
+	loggedIn bool
+	objects  map[ObjectHandle]softKey
+	next     ObjectHandle
+}
+
+type softKey struct {
+	label      string
+	privateKey ed25519.PrivateKey
+}
+
+// NewSoftToken returns an empty token protected by a PIN.
+func NewSoftToken(pin string) *SoftToken {
+	return &SoftToken{
+		pin:     pin,
+		objects: map[ObjectHandle]softKey{},
+		next:    1,
+	}
+}
+
+// Login logs the user in.
+func (t *SoftToken) Login(pin string) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if subtle.ConstantTimeCompare([]byte(pin), []byte(t.pin)) != 1 {
+		return ErrPinIncorrect
+	}
+	t.loggedIn = true
+	return nil
+}
+
+// Logout logs the user out.
+func (t *SoftToken) Logout() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.loggedIn = false
+}
+
+// GenerateKey generates a key of a label on the token, and returns its
+// address.
+func (t *SoftToken) GenerateKey(label string) (string, error) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", err
+	}
+	return t.add(label, privateKey)
+}
+
+// ImportKey imports a keypair on the token, with a label.
+func (t *SoftToken) ImportKey(label string, kp *keypair.Full) error {
+	rawSeed, err := strkey.Decode(strkey.VersionByteSeed, kp.Seed())
+	if err != nil {
+		return err
+	}
+	_, err = t.add(label, ed25519.NewKeyFromSeed(rawSeed))
+	return err
+}
+
+func (t *SoftToken) add(label string, privateKey ed25519.PrivateKey) (string, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if !t.loggedIn {
+		return "", ErrNotLoggedIn
+	}
+	t.objects[t.next] = softKey{label: label, privateKey: privateKey}
+	t.next++
+	return strkey.Encode(strkey.VersionByteAccountID, privateKey.Public().(ed25519.PublicKey))
+}
+
+// FindKey returns the first key of a label.
+func (t *SoftToken) FindKey(label string) (ObjectHandle, ed25519.PublicKey, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if !t.loggedIn {
+		return 0, nil, ErrNotLoggedIn
+	}
+	for handle := ObjectHandle(1); handle < t.next; handle++ {
+		if key, ok := t.objects[handle]; ok && key.label == label {
+			return handle, key.privateKey.Public().(ed25519.PublicKey), nil
+		}
+	}
+	return 0, nil, ErrKeyNotFound
+}
+
+// Sign signs data with a key.
+func (t *SoftToken) Sign(handle ObjectHandle, data []byte) ([]byte, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if !t.loggedIn {
+		return nil, ErrNotLoggedIn
+	}
+	key, ok := t.objects[handle]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return ed25519.Sign(key.privateKey, data), nil
+}
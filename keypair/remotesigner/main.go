@@ -0,0 +1,146 @@
+// Package remotesigner implements a keypair.Signer which signs with a key held
+// by a remote signing service, over HTTP.
+//
+// The service signs a payload when it receives a POST request with the JSON
+// body
+//
+//	{"public_key": "G...", "payload": "<base64>"}
+//
+// and responds with the JSON body
+//
+//	{"signature": "<base64>"}
+//
+// NewHandler serves this protocol with local signers, as a stand-in for a
+// signing service in development and tests.
+package remotesigner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/xdr"
+)
+
+// maxResponseSize is the maximum size of a response of a signing service.
+const maxResponseSize = 64 * 1024
+
+// Request is the body of a signing request.
+type Request struct {
+	PublicKey string `json:"public_key"`
+	Payload   []byte `json:"payload"`
+}
+
+// Response is the body of the response to a signing request.
+type Response struct {
+	Signature []byte `json:"signature"`
+}
+
+// Signer signs with the key of Address, held by the signing service at URL.
+// The signatures returned by the service are verified.
+type Signer struct {
+	URL     string
+	Address string
+	// HTTP is the client of the requests, http.DefaultClient if nil.
+	HTTP *http.Client
+	// Header is added to the requests, e.g. to authenticate them.
+	Header http.Header
+}
+
+// PublicKey returns the address of the key.
+func (s *Signer) PublicKey() string {
+	return s.Address
+}
+
+// SignDecorated requests the signing service to sign the payload.
+func (s *Signer) SignDecorated(ctx context.Context, payload []byte) (xdr.DecoratedSignature, error) {
+	kp, err := keypair.ParseAddress(s.Address)
+	if err != nil {
+		return xdr.DecoratedSignature{}, err
+	}
+	body, err := json.Marshal(Request{PublicKey: s.Address, Payload: payload})
+	if err != nil {
+		return xdr.DecoratedSignature{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return xdr.DecoratedSignature{}, err
+	}
+	for key, values := range s.Header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := s.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return xdr.DecoratedSignature{}, fmt.Errorf("could not request signature: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return xdr.DecoratedSignature{}, fmt.Errorf("could not read signature: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return xdr.DecoratedSignature{}, fmt.Errorf("signing service responded with status %d: %s", resp.StatusCode, bytes.TrimSpace(data))
+	}
+	var response Response
+	if err = json.Unmarshal(data, &response); err != nil {
+		return xdr.DecoratedSignature{}, fmt.Errorf("could not decode signature: %w", err)
+	}
+
+	if err = kp.Verify(payload, response.Signature); err != nil {
+		return xdr.DecoratedSignature{}, err
+	}
+	return xdr.DecoratedSignature{
+		Hint:      xdr.SignatureHint(kp.Hint()),
+		Signature: response.Signature,
+	}, nil
+}
+
+type handler struct {
+	signers map[string]keypair.Signer
+}
+
+// NewHandler returns a handler serving signing requests with the signers.
+func NewHandler(signers ...keypair.Signer) http.Handler {
+	h := handler{signers: map[string]keypair.Signer{}}
+	for _, signer := range signers {
+		h.signers[signer.PublicKey()] = signer
+	}
+	return h
+}
+
+func (h handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var request Request
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	signer, ok := h.signers[request.PublicKey]
+	if !ok {
+		http.Error(w, "unknown key", http.StatusNotFound)
+		return
+	}
+	signature, err := signer.SignDecorated(r.Context(), request.Payload)
+	if errors.Is(err, context.Canceled) {
+		return
+	} else if err != nil {
+		http.Error(w, "could not sign", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Response{Signature: signature.Signature})
+}
@@ -0,0 +1,68 @@
+package remotesigner
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigner(t *testing.T) {
+	kp := keypair.MustRandom()
+	handler := NewHandler(kp.Signer())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	signer := &Signer{
+		URL:     server.URL,
+		Address: kp.Address(),
+		Header:  http.Header{"Authorization": {"Bearer token"}},
+	}
+	assert.Equal(t, kp.Address(), signer.PublicKey())
+	payload := []byte("payload")
+	signature, err := signer.SignDecorated(context.Background(), payload)
+	require.NoError(t, err)
+	expected, err := kp.SignDecorated(payload)
+	require.NoError(t, err)
+	assert.Equal(t, expected, signature)
+	assert.NoError(t, keypair.VerifyDecorated(signer, payload, signature))
+
+	signer.Header = nil
+	_, err = signer.SignDecorated(context.Background(), payload)
+	assert.EqualError(t, err, "signing service responded with status 401: unauthorized")
+}
+
+func TestSignerUnknownKey(t *testing.T) {
+	server := httptest.NewServer(NewHandler(keypair.MustRandom().Signer()))
+	defer server.Close()
+
+	signer := &Signer{URL: server.URL, Address: keypair.MustRandom().Address()}
+	_, err := signer.SignDecorated(context.Background(), []byte("payload"))
+	assert.EqualError(t, err, "signing service responded with status 404: unknown key")
+}
+
+func TestSignerVerifiesSignature(t *testing.T) {
+	// the service signs with another key than the one requested
+	kp, other := keypair.MustRandom(), keypair.MustRandom()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature, err := other.Sign([]byte("payload"))
+		require.NoError(t, err)
+		json.NewEncoder(w).Encode(Response{Signature: signature})
+	}))
+	defer server.Close()
+
+	signer := &Signer{URL: server.URL, Address: kp.Address()}
+	_, err := signer.SignDecorated(context.Background(), []byte("payload"))
+	assert.ErrorIs(t, err, keypair.ErrInvalidSignature)
+}
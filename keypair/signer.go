@@ -0,0 +1,53 @@
+package keypair
+
+import (
+	"context"
+
+	"github.com/stellar/go/xdr"
+)
+
+// Signer signs payloads with an ed25519 key which may not be held in the
+// memory of the process, e.g. a key held by a hardware security module or a
+// remote signing service.
+type Signer interface {
+	// PublicKey returns the address (G...) of the key.
+	PublicKey() string
+	// SignDecorated signs a payload, e.g. the hash of a transaction, and
+	// returns the signature decorated with the hint of the key.
+	SignDecorated(ctx context.Context, payload []byte) (xdr.DecoratedSignature, error)
+}
+
+// fullSigner is the Signer of a Full keypair.
+type fullSigner struct {
+	kp *Full
+}
+
+// Signer returns the keypair as a Signer.
+func (kp *Full) Signer() Signer {
+	return fullSigner{kp: kp}
+}
+
+func (s fullSigner) PublicKey() string {
+	return s.kp.Address()
+}
+
+func (s fullSigner) SignDecorated(ctx context.Context, payload []byte) (xdr.DecoratedSignature, error) {
+	if err := ctx.Err(); err != nil {
+		return xdr.DecoratedSignature{}, err
+	}
+	return s.kp.SignDecorated(payload)
+}
+
+// VerifyDecorated verifies a signature returned by a Signer, which is
+// useful when the key is not held by the process: it checks that the
+// signature was made by the key of the signer, for the payload.
+func VerifyDecorated(signer Signer, payload []byte, signature xdr.DecoratedSignature) error {
+	kp, err := ParseAddress(signer.PublicKey())
+	if err != nil {
+		return err
+	}
+	if signature.Hint != xdr.SignatureHint(kp.Hint()) {
+		return ErrInvalidSignature
+	}
+	return kp.Verify(payload, signature.Signature)
+}
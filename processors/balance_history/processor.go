@@ -0,0 +1,301 @@
+// Package balance_history folds token transfer events into per holder and
+// asset balance snapshots, e.g. to build account statements.
+package balance_history
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/big"
+	"time"
+
+	assetProto "github.com/stellar/go/asset"
+	"github.com/stellar/go/ingest"
+	"github.com/stellar/go/ingest/sac"
+	"github.com/stellar/go/processors/token_transfer"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/xdr"
+)
+
+// Snapshot is the balance of a holder of an asset right after an event
+// changed it.
+type Snapshot struct {
+	// Holder is the address of the holder: an account, contract, claimable
+	// balance or liquidity pool strkey.
+	Holder string
+	// SubAccount identifies the muxed sub-account of Holder the snapshot is
+	// for, see SubAccountKey. It is empty for the balance of Holder itself.
+	SubAccount string
+	// ContractAddress is the token contract, which for classic assets is the
+	// Stellar Asset Contract of the asset.
+	ContractAddress string
+	// Asset is the classic asset, nil for custom tokens.
+	Asset *assetProto.Asset
+
+	Balance *big.Int
+	Delta   *big.Int
+
+	EventType      string
+	LedgerSequence uint32
+	ClosedAt       time.Time
+	TxHash         string
+	// OperationIndex is 1-indexed as per SEP-35 and nil for events which are
+	// not caused by an operation, such as fees.
+	OperationIndex *uint32
+}
+
+type balanceKey struct {
+	holder          string
+	subAccount      string
+	contractAddress string
+}
+
+// Processor keeps the balances of holders up to date from token transfer
+// events and returns a snapshot for every balance change.
+type Processor struct {
+	networkPassphrase string
+	events            *token_transfer.EventsProcessor
+	balances          map[balanceKey]*big.Int
+	holders           map[string]bool
+	subAccounts       bool
+}
+
+type Option func(*Processor)
+
+// WithHolders restricts the processor to the given holders. By default every
+// holder is tracked.
+func WithHolders(holders ...string) Option {
+	return func(p *Processor) {
+		p.holders = map[string]bool{}
+		for _, holder := range holders {
+			p.holders[holder] = true
+		}
+	}
+}
+
+// WithSubAccounts additionally tracks the funds received by muxed
+// sub-accounts, as identified by the destination MuxedInfo of events.
+// Sub-account balances only include credits since events do not identify
+// muxed sources.
+func WithSubAccounts() Option {
+	return func(p *Processor) {
+		p.subAccounts = true
+	}
+}
+
+// WithEventsProcessor sets the processor used to extract token transfer
+// events from ledgers. It defaults to token_transfer.NewEventsProcessor.
+func WithEventsProcessor(events *token_transfer.EventsProcessor) Option {
+	return func(p *Processor) {
+		p.events = events
+	}
+}
+
+func NewProcessor(networkPassphrase string, options ...Option) *Processor {
+	p := &Processor{
+		networkPassphrase: networkPassphrase,
+		events:            token_transfer.NewEventsProcessor(networkPassphrase),
+		balances:          map[balanceKey]*big.Int{},
+	}
+	for _, opt := range options {
+		opt(p)
+	}
+	return p
+}
+
+// SubAccountKey returns the key identifying the muxed sub-account of holder
+// described by info: the M-address for muxed ids and "text:<memo>" or
+// "hash:<hex>" for memo based sub-accounts.
+func SubAccountKey(holder string, info *token_transfer.MuxedInfo) string {
+	switch info.GetContent().(type) {
+	case *token_transfer.MuxedInfo_Id:
+		if muxed, err := xdr.MuxedAccountFromAccountId(holder, info.GetId()); err == nil {
+			return muxed.Address()
+		}
+		return fmt.Sprintf("id:%d", info.GetId())
+	case *token_transfer.MuxedInfo_Text:
+		return "text:" + info.GetText()
+	case *token_transfer.MuxedInfo_Hash:
+		return "hash:" + hex.EncodeToString(info.GetHash())
+	default:
+		return ""
+	}
+}
+
+// Seed initializes balances from a ledger state snapshot, typically a
+// CheckpointChangeReader. Accounts, trustlines, claimable balances,
+// liquidity pools and Stellar Asset Contract balances of contracts are
+// supported. Balances set by Seed do not produce snapshots.
+func (p *Processor) Seed(reader ingest.ChangeReader) error {
+	for {
+		change, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error reading change: %w", err)
+		}
+		if change.Post == nil {
+			continue
+		}
+		if err = p.seedEntry(*change.Post); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *Processor) seedEntry(entry xdr.LedgerEntry) error {
+	switch entry.Data.Type {
+	case xdr.LedgerEntryTypeAccount:
+		account := entry.Data.MustAccount()
+		return p.seedClassic(account.AccountId.Address(), xdr.MustNewNativeAsset(), account.Balance)
+	case xdr.LedgerEntryTypeTrustline:
+		trustline := entry.Data.MustTrustLine()
+		if trustline.Asset.Type == xdr.AssetTypeAssetTypePoolShare {
+			return nil
+		}
+		return p.seedClassic(trustline.AccountId.Address(), trustline.Asset.ToAsset(), trustline.Balance)
+	case xdr.LedgerEntryTypeClaimableBalance:
+		cb := entry.Data.MustClaimableBalance()
+		holder, err := cb.BalanceId.EncodeToStrkey()
+		if err != nil {
+			return fmt.Errorf("error encoding claimable balance id: %w", err)
+		}
+		return p.seedClassic(holder, cb.Asset, cb.Amount)
+	case xdr.LedgerEntryTypeLiquidityPool:
+		lp := entry.Data.MustLiquidityPool()
+		cp := lp.Body.ConstantProduct
+		if cp == nil {
+			return nil
+		}
+		holder := strkey.MustEncode(strkey.VersionByteLiquidityPool, lp.LiquidityPoolId[:])
+		if err := p.seedClassic(holder, cp.Params.AssetA, cp.ReserveA); err != nil {
+			return err
+		}
+		return p.seedClassic(holder, cp.Params.AssetB, cp.ReserveB)
+	case xdr.LedgerEntryTypeContractData:
+		holderID, balance, ok := sac.ContractBalanceFromContractData(entry, p.networkPassphrase)
+		if !ok {
+			return nil
+		}
+		contractID := entry.Data.MustContractData().Contract.ContractId
+		if contractID == nil {
+			return nil
+		}
+		p.seed(
+			strkey.MustEncode(strkey.VersionByteContract, holderID[:]),
+			strkey.MustEncode(strkey.VersionByteContract, contractID[:]),
+			balance,
+		)
+	}
+	return nil
+}
+
+func (p *Processor) seedClassic(holder string, asset xdr.Asset, amount xdr.Int64) error {
+	contractID, err := asset.ContractID(p.networkPassphrase)
+	if err != nil {
+		return fmt.Errorf("error computing contract id of %s: %w", asset.StringCanonical(), err)
+	}
+	p.seed(holder, strkey.MustEncode(strkey.VersionByteContract, contractID[:]), big.NewInt(int64(amount)))
+	return nil
+}
+
+func (p *Processor) seed(holder, contractAddress string, amount *big.Int) {
+	if !p.tracked(holder) {
+		return
+	}
+	p.balances[balanceKey{holder: holder, contractAddress: contractAddress}] = new(big.Int).Set(amount)
+}
+
+// ProcessLedger extracts the token transfer events of a ledger and returns
+// the resulting snapshots, in event order.
+func (p *Processor) ProcessLedger(lcm xdr.LedgerCloseMeta) ([]Snapshot, error) {
+	events, err := p.events.EventsFromLedger(lcm)
+	if err != nil {
+		return nil, fmt.Errorf("error extracting events from ledger %d: %w", lcm.LedgerSequence(), err)
+	}
+	var snapshots []Snapshot
+	for _, event := range events {
+		eventSnapshots, err := p.ProcessEvent(event)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, eventSnapshots...)
+	}
+	return snapshots, nil
+}
+
+// ProcessEvent applies a token transfer event and returns a snapshot for
+// every tracked balance it changed: the debited holder first, then the
+// credited holder and finally its muxed sub-account.
+func (p *Processor) ProcessEvent(event *token_transfer.TokenTransferEvent) ([]Snapshot, error) {
+	from, to, value, err := event.GetMovement()
+	if err != nil {
+		return nil, err
+	}
+	amount, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid amount %q in %s event, txHash: %v", value, event.GetEventType(), event.GetMeta().GetTxHash())
+	}
+
+	var snapshots []Snapshot
+	if from != "" && p.tracked(from) {
+		snapshots = append(snapshots, p.apply(event, balanceKey{holder: from}, new(big.Int).Neg(amount)))
+	}
+	if to != "" && p.tracked(to) {
+		snapshots = append(snapshots, p.apply(event, balanceKey{holder: to}, amount))
+		if muxed := event.GetMeta().GetToMuxedInfo(); p.subAccounts && muxed != nil {
+			if subAccount := SubAccountKey(to, muxed); subAccount != "" {
+				snapshots = append(snapshots, p.apply(event, balanceKey{holder: to, subAccount: subAccount}, amount))
+			}
+		}
+	}
+	return snapshots, nil
+}
+
+// Balance returns the current balance of holder for the token with the given
+// contract address. Use SubAccountKey to query a muxed sub-account, or an
+// empty subAccount for the holder itself.
+func (p *Processor) Balance(holder, subAccount, contractAddress string) *big.Int {
+	balance, ok := p.balances[balanceKey{holder: holder, subAccount: subAccount, contractAddress: contractAddress}]
+	if !ok {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(balance)
+}
+
+func (p *Processor) tracked(holder string) bool {
+	return p.holders == nil || p.holders[holder]
+}
+
+func (p *Processor) apply(event *token_transfer.TokenTransferEvent, key balanceKey, delta *big.Int) Snapshot {
+	meta := event.GetMeta()
+	key.contractAddress = meta.GetContractAddress()
+
+	balance, ok := p.balances[key]
+	if !ok {
+		balance = new(big.Int)
+		p.balances[key] = balance
+	}
+	balance.Add(balance, delta)
+
+	snapshot := Snapshot{
+		Holder:          key.holder,
+		SubAccount:      key.subAccount,
+		ContractAddress: key.contractAddress,
+		Asset:           event.GetAsset(),
+		Balance:         new(big.Int).Set(balance),
+		Delta:           new(big.Int).Set(delta),
+		EventType:       event.GetEventType(),
+		LedgerSequence:  meta.GetLedgerSequence(),
+		TxHash:          meta.GetTxHash(),
+	}
+	if meta.GetClosedAt() != nil {
+		snapshot.ClosedAt = meta.GetClosedAt().AsTime()
+	}
+	if meta.OperationIndex != nil {
+		opIndex := meta.GetOperationIndex()
+		snapshot.OperationIndex = &opIndex
+	}
+	return snapshot
+}
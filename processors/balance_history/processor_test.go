@@ -0,0 +1,149 @@
+package balance_history
+
+import (
+	"io"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	assetProto "github.com/stellar/go/asset"
+	"github.com/stellar/go/ingest"
+	"github.com/stellar/go/ingest/sac"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/processors/token_transfer"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/xdr"
+)
+
+var (
+	passphrase = network.TestNetworkPassphrase
+	alice      = keypair.MustRandom().Address()
+	bob        = keypair.MustRandom().Address()
+	issuer     = keypair.MustRandom().Address()
+	usdc       = xdr.MustNewCreditAsset("USDC", issuer)
+)
+
+func contractAddress(t *testing.T, asset xdr.Asset) string {
+	contractID, err := asset.ContractID(passphrase)
+	require.NoError(t, err)
+	return strkey.MustEncode(strkey.VersionByteContract, contractID[:])
+}
+
+func eventMeta(t *testing.T, asset xdr.Asset, opIndex *uint32) *token_transfer.EventMeta {
+	return &token_transfer.EventMeta{
+		LedgerSequence:   100,
+		ClosedAt:         timestamppb.New(time.Unix(1700000000, 0)),
+		TxHash:           "abcd",
+		TransactionIndex: 1,
+		OperationIndex:   opIndex,
+		ContractAddress:  contractAddress(t, asset),
+	}
+}
+
+func changeReader(entries ...xdr.LedgerEntryData) ingest.ChangeReader {
+	reader := &ingest.MockChangeReader{}
+	for i := range entries {
+		reader.On("Read").Return(ingest.Change{Type: entries[i].Type, Post: &xdr.LedgerEntry{Data: entries[i]}}, nil).Once()
+	}
+	reader.On("Read").Return(ingest.Change{}, io.EOF)
+	return reader
+}
+
+func TestProcessorSeedAndEvents(t *testing.T) {
+	p := NewProcessor(passphrase)
+
+	usdcContractID, err := usdc.ContractID(passphrase)
+	require.NoError(t, err)
+	holder := [32]byte{9}
+	require.NoError(t, p.Seed(changeReader(
+		xdr.LedgerEntryData{Type: xdr.LedgerEntryTypeAccount, Account: &xdr.AccountEntry{
+			AccountId: xdr.MustAddress(alice),
+			Balance:   1000,
+		}},
+		xdr.LedgerEntryData{Type: xdr.LedgerEntryTypeTrustline, TrustLine: &xdr.TrustLineEntry{
+			AccountId: xdr.MustAddress(alice),
+			Asset:     usdc.ToTrustLineAsset(),
+			Balance:   500,
+		}},
+		sac.BalanceToContractData(usdcContractID, holder, 42),
+	)))
+
+	xlm := xdr.MustNewNativeAsset()
+	assert.Equal(t, big.NewInt(1000), p.Balance(alice, "", contractAddress(t, xlm)))
+	assert.Equal(t, big.NewInt(500), p.Balance(alice, "", contractAddress(t, usdc)))
+	contractHolder := strkey.MustEncode(strkey.VersionByteContract, holder[:])
+	assert.Equal(t, big.NewInt(42), p.Balance(contractHolder, "", contractAddress(t, usdc)))
+
+	opIndex := uint32(1)
+	usdcProto := assetProto.NewProtoAsset(usdc)
+	snapshots, err := p.ProcessEvent(token_transfer.NewTransferEvent(eventMeta(t, usdc, &opIndex), alice, bob, "200", usdcProto))
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	assert.Equal(t, Snapshot{
+		Holder:          alice,
+		ContractAddress: contractAddress(t, usdc),
+		Asset:           usdcProto,
+		Balance:         big.NewInt(300),
+		Delta:           big.NewInt(-200),
+		EventType:       token_transfer.TransferEvent,
+		LedgerSequence:  100,
+		ClosedAt:        time.Unix(1700000000, 0).UTC(),
+		TxHash:          "abcd",
+		OperationIndex:  &opIndex,
+	}, snapshots[0])
+	assert.Equal(t, bob, snapshots[1].Holder)
+	assert.Equal(t, big.NewInt(200), snapshots[1].Balance)
+
+	// fee and fee refund
+	xlmProto := assetProto.NewNativeAsset()
+	snapshots, err = p.ProcessEvent(token_transfer.NewFeeEvent(eventMeta(t, xlm, nil), alice, "100", xlmProto))
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+	assert.Nil(t, snapshots[0].OperationIndex)
+	assert.Equal(t, big.NewInt(900), snapshots[0].Balance)
+	snapshots, err = p.ProcessEvent(token_transfer.NewFeeEvent(eventMeta(t, xlm, nil), alice, "-30", xlmProto))
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(930), snapshots[0].Balance)
+	assert.Equal(t, big.NewInt(30), snapshots[0].Delta)
+
+	_, err = p.ProcessEvent(token_transfer.NewBurnEvent(eventMeta(t, usdc, &opIndex), alice, "1.5", usdcProto))
+	assert.ErrorContains(t, err, "invalid amount")
+}
+
+func TestProcessorSubAccounts(t *testing.T) {
+	p := NewProcessor(passphrase, WithSubAccounts(), WithHolders(bob))
+
+	usdcProto := assetProto.NewProtoAsset(usdc)
+	for _, muxed := range []*token_transfer.MuxedInfo{
+		token_transfer.NewMuxedInfoFromId(7),
+		token_transfer.NewMuxedInfoFromId(7),
+		token_transfer.NewMuxedInfoFromText("invoice"),
+	} {
+		meta := eventMeta(t, usdc, nil)
+		meta.ToMuxedInfo = muxed
+		snapshots, err := p.ProcessEvent(token_transfer.NewMintEvent(meta, bob, "10", usdcProto))
+		require.NoError(t, err)
+		require.Len(t, snapshots, 2)
+		assert.Equal(t, "", snapshots[0].SubAccount)
+		assert.Equal(t, SubAccountKey(bob, muxed), snapshots[1].SubAccount)
+	}
+
+	muxedBob, err := xdr.MuxedAccountFromAccountId(bob, 7)
+	require.NoError(t, err)
+	usdcAddress := contractAddress(t, usdc)
+	assert.Equal(t, big.NewInt(30), p.Balance(bob, "", usdcAddress))
+	assert.Equal(t, big.NewInt(20), p.Balance(bob, muxedBob.Address(), usdcAddress))
+	assert.Equal(t, big.NewInt(10), p.Balance(bob, "text:invoice", usdcAddress))
+
+	// alice is not tracked
+	snapshots, err := p.ProcessEvent(token_transfer.NewTransferEvent(eventMeta(t, usdc, nil), alice, bob, "5", usdcProto))
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+	assert.Equal(t, bob, snapshots[0].Holder)
+	assert.Equal(t, big.NewInt(0), p.Balance(alice, "", usdcAddress))
+}
@@ -0,0 +1,986 @@
+package effects
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/ingest"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/protocols/horizon/base"
+	"github.com/stellar/go/protocols/horizon/effects"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/support/contractevents"
+	"github.com/stellar/go/xdr"
+)
+
+var errLiquidityPoolChangeNotFound = errors.New("liquidity pool change not found")
+
+func (o *operationEffects) createAccount() {
+	op := o.op.Body.MustCreateAccountOp()
+	o.add(effects.AccountCreated{
+		Base:            o.accountBase(op.Destination, effects.EffectAccountCreated),
+		StartingBalance: amount.String(op.StartingBalance),
+	})
+	o.add(effects.AccountDebited{
+		Base:   o.base(o.source(), effects.EffectAccountDebited),
+		Asset:  base.Asset{Type: "native"},
+		Amount: amount.String(op.StartingBalance),
+	})
+	destination := op.Destination.Address()
+	o.add(effects.SignerCreated{
+		Base:      o.accountBase(op.Destination, effects.EffectSignerCreated),
+		Weight:    keypair.DefaultSignerWeight,
+		PublicKey: destination,
+		Key:       destination,
+	})
+}
+
+func (o *operationEffects) payment() {
+	op := o.op.Body.MustPaymentOp()
+	o.add(effects.AccountCredited{
+		Base:   o.base(op.Destination, effects.EffectAccountCredited),
+		Asset:  assetDetails(op.Asset),
+		Amount: amount.String(op.Amount),
+	})
+	o.add(effects.AccountDebited{
+		Base:   o.base(o.source(), effects.EffectAccountDebited),
+		Asset:  assetDetails(op.Asset),
+		Amount: amount.String(op.Amount),
+	})
+}
+
+func (o *operationEffects) pathPaymentStrictReceive() error {
+	op := o.op.Body.MustPathPaymentStrictReceiveOp()
+	result := o.result.Tr.MustPathPaymentStrictReceiveResult()
+	o.add(effects.AccountCredited{
+		Base:   o.base(op.Destination, effects.EffectAccountCredited),
+		Asset:  assetDetails(op.DestAsset),
+		Amount: amount.String(op.DestAmount),
+	})
+	o.add(effects.AccountDebited{
+		Base:   o.base(o.source(), effects.EffectAccountDebited),
+		Asset:  assetDetails(op.SendAsset),
+		Amount: amount.String(result.SendAmount()),
+	})
+	return o.addTrades(o.source(), result.MustSuccess().Offers)
+}
+
+func (o *operationEffects) pathPaymentStrictSend() error {
+	op := o.op.Body.MustPathPaymentStrictSendOp()
+	result := o.result.Tr.MustPathPaymentStrictSendResult()
+	o.add(effects.AccountCredited{
+		Base:   o.base(op.Destination, effects.EffectAccountCredited),
+		Asset:  assetDetails(op.DestAsset),
+		Amount: amount.String(result.DestAmount()),
+	})
+	o.add(effects.AccountDebited{
+		Base:   o.base(o.source(), effects.EffectAccountDebited),
+		Asset:  assetDetails(op.SendAsset),
+		Amount: amount.String(op.SendAmount),
+	})
+	return o.addTrades(o.source(), result.MustSuccess().Offers)
+}
+
+// addTrades adds a pair of trade effects, one for the buyer and one for the
+// seller, for every offer crossed and a liquidity_pool_trade effect for every
+// liquidity pool the buyer traded with.
+func (o *operationEffects) addTrades(buyer xdr.MuxedAccount, claims []xdr.ClaimAtom) error {
+	for _, claim := range claims {
+		if claim.AmountSold() == 0 && claim.AmountBought() == 0 {
+			continue
+		}
+		if claim.Type == xdr.ClaimAtomTypeClaimAtomTypeLiquidityPool {
+			if err := o.addLiquidityPoolTrade(claim.MustLiquidityPool()); err != nil {
+				return err
+			}
+			continue
+		}
+
+		seller := claim.SellerId()
+		bought, sold := assetDetails(claim.AssetSold()), assetDetails(claim.AssetBought())
+		o.add(effects.Trade{
+			Base:              o.base(buyer, effects.EffectTrade),
+			Seller:            seller.Address(),
+			OfferID:           int64(claim.OfferId()),
+			SoldAmount:        amount.String(claim.AmountBought()),
+			SoldAssetType:     sold.Type,
+			SoldAssetCode:     sold.Code,
+			SoldAssetIssuer:   sold.Issuer,
+			BoughtAmount:      amount.String(claim.AmountSold()),
+			BoughtAssetType:   bought.Type,
+			BoughtAssetCode:   bought.Code,
+			BoughtAssetIssuer: bought.Issuer,
+		})
+
+		sellerTrade := effects.Trade{
+			Base:              o.accountBase(seller, effects.EffectTrade),
+			Seller:            buyer.ToAccountId().Address(),
+			OfferID:           int64(claim.OfferId()),
+			SoldAmount:        amount.String(claim.AmountSold()),
+			SoldAssetType:     bought.Type,
+			SoldAssetCode:     bought.Code,
+			SoldAssetIssuer:   bought.Issuer,
+			BoughtAmount:      amount.String(claim.AmountBought()),
+			BoughtAssetType:   sold.Type,
+			BoughtAssetCode:   sold.Code,
+			BoughtAssetIssuer: sold.Issuer,
+		}
+		if buyer.Type == xdr.CryptoKeyTypeKeyTypeMuxedEd25519 {
+			sellerTrade.SellerMuxed = buyer.Address()
+			sellerTrade.SellerMuxedID = uint64(buyer.Med25519.Id)
+		}
+		o.add(sellerTrade)
+	}
+	return nil
+}
+
+func (o *operationEffects) addLiquidityPoolTrade(claim xdr.ClaimLiquidityAtom) error {
+	lp, _, err := o.liquidityPoolAndDelta(&claim.LiquidityPoolId)
+	if err != nil {
+		return err
+	}
+	o.add(effects.LiquidityPoolTrade{
+		Base:          o.base(o.source(), effects.EffectLiquidityPoolTrade),
+		LiquidityPool: liquidityPoolDetails(lp),
+		Sold:          base.AssetAmount{Asset: claim.AssetSold.StringCanonical(), Amount: amount.String(claim.AmountSold)},
+		Bought:        base.AssetAmount{Asset: claim.AssetBought.StringCanonical(), Amount: amount.String(claim.AmountBought)},
+	})
+	return nil
+}
+
+func (o *operationEffects) setOptions() {
+	source := o.source()
+	op := o.op.Body.MustSetOptionsOp()
+
+	if op.HomeDomain != nil {
+		o.add(effects.AccountHomeDomainUpdated{
+			Base:       o.base(source, effects.EffectAccountHomeDomainUpdated),
+			HomeDomain: string(*op.HomeDomain),
+		})
+	}
+
+	if op.LowThreshold != nil || op.MedThreshold != nil || op.HighThreshold != nil {
+		thresholds := effects.AccountThresholdsUpdated{Base: o.base(source, effects.EffectAccountThresholdsUpdated)}
+		if op.LowThreshold != nil {
+			thresholds.LowThreshold = int32(*op.LowThreshold)
+		}
+		if op.MedThreshold != nil {
+			thresholds.MedThreshold = int32(*op.MedThreshold)
+		}
+		if op.HighThreshold != nil {
+			thresholds.HighThreshold = int32(*op.HighThreshold)
+		}
+		o.add(thresholds)
+	}
+
+	// Horizon only exposes the auth_required and auth_revocable flags but an
+	// effect is generated when any flag is set or cleared.
+	var flags effects.AccountFlagsUpdated
+	flagsUpdated := false
+	updateFlags := func(accountFlags xdr.AccountFlags, value bool) {
+		if accountFlags.IsAuthRequired() {
+			flags.AuthRequired = &value
+		}
+		if accountFlags.IsAuthRevocable() {
+			flags.AuthRevokable = &value
+		}
+		if accountFlags.IsAuthRequired() || accountFlags.IsAuthRevocable() ||
+			accountFlags.IsAuthImmutable() || accountFlags.IsAuthClawbackEnabled() {
+			flagsUpdated = true
+		}
+	}
+	if op.SetFlags != nil {
+		updateFlags(xdr.AccountFlags(*op.SetFlags), true)
+	}
+	if op.ClearFlags != nil {
+		updateFlags(xdr.AccountFlags(*op.ClearFlags), false)
+	}
+	if flagsUpdated {
+		flags.Base = o.base(source, effects.EffectAccountFlagsUpdated)
+		o.add(flags)
+	}
+
+	if op.InflationDest != nil {
+		// there is no dedicated type for this effect
+		o.add(o.base(source, effects.EffectAccountInflationDestinationUpdated))
+	}
+
+	for _, change := range o.changes {
+		if change.Type != xdr.LedgerEntryTypeAccount || change.Pre == nil || change.Post == nil {
+			continue
+		}
+		before := change.Pre.Data.Account.SignerSummary()
+		after := change.Post.Data.Account.SignerSummary()
+		if reflect.DeepEqual(before, after) {
+			continue
+		}
+
+		for _, signer := range sortedKeys(before) {
+			weight, ok := after[signer]
+			if !ok {
+				o.add(effects.SignerRemoved{
+					Base:      o.base(source, effects.EffectSignerRemoved),
+					PublicKey: signer,
+					Key:       signer,
+				})
+				continue
+			}
+			if weight != before[signer] {
+				o.add(effects.SignerUpdated{
+					Base:      o.base(source, effects.EffectSignerUpdated),
+					Weight:    weight,
+					PublicKey: signer,
+					Key:       signer,
+				})
+			}
+		}
+		for _, signer := range sortedKeys(after) {
+			if _, ok := before[signer]; ok {
+				continue
+			}
+			o.add(effects.SignerCreated{
+				Base:      o.base(source, effects.EffectSignerCreated),
+				Weight:    after[signer],
+				PublicKey: signer,
+				Key:       signer,
+			})
+		}
+	}
+}
+
+func (o *operationEffects) changeTrust() {
+	op := o.op.Body.MustChangeTrustOp()
+	for _, change := range o.changes {
+		if change.Type != xdr.LedgerEntryTypeTrustline {
+			continue
+		}
+
+		var effectType effects.EffectType
+		entry := change.Post
+		switch {
+		case change.Pre == nil && change.Post != nil:
+			effectType = effects.EffectTrustlineCreated
+		case change.Pre != nil && change.Post == nil:
+			effectType = effects.EffectTrustlineRemoved
+			entry = change.Pre
+		default:
+			effectType = effects.EffectTrustlineUpdated
+		}
+
+		var line base.LiquidityPoolOrAsset
+		if op.Line.Type == xdr.AssetTypeAssetTypePoolShare {
+			line.Type = "liquidity_pool_shares"
+			line.LiquidityPoolID = poolIDString(*entry.Data.MustTrustLine().Asset.LiquidityPoolId)
+		} else {
+			line.Asset = assetDetails(op.Line.ToAsset())
+		}
+		b := o.base(o.source(), effectType)
+		limit := amount.String(op.Limit)
+		switch effectType {
+		case effects.EffectTrustlineCreated:
+			o.add(effects.TrustlineCreated{Base: b, LiquidityPoolOrAsset: line, Limit: limit})
+		case effects.EffectTrustlineRemoved:
+			o.add(effects.TrustlineRemoved{Base: b, LiquidityPoolOrAsset: line, Limit: limit})
+		default:
+			o.add(effects.TrustlineUpdated{Base: b, LiquidityPoolOrAsset: line, Limit: limit})
+		}
+		return
+	}
+}
+
+func (o *operationEffects) allowTrust() error {
+	source := o.source()
+	op := o.op.Body.MustAllowTrustOp()
+	asset := op.Asset.ToAsset(source.ToAccountId())
+	details := assetDetails(asset)
+	trustor := op.Trustor.Address()
+
+	// The deprecated authorization effects are followed by the equivalent
+	// trustline_flags_updated effect.
+	switch flags := xdr.TrustLineFlags(op.Authorize); {
+	case flags.IsAuthorized():
+		o.add(effects.TrustlineAuthorized{
+			Base:      o.base(source, effects.EffectTrustlineAuthorized),
+			Trustor:   trustor,
+			AssetType: details.Type,
+			AssetCode: details.Code,
+		})
+		setFlags := xdr.Uint32(xdr.TrustLineFlagsAuthorizedFlag)
+		o.addTrustLineFlags(source, op.Trustor, asset, &setFlags, nil)
+	case flags.IsAuthorizedToMaintainLiabilitiesFlag():
+		o.add(effects.TrustlineAuthorizedToMaintainLiabilities{
+			Base:      o.base(source, effects.EffectTrustlineAuthorizedToMaintainLiabilities),
+			Trustor:   trustor,
+			AssetType: details.Type,
+			AssetCode: details.Code,
+		})
+		setFlags := xdr.Uint32(xdr.TrustLineFlagsAuthorizedToMaintainLiabilitiesFlag)
+		o.addTrustLineFlags(source, op.Trustor, asset, &setFlags, nil)
+	default:
+		o.add(effects.TrustlineDeauthorized{
+			Base:      o.base(source, effects.EffectTrustlineDeauthorized),
+			Trustor:   trustor,
+			AssetType: details.Type,
+			AssetCode: details.Code,
+		})
+		clearFlags := xdr.Uint32(xdr.TrustLineFlagsAuthorizedFlag | xdr.TrustLineFlagsAuthorizedToMaintainLiabilitiesFlag)
+		o.addTrustLineFlags(source, op.Trustor, asset, nil, &clearFlags)
+	}
+	return o.addLiquidityPoolRevoked()
+}
+
+func (o *operationEffects) setTrustLineFlags() error {
+	op := o.op.Body.MustSetTrustLineFlagsOp()
+	o.addTrustLineFlags(o.source(), op.Trustor, op.Asset, &op.SetFlags, &op.ClearFlags)
+	return o.addLiquidityPoolRevoked()
+}
+
+func (o *operationEffects) addTrustLineFlags(source xdr.MuxedAccount, trustor xdr.AccountId, asset xdr.Asset, setFlags, clearFlags *xdr.Uint32) {
+	if setFlags == nil && clearFlags == nil {
+		return
+	}
+	effect := effects.TrustlineFlagsUpdated{
+		Base:    o.base(source, effects.EffectTrustlineFlagsUpdated),
+		Asset:   assetDetails(asset),
+		Trustor: trustor.Address(),
+	}
+	update := func(flags xdr.TrustLineFlags, value bool) {
+		if flags.IsAuthorized() {
+			effect.Authorized = &value
+		}
+		if flags.IsAuthorizedToMaintainLiabilitiesFlag() {
+			effect.AuthorizedToMaintainLiabilities = &value
+		}
+		if flags.IsClawbackEnabledFlag() {
+			effect.ClawbackEnabled = &value
+		}
+	}
+	if setFlags != nil {
+		update(xdr.TrustLineFlags(*setFlags), true)
+	}
+	if clearFlags != nil {
+		update(xdr.TrustLineFlags(*clearFlags), false)
+	}
+	o.add(effect)
+}
+
+// addLiquidityPoolRevoked adds the effects of revoking the authorization of
+// a trustor which deposited into liquidity pools: its pool shares are redeemed
+// into claimable balances.
+func (o *operationEffects) addLiquidityPoolRevoked() error {
+	lp, delta, err := o.liquidityPoolAndDelta(nil)
+	if err == errLiquidityPoolChangeNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	source := o.source()
+	balanceIDs := map[string]string{}
+	for _, change := range o.changes {
+		if change.Type != xdr.LedgerEntryTypeClaimableBalance || change.Pre != nil || change.Post == nil {
+			continue
+		}
+		cb := change.Post.Data.MustClaimableBalance()
+		id, err := balanceIDString(cb.BalanceId)
+		if err != nil {
+			return err
+		}
+		balanceIDs[cb.Asset.StringCanonical()] = id
+		if err := o.addClaimableBalanceCreated(source, cb); err != nil {
+			return err
+		}
+	}
+	// without claimable balances no shares were revoked
+	if len(balanceIDs) == 0 {
+		return nil
+	}
+
+	cp := lp.Body.MustConstantProduct()
+	var reservesRevoked []effects.LiquidityPoolClaimableAssetAmount
+	for _, reserve := range []base.AssetAmount{
+		{Asset: cp.Params.AssetA.StringCanonical(), Amount: amount.String(-delta.ReserveA)},
+		{Asset: cp.Params.AssetB.StringCanonical(), Amount: amount.String(-delta.ReserveB)},
+	} {
+		if id, ok := balanceIDs[reserve.Asset]; ok {
+			reservesRevoked = append(reservesRevoked, effects.LiquidityPoolClaimableAssetAmount{
+				Asset:              reserve.Asset,
+				Amount:             reserve.Amount,
+				ClaimableBalanceID: id,
+			})
+		}
+	}
+	o.add(effects.LiquidityPoolRevoked{
+		Base:            o.base(source, effects.EffectLiquidityPoolRevoked),
+		LiquidityPool:   liquidityPoolDetails(lp),
+		ReservesRevoked: reservesRevoked,
+		SharesRevoked:   amount.String(-delta.TotalPoolShares),
+	})
+	return nil
+}
+
+func (o *operationEffects) accountMerge() {
+	source := o.source()
+	balance := amount.String(*o.result.Tr.MustAccountMergeResult().SourceAccountBalance)
+	o.add(effects.AccountDebited{
+		Base:   o.base(source, effects.EffectAccountDebited),
+		Asset:  base.Asset{Type: "native"},
+		Amount: balance,
+	})
+	o.add(effects.AccountCredited{
+		Base:   o.base(o.op.Body.MustDestination(), effects.EffectAccountCredited),
+		Asset:  base.Asset{Type: "native"},
+		Amount: balance,
+	})
+	o.add(o.base(source, effects.EffectAccountRemoved))
+}
+
+func (o *operationEffects) inflation() {
+	for _, payout := range o.result.Tr.MustInflationResult().MustPayouts() {
+		o.add(effects.AccountCredited{
+			Base:   o.accountBase(payout.Destination, effects.EffectAccountCredited),
+			Asset:  base.Asset{Type: "native"},
+			Amount: amount.String(payout.Amount),
+		})
+	}
+}
+
+func (o *operationEffects) manageData() {
+	op := o.op.Body.MustManageDataOp()
+	name := string(op.DataName)
+	for _, change := range o.changes {
+		if change.Type != xdr.LedgerEntryTypeData {
+			continue
+		}
+		switch {
+		case change.Pre == nil && change.Post != nil:
+			o.add(effects.DataCreated{
+				Base:  o.base(o.source(), effects.EffectDataCreated),
+				Name:  name,
+				Value: base64.StdEncoding.EncodeToString(change.Post.Data.MustData().DataValue),
+			})
+		case change.Pre != nil && change.Post == nil:
+			o.add(effects.DataRemoved{
+				Base: o.base(o.source(), effects.EffectDataRemoved),
+				Name: name,
+			})
+		default:
+			o.add(effects.DataUpdated{
+				Base:  o.base(o.source(), effects.EffectDataUpdated),
+				Name:  name,
+				Value: base64.StdEncoding.EncodeToString(change.Post.Data.MustData().DataValue),
+			})
+		}
+		return
+	}
+}
+
+func (o *operationEffects) bumpSequence() {
+	for _, change := range o.changes {
+		// the sponsorship of the account might have changed too
+		if change.Type != xdr.LedgerEntryTypeAccount || change.Pre == nil || change.Post == nil {
+			continue
+		}
+		before, after := change.Pre.Data.MustAccount(), change.Post.Data.MustAccount()
+		if before.SeqNum != after.SeqNum {
+			o.add(effects.SequenceBumped{
+				Base:   o.base(o.source(), effects.EffectSequenceBumped),
+				NewSeq: int64(after.SeqNum),
+			})
+		}
+		return
+	}
+}
+
+func (o *operationEffects) createClaimableBalance() error {
+	source := o.source()
+	for _, change := range o.changes {
+		if change.Type != xdr.LedgerEntryTypeClaimableBalance || change.Post == nil {
+			continue
+		}
+		cb := change.Post.Data.MustClaimableBalance()
+		if err := o.addClaimableBalanceCreated(source, cb); err != nil {
+			return err
+		}
+		o.add(effects.AccountDebited{
+			Base:   o.base(source, effects.EffectAccountDebited),
+			Asset:  assetDetails(cb.Asset),
+			Amount: amount.String(cb.Amount),
+		})
+		return nil
+	}
+	return errors.New("claimable balance entry not found")
+}
+
+// addClaimableBalanceCreated adds the effects of creating a claimable
+// balance, either by create_claimable_balance or by revoking pool shares.
+func (o *operationEffects) addClaimableBalanceCreated(source xdr.MuxedAccount, cb xdr.ClaimableBalanceEntry) error {
+	id, err := balanceIDString(cb.BalanceId)
+	if err != nil {
+		return err
+	}
+	o.add(effects.ClaimableBalanceCreated{
+		Base:      o.base(source, effects.EffectClaimableBalanceCreated),
+		Asset:     cb.Asset.StringCanonical(),
+		BalanceID: id,
+		Amount:    amount.String(cb.Amount),
+	})
+	for _, claimant := range cb.Claimants {
+		v0 := claimant.MustV0()
+		o.add(effects.ClaimableBalanceClaimantCreated{
+			Base:      o.accountBase(v0.Destination, effects.EffectClaimableBalanceClaimantCreated),
+			Asset:     cb.Asset.StringCanonical(),
+			BalanceID: id,
+			Amount:    amount.String(cb.Amount),
+			Predicate: v0.Predicate,
+		})
+	}
+	return nil
+}
+
+// removedClaimableBalance returns the claimable balance with the given id
+// removed by the operation.
+func (o *operationEffects) removedClaimableBalance(id string) (xdr.ClaimableBalanceEntry, error) {
+	for _, change := range o.changes {
+		if change.Type != xdr.LedgerEntryTypeClaimableBalance || change.Pre == nil || change.Post != nil {
+			continue
+		}
+		cb := change.Pre.Data.MustClaimableBalance()
+		preID, err := balanceIDString(cb.BalanceId)
+		if err != nil {
+			return xdr.ClaimableBalanceEntry{}, err
+		}
+		if preID == id {
+			return cb, nil
+		}
+	}
+	return xdr.ClaimableBalanceEntry{}, fmt.Errorf("claimable balance %s not found", id)
+}
+
+func (o *operationEffects) claimClaimableBalance() error {
+	source := o.source()
+	id, err := balanceIDString(o.op.Body.MustClaimClaimableBalanceOp().BalanceId)
+	if err != nil {
+		return err
+	}
+	cb, err := o.removedClaimableBalance(id)
+	if err != nil {
+		return err
+	}
+	o.add(effects.ClaimableBalanceClaimed{
+		Base:      o.base(source, effects.EffectClaimableBalanceClaimed),
+		Asset:     cb.Asset.StringCanonical(),
+		BalanceID: id,
+		Amount:    amount.String(cb.Amount),
+	})
+	o.add(effects.AccountCredited{
+		Base:   o.base(source, effects.EffectAccountCredited),
+		Asset:  assetDetails(cb.Asset),
+		Amount: amount.String(cb.Amount),
+	})
+	return nil
+}
+
+func (o *operationEffects) clawback() {
+	op := o.op.Body.MustClawbackOp()
+	// the funds are burned but the issuer is credited nonetheless
+	o.add(effects.AccountCredited{
+		Base:   o.base(o.source(), effects.EffectAccountCredited),
+		Asset:  assetDetails(op.Asset),
+		Amount: amount.String(op.Amount),
+	})
+	o.add(effects.AccountDebited{
+		Base:   o.base(op.From, effects.EffectAccountDebited),
+		Asset:  assetDetails(op.Asset),
+		Amount: amount.String(op.Amount),
+	})
+}
+
+func (o *operationEffects) clawbackClaimableBalance() error {
+	source := o.source()
+	id, err := balanceIDString(o.op.Body.MustClawbackClaimableBalanceOp().BalanceId)
+	if err != nil {
+		return err
+	}
+	o.add(effects.ClaimableBalanceClawedBack{
+		Base:      o.base(source, effects.EffectClaimableBalanceClawedBack),
+		BalanceID: id,
+	})
+	cb, err := o.removedClaimableBalance(id)
+	if err != nil {
+		return err
+	}
+	o.add(effects.AccountCredited{
+		Base:   o.base(source, effects.EffectAccountCredited),
+		Asset:  assetDetails(cb.Asset),
+		Amount: amount.String(cb.Amount),
+	})
+	return nil
+}
+
+func (o *operationEffects) liquidityPoolDeposit() error {
+	op := o.op.Body.MustLiquidityPoolDepositOp()
+	lp, delta, err := o.liquidityPoolAndDelta(&op.LiquidityPoolId)
+	if err != nil {
+		return err
+	}
+	cp := lp.Body.MustConstantProduct()
+	o.add(effects.LiquidityPoolDeposited{
+		Base:          o.base(o.source(), effects.EffectLiquidityPoolDeposited),
+		LiquidityPool: liquidityPoolDetails(lp),
+		ReservesDeposited: []base.AssetAmount{
+			{Asset: cp.Params.AssetA.StringCanonical(), Amount: amount.String(delta.ReserveA)},
+			{Asset: cp.Params.AssetB.StringCanonical(), Amount: amount.String(delta.ReserveB)},
+		},
+		SharesReceived: amount.String(delta.TotalPoolShares),
+	})
+	return nil
+}
+
+func (o *operationEffects) liquidityPoolWithdraw() error {
+	op := o.op.Body.MustLiquidityPoolWithdrawOp()
+	lp, delta, err := o.liquidityPoolAndDelta(&op.LiquidityPoolId)
+	if err != nil {
+		return err
+	}
+	cp := lp.Body.MustConstantProduct()
+	o.add(effects.LiquidityPoolWithdrew{
+		Base:          o.base(o.source(), effects.EffectLiquidityPoolWithdrew),
+		LiquidityPool: liquidityPoolDetails(lp),
+		ReservesReceived: []base.AssetAmount{
+			{Asset: cp.Params.AssetA.StringCanonical(), Amount: amount.String(-delta.ReserveA)},
+			{Asset: cp.Params.AssetB.StringCanonical(), Amount: amount.String(-delta.ReserveB)},
+		},
+		SharesRedeemed: amount.String(-delta.TotalPoolShares),
+	})
+	return nil
+}
+
+// invokeHostFunction adds the balance changes reported by Stellar Asset
+// Contract events. Other contract events have no effects.
+func (o *operationEffects) invokeHostFunction() error {
+	events, err := o.tx.GetContractEventsForOperation(o.index)
+	if err != nil {
+		return err
+	}
+	source := o.source()
+	for i := range events {
+		evt, err := contractevents.NewStellarAssetContractEvent(&events[i], o.processor.networkPassphrase)
+		if err != nil {
+			// not a Stellar Asset Contract event or an unsupported one
+			continue
+		}
+		asset := assetDetails(evt.GetAsset())
+		switch evt.GetType() {
+		case contractevents.EventTypeTransfer:
+			transfer := evt.(*contractevents.TransferEvent)
+			o.addContractDebited(source, transfer.From, asset, transfer.Amount)
+			o.addContractCredited(source, transfer.To, asset, transfer.Amount)
+		case contractevents.EventTypeMint:
+			mint := evt.(*contractevents.MintEvent)
+			o.addContractCredited(source, mint.To, asset, mint.Amount)
+		case contractevents.EventTypeClawback:
+			clawback := evt.(*contractevents.ClawbackEvent)
+			o.addContractDebited(source, clawback.From, asset, clawback.Amount)
+		case contractevents.EventTypeBurn:
+			burn := evt.(*contractevents.BurnEvent)
+			o.addContractDebited(source, burn.From, asset, burn.Amount)
+		}
+	}
+	return nil
+}
+
+// addContractCredited adds an account_credited effect when address is an
+// account and otherwise a contract_credited effect belonging to the source of
+// the operation.
+func (o *operationEffects) addContractCredited(source xdr.MuxedAccount, address string, asset base.Asset, value xdr.Int128Parts) {
+	if strkey.IsValidEd25519PublicKey(address) {
+		o.add(effects.AccountCredited{
+			Base:   o.accountBase(xdr.MustAddress(address), effects.EffectAccountCredited),
+			Asset:  asset,
+			Amount: amount.String128(value),
+		})
+		return
+	}
+	o.add(effects.ContractCredited{
+		Base:     o.base(source, effects.EffectContractCredited),
+		Asset:    asset,
+		Contract: address,
+		Amount:   amount.String128(value),
+	})
+}
+
+// addContractDebited is the debit counterpart of addContractCredited.
+func (o *operationEffects) addContractDebited(source xdr.MuxedAccount, address string, asset base.Asset, value xdr.Int128Parts) {
+	if strkey.IsValidEd25519PublicKey(address) {
+		o.add(effects.AccountDebited{
+			Base:   o.accountBase(xdr.MustAddress(address), effects.EffectAccountDebited),
+			Asset:  asset,
+			Amount: amount.String128(value),
+		})
+		return
+	}
+	o.add(effects.ContractDebited{
+		Base:     o.base(source, effects.EffectContractDebited),
+		Asset:    asset,
+		Contract: address,
+		Amount:   amount.String128(value),
+	})
+}
+
+// addLedgerEntrySponsorship adds the sponsorship effect of an account,
+// trustline, data or claimable balance change, if its sponsor changed.
+func (o *operationEffects) addLedgerEntrySponsorship(change ingest.Change) {
+	switch change.Type {
+	case xdr.LedgerEntryTypeAccount, xdr.LedgerEntryTypeTrustline,
+		xdr.LedgerEntryTypeData, xdr.LedgerEntryTypeClaimableBalance:
+	default:
+		return
+	}
+
+	var former, sponsor *xdr.AccountId
+	if change.Pre != nil {
+		former = change.Pre.SponsoringID()
+	}
+	if change.Post != nil {
+		sponsor = change.Post.SponsoringID()
+	}
+	if former == nil && sponsor == nil {
+		return
+	}
+	if former != nil && sponsor != nil && former.Equals(*sponsor) {
+		return
+	}
+
+	data := change.Pre
+	if change.Post != nil {
+		data = change.Post
+	}
+
+	var b effects.Base
+	sponsorshipBase := func(created, updated, removed effects.EffectType, account xdr.MuxedAccount) effects.Base {
+		switch {
+		case former == nil:
+			return o.base(account, created)
+		case sponsor == nil:
+			return o.base(account, removed)
+		default:
+			return o.base(account, updated)
+		}
+	}
+
+	switch change.Type {
+	case xdr.LedgerEntryTypeAccount:
+		account := data.Data.MustAccount().AccountId
+		b = sponsorshipBase(effects.EffectAccountSponsorshipCreated, effects.EffectAccountSponsorshipUpdated,
+			effects.EffectAccountSponsorshipRemoved, account.ToMuxedAccount())
+		switch {
+		case former == nil:
+			o.add(effects.AccountSponsorshipCreated{Base: b, Sponsor: sponsor.Address()})
+		case sponsor == nil:
+			o.add(effects.AccountSponsorshipRemoved{Base: b, FormerSponsor: former.Address()})
+		default:
+			o.add(effects.AccountSponsorshipUpdated{Base: b, FormerSponsor: former.Address(), NewSponsor: sponsor.Address()})
+		}
+	case xdr.LedgerEntryTypeTrustline:
+		trustline := data.Data.MustTrustLine()
+		var assetType, asset, poolID string
+		if trustline.Asset.Type == xdr.AssetTypeAssetTypePoolShare {
+			assetType = "liquidity_pool"
+			poolID = poolIDString(*trustline.Asset.LiquidityPoolId)
+		} else {
+			asset = trustline.Asset.ToAsset().StringCanonical()
+		}
+		b = sponsorshipBase(effects.EffectTrustlineSponsorshipCreated, effects.EffectTrustlineSponsorshipUpdated,
+			effects.EffectTrustlineSponsorshipRemoved, trustline.AccountId.ToMuxedAccount())
+		switch {
+		case former == nil:
+			o.add(effects.TrustlineSponsorshipCreated{Base: b, Type: assetType, Asset: asset, LiquidityPoolID: poolID,
+				Sponsor: sponsor.Address()})
+		case sponsor == nil:
+			o.add(effects.TrustlineSponsorshipRemoved{Base: b, Type: assetType, Asset: asset, LiquidityPoolID: poolID,
+				FormerSponsor: former.Address()})
+		default:
+			o.add(effects.TrustlineSponsorshipUpdated{Base: b, Type: assetType, Asset: asset, LiquidityPoolID: poolID,
+				FormerSponsor: former.Address(), NewSponsor: sponsor.Address()})
+		}
+	case xdr.LedgerEntryTypeData:
+		name := string(data.Data.MustData().DataName)
+		b = sponsorshipBase(effects.EffectDataSponsorshipCreated, effects.EffectDataSponsorshipUpdated,
+			effects.EffectDataSponsorshipRemoved, o.source())
+		switch {
+		case former == nil:
+			o.add(effects.DataSponsorshipCreated{Base: b, DataName: name, Sponsor: sponsor.Address()})
+		case sponsor == nil:
+			o.add(effects.DataSponsorshipRemoved{Base: b, DataName: name, FormerSponsor: former.Address()})
+		default:
+			o.add(effects.DataSponsorshipUpdated{Base: b, DataName: name, FormerSponsor: former.Address(), NewSponsor: sponsor.Address()})
+		}
+	case xdr.LedgerEntryTypeClaimableBalance:
+		// the id of a claimable balance is always marshalable
+		id, _ := balanceIDString(data.Data.MustClaimableBalance().BalanceId)
+		b = sponsorshipBase(effects.EffectClaimableBalanceSponsorshipCreated, effects.EffectClaimableBalanceSponsorshipUpdated,
+			effects.EffectClaimableBalanceSponsorshipRemoved, o.source())
+		switch {
+		case former == nil:
+			o.add(effects.ClaimableBalanceSponsorshipCreated{Base: b, BalanceID: id, Sponsor: sponsor.Address()})
+		case sponsor == nil:
+			o.add(effects.ClaimableBalanceSponsorshipRemoved{Base: b, BalanceID: id, FormerSponsor: former.Address()})
+		default:
+			o.add(effects.ClaimableBalanceSponsorshipUpdated{Base: b, BalanceID: id, FormerSponsor: former.Address(), NewSponsor: sponsor.Address()})
+		}
+	}
+}
+
+// addSignerSponsorships adds the effects of changes to the sponsors of the
+// signers of an account.
+func (o *operationEffects) addSignerSponsorships(change ingest.Change) {
+	if change.Type != xdr.LedgerEntryTypeAccount {
+		return
+	}
+
+	before, after := map[string]xdr.AccountId{}, map[string]xdr.AccountId{}
+	var account xdr.AccountId
+	if change.Pre != nil {
+		entry := change.Pre.Data.MustAccount()
+		before = entry.SponsorPerSigner()
+		account = entry.AccountId
+	}
+	if change.Post != nil {
+		entry := change.Post.Data.MustAccount()
+		after = entry.SponsorPerSigner()
+		account = entry.AccountId
+	}
+
+	signers := map[string]bool{}
+	for signer := range before {
+		signers[signer] = true
+	}
+	for signer := range after {
+		signers[signer] = true
+	}
+
+	for _, signer := range sortedKeys(signers) {
+		former, hadSponsor := before[signer]
+		sponsor, hasSponsor := after[signer]
+		switch {
+		case !hadSponsor:
+			o.add(effects.SignerSponsorshipCreated{
+				Base:    o.accountBase(account, effects.EffectSignerSponsorshipCreated),
+				Signer:  signer,
+				Sponsor: sponsor.Address(),
+			})
+		case !hasSponsor:
+			o.add(effects.SignerSponsorshipRemoved{
+				Base:          o.accountBase(account, effects.EffectSignerSponsorshipRemoved),
+				Signer:        signer,
+				FormerSponsor: former.Address(),
+			})
+		case !former.Equals(sponsor):
+			o.add(effects.SignerSponsorshipUpdated{
+				Base:          o.accountBase(account, effects.EffectSignerSponsorshipUpdated),
+				Signer:        signer,
+				FormerSponsor: former.Address(),
+				NewSponsor:    sponsor.Address(),
+			})
+		}
+	}
+}
+
+// addLiquidityPoolEntry adds the effects of creating or removing a liquidity
+// pool, which happens when the first trustline to it is created or the last
+// one removed.
+func (o *operationEffects) addLiquidityPoolEntry(change ingest.Change) {
+	if change.Type != xdr.LedgerEntryTypeLiquidityPool {
+		return
+	}
+	switch {
+	case change.Pre == nil && change.Post != nil:
+		lp := change.Post.Data.MustLiquidityPool()
+		o.add(effects.LiquidityPoolCreated{
+			Base:          o.base(o.source(), effects.EffectLiquidityPoolCreated),
+			LiquidityPool: liquidityPoolDetails(&lp),
+		})
+	case change.Pre != nil && change.Post == nil:
+		o.add(effects.LiquidityPoolRemoved{
+			Base:            o.base(o.source(), effects.EffectLiquidityPoolRemoved),
+			LiquidityPoolID: poolIDString(change.Pre.Data.MustLiquidityPool().LiquidityPoolId),
+		})
+	}
+}
+
+type liquidityPoolDelta struct {
+	ReserveA        xdr.Int64
+	ReserveB        xdr.Int64
+	TotalPoolShares xdr.Int64
+}
+
+// liquidityPoolAndDelta returns the state of a liquidity pool after the
+// operation and how its reserves and shares changed. When poolID is nil the
+// first liquidity pool changed by the operation is used.
+func (o *operationEffects) liquidityPoolAndDelta(poolID *xdr.PoolId) (*xdr.LiquidityPoolEntry, liquidityPoolDelta, error) {
+	for _, change := range o.changes {
+		if change.Type != xdr.LedgerEntryTypeLiquidityPool {
+			continue
+		}
+		var pre, post *xdr.LiquidityPoolEntry
+		if change.Pre != nil {
+			pre = change.Pre.Data.LiquidityPool
+		}
+		if change.Post != nil {
+			post = change.Post.Data.LiquidityPool
+		}
+		lp := post
+		if lp == nil {
+			lp = pre
+		}
+		if poolID != nil && lp.LiquidityPoolId != *poolID {
+			continue
+		}
+
+		var delta liquidityPoolDelta
+		if post != nil {
+			cp := post.Body.MustConstantProduct()
+			delta.ReserveA += cp.ReserveA
+			delta.ReserveB += cp.ReserveB
+			delta.TotalPoolShares += cp.TotalPoolShares
+		}
+		if pre != nil {
+			cp := pre.Body.MustConstantProduct()
+			delta.ReserveA -= cp.ReserveA
+			delta.ReserveB -= cp.ReserveB
+			delta.TotalPoolShares -= cp.TotalPoolShares
+		}
+		return lp, delta, nil
+	}
+	return nil, liquidityPoolDelta{}, errLiquidityPoolChangeNotFound
+}
+
+func liquidityPoolDetails(lp *xdr.LiquidityPoolEntry) effects.LiquidityPool {
+	cp := lp.Body.MustConstantProduct()
+	return effects.LiquidityPool{
+		ID:              poolIDString(lp.LiquidityPoolId),
+		FeeBP:           uint32(cp.Params.Fee),
+		Type:            "constant_product",
+		TotalTrustlines: uint64(cp.PoolSharesTrustLineCount),
+		TotalShares:     amount.String(cp.TotalPoolShares),
+		Reserves: []base.AssetAmount{
+			{Asset: cp.Params.AssetA.StringCanonical(), Amount: amount.String(cp.ReserveA)},
+			{Asset: cp.Params.AssetB.StringCanonical(), Amount: amount.String(cp.ReserveB)},
+		},
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
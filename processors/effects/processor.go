@@ -0,0 +1,260 @@
+// Package effects computes the effects of operations, as served by Horizon's
+// /effects endpoints, directly from ledger data.
+package effects
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/stellar/go/ingest"
+	"github.com/stellar/go/protocols/horizon/base"
+	"github.com/stellar/go/protocols/horizon/effects"
+	"github.com/stellar/go/support/render/hal"
+	"github.com/stellar/go/toid"
+	"github.com/stellar/go/xdr"
+)
+
+// Processor generates Horizon compatible effects from transactions.
+type Processor struct {
+	networkPassphrase string
+	links             hal.LinkBuilder
+}
+
+type Option func(*Processor)
+
+// WithBaseURL sets the Horizon URL against which the links of effects are
+// resolved. By default links are relative, e.g. "/operations/123".
+func WithBaseURL(baseURL *url.URL) Option {
+	return func(p *Processor) {
+		p.links.Base = baseURL
+	}
+}
+
+func NewProcessor(networkPassphrase string, options ...Option) *Processor {
+	p := &Processor{networkPassphrase: networkPassphrase}
+	for _, opt := range options {
+		opt(p)
+	}
+	return p
+}
+
+// EffectsFromLedger returns the effects of all the transactions of a ledger,
+// in the order Horizon assigns to them.
+func (p *Processor) EffectsFromLedger(lcm xdr.LedgerCloseMeta) ([]effects.Effect, error) {
+	reader, err := ingest.NewLedgerTransactionReaderFromLedgerCloseMeta(p.networkPassphrase, lcm)
+	if err != nil {
+		return nil, fmt.Errorf("error creating transaction reader: %w", err)
+	}
+	defer reader.Close()
+
+	var result []effects.Effect
+	for {
+		tx, err := reader.Read()
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading transaction: %w", err)
+		}
+		txEffects, err := p.EffectsFromTransaction(tx)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, txEffects...)
+	}
+}
+
+// EffectsFromTransaction returns the effects of every operation of a
+// transaction. Failed transactions have no effects.
+func (p *Processor) EffectsFromTransaction(tx ingest.LedgerTransaction) ([]effects.Effect, error) {
+	if !tx.Successful() {
+		return nil, nil
+	}
+	var result []effects.Effect
+	for i := range tx.Envelope.Operations() {
+		opEffects, err := p.EffectsFromOperation(tx, uint32(i))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, opEffects...)
+	}
+	return result, nil
+}
+
+// EffectsFromOperation returns the effects of the operation at the given
+// 0-indexed position of a transaction.
+func (p *Processor) EffectsFromOperation(tx ingest.LedgerTransaction, opIndex uint32) ([]effects.Effect, error) {
+	if !tx.Successful() {
+		return nil, nil
+	}
+	op, ok := tx.GetOperation(opIndex)
+	if !ok {
+		return nil, fmt.Errorf("operation %d not found in transaction %s", opIndex, tx.Hash.HexString())
+	}
+	results, ok := tx.Result.OperationResults()
+	if !ok || int(opIndex) >= len(results) {
+		return nil, fmt.Errorf("operation result %d not found in transaction %s", opIndex, tx.Hash.HexString())
+	}
+	changes, err := tx.GetOperationChanges(opIndex)
+	if err != nil {
+		return nil, fmt.Errorf("error reading changes of operation %d in transaction %s: %w", opIndex, tx.Hash.HexString(), err)
+	}
+
+	o := &operationEffects{
+		processor: p,
+		tx:        &tx,
+		index:     opIndex,
+		op:        op,
+		result:    results[opIndex],
+		changes:   changes,
+		id:        toid.New(int32(tx.Ledger.LedgerSequence()), int32(tx.Index), int32(opIndex+1)).ToInt64(),
+		closedAt:  tx.Ledger.ClosedAt(),
+	}
+	if err := o.generate(); err != nil {
+		return nil, fmt.Errorf("error generating effects of operation %d (%s) in transaction %s: %w",
+			opIndex, op.Body.Type, tx.Hash.HexString(), err)
+	}
+	return o.effects, nil
+}
+
+// operationEffects accumulates the effects of a single operation.
+type operationEffects struct {
+	processor *Processor
+	tx        *ingest.LedgerTransaction
+	index     uint32
+	op        xdr.Operation
+	result    xdr.OperationResult
+	changes   []ingest.Change
+	id        int64
+	closedAt  time.Time
+
+	order   int
+	effects []effects.Effect
+}
+
+func (o *operationEffects) generate() error {
+	var err error
+	switch o.op.Body.Type {
+	case xdr.OperationTypeCreateAccount:
+		o.createAccount()
+	case xdr.OperationTypePayment:
+		o.payment()
+	case xdr.OperationTypePathPaymentStrictReceive:
+		err = o.pathPaymentStrictReceive()
+	case xdr.OperationTypePathPaymentStrictSend:
+		err = o.pathPaymentStrictSend()
+	case xdr.OperationTypeManageSellOffer:
+		err = o.addTrades(o.source(), o.result.Tr.MustManageSellOfferResult().MustSuccess().OffersClaimed)
+	case xdr.OperationTypeManageBuyOffer:
+		err = o.addTrades(o.source(), o.result.Tr.MustManageBuyOfferResult().MustSuccess().OffersClaimed)
+	case xdr.OperationTypeCreatePassiveSellOffer:
+		err = o.addTrades(o.source(), o.result.Tr.MustCreatePassiveSellOfferResult().MustSuccess().OffersClaimed)
+	case xdr.OperationTypeSetOptions:
+		o.setOptions()
+	case xdr.OperationTypeChangeTrust:
+		o.changeTrust()
+	case xdr.OperationTypeAllowTrust:
+		err = o.allowTrust()
+	case xdr.OperationTypeAccountMerge:
+		o.accountMerge()
+	case xdr.OperationTypeInflation:
+		o.inflation()
+	case xdr.OperationTypeManageData:
+		o.manageData()
+	case xdr.OperationTypeBumpSequence:
+		o.bumpSequence()
+	case xdr.OperationTypeCreateClaimableBalance:
+		err = o.createClaimableBalance()
+	case xdr.OperationTypeClaimClaimableBalance:
+		err = o.claimClaimableBalance()
+	case xdr.OperationTypeClawback:
+		o.clawback()
+	case xdr.OperationTypeClawbackClaimableBalance:
+		err = o.clawbackClaimableBalance()
+	case xdr.OperationTypeSetTrustLineFlags:
+		err = o.setTrustLineFlags()
+	case xdr.OperationTypeLiquidityPoolDeposit:
+		err = o.liquidityPoolDeposit()
+	case xdr.OperationTypeLiquidityPoolWithdraw:
+		err = o.liquidityPoolWithdraw()
+	case xdr.OperationTypeInvokeHostFunction:
+		err = o.invokeHostFunction()
+	case xdr.OperationTypeBeginSponsoringFutureReserves,
+		xdr.OperationTypeEndSponsoringFutureReserves,
+		xdr.OperationTypeRevokeSponsorship:
+		// only sponsorship effects, generated below
+	case xdr.OperationTypeExtendFootprintTtl, xdr.OperationTypeRestoreFootprint:
+		// no effects
+	default:
+		return fmt.Errorf("unknown operation type %s", o.op.Body.Type)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Effects generated by several operation types are kept grouped by
+	// category, after the effects specific to the operation type.
+	for _, change := range o.changes {
+		o.addLedgerEntrySponsorship(change)
+		o.addSignerSponsorships(change)
+	}
+	for _, change := range o.changes {
+		o.addLiquidityPoolEntry(change)
+	}
+	return nil
+}
+
+func (o *operationEffects) source() xdr.MuxedAccount {
+	if o.op.SourceAccount != nil {
+		return *o.op.SourceAccount
+	}
+	return o.tx.Envelope.SourceAccount()
+}
+
+// base returns the common fields of the next effect of the operation, which
+// belongs to account.
+func (o *operationEffects) base(account xdr.MuxedAccount, effectType effects.EffectType) effects.Base {
+	o.order++
+	b := effects.Base{
+		ID:              fmt.Sprintf("%019d-%010d", o.id, o.order),
+		PT:              fmt.Sprintf("%d-%d", o.id, o.order),
+		Account:         account.ToAccountId().Address(),
+		Type:            effects.EffectTypeNames[effectType],
+		TypeI:           int32(effectType),
+		LedgerCloseTime: o.closedAt,
+	}
+	if account.Type == xdr.CryptoKeyTypeKeyTypeMuxedEd25519 {
+		b.AccountMuxed = account.Address()
+		b.AccountMuxedID = uint64(account.Med25519.Id)
+	}
+	b.Links.Operation = o.processor.links.Linkf("/operations/%d", o.id)
+	b.Links.Succeeds = o.processor.links.Linkf("/effects?order=desc&cursor=%s", b.PT)
+	b.Links.Precedes = o.processor.links.Linkf("/effects?order=asc&cursor=%s", b.PT)
+	return b
+}
+
+func (o *operationEffects) accountBase(account xdr.AccountId, effectType effects.EffectType) effects.Base {
+	return o.base(account.ToMuxedAccount(), effectType)
+}
+
+func (o *operationEffects) add(effect effects.Effect) {
+	o.effects = append(o.effects, effect)
+}
+
+func assetDetails(asset xdr.Asset) base.Asset {
+	var details base.Asset
+	// Extract cannot fail with string arguments
+	_ = asset.Extract(&details.Type, &details.Code, &details.Issuer)
+	return details
+}
+
+func poolIDString(id xdr.PoolId) string {
+	return hex.EncodeToString(id[:])
+}
+
+func balanceIDString(id xdr.ClaimableBalanceId) (string, error) {
+	return xdr.MarshalHex(id)
+}
@@ -0,0 +1,590 @@
+package effects
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/processors/internal/processorstest"
+	"github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/protocols/horizon/base"
+	"github.com/stellar/go/protocols/horizon/effects"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/support/contractevents"
+	"github.com/stellar/go/xdr"
+)
+
+var (
+	passphrase = network.TestNetworkPassphrase
+	alice      = keypair.Root("alice").Address()
+	bob        = keypair.Root("bob").Address()
+	carol      = keypair.Root("carol").Address()
+	sponsor    = keypair.Root("sponsor").Address()
+	issuer     = keypair.Root("issuer").Address()
+	xlm        = xdr.MustNewNativeAsset()
+	usdc       = xdr.MustNewCreditAsset("USDC", issuer)
+	poolID, _  = xdr.NewPoolId(xlm, usdc, xdr.LiquidityPoolFeeV18)
+	balanceID  = xdr.ClaimableBalanceId{Type: xdr.ClaimableBalanceIdTypeClaimableBalanceIdTypeV0, V0: &xdr.Hash{1, 2, 3}}
+)
+
+func createAccount() processorstest.Operation {
+	return processorstest.Operation{
+		Op: xdr.Operation{Body: xdr.OperationBody{
+			Type: xdr.OperationTypeCreateAccount,
+			CreateAccountOp: &xdr.CreateAccountOp{
+				Destination:     xdr.MustAddress(bob),
+				StartingBalance: 100_0000000,
+			},
+		}},
+		Result: xdr.OperationResultTr{
+			Type:                xdr.OperationTypeCreateAccount,
+			CreateAccountResult: &xdr.CreateAccountResult{Code: xdr.CreateAccountResultCodeCreateAccountSuccess},
+		},
+		Changes: []xdr.LedgerEntryChanges{
+			processorstest.Updated(processorstest.AccountEntry(alice, 1000_0000000), processorstest.AccountEntry(alice, 900_0000000)),
+			processorstest.Created(processorstest.Sponsored(processorstest.AccountEntry(bob, 100_0000000), sponsor)),
+		},
+	}
+}
+
+func muxedPayment() processorstest.Operation {
+	source, err := xdr.MuxedAccountFromAccountId(alice, 7)
+	if err != nil {
+		panic(err)
+	}
+	return processorstest.Operation{
+		Op: xdr.Operation{
+			SourceAccount: &source,
+			Body: xdr.OperationBody{
+				Type: xdr.OperationTypePayment,
+				PaymentOp: &xdr.PaymentOp{
+					Destination: xdr.MustMuxedAddress(bob),
+					Asset:       usdc,
+					Amount:      10_0000000,
+				},
+			},
+		},
+		Result: xdr.OperationResultTr{
+			Type:          xdr.OperationTypePayment,
+			PaymentResult: &xdr.PaymentResult{Code: xdr.PaymentResultCodePaymentSuccess},
+		},
+		Changes: []xdr.LedgerEntryChanges{
+			processorstest.Updated(processorstest.TrustlineEntry(alice, usdc, 50_0000000), processorstest.TrustlineEntry(alice, usdc, 40_0000000)),
+			processorstest.Updated(processorstest.TrustlineEntry(bob, usdc, 0), processorstest.TrustlineEntry(bob, usdc, 10_0000000)),
+		},
+	}
+}
+
+func pathPaymentStrictSend() processorstest.Operation {
+	return processorstest.Operation{
+		Op: xdr.Operation{Body: xdr.OperationBody{
+			Type: xdr.OperationTypePathPaymentStrictSend,
+			PathPaymentStrictSendOp: &xdr.PathPaymentStrictSendOp{
+				SendAsset:   xlm,
+				SendAmount:  20_0000000,
+				Destination: xdr.MustMuxedAddress(bob),
+				DestAsset:   usdc,
+				DestMin:     9_0000000,
+			},
+		}},
+		Result: xdr.OperationResultTr{
+			Type: xdr.OperationTypePathPaymentStrictSend,
+			PathPaymentStrictSendResult: &xdr.PathPaymentStrictSendResult{
+				Code: xdr.PathPaymentStrictSendResultCodePathPaymentStrictSendSuccess,
+				Success: &xdr.PathPaymentStrictSendResultSuccess{
+					Offers: []xdr.ClaimAtom{
+						{
+							Type: xdr.ClaimAtomTypeClaimAtomTypeOrderBook,
+							OrderBook: &xdr.ClaimOfferAtom{
+								SellerId:     xdr.MustAddress(carol),
+								OfferId:      42,
+								AssetSold:    usdc,
+								AmountSold:   5_0000000,
+								AssetBought:  xlm,
+								AmountBought: 10_0000000,
+							},
+						},
+						{
+							Type: xdr.ClaimAtomTypeClaimAtomTypeLiquidityPool,
+							LiquidityPool: &xdr.ClaimLiquidityAtom{
+								LiquidityPoolId: poolID,
+								AssetSold:       usdc,
+								AmountSold:      5_0000000,
+								AssetBought:     xlm,
+								AmountBought:    10_0000000,
+							},
+						},
+					},
+					Last: xdr.SimplePaymentResult{
+						Destination: xdr.MustAddress(bob),
+						Asset:       usdc,
+						Amount:      10_0000000,
+					},
+				},
+			},
+		},
+		Changes: []xdr.LedgerEntryChanges{
+			processorstest.Updated(
+				processorstest.PoolEntry(xlm, usdc, 100_0000000, 50_0000000, 1000_0000000),
+				processorstest.PoolEntry(xlm, usdc, 110_0000000, 45_0000000, 1000_0000000),
+			),
+		},
+	}
+}
+
+func setOptions() processorstest.Operation {
+	homeDomain := xdr.String32("example.com")
+	low, high := xdr.Uint32(1), xdr.Uint32(3)
+	setFlags := xdr.Uint32(xdr.AccountFlagsAuthRequiredFlag)
+	signer := xdr.Signer{Key: xdr.MustSigner(carol), Weight: 2}
+	return processorstest.Operation{
+		Op: xdr.Operation{Body: xdr.OperationBody{
+			Type: xdr.OperationTypeSetOptions,
+			SetOptionsOp: &xdr.SetOptionsOp{
+				SetFlags:      &setFlags,
+				LowThreshold:  &low,
+				HighThreshold: &high,
+				HomeDomain:    &homeDomain,
+				Signer:        &signer,
+			},
+		}},
+		Result: xdr.OperationResultTr{
+			Type:             xdr.OperationTypeSetOptions,
+			SetOptionsResult: &xdr.SetOptionsResult{Code: xdr.SetOptionsResultCodeSetOptionsSuccess},
+		},
+		Changes: []xdr.LedgerEntryChanges{
+			processorstest.Updated(processorstest.AccountEntry(alice, 1000_0000000), processorstest.AccountEntry(alice, 1000_0000000, signer)),
+		},
+	}
+}
+
+func changeTrust() processorstest.Operation {
+	return processorstest.Operation{
+		Op: xdr.Operation{
+			SourceAccount: processorstest.Muxed(bob),
+			Body: xdr.OperationBody{
+				Type: xdr.OperationTypeChangeTrust,
+				ChangeTrustOp: &xdr.ChangeTrustOp{
+					Line:  usdc.ToChangeTrustAsset(),
+					Limit: 1000_0000000,
+				},
+			},
+		},
+		Result: xdr.OperationResultTr{
+			Type:              xdr.OperationTypeChangeTrust,
+			ChangeTrustResult: &xdr.ChangeTrustResult{Code: xdr.ChangeTrustResultCodeChangeTrustSuccess},
+		},
+		Changes: []xdr.LedgerEntryChanges{
+			processorstest.Created(processorstest.Sponsored(processorstest.TrustlineEntry(bob, usdc, 0), sponsor)),
+		},
+	}
+}
+
+func manageData() processorstest.Operation {
+	value := xdr.DataValue("hello")
+	return processorstest.Operation{
+		Op: xdr.Operation{Body: xdr.OperationBody{
+			Type: xdr.OperationTypeManageData,
+			ManageDataOp: &xdr.ManageDataOp{
+				DataName:  "greeting",
+				DataValue: &value,
+			},
+		}},
+		Result: xdr.OperationResultTr{
+			Type:             xdr.OperationTypeManageData,
+			ManageDataResult: &xdr.ManageDataResult{Code: xdr.ManageDataResultCodeManageDataSuccess},
+		},
+		Changes: []xdr.LedgerEntryChanges{
+			processorstest.Created(xdr.LedgerEntry{Data: xdr.LedgerEntryData{
+				Type: xdr.LedgerEntryTypeData,
+				Data: &xdr.DataEntry{
+					AccountId: xdr.MustAddress(alice),
+					DataName:  "greeting",
+					DataValue: value,
+				},
+			}}),
+		},
+	}
+}
+
+func createClaimableBalance() processorstest.Operation {
+	claimants := []xdr.Claimant{{
+		Type: xdr.ClaimantTypeClaimantTypeV0,
+		V0: &xdr.ClaimantV0{
+			Destination: xdr.MustAddress(bob),
+			Predicate:   xdr.ClaimPredicate{Type: xdr.ClaimPredicateTypeClaimPredicateUnconditional},
+		},
+	}}
+	return processorstest.Operation{
+		Op: xdr.Operation{Body: xdr.OperationBody{
+			Type: xdr.OperationTypeCreateClaimableBalance,
+			CreateClaimableBalanceOp: &xdr.CreateClaimableBalanceOp{
+				Asset:     usdc,
+				Amount:    5_0000000,
+				Claimants: claimants,
+			},
+		}},
+		Result: xdr.OperationResultTr{
+			Type: xdr.OperationTypeCreateClaimableBalance,
+			CreateClaimableBalanceResult: &xdr.CreateClaimableBalanceResult{
+				Code:      xdr.CreateClaimableBalanceResultCodeCreateClaimableBalanceSuccess,
+				BalanceId: &balanceID,
+			},
+		},
+		Changes: []xdr.LedgerEntryChanges{
+			processorstest.Created(processorstest.Sponsored(xdr.LedgerEntry{Data: xdr.LedgerEntryData{
+				Type: xdr.LedgerEntryTypeClaimableBalance,
+				ClaimableBalance: &xdr.ClaimableBalanceEntry{
+					BalanceId: balanceID,
+					Claimants: claimants,
+					Asset:     usdc,
+					Amount:    5_0000000,
+				},
+			}}, alice)),
+			processorstest.Updated(processorstest.TrustlineEntry(alice, usdc, 50_0000000), processorstest.TrustlineEntry(alice, usdc, 45_0000000)),
+		},
+	}
+}
+
+func accountMerge() processorstest.Operation {
+	balance := xdr.Int64(50_0000000)
+	return processorstest.Operation{
+		Op: xdr.Operation{
+			SourceAccount: processorstest.Muxed(carol),
+			Body: xdr.OperationBody{
+				Type:        xdr.OperationTypeAccountMerge,
+				Destination: processorstest.Muxed(alice),
+			},
+		},
+		Result: xdr.OperationResultTr{
+			Type: xdr.OperationTypeAccountMerge,
+			AccountMergeResult: &xdr.AccountMergeResult{
+				Code:                 xdr.AccountMergeResultCodeAccountMergeSuccess,
+				SourceAccountBalance: &balance,
+			},
+		},
+		Changes: []xdr.LedgerEntryChanges{
+			processorstest.Removed(processorstest.AccountEntry(carol, balance)),
+			processorstest.Updated(processorstest.AccountEntry(alice, 1000_0000000), processorstest.AccountEntry(alice, 1050_0000000)),
+		},
+	}
+}
+
+// xlmContract returns the address of the Stellar Asset Contract of XLM.
+func xlmContract(t *testing.T) string {
+	contractID, err := xlm.ContractID(passphrase)
+	require.NoError(t, err)
+	return strkey.MustEncode(strkey.VersionByteContract, contractID[:])
+}
+
+func invokeHostFunction(t *testing.T) processorstest.Operation {
+	contract := xlmContract(t)
+	return processorstest.Operation{
+		Op: xdr.Operation{Body: xdr.OperationBody{
+			Type: xdr.OperationTypeInvokeHostFunction,
+			InvokeHostFunctionOp: &xdr.InvokeHostFunctionOp{
+				HostFunction: xdr.HostFunction{
+					Type:           xdr.HostFunctionTypeHostFunctionTypeInvokeContract,
+					InvokeContract: &xdr.InvokeContractArgs{FunctionName: "transfer"},
+				},
+			},
+		}},
+		Result: xdr.OperationResultTr{
+			Type: xdr.OperationTypeInvokeHostFunction,
+			InvokeHostFunctionResult: &xdr.InvokeHostFunctionResult{
+				Code:    xdr.InvokeHostFunctionResultCodeInvokeHostFunctionSuccess,
+				Success: &xdr.Hash{},
+			},
+		},
+		Events: []xdr.ContractEvent{
+			contractevents.GenerateEvent(contractevents.EventTypeTransfer, alice, contract, "", xlm, big.NewInt(3_0000000), passphrase),
+			contractevents.GenerateEvent(contractevents.EventTypeTransfer, contract, bob, "", xlm, big.NewInt(1_0000000), passphrase),
+		},
+	}
+}
+
+// effectsOf returns the effects of a transaction of alice made of a
+// single operation, after checking their types.
+func effectsOf(t *testing.T, operation processorstest.Operation, types ...string) []effects.Effect {
+	result, err := NewProcessor(passphrase).EffectsFromTransaction(processorstest.Transaction(alice, operation))
+	require.NoError(t, err)
+	var actual []string
+	for _, effect := range result {
+		actual = append(actual, effect.GetType())
+	}
+	require.Equal(t, types, actual)
+	return result
+}
+
+func TestCreateAccountEffects(t *testing.T) {
+	result := effectsOf(t, createAccount(),
+		"account_created", "account_debited", "signer_created", "account_sponsorship_created")
+
+	created := result[0].(effects.AccountCreated)
+	assert.Equal(t, bob, created.Account)
+	assert.Equal(t, "100.0000000", created.StartingBalance)
+	assert.Equal(t, processorstest.CloseTime, created.LedgerCloseTime)
+	assert.Equal(t, "/operations/429496733697", created.Links.Operation.Href)
+	debited := result[1].(effects.AccountDebited)
+	assert.Equal(t, alice, debited.Account)
+	assert.Equal(t, "native", debited.Asset.Type)
+	assert.Equal(t, "100.0000000", debited.Amount)
+	signer := result[2].(effects.SignerCreated)
+	assert.Equal(t, bob, signer.Account)
+	assert.Equal(t, bob, signer.Key)
+	assert.Equal(t, int32(1), signer.Weight)
+	sponsorship := result[3].(effects.AccountSponsorshipCreated)
+	assert.Equal(t, bob, sponsorship.Account)
+	assert.Equal(t, sponsor, sponsorship.Sponsor)
+}
+
+func TestMuxedPaymentEffects(t *testing.T) {
+	result := effectsOf(t, muxedPayment(), "account_credited", "account_debited")
+
+	credited := result[0].(effects.AccountCredited)
+	assert.Equal(t, bob, credited.Account)
+	assert.Empty(t, credited.AccountMuxed)
+	assert.Equal(t, "USDC", credited.Asset.Code)
+	assert.Equal(t, issuer, credited.Asset.Issuer)
+	assert.Equal(t, "10.0000000", credited.Amount)
+	debited := result[1].(effects.AccountDebited)
+	assert.Equal(t, alice, debited.Account)
+	source, err := xdr.MuxedAccountFromAccountId(alice, 7)
+	require.NoError(t, err)
+	assert.Equal(t, source.Address(), debited.AccountMuxed)
+	assert.Equal(t, uint64(7), debited.AccountMuxedID)
+	assert.Equal(t, "10.0000000", debited.Amount)
+}
+
+func TestPathPaymentStrictSendEffects(t *testing.T) {
+	result := effectsOf(t, pathPaymentStrictSend(),
+		"account_credited", "account_debited", "trade", "trade", "liquidity_pool_trade")
+
+	credited := result[0].(effects.AccountCredited)
+	assert.Equal(t, bob, credited.Account)
+	assert.Equal(t, "USDC", credited.Asset.Code)
+	assert.Equal(t, "10.0000000", credited.Amount)
+	debited := result[1].(effects.AccountDebited)
+	assert.Equal(t, alice, debited.Account)
+	assert.Equal(t, "native", debited.Asset.Type)
+	assert.Equal(t, "20.0000000", debited.Amount)
+
+	// the offer of carol produces a trade effect for each side
+	buyer := result[2].(effects.Trade)
+	assert.Equal(t, alice, buyer.Account)
+	assert.Equal(t, carol, buyer.Seller)
+	assert.Equal(t, int64(42), buyer.OfferID)
+	assert.Equal(t, "native", buyer.SoldAssetType)
+	assert.Equal(t, "10.0000000", buyer.SoldAmount)
+	assert.Equal(t, "USDC", buyer.BoughtAssetCode)
+	assert.Equal(t, "5.0000000", buyer.BoughtAmount)
+	seller := result[3].(effects.Trade)
+	assert.Equal(t, carol, seller.Account)
+	assert.Equal(t, alice, seller.Seller)
+	assert.Equal(t, "USDC", seller.SoldAssetCode)
+	assert.Equal(t, "5.0000000", seller.SoldAmount)
+	assert.Equal(t, "native", seller.BoughtAssetType)
+	assert.Equal(t, "10.0000000", seller.BoughtAmount)
+
+	// the pool is reported as it is after the operation
+	poolTrade := result[4].(effects.LiquidityPoolTrade)
+	assert.Equal(t, alice, poolTrade.Account)
+	assert.Equal(t, hex.EncodeToString(poolID[:]), poolTrade.LiquidityPool.ID)
+	assert.Equal(t, uint32(xdr.LiquidityPoolFeeV18), poolTrade.LiquidityPool.FeeBP)
+	assert.Equal(t, uint64(3), poolTrade.LiquidityPool.TotalTrustlines)
+	assert.Equal(t, []base.AssetAmount{
+		{Asset: "native", Amount: "110.0000000"},
+		{Asset: "USDC:" + issuer, Amount: "45.0000000"},
+	}, poolTrade.LiquidityPool.Reserves)
+	assert.Equal(t, base.AssetAmount{Asset: "USDC:" + issuer, Amount: "5.0000000"}, poolTrade.Sold)
+	assert.Equal(t, base.AssetAmount{Asset: "native", Amount: "10.0000000"}, poolTrade.Bought)
+}
+
+func TestSetOptionsEffects(t *testing.T) {
+	result := effectsOf(t, setOptions(),
+		"account_home_domain_updated", "account_thresholds_updated", "account_flags_updated", "signer_created")
+
+	assert.Equal(t, "example.com", result[0].(effects.AccountHomeDomainUpdated).HomeDomain)
+	thresholds := result[1].(effects.AccountThresholdsUpdated)
+	assert.Equal(t, int32(1), thresholds.LowThreshold)
+	assert.Equal(t, int32(0), thresholds.MedThreshold)
+	assert.Equal(t, int32(3), thresholds.HighThreshold)
+	flags := result[2].(effects.AccountFlagsUpdated)
+	require.NotNil(t, flags.AuthRequired)
+	assert.True(t, *flags.AuthRequired)
+	assert.Nil(t, flags.AuthRevokable)
+	signer := result[3].(effects.SignerCreated)
+	assert.Equal(t, alice, signer.Account)
+	assert.Equal(t, carol, signer.Key)
+	assert.Equal(t, int32(2), signer.Weight)
+}
+
+func TestChangeTrustEffects(t *testing.T) {
+	result := effectsOf(t, changeTrust(), "trustline_created", "trustline_sponsorship_created")
+
+	trustline := result[0].(effects.TrustlineCreated)
+	assert.Equal(t, bob, trustline.Account)
+	assert.Equal(t, "USDC", trustline.Code)
+	assert.Equal(t, issuer, trustline.Issuer)
+	assert.Equal(t, "1000.0000000", trustline.Limit)
+	sponsorship := result[1].(effects.TrustlineSponsorshipCreated)
+	assert.Equal(t, bob, sponsorship.Account)
+	assert.Equal(t, "USDC:"+issuer, sponsorship.Asset)
+	assert.Equal(t, sponsor, sponsorship.Sponsor)
+}
+
+func TestManageDataEffects(t *testing.T) {
+	result := effectsOf(t, manageData(), "data_created")
+
+	data := result[0].(effects.DataCreated)
+	assert.Equal(t, alice, data.Account)
+	assert.Equal(t, "greeting", data.Name)
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("hello")), data.Value)
+}
+
+func TestCreateClaimableBalanceEffects(t *testing.T) {
+	result := effectsOf(t, createClaimableBalance(),
+		"claimable_balance_created", "claimable_balance_claimant_created", "account_debited",
+		"claimable_balance_sponsorship_created")
+	id, err := xdr.MarshalHex(balanceID)
+	require.NoError(t, err)
+
+	created := result[0].(effects.ClaimableBalanceCreated)
+	assert.Equal(t, alice, created.Account)
+	assert.Equal(t, id, created.BalanceID)
+	assert.Equal(t, "USDC:"+issuer, created.Asset)
+	assert.Equal(t, "5.0000000", created.Amount)
+	claimant := result[1].(effects.ClaimableBalanceClaimantCreated)
+	assert.Equal(t, bob, claimant.Account)
+	assert.Equal(t, id, claimant.BalanceID)
+	assert.Equal(t, xdr.ClaimPredicateTypeClaimPredicateUnconditional, claimant.Predicate.Type)
+	debited := result[2].(effects.AccountDebited)
+	assert.Equal(t, alice, debited.Account)
+	assert.Equal(t, "USDC", debited.Asset.Code)
+	assert.Equal(t, "5.0000000", debited.Amount)
+	sponsorship := result[3].(effects.ClaimableBalanceSponsorshipCreated)
+	assert.Equal(t, alice, sponsorship.Account)
+	assert.Equal(t, id, sponsorship.BalanceID)
+	assert.Equal(t, alice, sponsorship.Sponsor)
+}
+
+func TestAccountMergeEffects(t *testing.T) {
+	result := effectsOf(t, accountMerge(), "account_debited", "account_credited", "account_removed")
+
+	debited := result[0].(effects.AccountDebited)
+	assert.Equal(t, carol, debited.Account)
+	assert.Equal(t, "native", debited.Asset.Type)
+	assert.Equal(t, "50.0000000", debited.Amount)
+	credited := result[1].(effects.AccountCredited)
+	assert.Equal(t, alice, credited.Account)
+	assert.Equal(t, "50.0000000", credited.Amount)
+	assert.Equal(t, carol, result[2].GetAccount())
+}
+
+func TestInvokeHostFunctionEffects(t *testing.T) {
+	result := effectsOf(t, invokeHostFunction(t),
+		"account_debited", "contract_credited", "contract_debited", "account_credited")
+	contract := xlmContract(t)
+
+	// transfers between accounts and contracts produce an effect for each side
+	debited := result[0].(effects.AccountDebited)
+	assert.Equal(t, alice, debited.Account)
+	assert.Equal(t, "native", debited.Asset.Type)
+	assert.Equal(t, "3.0000000", debited.Amount)
+	contractCredited := result[1].(effects.ContractCredited)
+	assert.Equal(t, alice, contractCredited.Account)
+	assert.Equal(t, contract, contractCredited.Contract)
+	assert.Equal(t, "3.0000000", contractCredited.Amount)
+	contractDebited := result[2].(effects.ContractDebited)
+	assert.Equal(t, alice, contractDebited.Account)
+	assert.Equal(t, contract, contractDebited.Contract)
+	assert.Equal(t, "1.0000000", contractDebited.Amount)
+	credited := result[3].(effects.AccountCredited)
+	assert.Equal(t, bob, credited.Account)
+	assert.Equal(t, "1.0000000", credited.Amount)
+}
+
+func TestEffectsIDs(t *testing.T) {
+	tx := processorstest.Transaction(alice, createAccount(), manageData())
+	result, err := NewProcessor(passphrase).EffectsFromTransaction(tx)
+	require.NoError(t, err)
+	require.Len(t, result, 5)
+
+	// ids are made of the operation id and the position of the effect in it
+	assert.Equal(t, "0000000429496733697-0000000001", result[0].GetID())
+	assert.Equal(t, "429496733697-4", result[3].PagingToken())
+	assert.Equal(t, "0000000429496733698-0000000001", result[4].GetID())
+	assert.Equal(t, "data_created", result[4].GetType())
+
+	tx.Result.Result.Result.Code = xdr.TransactionResultCodeTxFailed
+	result, err = NewProcessor(passphrase).EffectsFromTransaction(tx)
+	require.NoError(t, err)
+	assert.Empty(t, result)
+}
+
+func TestEffectsMissingChanges(t *testing.T) {
+	op := createClaimableBalance()
+	op.Changes = nil
+	_, err := NewProcessor(passphrase).EffectsFromTransaction(processorstest.Transaction(alice, op))
+	assert.ErrorContains(t, err, "claimable balance entry not found")
+}
+
+// recordedEffects returns the effects of a transaction recorded from Horizon,
+// after checking their types and ids, along with the transaction as served by
+// Horizon.
+func recordedEffects(t *testing.T, name string, types ...string) (horizon.Transaction, []effects.Effect) {
+	served, tx, err := processorstest.HorizonTransaction(name)
+	require.NoError(t, err)
+	result, err := NewProcessor(passphrase).EffectsFromTransaction(tx)
+	require.NoError(t, err)
+
+	// the transaction has a single operation, which follows it in Horizon's ids
+	id, err := strconv.ParseInt(served.PT, 10, 64)
+	require.NoError(t, err)
+	var actual []string
+	for i, effect := range result {
+		actual = append(actual, effect.GetType())
+		assert.Equal(t, fmt.Sprintf("%019d-%010d", id+1, i+1), effect.GetID())
+	}
+	require.Equal(t, types, actual)
+	return served, result
+}
+
+func TestRecordedCreateAccountEffects(t *testing.T) {
+	served, result := recordedEffects(t, "testnet_create_account.json",
+		"account_created", "account_debited", "signer_created")
+
+	created := result[0].(effects.AccountCreated)
+	assert.True(t, served.LedgerCloseTime.Equal(created.LedgerCloseTime))
+	assert.Equal(t, "GDHPNWUTDBWACZQE2ICEG73KL5O2MFAKOH6LYTBSIIRU4JMC4Y2RELOJ", created.Account)
+	assert.Equal(t, "10000.0000000", created.StartingBalance)
+	debited := result[1].(effects.AccountDebited)
+	assert.Equal(t, "GAIH3ULLFQ4DGSECF2AR555KZ4KNDGEKN4AFI4SU2M7B43MGK3QJZNSR", debited.Account)
+	assert.Equal(t, "native", debited.Asset.Type)
+	assert.Equal(t, "10000.0000000", debited.Amount)
+	signer := result[2].(effects.SignerCreated)
+	assert.Equal(t, "GDHPNWUTDBWACZQE2ICEG73KL5O2MFAKOH6LYTBSIIRU4JMC4Y2RELOJ", signer.Account)
+	assert.Equal(t, signer.Account, signer.Key)
+	assert.Equal(t, int32(1), signer.Weight)
+}
+
+func TestRecordedPaymentEffects(t *testing.T) {
+	served, result := recordedEffects(t, "testnet_payment.json", "account_credited", "account_debited")
+
+	credited := result[0].(effects.AccountCredited)
+	assert.True(t, served.LedgerCloseTime.Equal(credited.LedgerCloseTime))
+	assert.Equal(t, "GBG6H3TO4WCZ37M6QA3U5BOWBYZH2QXDE2NJ75KRUIQNDSCYZUKKKJHM", credited.Account)
+	assert.Equal(t, "native", credited.Asset.Type)
+	assert.Equal(t, "987.0000000", credited.Amount)
+	debited := result[1].(effects.AccountDebited)
+	assert.Equal(t, "GDRZVYB5QI6UFR4NR4RXQ3HR5IH4KL2ECR4IUZXGHOUMPGLN2OGCSAOK", debited.Account)
+	assert.Equal(t, "987.0000000", debited.Amount)
+}
@@ -0,0 +1,70 @@
+package processorstest
+
+import (
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/stellar/go/ingest"
+	"github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/toid"
+	"github.com/stellar/go/xdr"
+)
+
+// testdata holds transactions as served by Horizon's /transactions endpoint,
+// recorded from the public Horizon instances:
+//
+//   - testnet_create_account.json is a testnet create_account transaction of
+//     ledger 438134.
+//   - testnet_payment.json is a testnet native payment of ledger 364625.
+//
+//go:embed testdata/*.json
+var testdata embed.FS
+
+// HorizonTransaction returns a recorded transaction, both as served by Horizon
+// and as read from its ledger. Only the sequence and close time of the ledger
+// header are known.
+func HorizonTransaction(name string) (horizon.Transaction, ingest.LedgerTransaction, error) {
+	var served horizon.Transaction
+	content, err := testdata.ReadFile("testdata/" + name)
+	if err != nil {
+		return served, ingest.LedgerTransaction{}, err
+	}
+	if err = json.Unmarshal(content, &served); err != nil {
+		return served, ingest.LedgerTransaction{}, fmt.Errorf("error decoding %s: %w", name, err)
+	}
+
+	tx := ingest.LedgerTransaction{
+		Ledger: xdr.LedgerCloseMeta{V: 0, V0: &xdr.LedgerCloseMetaV0{
+			LedgerHeader: xdr.LedgerHeaderHistoryEntry{Header: xdr.LedgerHeader{
+				LedgerSeq: xdr.Uint32(served.Ledger),
+				ScpValue:  xdr.StellarValue{CloseTime: xdr.TimePoint(served.LedgerCloseTime.Unix())},
+			}},
+		}},
+	}
+	hash, err := hex.DecodeString(served.Hash)
+	if err != nil {
+		return served, tx, fmt.Errorf("error decoding hash of %s: %w", name, err)
+	}
+	copy(tx.Hash[:], hash)
+	tx.Result.TransactionHash = tx.Hash
+
+	id, err := strconv.ParseInt(served.PT, 10, 64)
+	if err != nil {
+		return served, tx, fmt.Errorf("error decoding paging token of %s: %w", name, err)
+	}
+	tx.Index = uint32(toid.Parse(id).TransactionOrder)
+
+	if err = xdr.SafeUnmarshalBase64(served.EnvelopeXdr, &tx.Envelope); err != nil {
+		return served, tx, fmt.Errorf("error decoding envelope of %s: %w", name, err)
+	}
+	if err = xdr.SafeUnmarshalBase64(served.ResultXdr, &tx.Result.Result); err != nil {
+		return served, tx, fmt.Errorf("error decoding result of %s: %w", name, err)
+	}
+	if err = xdr.SafeUnmarshalBase64(served.ResultMetaXdr, &tx.UnsafeMeta); err != nil {
+		return served, tx, fmt.Errorf("error decoding meta of %s: %w", name, err)
+	}
+	return served, tx, nil
+}
@@ -0,0 +1,197 @@
+// Package processorstest builds the ledger entries and transactions the tests
+// of the processors run against. The transactions built by Transaction are
+// synthetic: they are only as faithful to stellar-core as the operations,
+// results and changes given to it. HorizonTransaction returns transactions
+// recorded from Horizon instead.
+package processorstest
+
+import (
+	"time"
+
+	"github.com/stellar/go/ingest"
+	"github.com/stellar/go/xdr"
+)
+
+// LedgerSequence is the sequence of the ledger of the transactions built by
+// Transaction.
+const LedgerSequence = 100
+
+// CloseTime is the close time of the ledger of the transactions built by
+// Transaction.
+var CloseTime = time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC)
+
+// Muxed returns the muxed account of an address.
+func Muxed(address string) *xdr.MuxedAccount {
+	account := xdr.MustMuxedAddress(address)
+	return &account
+}
+
+// AccountEntry returns the entry of an account with a balance, a master key
+// of weight 1 and the given signers.
+func AccountEntry(address string, balance xdr.Int64, signers ...xdr.Signer) xdr.LedgerEntry {
+	return xdr.LedgerEntry{Data: xdr.LedgerEntryData{
+		Type: xdr.LedgerEntryTypeAccount,
+		Account: &xdr.AccountEntry{
+			AccountId:  xdr.MustAddress(address),
+			Balance:    balance,
+			SeqNum:     1,
+			Thresholds: xdr.Thresholds{1, 0, 0, 0},
+			Signers:    signers,
+		},
+	}}
+}
+
+// TrustlineEntry returns the entry of an authorized trustline with a limit of
+// 1000 units.
+func TrustlineEntry(address string, asset xdr.Asset, balance xdr.Int64) xdr.LedgerEntry {
+	return xdr.LedgerEntry{Data: xdr.LedgerEntryData{
+		Type: xdr.LedgerEntryTypeTrustline,
+		TrustLine: &xdr.TrustLineEntry{
+			AccountId: xdr.MustAddress(address),
+			Asset:     asset.ToTrustLineAsset(),
+			Balance:   balance,
+			Limit:     1000_0000000,
+			Flags:     xdr.Uint32(xdr.TrustLineFlagsAuthorizedFlag),
+		},
+	}}
+}
+
+// PoolEntry returns the entry of the constant product pool of two assets,
+// which must be in canonical order, with the default fee and 3 trustlines.
+func PoolEntry(assetA, assetB xdr.Asset, reserveA, reserveB, shares xdr.Int64) xdr.LedgerEntry {
+	poolID, err := xdr.NewPoolId(assetA, assetB, xdr.LiquidityPoolFeeV18)
+	if err != nil {
+		panic(err)
+	}
+	return xdr.LedgerEntry{Data: xdr.LedgerEntryData{
+		Type: xdr.LedgerEntryTypeLiquidityPool,
+		LiquidityPool: &xdr.LiquidityPoolEntry{
+			LiquidityPoolId: poolID,
+			Body: xdr.LiquidityPoolEntryBody{
+				Type: xdr.LiquidityPoolTypeLiquidityPoolConstantProduct,
+				ConstantProduct: &xdr.LiquidityPoolEntryConstantProduct{
+					Params: xdr.LiquidityPoolConstantProductParameters{
+						AssetA: assetA,
+						AssetB: assetB,
+						Fee:    xdr.LiquidityPoolFeeV18,
+					},
+					ReserveA:                 reserveA,
+					ReserveB:                 reserveB,
+					TotalPoolShares:          shares,
+					PoolSharesTrustLineCount: 3,
+				},
+			},
+		},
+	}}
+}
+
+// Sponsored returns the entry with its reserve paid by address.
+func Sponsored(entry xdr.LedgerEntry, address string) xdr.LedgerEntry {
+	id := xdr.MustAddress(address)
+	entry.Ext = xdr.LedgerEntryExt{V: 1, V1: &xdr.LedgerEntryExtensionV1{SponsoringId: &id}}
+	return entry
+}
+
+// Created returns the changes of the creation of an entry.
+func Created(entry xdr.LedgerEntry) xdr.LedgerEntryChanges {
+	return xdr.LedgerEntryChanges{{Type: xdr.LedgerEntryChangeTypeLedgerEntryCreated, Created: &entry}}
+}
+
+// Updated returns the changes of the update of an entry from pre to post.
+func Updated(pre, post xdr.LedgerEntry) xdr.LedgerEntryChanges {
+	return xdr.LedgerEntryChanges{
+		{Type: xdr.LedgerEntryChangeTypeLedgerEntryState, State: &pre},
+		{Type: xdr.LedgerEntryChangeTypeLedgerEntryUpdated, Updated: &post},
+	}
+}
+
+// Removed returns the changes of the removal of an entry.
+func Removed(entry xdr.LedgerEntry) xdr.LedgerEntryChanges {
+	key, err := entry.LedgerKey()
+	if err != nil {
+		panic(err)
+	}
+	return xdr.LedgerEntryChanges{
+		{Type: xdr.LedgerEntryChangeTypeLedgerEntryState, State: &entry},
+		{Type: xdr.LedgerEntryChangeTypeLedgerEntryRemoved, Removed: &key},
+	}
+}
+
+// OfferAtom returns the claim atom of an offer of seller crossed by a trade.
+func OfferAtom(seller string, offerID xdr.Int64, sold xdr.Asset, amountSold xdr.Int64, bought xdr.Asset, amountBought xdr.Int64) xdr.ClaimAtom {
+	return xdr.ClaimAtom{
+		Type: xdr.ClaimAtomTypeClaimAtomTypeOrderBook,
+		OrderBook: &xdr.ClaimOfferAtom{
+			SellerId:     xdr.MustAddress(seller),
+			OfferId:      offerID,
+			AssetSold:    sold,
+			AmountSold:   amountSold,
+			AssetBought:  bought,
+			AmountBought: amountBought,
+		},
+	}
+}
+
+// Operation is an operation along with its result and meta.
+type Operation struct {
+	Op      xdr.Operation
+	Result  xdr.OperationResultTr
+	Changes []xdr.LedgerEntryChanges
+	Events  []xdr.ContractEvent
+}
+
+// Transaction returns the first transaction of ledger LedgerSequence, sent by
+// source, in which all the operations succeeded.
+func Transaction(source string, operations ...Operation) ingest.LedgerTransaction {
+	var (
+		ops     []xdr.Operation
+		results []xdr.OperationResult
+		metas   []xdr.OperationMetaV2
+	)
+	for _, o := range operations {
+		ops = append(ops, o.Op)
+		result := o.Result
+		results = append(results, xdr.OperationResult{Code: xdr.OperationResultCodeOpInner, Tr: &result})
+		meta := xdr.OperationMetaV2{Events: o.Events}
+		for _, changes := range o.Changes {
+			meta.Changes = append(meta.Changes, changes...)
+		}
+		metas = append(metas, meta)
+	}
+
+	return ingest.LedgerTransaction{
+		Index: 1,
+		Hash:  xdr.Hash{0xab, 0xcd},
+		Envelope: xdr.TransactionEnvelope{
+			Type: xdr.EnvelopeTypeEnvelopeTypeTx,
+			V1: &xdr.TransactionV1Envelope{Tx: xdr.Transaction{
+				SourceAccount: xdr.MustMuxedAddress(source),
+				Fee:           100,
+				SeqNum:        2,
+				Operations:    ops,
+			}},
+		},
+		Result: xdr.TransactionResultPair{Result: xdr.TransactionResult{
+			FeeCharged: 100,
+			Result: xdr.TransactionResultResult{
+				Code:    xdr.TransactionResultCodeTxSuccess,
+				Results: &results,
+			},
+		}},
+		UnsafeMeta: xdr.TransactionMeta{V: 4, V4: &xdr.TransactionMetaV4{Operations: metas}},
+		Ledger: xdr.LedgerCloseMeta{V: 0, V0: &xdr.LedgerCloseMetaV0{
+			LedgerHeader: xdr.LedgerHeaderHistoryEntry{Header: xdr.LedgerHeader{
+				LedgerSeq: LedgerSequence,
+				ScpValue:  xdr.StellarValue{CloseTime: xdr.TimePoint(CloseTime.Unix())},
+			}},
+		}},
+	}
+}
+
+// Failed returns the transaction as it would be had it failed: without
+// operation meta.
+func Failed(tx ingest.LedgerTransaction) ingest.LedgerTransaction {
+	tx.Result.Result.Result.Code = xdr.TransactionResultCodeTxFailed
+	tx.UnsafeMeta = xdr.TransactionMeta{V: 4, V4: &xdr.TransactionMetaV4{}}
+	return tx
+}
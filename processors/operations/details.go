@@ -0,0 +1,624 @@
+package operations
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/protocols/horizon/base"
+	"github.com/stellar/go/protocols/horizon/operations"
+	"github.com/stellar/go/support/contractevents"
+	"github.com/stellar/go/xdr"
+)
+
+func (w *operationWrapper) operation() (operations.Operation, error) {
+	b := w.base()
+	source, sourceMuxed, sourceMuxedID := muxedAccountDetails(w.source())
+
+	switch w.op.Body.Type {
+	case xdr.OperationTypeCreateAccount:
+		op := w.op.Body.MustCreateAccountOp()
+		return operations.CreateAccount{
+			Base:            b,
+			StartingBalance: amount.String(op.StartingBalance),
+			Funder:          source,
+			FunderMuxed:     sourceMuxed,
+			FunderMuxedID:   sourceMuxedID,
+			Account:         op.Destination.Address(),
+		}, nil
+	case xdr.OperationTypePayment:
+		op := w.op.Body.MustPaymentOp()
+		return w.payment(b, op.Destination, op.Asset, op.Amount), nil
+	case xdr.OperationTypePathPaymentStrictReceive:
+		op := w.op.Body.MustPathPaymentStrictReceiveOp()
+		sourceAmount := xdr.Int64(0)
+		if w.successful() {
+			result := w.result.Tr.MustPathPaymentStrictReceiveResult()
+			sourceAmount = result.SendAmount()
+		}
+		sendAsset := assetDetails(op.SendAsset)
+		return operations.PathPayment{
+			Payment:           w.payment(b, op.Destination, op.DestAsset, op.DestAmount),
+			Path:              pathDetails(op.Path),
+			SourceAmount:      amount.String(sourceAmount),
+			SourceMax:         amount.String(op.SendMax),
+			SourceAssetType:   sendAsset.Type,
+			SourceAssetCode:   sendAsset.Code,
+			SourceAssetIssuer: sendAsset.Issuer,
+		}, nil
+	case xdr.OperationTypePathPaymentStrictSend:
+		op := w.op.Body.MustPathPaymentStrictSendOp()
+		destAmount := xdr.Int64(0)
+		if w.successful() {
+			result := w.result.Tr.MustPathPaymentStrictSendResult()
+			destAmount = result.DestAmount()
+		}
+		sendAsset := assetDetails(op.SendAsset)
+		return operations.PathPaymentStrictSend{
+			Payment:           w.payment(b, op.Destination, op.DestAsset, destAmount),
+			Path:              pathDetails(op.Path),
+			SourceAmount:      amount.String(op.SendAmount),
+			DestinationMin:    amount.String(op.DestMin),
+			SourceAssetType:   sendAsset.Type,
+			SourceAssetCode:   sendAsset.Code,
+			SourceAssetIssuer: sendAsset.Issuer,
+		}, nil
+	case xdr.OperationTypeManageBuyOffer:
+		op := w.op.Body.MustManageBuyOfferOp()
+		return operations.ManageBuyOffer{
+			Offer:   offerDetails(b, op.BuyAmount, op.Price, op.Buying, op.Selling),
+			OfferID: int64(op.OfferId),
+		}, nil
+	case xdr.OperationTypeManageSellOffer:
+		op := w.op.Body.MustManageSellOfferOp()
+		return operations.ManageSellOffer{
+			Offer:   offerDetails(b, op.Amount, op.Price, op.Buying, op.Selling),
+			OfferID: int64(op.OfferId),
+		}, nil
+	case xdr.OperationTypeCreatePassiveSellOffer:
+		op := w.op.Body.MustCreatePassiveSellOfferOp()
+		return operations.CreatePassiveSellOffer{
+			Offer: offerDetails(b, op.Amount, op.Price, op.Buying, op.Selling),
+		}, nil
+	case xdr.OperationTypeSetOptions:
+		return setOptionsDetails(b, w.op.Body.MustSetOptionsOp()), nil
+	case xdr.OperationTypeChangeTrust:
+		op := w.op.Body.MustChangeTrustOp()
+		result := operations.ChangeTrust{
+			Base:           b,
+			Limit:          amount.String(op.Limit),
+			Trustor:        source,
+			TrustorMuxed:   sourceMuxed,
+			TrustorMuxedID: sourceMuxedID,
+		}
+		if op.Line.Type == xdr.AssetTypeAssetTypePoolShare {
+			cp := op.Line.MustLiquidityPool().MustConstantProduct()
+			poolID, err := xdr.NewPoolId(cp.AssetA, cp.AssetB, cp.Fee)
+			if err != nil {
+				return nil, err
+			}
+			result.Type = "liquidity_pool_shares"
+			result.LiquidityPoolID = hex.EncodeToString(poolID[:])
+		} else {
+			result.Asset = assetDetails(op.Line.ToAsset())
+			result.Trustee = result.Issuer
+		}
+		return result, nil
+	case xdr.OperationTypeAllowTrust:
+		op := w.op.Body.MustAllowTrustOp()
+		flags := xdr.TrustLineFlags(op.Authorize)
+		return operations.AllowTrust{
+			Base:                           b,
+			Asset:                          assetDetails(op.Asset.ToAsset(w.source().ToAccountId())),
+			Trustee:                        source,
+			TrusteeMuxed:                   sourceMuxed,
+			TrusteeMuxedID:                 sourceMuxedID,
+			Trustor:                        op.Trustor.Address(),
+			Authorize:                      flags.IsAuthorized(),
+			AuthorizeToMaintainLiabilities: flags.IsAuthorizedToMaintainLiabilitiesFlag(),
+		}, nil
+	case xdr.OperationTypeAccountMerge:
+		into, intoMuxed, intoMuxedID := muxedAccountDetails(w.op.Body.MustDestination())
+		return operations.AccountMerge{
+			Base:           b,
+			Account:        source,
+			AccountMuxed:   sourceMuxed,
+			AccountMuxedID: sourceMuxedID,
+			Into:           into,
+			IntoMuxed:      intoMuxed,
+			IntoMuxedID:    intoMuxedID,
+		}, nil
+	case xdr.OperationTypeInflation:
+		return operations.Inflation{Base: b}, nil
+	case xdr.OperationTypeManageData:
+		op := w.op.Body.MustManageDataOp()
+		result := operations.ManageData{Base: b, Name: string(op.DataName)}
+		if op.DataValue != nil {
+			result.Value = base64.StdEncoding.EncodeToString(*op.DataValue)
+		}
+		return result, nil
+	case xdr.OperationTypeBumpSequence:
+		op := w.op.Body.MustBumpSequenceOp()
+		return operations.BumpSequence{Base: b, BumpTo: strconv.FormatInt(int64(op.BumpTo), 10)}, nil
+	case xdr.OperationTypeCreateClaimableBalance:
+		op := w.op.Body.MustCreateClaimableBalanceOp()
+		claimants := make([]horizon.Claimant, 0, len(op.Claimants))
+		for _, claimant := range op.Claimants {
+			v0 := claimant.MustV0()
+			claimants = append(claimants, horizon.Claimant{
+				Destination: v0.Destination.Address(),
+				Predicate:   v0.Predicate,
+			})
+		}
+		return operations.CreateClaimableBalance{
+			Base:      b,
+			Asset:     op.Asset.StringCanonical(),
+			Amount:    amount.String(op.Amount),
+			Claimants: claimants,
+		}, nil
+	case xdr.OperationTypeClaimClaimableBalance:
+		op := w.op.Body.MustClaimClaimableBalanceOp()
+		balanceID, err := xdr.MarshalHex(op.BalanceId)
+		if err != nil {
+			return nil, err
+		}
+		return operations.ClaimClaimableBalance{
+			Base:            b,
+			BalanceID:       balanceID,
+			Claimant:        source,
+			ClaimantMuxed:   sourceMuxed,
+			ClaimantMuxedID: sourceMuxedID,
+		}, nil
+	case xdr.OperationTypeBeginSponsoringFutureReserves:
+		op := w.op.Body.MustBeginSponsoringFutureReservesOp()
+		return operations.BeginSponsoringFutureReserves{Base: b, SponsoredID: op.SponsoredId.Address()}, nil
+	case xdr.OperationTypeEndSponsoringFutureReserves:
+		result := operations.EndSponsoringFutureReserves{Base: b}
+		if begin, ok := w.beginSponsoringOp(); ok {
+			result.BeginSponsor, result.BeginSponsorMuxed, result.BeginSponsorMuxedID = muxedAccountDetails(w.operationSource(begin))
+		}
+		return result, nil
+	case xdr.OperationTypeRevokeSponsorship:
+		return revokeSponsorshipDetails(b, w.op.Body.MustRevokeSponsorshipOp())
+	case xdr.OperationTypeClawback:
+		op := w.op.Body.MustClawbackOp()
+		from, fromMuxed, fromMuxedID := muxedAccountDetails(op.From)
+		return operations.Clawback{
+			Base:        b,
+			Asset:       assetDetails(op.Asset),
+			From:        from,
+			FromMuxed:   fromMuxed,
+			FromMuxedID: fromMuxedID,
+			Amount:      amount.String(op.Amount),
+		}, nil
+	case xdr.OperationTypeClawbackClaimableBalance:
+		op := w.op.Body.MustClawbackClaimableBalanceOp()
+		balanceID, err := xdr.MarshalHex(op.BalanceId)
+		if err != nil {
+			return nil, err
+		}
+		return operations.ClawbackClaimableBalance{Base: b, BalanceID: balanceID}, nil
+	case xdr.OperationTypeSetTrustLineFlags:
+		op := w.op.Body.MustSetTrustLineFlagsOp()
+		result := operations.SetTrustLineFlags{
+			Base:    b,
+			Asset:   assetDetails(op.Asset),
+			Trustor: op.Trustor.Address(),
+		}
+		if op.SetFlags > 0 {
+			result.SetFlags, result.SetFlagsS = trustLineFlagDetails(xdr.TrustLineFlags(op.SetFlags))
+		}
+		if op.ClearFlags > 0 {
+			result.ClearFlags, result.ClearFlagsS = trustLineFlagDetails(xdr.TrustLineFlags(op.ClearFlags))
+		}
+		return result, nil
+	case xdr.OperationTypeLiquidityPoolDeposit:
+		return w.liquidityPoolDeposit(b)
+	case xdr.OperationTypeLiquidityPoolWithdraw:
+		return w.liquidityPoolWithdraw(b)
+	case xdr.OperationTypeInvokeHostFunction:
+		return w.invokeHostFunction(b)
+	case xdr.OperationTypeExtendFootprintTtl:
+		op := w.op.Body.MustExtendFootprintTtlOp()
+		return operations.ExtendFootprintTtl{Base: b, ExtendTo: uint32(op.ExtendTo)}, nil
+	case xdr.OperationTypeRestoreFootprint:
+		return operations.RestoreFootprint{Base: b}, nil
+	default:
+		return nil, fmt.Errorf("unknown operation type %s", w.op.Body.Type)
+	}
+}
+
+func (w *operationWrapper) payment(b operations.Base, destination xdr.MuxedAccount, asset xdr.Asset, value xdr.Int64) operations.Payment {
+	payment := operations.Payment{
+		Base:   b,
+		Asset:  assetDetails(asset),
+		Amount: amount.String(value),
+	}
+	payment.From, payment.FromMuxed, payment.FromMuxedID = muxedAccountDetails(w.source())
+	payment.To, payment.ToMuxed, payment.ToMuxedID = muxedAccountDetails(destination)
+	return payment
+}
+
+func pathDetails(path []xdr.Asset) []base.Asset {
+	result := make([]base.Asset, 0, len(path))
+	for _, asset := range path {
+		result = append(result, assetDetails(asset))
+	}
+	return result
+}
+
+func offerDetails(b operations.Base, value xdr.Int64, price xdr.Price, buying, selling xdr.Asset) operations.Offer {
+	buyingAsset, sellingAsset := assetDetails(buying), assetDetails(selling)
+	return operations.Offer{
+		Base:               b,
+		Amount:             amount.String(value),
+		Price:              price.String(),
+		PriceR:             base.Price{N: int32(price.N), D: int32(price.D)},
+		BuyingAssetType:    buyingAsset.Type,
+		BuyingAssetCode:    buyingAsset.Code,
+		BuyingAssetIssuer:  buyingAsset.Issuer,
+		SellingAssetType:   sellingAsset.Type,
+		SellingAssetCode:   sellingAsset.Code,
+		SellingAssetIssuer: sellingAsset.Issuer,
+	}
+}
+
+func setOptionsDetails(b operations.Base, op xdr.SetOptionsOp) operations.SetOptions {
+	result := operations.SetOptions{Base: b}
+	intPtr := func(v xdr.Uint32) *int {
+		i := int(v)
+		return &i
+	}
+
+	if op.InflationDest != nil {
+		result.InflationDest = op.InflationDest.Address()
+	}
+	if op.SetFlags != nil && *op.SetFlags > 0 {
+		result.SetFlags, result.SetFlagsS = accountFlagDetails(xdr.AccountFlags(*op.SetFlags))
+	}
+	if op.ClearFlags != nil && *op.ClearFlags > 0 {
+		result.ClearFlags, result.ClearFlagsS = accountFlagDetails(xdr.AccountFlags(*op.ClearFlags))
+	}
+	if op.MasterWeight != nil {
+		result.MasterKeyWeight = intPtr(*op.MasterWeight)
+	}
+	if op.LowThreshold != nil {
+		result.LowThreshold = intPtr(*op.LowThreshold)
+	}
+	if op.MedThreshold != nil {
+		result.MedThreshold = intPtr(*op.MedThreshold)
+	}
+	if op.HighThreshold != nil {
+		result.HighThreshold = intPtr(*op.HighThreshold)
+	}
+	if op.HomeDomain != nil {
+		result.HomeDomain = string(*op.HomeDomain)
+	}
+	if op.Signer != nil {
+		result.SignerKey = op.Signer.Key.Address()
+		result.SignerWeight = intPtr(op.Signer.Weight)
+	}
+	return result
+}
+
+func accountFlagDetails(flags xdr.AccountFlags) ([]int, []string) {
+	var (
+		n []int
+		s []string
+	)
+	if flags.IsAuthRequired() {
+		n = append(n, int(xdr.AccountFlagsAuthRequiredFlag))
+		s = append(s, "auth_required")
+	}
+	if flags.IsAuthRevocable() {
+		n = append(n, int(xdr.AccountFlagsAuthRevocableFlag))
+		s = append(s, "auth_revocable")
+	}
+	if flags.IsAuthImmutable() {
+		n = append(n, int(xdr.AccountFlagsAuthImmutableFlag))
+		s = append(s, "auth_immutable")
+	}
+	if flags.IsAuthClawbackEnabled() {
+		n = append(n, int(xdr.AccountFlagsAuthClawbackEnabledFlag))
+		s = append(s, "auth_clawback_enabled")
+	}
+	return n, s
+}
+
+func trustLineFlagDetails(flags xdr.TrustLineFlags) ([]int, []string) {
+	var (
+		n []int
+		s []string
+	)
+	if flags.IsAuthorized() {
+		n = append(n, int(xdr.TrustLineFlagsAuthorizedFlag))
+		s = append(s, "authorized")
+	}
+	if flags.IsAuthorizedToMaintainLiabilitiesFlag() {
+		n = append(n, int(xdr.TrustLineFlagsAuthorizedToMaintainLiabilitiesFlag))
+		// the misspelling is part of the Horizon API
+		s = append(s, "authorized_to_maintain_liabilites")
+	}
+	if flags.IsClawbackEnabledFlag() {
+		n = append(n, int(xdr.TrustLineFlagsTrustlineClawbackEnabledFlag))
+		s = append(s, "clawback_enabled")
+	}
+	return n, s
+}
+
+func revokeSponsorshipDetails(b operations.Base, op xdr.RevokeSponsorshipOp) (operations.RevokeSponsorship, error) {
+	result := operations.RevokeSponsorship{Base: b}
+	str := func(s string) *string { return &s }
+
+	switch op.Type {
+	case xdr.RevokeSponsorshipTypeRevokeSponsorshipLedgerEntry:
+		key := op.MustLedgerKey()
+		switch key.Type {
+		case xdr.LedgerEntryTypeAccount:
+			result.AccountID = str(key.MustAccount().AccountId.Address())
+		case xdr.LedgerEntryTypeClaimableBalance:
+			balanceID, err := xdr.MarshalHex(key.MustClaimableBalance().BalanceId)
+			if err != nil {
+				return result, err
+			}
+			result.ClaimableBalanceID = &balanceID
+		case xdr.LedgerEntryTypeData:
+			data := key.MustData()
+			result.DataAccountID = str(data.AccountId.Address())
+			result.DataName = str(string(data.DataName))
+		case xdr.LedgerEntryTypeOffer:
+			offerID := int64(key.MustOffer().OfferId)
+			result.OfferID = &offerID
+		case xdr.LedgerEntryTypeTrustline:
+			trustline := key.MustTrustLine()
+			result.TrustlineAccountID = str(trustline.AccountId.Address())
+			if trustline.Asset.Type == xdr.AssetTypeAssetTypePoolShare {
+				result.TrustlineLiquidityPoolID = str(hex.EncodeToString(trustline.Asset.LiquidityPoolId[:]))
+			} else {
+				result.TrustlineAsset = str(trustline.Asset.ToAsset().StringCanonical())
+			}
+		default:
+			return result, fmt.Errorf("unknown revoked ledger entry type %s", key.Type)
+		}
+	case xdr.RevokeSponsorshipTypeRevokeSponsorshipSigner:
+		signer := op.MustSigner()
+		result.SignerAccountID = str(signer.AccountId.Address())
+		result.SignerKey = str(signer.SignerKey.Address())
+	default:
+		return result, fmt.Errorf("unknown revoke sponsorship type %s", op.Type)
+	}
+	return result, nil
+}
+
+func (w *operationWrapper) liquidityPoolDeposit(b operations.Base) (operations.LiquidityPoolDeposit, error) {
+	op := w.op.Body.MustLiquidityPoolDepositOp()
+	// the assets are omitted and the amounts zero when the transaction failed
+	var (
+		assetA, assetB string
+		delta          liquidityPoolDelta
+	)
+	if w.successful() {
+		lp, lpDelta, err := w.liquidityPoolAndDelta(op.LiquidityPoolId)
+		if err != nil {
+			return operations.LiquidityPoolDeposit{}, err
+		}
+		params := lp.Body.MustConstantProduct().Params
+		assetA, assetB = params.AssetA.StringCanonical(), params.AssetB.StringCanonical()
+		delta = lpDelta
+	}
+	return operations.LiquidityPoolDeposit{
+		Base:            b,
+		LiquidityPoolID: hex.EncodeToString(op.LiquidityPoolId[:]),
+		ReservesMax: []base.AssetAmount{
+			{Asset: assetA, Amount: amount.String(op.MaxAmountA)},
+			{Asset: assetB, Amount: amount.String(op.MaxAmountB)},
+		},
+		MinPrice:  op.MinPrice.String(),
+		MinPriceR: base.Price{N: int32(op.MinPrice.N), D: int32(op.MinPrice.D)},
+		MaxPrice:  op.MaxPrice.String(),
+		MaxPriceR: base.Price{N: int32(op.MaxPrice.N), D: int32(op.MaxPrice.D)},
+		ReservesDeposited: []base.AssetAmount{
+			{Asset: assetA, Amount: amount.String(delta.ReserveA)},
+			{Asset: assetB, Amount: amount.String(delta.ReserveB)},
+		},
+		SharesReceived: amount.String(delta.TotalPoolShares),
+	}, nil
+}
+
+func (w *operationWrapper) liquidityPoolWithdraw(b operations.Base) (operations.LiquidityPoolWithdraw, error) {
+	op := w.op.Body.MustLiquidityPoolWithdrawOp()
+	// the assets are omitted and the amounts zero when the transaction failed
+	var (
+		assetA, assetB string
+		delta          liquidityPoolDelta
+	)
+	if w.successful() {
+		lp, lpDelta, err := w.liquidityPoolAndDelta(op.LiquidityPoolId)
+		if err != nil {
+			return operations.LiquidityPoolWithdraw{}, err
+		}
+		params := lp.Body.MustConstantProduct().Params
+		assetA, assetB = params.AssetA.StringCanonical(), params.AssetB.StringCanonical()
+		delta = lpDelta
+	}
+	return operations.LiquidityPoolWithdraw{
+		Base:            b,
+		LiquidityPoolID: hex.EncodeToString(op.LiquidityPoolId[:]),
+		ReservesMin: []base.AssetAmount{
+			{Asset: assetA, Amount: amount.String(op.MinAmountA)},
+			{Asset: assetB, Amount: amount.String(op.MinAmountB)},
+		},
+		Shares: amount.String(op.Amount),
+		ReservesReceived: []base.AssetAmount{
+			{Asset: assetA, Amount: amount.String(-delta.ReserveA)},
+			{Asset: assetB, Amount: amount.String(-delta.ReserveB)},
+		},
+	}, nil
+}
+
+type liquidityPoolDelta struct {
+	ReserveA        xdr.Int64
+	ReserveB        xdr.Int64
+	TotalPoolShares xdr.Int64
+}
+
+// liquidityPoolAndDelta returns the state of a liquidity pool after the
+// operation and how its reserves and shares changed.
+func (w *operationWrapper) liquidityPoolAndDelta(poolID xdr.PoolId) (*xdr.LiquidityPoolEntry, liquidityPoolDelta, error) {
+	for _, change := range w.changes {
+		if change.Type != xdr.LedgerEntryTypeLiquidityPool {
+			continue
+		}
+		var pre, post *xdr.LiquidityPoolEntry
+		if change.Pre != nil {
+			pre = change.Pre.Data.LiquidityPool
+		}
+		if change.Post != nil {
+			post = change.Post.Data.LiquidityPool
+		}
+		lp := post
+		if lp == nil {
+			lp = pre
+		}
+		if lp.LiquidityPoolId != poolID {
+			continue
+		}
+
+		var delta liquidityPoolDelta
+		if post != nil {
+			cp := post.Body.MustConstantProduct()
+			delta.ReserveA += cp.ReserveA
+			delta.ReserveB += cp.ReserveB
+			delta.TotalPoolShares += cp.TotalPoolShares
+		}
+		if pre != nil {
+			cp := pre.Body.MustConstantProduct()
+			delta.ReserveA -= cp.ReserveA
+			delta.ReserveB -= cp.ReserveB
+			delta.TotalPoolShares -= cp.TotalPoolShares
+		}
+		return lp, delta, nil
+	}
+	return nil, liquidityPoolDelta{}, errors.New("liquidity pool change not found")
+}
+
+func (w *operationWrapper) invokeHostFunction(b operations.Base) (operations.InvokeHostFunction, error) {
+	op := w.op.Body.MustInvokeHostFunctionOp()
+	result := operations.InvokeHostFunction{
+		Base:     b,
+		Function: op.HostFunction.Type.String(),
+	}
+
+	switch op.HostFunction.Type {
+	case xdr.HostFunctionTypeHostFunctionTypeInvokeContract:
+		invoke := op.HostFunction.MustInvokeContract()
+		args := make([]xdr.ScVal, 0, len(invoke.Args)+2)
+		args = append(args, xdr.ScVal{Type: xdr.ScValTypeScvAddress, Address: &invoke.ContractAddress})
+		args = append(args, xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &invoke.FunctionName})
+		args = append(args, invoke.Args...)
+		result.Parameters = parameterDetails(args)
+	case xdr.HostFunctionTypeHostFunctionTypeCreateContract:
+		if err := contractIDPreimageDetails(&result, op.HostFunction.MustCreateContract().ContractIdPreimage); err != nil {
+			return result, err
+		}
+	case xdr.HostFunctionTypeHostFunctionTypeCreateContractV2:
+		create := op.HostFunction.MustCreateContractV2()
+		if err := contractIDPreimageDetails(&result, create.ContractIdPreimage); err != nil {
+			return result, err
+		}
+		result.Parameters = parameterDetails(create.ConstructorArgs)
+	case xdr.HostFunctionTypeHostFunctionTypeUploadContractWasm:
+	default:
+		return result, fmt.Errorf("unknown host function type %s", op.HostFunction.Type)
+	}
+
+	changes, err := w.assetBalanceChanges()
+	if err != nil {
+		return result, err
+	}
+	result.AssetBalanceChanges = changes
+	return result, nil
+}
+
+func contractIDPreimageDetails(result *operations.InvokeHostFunction, preimage xdr.ContractIdPreimage) error {
+	if fromAddress, ok := preimage.GetFromAddress(); ok {
+		address, err := fromAddress.Address.String()
+		if err != nil {
+			return err
+		}
+		result.Address = address
+		result.Salt = fromAddress.Salt.String()
+	}
+	return nil
+}
+
+// parameterDetails returns the type and the base64 encoded XDR of every
+// parameter, or "n/a" when they cannot be determined.
+func parameterDetails(args []xdr.ScVal) []operations.HostFunctionParameter {
+	params := make([]operations.HostFunctionParameter, 0, len(args))
+	for _, arg := range args {
+		param := operations.HostFunctionParameter{Value: "n/a", Type: "n/a"}
+		if typeName, ok := arg.ArmForSwitch(int32(arg.Type)); ok {
+			param.Type = typeName
+			if raw, err := arg.MarshalBinary(); err == nil {
+				param.Value = base64.StdEncoding.EncodeToString(raw)
+			}
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+// assetBalanceChanges returns the balance changes reported by the Stellar
+// Asset Contract events of the operation.
+func (w *operationWrapper) assetBalanceChanges() ([]operations.AssetContractBalanceChange, error) {
+	changes := []operations.AssetContractBalanceChange{}
+	if !w.successful() {
+		return changes, nil
+	}
+	events, err := w.tx.GetContractEventsForOperation(w.index)
+	if err != nil {
+		return nil, err
+	}
+	for i := range events {
+		evt, err := contractevents.NewStellarAssetContractEvent(&events[i], w.processor.networkPassphrase)
+		if err != nil {
+			// not a Stellar Asset Contract event or an unsupported one
+			continue
+		}
+		change := operations.AssetContractBalanceChange{Asset: assetDetails(evt.GetAsset())}
+		var value xdr.Int128Parts
+		switch evt.GetType() {
+		case contractevents.EventTypeTransfer:
+			transfer := evt.(*contractevents.TransferEvent)
+			change.Type, change.From, change.To, value = "transfer", transfer.From, transfer.To, transfer.Amount
+			change.DestinationMuxedId = muxedIDDetails(transfer.ToMuxedID)
+		case contractevents.EventTypeMint:
+			mint := evt.(*contractevents.MintEvent)
+			change.Type, change.To, value = "mint", mint.To, mint.Amount
+			change.DestinationMuxedId = muxedIDDetails(mint.ToMuxedID)
+		case contractevents.EventTypeClawback:
+			clawback := evt.(*contractevents.ClawbackEvent)
+			change.Type, change.From, value = "clawback", clawback.From, clawback.Amount
+		case contractevents.EventTypeBurn:
+			burn := evt.(*contractevents.BurnEvent)
+			change.Type, change.From, value = "burn", burn.From, burn.Amount
+		default:
+			continue
+		}
+		change.Amount = amount.String128(value)
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+func muxedIDDetails(id *contractevents.MuxedID) string {
+	if id == nil || id.ID == nil {
+		return ""
+	}
+	return strconv.FormatUint(*id.ID, 10)
+}
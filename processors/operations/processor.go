@@ -0,0 +1,258 @@
+// Package operations builds the operation resources served by Horizon's
+// /operations endpoints directly from ledger data.
+package operations
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+
+	"github.com/stellar/go/ingest"
+	"github.com/stellar/go/protocols/horizon/base"
+	"github.com/stellar/go/protocols/horizon/operations"
+	"github.com/stellar/go/support/render/hal"
+	"github.com/stellar/go/toid"
+	"github.com/stellar/go/xdr"
+)
+
+// Processor generates Horizon compatible operation resources from
+// transactions.
+type Processor struct {
+	networkPassphrase string
+	links             hal.LinkBuilder
+}
+
+type Option func(*Processor)
+
+// WithBaseURL sets the Horizon URL against which the links of operations are
+// resolved. By default links are relative, e.g. "/operations/123".
+func WithBaseURL(baseURL *url.URL) Option {
+	return func(p *Processor) {
+		p.links.Base = baseURL
+	}
+}
+
+func NewProcessor(networkPassphrase string, options ...Option) *Processor {
+	p := &Processor{networkPassphrase: networkPassphrase}
+	for _, opt := range options {
+		opt(p)
+	}
+	return p
+}
+
+// OperationsFromLedger returns the operations of all the transactions of a
+// ledger, including those of failed transactions.
+func (p *Processor) OperationsFromLedger(lcm xdr.LedgerCloseMeta) ([]operations.Operation, error) {
+	reader, err := ingest.NewLedgerTransactionReaderFromLedgerCloseMeta(p.networkPassphrase, lcm)
+	if err != nil {
+		return nil, fmt.Errorf("error creating transaction reader: %w", err)
+	}
+	defer reader.Close()
+
+	var result []operations.Operation
+	for {
+		tx, err := reader.Read()
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading transaction: %w", err)
+		}
+		txOperations, err := p.OperationsFromTransaction(tx)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, txOperations...)
+	}
+}
+
+// OperationsFromTransaction returns the operations of a transaction. The
+// fields which depend on the operation results, such as the amount sent by
+// path payments, have zero values for failed transactions.
+func (p *Processor) OperationsFromTransaction(tx ingest.LedgerTransaction) ([]operations.Operation, error) {
+	ops := tx.Envelope.Operations()
+	result := make([]operations.Operation, 0, len(ops))
+	for i := range ops {
+		op, err := p.OperationFromTransaction(tx, uint32(i))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, op)
+	}
+	return result, nil
+}
+
+// OperationFromTransaction returns the operation at the given 0-indexed
+// position of a transaction.
+func (p *Processor) OperationFromTransaction(tx ingest.LedgerTransaction, opIndex uint32) (operations.Operation, error) {
+	op, ok := tx.GetOperation(opIndex)
+	if !ok {
+		return nil, fmt.Errorf("operation %d not found in transaction %s", opIndex, tx.Hash.HexString())
+	}
+
+	w := &operationWrapper{
+		processor: p,
+		tx:        &tx,
+		index:     opIndex,
+		op:        op,
+		id:        toid.New(int32(tx.Ledger.LedgerSequence()), int32(tx.Index), int32(opIndex+1)).ToInt64(),
+	}
+	if tx.Successful() {
+		results, ok := tx.Result.OperationResults()
+		if !ok || int(opIndex) >= len(results) {
+			return nil, fmt.Errorf("operation result %d not found in transaction %s", opIndex, tx.Hash.HexString())
+		}
+		w.result = &results[opIndex]
+		changes, err := tx.GetOperationChanges(opIndex)
+		if err != nil {
+			return nil, fmt.Errorf("error reading changes of operation %d in transaction %s: %w", opIndex, tx.Hash.HexString(), err)
+		}
+		w.changes = changes
+	}
+
+	result, err := w.operation()
+	if err != nil {
+		return nil, fmt.Errorf("error processing operation %d (%s) in transaction %s: %w",
+			opIndex, op.Body.Type, tx.Hash.HexString(), err)
+	}
+	return result, nil
+}
+
+// operationWrapper holds an operation along with the transaction it belongs
+// to. result and changes are only set for successful transactions.
+type operationWrapper struct {
+	processor *Processor
+	tx        *ingest.LedgerTransaction
+	index     uint32
+	op        xdr.Operation
+	result    *xdr.OperationResult
+	changes   []ingest.Change
+	id        int64
+}
+
+func (w *operationWrapper) successful() bool {
+	return w.result != nil
+}
+
+func (w *operationWrapper) source() xdr.MuxedAccount {
+	if w.op.SourceAccount != nil {
+		return *w.op.SourceAccount
+	}
+	return w.tx.Envelope.SourceAccount()
+}
+
+func (w *operationWrapper) base() operations.Base {
+	source, sourceMuxed, sourceMuxedID := muxedAccountDetails(w.source())
+	id := strconv.FormatInt(w.id, 10)
+	hash := w.tx.Hash.HexString()
+
+	b := operations.Base{
+		ID:                    id,
+		PT:                    id,
+		TransactionSuccessful: w.tx.Successful(),
+		SourceAccount:         source,
+		SourceAccountMuxed:    sourceMuxed,
+		SourceAccountMuxedID:  sourceMuxedID,
+		Type:                  operations.TypeNames[w.op.Body.Type],
+		TypeI:                 int32(w.op.Body.Type),
+		LedgerCloseTime:       w.tx.Ledger.ClosedAt(),
+		TransactionHash:       hash,
+	}
+	if sponsor := w.sponsor(); sponsor != nil {
+		b.Sponsor = sponsor.Address()
+	}
+
+	links := &w.processor.links
+	b.Links.Self = links.Link("/operations", id)
+	b.Links.Transaction = links.Linkf("/transactions/%s", hash)
+	b.Links.Effects = links.Link("/operations", id, "effects")
+	b.Links.Succeeds = links.Linkf("/effects?order=desc&cursor=%s", b.PT)
+	b.Links.Precedes = links.Linkf("/effects?order=asc&cursor=%s", b.PT)
+	return b
+}
+
+// sponsor returns the account sponsoring the ledger entry, or the signer,
+// created by the operation.
+func (w *operationWrapper) sponsor() *xdr.AccountId {
+	var signerKey string
+	if setOptions, ok := w.op.Body.GetSetOptionsOp(); ok && setOptions.Signer != nil {
+		signerKey = setOptions.Signer.Key.Address()
+	}
+
+	for _, change := range w.changes {
+		if signerKey != "" {
+			if sponsor := signerSponsorInChange(signerKey, change); sponsor != nil {
+				return sponsor
+			}
+		}
+		// only creations associate a sponsor to the entry of the operation
+		if change.Pre != nil || change.Post == nil {
+			continue
+		}
+		if sponsor := change.Post.SponsoringID(); sponsor != nil {
+			return sponsor
+		}
+	}
+	return nil
+}
+
+func signerSponsorInChange(signerKey string, change ingest.Change) *xdr.AccountId {
+	if change.Type != xdr.LedgerEntryTypeAccount || change.Post == nil {
+		return nil
+	}
+	before := map[string]xdr.AccountId{}
+	if change.Pre != nil {
+		before = change.Pre.Data.Account.SponsorPerSigner()
+	}
+	after := change.Post.Data.Account.SponsorPerSigner()
+
+	sponsor, ok := after[signerKey]
+	if !ok {
+		return nil
+	}
+	if former, ok := before[signerKey]; ok && former.Equals(sponsor) {
+		return nil
+	}
+	return &sponsor
+}
+
+// beginSponsoringOp returns the begin_sponsoring_future_reserves operation
+// which an end_sponsoring_future_reserves operation closes.
+func (w *operationWrapper) beginSponsoringOp() (xdr.Operation, bool) {
+	if !w.successful() {
+		return xdr.Operation{}, false
+	}
+	sponsored := w.source().ToAccountId()
+	ops := w.tx.Envelope.Operations()
+	for i := int(w.index) - 1; i >= 0; i-- {
+		begin, ok := ops[i].Body.GetBeginSponsoringFutureReservesOp()
+		if ok && begin.SponsoredId.Equals(sponsored) {
+			return ops[i], true
+		}
+	}
+	return xdr.Operation{}, false
+}
+
+func (w *operationWrapper) operationSource(op xdr.Operation) xdr.MuxedAccount {
+	if op.SourceAccount != nil {
+		return *op.SourceAccount
+	}
+	return w.tx.Envelope.SourceAccount()
+}
+
+func muxedAccountDetails(account xdr.MuxedAccount) (address, muxed string, muxedID uint64) {
+	address = account.ToAccountId().Address()
+	if account.Type == xdr.CryptoKeyTypeKeyTypeMuxedEd25519 {
+		muxed = account.Address()
+		muxedID = uint64(account.Med25519.Id)
+	}
+	return address, muxed, muxedID
+}
+
+func assetDetails(asset xdr.Asset) base.Asset {
+	var details base.Asset
+	// Extract cannot fail with string arguments
+	_ = asset.Extract(&details.Type, &details.Code, &details.Issuer)
+	return details
+}
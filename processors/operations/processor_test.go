@@ -0,0 +1,447 @@
+package operations
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/ingest"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/processors/internal/processorstest"
+	"github.com/stellar/go/protocols/horizon/base"
+	"github.com/stellar/go/protocols/horizon/operations"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/support/contractevents"
+	"github.com/stellar/go/xdr"
+)
+
+var (
+	passphrase = network.TestNetworkPassphrase
+	alice      = keypair.Root("alice").Address()
+	bob        = keypair.Root("bob").Address()
+	carol      = keypair.Root("carol").Address()
+	sponsor    = keypair.Root("sponsor").Address()
+	issuer     = keypair.Root("issuer").Address()
+	xlm        = xdr.MustNewNativeAsset()
+	usdc       = xdr.MustNewCreditAsset("USDC", issuer)
+	eurc       = xdr.MustNewCreditAsset("EURC", issuer)
+	poolID, _  = xdr.NewPoolId(xlm, usdc, xdr.LiquidityPoolFeeV18)
+)
+
+func muxedPayment() processorstest.Operation {
+	source, err := xdr.MuxedAccountFromAccountId(alice, 7)
+	if err != nil {
+		panic(err)
+	}
+	return processorstest.Operation{
+		Op: xdr.Operation{
+			SourceAccount: &source,
+			Body: xdr.OperationBody{
+				Type: xdr.OperationTypePayment,
+				PaymentOp: &xdr.PaymentOp{
+					Destination: xdr.MustMuxedAddress(bob),
+					Asset:       usdc,
+					Amount:      10_0000000,
+				},
+			},
+		},
+		Result: xdr.OperationResultTr{
+			Type:          xdr.OperationTypePayment,
+			PaymentResult: &xdr.PaymentResult{Code: xdr.PaymentResultCodePaymentSuccess},
+		},
+	}
+}
+
+func pathPaymentStrictReceive() processorstest.Operation {
+	return processorstest.Operation{
+		Op: xdr.Operation{Body: xdr.OperationBody{
+			Type: xdr.OperationTypePathPaymentStrictReceive,
+			PathPaymentStrictReceiveOp: &xdr.PathPaymentStrictReceiveOp{
+				SendAsset:   xlm,
+				SendMax:     25_0000000,
+				Destination: xdr.MustMuxedAddress(bob),
+				DestAsset:   usdc,
+				DestAmount:  10_0000000,
+				Path:        []xdr.Asset{eurc},
+			},
+		}},
+		Result: xdr.OperationResultTr{
+			Type: xdr.OperationTypePathPaymentStrictReceive,
+			PathPaymentStrictReceiveResult: &xdr.PathPaymentStrictReceiveResult{
+				Code: xdr.PathPaymentStrictReceiveResultCodePathPaymentStrictReceiveSuccess,
+				Success: &xdr.PathPaymentStrictReceiveResultSuccess{
+					Offers: []xdr.ClaimAtom{{
+						Type: xdr.ClaimAtomTypeClaimAtomTypeOrderBook,
+						OrderBook: &xdr.ClaimOfferAtom{
+							SellerId:     xdr.MustAddress(carol),
+							OfferId:      42,
+							AssetSold:    usdc,
+							AmountSold:   10_0000000,
+							AssetBought:  xlm,
+							AmountBought: 21_0000000,
+						},
+					}},
+					Last: xdr.SimplePaymentResult{
+						Destination: xdr.MustAddress(bob),
+						Asset:       usdc,
+						Amount:      10_0000000,
+					},
+				},
+			},
+		},
+	}
+}
+
+func setOptions() processorstest.Operation {
+	homeDomain := xdr.String32("example.com")
+	masterWeight, high := xdr.Uint32(0), xdr.Uint32(3)
+	setFlags := xdr.Uint32(xdr.AccountFlagsAuthRequiredFlag | xdr.AccountFlagsAuthRevocableFlag)
+	clearFlags := xdr.Uint32(xdr.AccountFlagsAuthClawbackEnabledFlag)
+	return processorstest.Operation{
+		Op: xdr.Operation{Body: xdr.OperationBody{
+			Type: xdr.OperationTypeSetOptions,
+			SetOptionsOp: &xdr.SetOptionsOp{
+				SetFlags:      &setFlags,
+				ClearFlags:    &clearFlags,
+				MasterWeight:  &masterWeight,
+				HighThreshold: &high,
+				HomeDomain:    &homeDomain,
+				Signer:        &xdr.Signer{Key: xdr.MustSigner(carol), Weight: 2},
+			},
+		}},
+		Result: xdr.OperationResultTr{
+			Type:             xdr.OperationTypeSetOptions,
+			SetOptionsResult: &xdr.SetOptionsResult{Code: xdr.SetOptionsResultCodeSetOptionsSuccess},
+		},
+	}
+}
+
+func liquidityPoolDeposit() processorstest.Operation {
+	return processorstest.Operation{
+		Op: xdr.Operation{Body: xdr.OperationBody{
+			Type: xdr.OperationTypeLiquidityPoolDeposit,
+			LiquidityPoolDepositOp: &xdr.LiquidityPoolDepositOp{
+				LiquidityPoolId: poolID,
+				MaxAmountA:      20_0000000,
+				MaxAmountB:      10_0000000,
+				MinPrice:        xdr.Price{N: 1, D: 3},
+				MaxPrice:        xdr.Price{N: 1, D: 1},
+			},
+		}},
+		Result: xdr.OperationResultTr{
+			Type: xdr.OperationTypeLiquidityPoolDeposit,
+			LiquidityPoolDepositResult: &xdr.LiquidityPoolDepositResult{
+				Code: xdr.LiquidityPoolDepositResultCodeLiquidityPoolDepositSuccess,
+			},
+		},
+		Changes: []xdr.LedgerEntryChanges{
+			processorstest.Updated(
+				processorstest.PoolEntry(xlm, usdc, 100_0000000, 50_0000000, 70_0000000),
+				processorstest.PoolEntry(xlm, usdc, 120_0000000, 60_0000000, 84_0000000),
+			),
+		},
+	}
+}
+
+// xlmContract returns the id and the address of the Stellar Asset Contract of
+// XLM.
+func xlmContract(t *testing.T) (xdr.Hash, string) {
+	contractID, err := xlm.ContractID(passphrase)
+	require.NoError(t, err)
+	return contractID, strkey.MustEncode(strkey.VersionByteContract, contractID[:])
+}
+
+func invokeHostFunction(t *testing.T) processorstest.Operation {
+	nativeContractID, contract := xlmContract(t)
+	contractAddress := xdr.ScAddress{Type: xdr.ScAddressTypeScAddressTypeContract, ContractId: (*xdr.ContractId)(&nativeContractID)}
+	amount := xdr.Int128Parts{Lo: 3_0000000}
+	return processorstest.Operation{
+		Op: xdr.Operation{Body: xdr.OperationBody{
+			Type: xdr.OperationTypeInvokeHostFunction,
+			InvokeHostFunctionOp: &xdr.InvokeHostFunctionOp{
+				HostFunction: xdr.HostFunction{
+					Type: xdr.HostFunctionTypeHostFunctionTypeInvokeContract,
+					InvokeContract: &xdr.InvokeContractArgs{
+						ContractAddress: contractAddress,
+						FunctionName:    "transfer",
+						Args:            []xdr.ScVal{{Type: xdr.ScValTypeScvI128, I128: &amount}},
+					},
+				},
+			},
+		}},
+		Result: xdr.OperationResultTr{
+			Type: xdr.OperationTypeInvokeHostFunction,
+			InvokeHostFunctionResult: &xdr.InvokeHostFunctionResult{
+				Code:    xdr.InvokeHostFunctionResultCodeInvokeHostFunctionSuccess,
+				Success: &xdr.Hash{},
+			},
+		},
+		Events: []xdr.ContractEvent{
+			contractevents.GenerateEvent(contractevents.EventTypeTransfer, alice, contract, "", xlm, big.NewInt(3_0000000), passphrase),
+			contractevents.GenerateEvent(contractevents.EventTypeTransfer, contract, bob, "", xlm, big.NewInt(1_0000000), passphrase),
+		},
+	}
+}
+
+// sponsorship returns a sandwich in which sponsor pays the reserve of the
+// account created for bob.
+func sponsorship() []processorstest.Operation {
+	return []processorstest.Operation{
+		{
+			Op: xdr.Operation{
+				SourceAccount: processorstest.Muxed(sponsor),
+				Body: xdr.OperationBody{
+					Type:                            xdr.OperationTypeBeginSponsoringFutureReserves,
+					BeginSponsoringFutureReservesOp: &xdr.BeginSponsoringFutureReservesOp{SponsoredId: xdr.MustAddress(alice)},
+				},
+			},
+			Result: xdr.OperationResultTr{
+				Type: xdr.OperationTypeBeginSponsoringFutureReserves,
+				BeginSponsoringFutureReservesResult: &xdr.BeginSponsoringFutureReservesResult{
+					Code: xdr.BeginSponsoringFutureReservesResultCodeBeginSponsoringFutureReservesSuccess,
+				},
+			},
+		},
+		{
+			Op: xdr.Operation{Body: xdr.OperationBody{
+				Type: xdr.OperationTypeCreateAccount,
+				CreateAccountOp: &xdr.CreateAccountOp{
+					Destination:     xdr.MustAddress(bob),
+					StartingBalance: 100_0000000,
+				},
+			}},
+			Result: xdr.OperationResultTr{
+				Type:                xdr.OperationTypeCreateAccount,
+				CreateAccountResult: &xdr.CreateAccountResult{Code: xdr.CreateAccountResultCodeCreateAccountSuccess},
+			},
+			Changes: []xdr.LedgerEntryChanges{
+				processorstest.Updated(processorstest.AccountEntry(alice, 1000_0000000), processorstest.AccountEntry(alice, 900_0000000)),
+				processorstest.Created(processorstest.Sponsored(processorstest.AccountEntry(bob, 100_0000000), sponsor)),
+			},
+		},
+		{
+			Op: xdr.Operation{Body: xdr.OperationBody{Type: xdr.OperationTypeEndSponsoringFutureReserves}},
+			Result: xdr.OperationResultTr{
+				Type: xdr.OperationTypeEndSponsoringFutureReserves,
+				EndSponsoringFutureReservesResult: &xdr.EndSponsoringFutureReservesResult{
+					Code: xdr.EndSponsoringFutureReservesResultCodeEndSponsoringFutureReservesSuccess,
+				},
+			},
+		},
+	}
+}
+
+// singleOperation returns the only operation of a transaction of alice, after
+// checking its type.
+func singleOperation(t *testing.T, tx ingest.LedgerTransaction, operationType string) operations.Operation {
+	result, err := NewProcessor(passphrase).OperationsFromTransaction(tx)
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	op := result[0]
+	assert.Equal(t, operationType, op.GetType())
+	assert.Equal(t, "429496733697", op.GetID())
+	assert.Equal(t, "abcd"+strings.Repeat("0", 60), op.GetTransactionHash())
+	assert.Equal(t, processorstest.CloseTime, op.GetBase().LedgerCloseTime)
+	return op
+}
+
+func TestMuxedPaymentOperation(t *testing.T) {
+	op := singleOperation(t, processorstest.Transaction(alice, muxedPayment()), "payment")
+	source, err := xdr.MuxedAccountFromAccountId(alice, 7)
+	require.NoError(t, err)
+
+	payment := op.(operations.Payment)
+	assert.True(t, payment.TransactionSuccessful)
+	assert.Equal(t, alice, payment.SourceAccount)
+	assert.Equal(t, source.Address(), payment.SourceAccountMuxed)
+	assert.Equal(t, uint64(7), payment.SourceAccountMuxedID)
+	assert.Equal(t, alice, payment.From)
+	assert.Equal(t, source.Address(), payment.FromMuxed)
+	assert.Equal(t, uint64(7), payment.FromMuxedID)
+	assert.Equal(t, bob, payment.To)
+	assert.Equal(t, base.Asset{Type: "credit_alphanum4", Code: "USDC", Issuer: issuer}, payment.Asset)
+	assert.Equal(t, "10.0000000", payment.Amount)
+}
+
+func TestPathPaymentStrictReceiveOperation(t *testing.T) {
+	op := singleOperation(t, processorstest.Transaction(alice, pathPaymentStrictReceive()), "path_payment_strict_receive")
+
+	payment := op.(operations.PathPayment)
+	assert.Equal(t, alice, payment.From)
+	assert.Equal(t, bob, payment.To)
+	assert.Equal(t, "USDC", payment.Code)
+	assert.Equal(t, "10.0000000", payment.Amount)
+	assert.Equal(t, []base.Asset{{Type: "credit_alphanum4", Code: "EURC", Issuer: issuer}}, payment.Path)
+	assert.Equal(t, "native", payment.SourceAssetType)
+	assert.Equal(t, "25.0000000", payment.SourceMax)
+	// the amount sent is the sum of the amounts bought from the offers
+	assert.Equal(t, "21.0000000", payment.SourceAmount)
+
+	// failed path payments sent nothing
+	op = singleOperation(t, processorstest.Failed(processorstest.Transaction(alice, pathPaymentStrictReceive())), "path_payment_strict_receive")
+	payment = op.(operations.PathPayment)
+	assert.False(t, payment.TransactionSuccessful)
+	assert.Equal(t, "10.0000000", payment.Amount)
+	assert.Equal(t, "0.0000000", payment.SourceAmount)
+}
+
+func TestSetOptionsOperation(t *testing.T) {
+	op := singleOperation(t, processorstest.Transaction(alice, setOptions()), "set_options")
+
+	setOptions := op.(operations.SetOptions)
+	assert.Equal(t, "example.com", setOptions.HomeDomain)
+	require.NotNil(t, setOptions.MasterKeyWeight)
+	assert.Equal(t, 0, *setOptions.MasterKeyWeight)
+	assert.Equal(t, carol, setOptions.SignerKey)
+	require.NotNil(t, setOptions.SignerWeight)
+	assert.Equal(t, 2, *setOptions.SignerWeight)
+	assert.Equal(t, []int{1, 2}, setOptions.SetFlags)
+	assert.Equal(t, []string{"auth_required", "auth_revocable"}, setOptions.SetFlagsS)
+	assert.Equal(t, []int{8}, setOptions.ClearFlags)
+	assert.Equal(t, []string{"auth_clawback_enabled"}, setOptions.ClearFlagsS)
+	assert.Nil(t, setOptions.LowThreshold)
+	assert.Nil(t, setOptions.MedThreshold)
+	require.NotNil(t, setOptions.HighThreshold)
+	assert.Equal(t, 3, *setOptions.HighThreshold)
+}
+
+func TestLiquidityPoolDepositOperation(t *testing.T) {
+	op := singleOperation(t, processorstest.Transaction(alice, liquidityPoolDeposit()), "liquidity_pool_deposit")
+
+	deposit := op.(operations.LiquidityPoolDeposit)
+	assert.Equal(t, hex.EncodeToString(poolID[:]), deposit.LiquidityPoolID)
+	assert.Equal(t, []base.AssetAmount{
+		{Asset: "native", Amount: "20.0000000"},
+		{Asset: "USDC:" + issuer, Amount: "10.0000000"},
+	}, deposit.ReservesMax)
+	assert.Equal(t, "0.3333333", deposit.MinPrice)
+	assert.Equal(t, base.Price{N: 1, D: 3}, deposit.MinPriceR)
+	assert.Equal(t, "1.0000000", deposit.MaxPrice)
+	// the amounts deposited and the shares received are the changes of the pool
+	assert.Equal(t, []base.AssetAmount{
+		{Asset: "native", Amount: "20.0000000"},
+		{Asset: "USDC:" + issuer, Amount: "10.0000000"},
+	}, deposit.ReservesDeposited)
+	assert.Equal(t, "14.0000000", deposit.SharesReceived)
+
+	// without the pool in the meta of failed transactions, the assets are unknown
+	op = singleOperation(t, processorstest.Failed(processorstest.Transaction(alice, liquidityPoolDeposit())), "liquidity_pool_deposit")
+	deposit = op.(operations.LiquidityPoolDeposit)
+	assert.False(t, deposit.TransactionSuccessful)
+	assert.Equal(t, []base.AssetAmount{{Amount: "20.0000000"}, {Amount: "10.0000000"}}, deposit.ReservesMax)
+	assert.Equal(t, []base.AssetAmount{{Amount: "0.0000000"}, {Amount: "0.0000000"}}, deposit.ReservesDeposited)
+	assert.Equal(t, "0.0000000", deposit.SharesReceived)
+}
+
+func TestInvokeHostFunctionOperation(t *testing.T) {
+	op := singleOperation(t, processorstest.Transaction(alice, invokeHostFunction(t)), "invoke_host_function")
+	_, contract := xlmContract(t)
+
+	invoke := op.(operations.InvokeHostFunction)
+	assert.Equal(t, "HostFunctionTypeHostFunctionTypeInvokeContract", invoke.Function)
+	var parameterTypes []string
+	for _, parameter := range invoke.Parameters {
+		parameterTypes = append(parameterTypes, parameter.Type)
+	}
+	assert.Equal(t, []string{"Address", "Sym", "I128"}, parameterTypes)
+	// the transfer events of the Stellar Asset Contract are the balance changes
+	assert.Equal(t, []operations.AssetContractBalanceChange{
+		{Asset: base.Asset{Type: "native"}, Type: "transfer", From: alice, To: contract, Amount: "3.0000000"},
+		{Asset: base.Asset{Type: "native"}, Type: "transfer", From: contract, To: bob, Amount: "1.0000000"},
+	}, invoke.AssetBalanceChanges)
+}
+
+func TestSponsorshipOperations(t *testing.T) {
+	result, err := NewProcessor(passphrase).OperationsFromTransaction(processorstest.Transaction(alice, sponsorship()...))
+	require.NoError(t, err)
+	require.Len(t, result, 3)
+
+	begin := result[0].(operations.BeginSponsoringFutureReserves)
+	assert.Equal(t, sponsor, begin.SourceAccount)
+	assert.Equal(t, alice, begin.SponsoredID)
+	createAccount := result[1].(operations.CreateAccount)
+	assert.Equal(t, "429496733698", createAccount.ID)
+	assert.Equal(t, alice, createAccount.Funder)
+	assert.Equal(t, bob, createAccount.Account)
+	assert.Equal(t, "100.0000000", createAccount.StartingBalance)
+	assert.Equal(t, sponsor, createAccount.Sponsor)
+	end := result[2].(operations.EndSponsoringFutureReserves)
+	assert.Equal(t, alice, end.SourceAccount)
+	assert.Equal(t, sponsor, end.BeginSponsor)
+}
+
+func TestOperationFromTransaction(t *testing.T) {
+	tx := processorstest.Transaction(alice, sponsorship()...)
+	op, err := NewProcessor(passphrase).OperationFromTransaction(tx, 1)
+	require.NoError(t, err)
+
+	createAccount, ok := op.(operations.CreateAccount)
+	require.True(t, ok)
+	assert.Equal(t, "429496733698", createAccount.ID)
+	assert.Equal(t, sponsor, createAccount.Sponsor)
+	assert.Equal(t, bob, createAccount.Account)
+
+	_, err = NewProcessor(passphrase).OperationFromTransaction(tx, 3)
+	assert.ErrorContains(t, err, "operation 3 not found")
+}
+
+func TestOperationsMissingChanges(t *testing.T) {
+	op := liquidityPoolDeposit()
+	op.Changes = nil
+	_, err := NewProcessor(passphrase).OperationsFromTransaction(processorstest.Transaction(alice, op))
+	assert.ErrorContains(t, err, "liquidity pool change not found")
+}
+
+// recordedOperation returns the only operation of a transaction recorded from
+// Horizon, after checking it against the fields of the transaction served by
+// Horizon.
+func recordedOperation(t *testing.T, name string) operations.Operation {
+	served, tx, err := processorstest.HorizonTransaction(name)
+	require.NoError(t, err)
+	result, err := NewProcessor(passphrase).OperationsFromTransaction(tx)
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+
+	// the first operation follows its transaction in Horizon's ids
+	id, err := strconv.ParseInt(served.PT, 10, 64)
+	require.NoError(t, err)
+	op := result[0]
+	assert.Equal(t, strconv.FormatInt(id+1, 10), op.GetID())
+	assert.Equal(t, op.GetID(), op.PagingToken())
+	assert.Equal(t, served.Hash, op.GetTransactionHash())
+	assert.Equal(t, served.Successful, op.IsTransactionSuccessful())
+	assert.Equal(t, served.Account, op.GetBase().SourceAccount)
+	assert.True(t, served.LedgerCloseTime.Equal(op.GetBase().LedgerCloseTime))
+	return op
+}
+
+func TestRecordedCreateAccountOperation(t *testing.T) {
+	op := recordedOperation(t, "testnet_create_account.json")
+
+	createAccount, ok := op.(operations.CreateAccount)
+	require.True(t, ok)
+	assert.Equal(t, "create_account", createAccount.GetType())
+	assert.Equal(t, int32(0), createAccount.TypeI)
+	assert.Equal(t, "GAIH3ULLFQ4DGSECF2AR555KZ4KNDGEKN4AFI4SU2M7B43MGK3QJZNSR", createAccount.Funder)
+	assert.Equal(t, "GDHPNWUTDBWACZQE2ICEG73KL5O2MFAKOH6LYTBSIIRU4JMC4Y2RELOJ", createAccount.Account)
+	assert.Equal(t, "10000.0000000", createAccount.StartingBalance)
+}
+
+func TestRecordedPaymentOperation(t *testing.T) {
+	op := recordedOperation(t, "testnet_payment.json")
+
+	payment, ok := op.(operations.Payment)
+	require.True(t, ok)
+	assert.Equal(t, "payment", payment.GetType())
+	assert.Equal(t, int32(1), payment.GetBase().TypeI)
+	assert.Equal(t, "GDRZVYB5QI6UFR4NR4RXQ3HR5IH4KL2ECR4IUZXGHOUMPGLN2OGCSAOK", payment.From)
+	assert.Equal(t, "GBG6H3TO4WCZ37M6QA3U5BOWBYZH2QXDE2NJ75KRUIQNDSCYZUKKKJHM", payment.To)
+	assert.Equal(t, base.Asset{Type: "native"}, payment.Asset)
+	assert.Equal(t, "987.0000000", payment.Amount)
+}
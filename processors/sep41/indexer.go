@@ -0,0 +1,348 @@
+// Package sep41 indexes SEP-41 token metadata and holder balances from the
+// token transfer events emitted by processors/token_transfer.
+package sep41
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+
+	"github.com/stellar/go/ingest"
+	"github.com/stellar/go/ingest/sac"
+	"github.com/stellar/go/processors/token_transfer"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/xdr"
+)
+
+// Token describes a SEP-41 token known to the Indexer.
+type Token struct {
+	// ContractID is the strkey of the token contract.
+	ContractID string
+	// Metadata is the token metadata read from the contract instance storage.
+	// It is only valid if HasMetadata is true.
+	Metadata    sac.TokenMetadata
+	HasMetadata bool
+	// Asset is the classic asset wrapped by the token, if the token is a
+	// Stellar Asset Contract.
+	Asset *xdr.Asset
+}
+
+// BalanceMismatch is reported when a Stellar Asset Contract balance entry
+// disagrees with the balance derived from token transfer events. The indexed
+// balance is reset to the value found in the ledger entry.
+type BalanceMismatch struct {
+	Ledger     uint32
+	TxHash     string
+	ContractID string
+	Holder     string
+	// Expected is the balance found in the contract data ledger entry.
+	Expected *big.Int
+	// Indexed is the balance derived from token transfer events.
+	Indexed *big.Int
+}
+
+func (m BalanceMismatch) String() string {
+	return fmt.Sprintf(
+		"balance mismatch for holder %s of token %s in ledger %d (tx %s): expected %s, indexed %s",
+		m.Holder, m.ContractID, m.Ledger, m.TxHash, m.Expected, m.Indexed,
+	)
+}
+
+type holderKey struct {
+	contractID string
+	holder     string
+}
+
+// Indexer keeps track of the metadata of every token seen and of the balance
+// of every holder of those tokens.
+//
+// Balances are derived from token transfer events, so a holder's balance is
+// only accurate if the Indexer has processed every ledger since the holder
+// first received the token, or if the balance was seeded with
+// ProcessContractData. Balances of contract holders of Stellar Asset
+// Contracts are anchored to, and verified against, the balance entries
+// written by the contract whenever they change. Balances of other holders
+// may therefore be negative if the Indexer started in the middle of the
+// token's history.
+type Indexer struct {
+	networkPassphrase string
+	processor         *token_transfer.EventsProcessor
+	tokens            map[string]*Token
+	balances          map[string]map[string]*big.Int
+	anchored          map[holderKey]bool
+}
+
+// NewIndexer creates an Indexer. The options configure the underlying
+// token_transfer.EventsProcessor.
+func NewIndexer(networkPassphrase string, options ...token_transfer.EventsProcessorOption) *Indexer {
+	indexer := &Indexer{
+		networkPassphrase: networkPassphrase,
+		processor:         token_transfer.NewEventsProcessor(networkPassphrase, options...),
+		tokens:            map[string]*Token{},
+		balances:          map[string]map[string]*big.Int{},
+		anchored:          map[holderKey]bool{},
+	}
+
+	// The native Stellar Asset Contract does not store any metadata.
+	native := xdr.MustNewNativeAsset()
+	if contractID, err := native.ContractID(networkPassphrase); err == nil {
+		indexer.tokens[strkey.MustEncode(strkey.VersionByteContract, contractID[:])] = &Token{
+			ContractID:  strkey.MustEncode(strkey.VersionByteContract, contractID[:]),
+			Metadata:    sac.TokenMetadata{Decimal: 7, Name: "native", Symbol: "native"},
+			HasMetadata: true,
+			Asset:       &native,
+		}
+	}
+	return indexer
+}
+
+// ProcessLedger updates the index with every transaction in the ledger and
+// returns the balance mismatches found along the way.
+func (i *Indexer) ProcessLedger(lcm xdr.LedgerCloseMeta) ([]BalanceMismatch, error) {
+	txReader, err := ingest.NewLedgerTransactionReaderFromLedgerCloseMeta(i.networkPassphrase, lcm)
+	if err != nil {
+		return nil, fmt.Errorf("error creating transaction reader: %w", err)
+	}
+	defer txReader.Close()
+
+	var mismatches []BalanceMismatch
+	for {
+		tx, err := txReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading transaction: %w", err)
+		}
+		txMismatches, err := i.ProcessTransaction(tx)
+		if err != nil {
+			return nil, err
+		}
+		mismatches = append(mismatches, txMismatches...)
+	}
+	return mismatches, nil
+}
+
+// ProcessTransaction updates the index with the token transfer events and
+// contract data changes of a single transaction and returns the balance
+// mismatches found.
+func (i *Indexer) ProcessTransaction(tx ingest.LedgerTransaction) ([]BalanceMismatch, error) {
+	changes, err := tx.GetChanges()
+	if err != nil {
+		return nil, fmt.Errorf("error reading changes for txHash: %v, error: %w", tx.Hash.HexString(), err)
+	}
+
+	// Anchor holders seen for the first time to their balance before the
+	// transaction so that the events below can be verified.
+	for _, change := range changes {
+		if change.Type != xdr.LedgerEntryTypeContractData || change.Post == nil {
+			continue
+		}
+		key, _, ok := i.contractBalance(*change.Post)
+		if !ok || i.anchored[key] {
+			continue
+		}
+		pre := new(big.Int)
+		if change.Pre != nil {
+			if _, amount, ok := i.contractBalance(*change.Pre); ok {
+				pre = amount
+			}
+		}
+		i.setBalance(key, pre)
+	}
+
+	txEvents, err := i.processor.EventsFromTransaction(tx)
+	if err != nil {
+		return nil, fmt.Errorf("error reading events for txHash: %v, error: %w", tx.Hash.HexString(), err)
+	}
+	for _, events := range [][]*token_transfer.TokenTransferEvent{txEvents.FeeEvents, txEvents.OperationEvents} {
+		for _, event := range events {
+			if err := i.ProcessEvent(event); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var mismatches []BalanceMismatch
+	for _, change := range changes {
+		if change.Type != xdr.LedgerEntryTypeContractData || change.Post == nil {
+			continue
+		}
+		i.processMetadata(*change.Post)
+
+		key, expected, ok := i.contractBalance(*change.Post)
+		if !ok {
+			continue
+		}
+		if indexed := i.balance(key); indexed.Cmp(expected) != 0 {
+			mismatches = append(mismatches, BalanceMismatch{
+				Ledger:     tx.Ledger.LedgerSequence(),
+				TxHash:     tx.Hash.HexString(),
+				ContractID: key.contractID,
+				Holder:     key.holder,
+				Expected:   new(big.Int).Set(expected),
+				Indexed:    new(big.Int).Set(indexed),
+			})
+		}
+		i.setBalance(key, expected)
+	}
+	return mismatches, nil
+}
+
+// ProcessEvent applies a single token transfer event to the holder balances.
+func (i *Indexer) ProcessEvent(event *token_transfer.TokenTransferEvent) error {
+	contractID := event.GetMeta().GetContractAddress()
+	if contractID == "" {
+		return fmt.Errorf("token transfer event has no contract address")
+	}
+	from, to, value, err := event.GetMovement()
+	if err != nil {
+		return err
+	}
+	amount, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return fmt.Errorf("invalid amount %q in %s event for token %s", value, event.GetEventType(), contractID)
+	}
+
+	token := i.token(contractID)
+	if token.Asset == nil && event.GetAsset() != nil {
+		asset := event.GetAsset().ToXdrAsset()
+		token.Asset = &asset
+	}
+
+	if from != "" {
+		i.addBalance(holderKey{contractID, from}, new(big.Int).Neg(amount))
+	}
+	if to != "" {
+		i.addBalance(holderKey{contractID, to}, amount)
+	}
+	return nil
+}
+
+// ProcessContractData seeds the index from a contract data ledger entry, for
+// example one read from a checkpoint. Token metadata is recorded and Stellar
+// Asset Contract balance entries set the balance of the holder.
+func (i *Indexer) ProcessContractData(entry xdr.LedgerEntry) {
+	i.processMetadata(entry)
+	if key, amount, ok := i.contractBalance(entry); ok {
+		i.setBalance(key, amount)
+	}
+}
+
+// Token returns the token with the given contract strkey.
+func (i *Indexer) Token(contractID string) (Token, bool) {
+	token, ok := i.tokens[contractID]
+	if !ok {
+		return Token{}, false
+	}
+	return *token, true
+}
+
+// Tokens returns every known token, sorted by contract ID.
+func (i *Indexer) Tokens() []Token {
+	tokens := make([]Token, 0, len(i.tokens))
+	for _, token := range i.tokens {
+		tokens = append(tokens, *token)
+	}
+	sort.Slice(tokens, func(a, b int) bool {
+		return tokens[a].ContractID < tokens[b].ContractID
+	})
+	return tokens
+}
+
+// Balance returns the balance of holder for the token with the given contract
+// strkey. Unknown holders have a zero balance.
+func (i *Indexer) Balance(contractID, holder string) *big.Int {
+	return new(big.Int).Set(i.balance(holderKey{contractID, holder}))
+}
+
+// Balances returns a copy of the balances of every holder of the token with
+// the given contract strkey.
+func (i *Indexer) Balances(contractID string) map[string]*big.Int {
+	balances := make(map[string]*big.Int, len(i.balances[contractID]))
+	for holder, balance := range i.balances[contractID] {
+		balances[holder] = new(big.Int).Set(balance)
+	}
+	return balances
+}
+
+func (i *Indexer) token(contractID string) *Token {
+	token, ok := i.tokens[contractID]
+	if !ok {
+		token = &Token{ContractID: contractID}
+		i.tokens[contractID] = token
+	}
+	return token
+}
+
+func (i *Indexer) processMetadata(entry xdr.LedgerEntry) {
+	contractData, ok := entry.Data.GetContractData()
+	if !ok || contractData.Contract.ContractId == nil {
+		return
+	}
+	metadata, hasMetadata := sac.MetadataFromContractData(entry)
+	asset, isSAC := sac.AssetFromContractData(entry, i.networkPassphrase)
+	if !hasMetadata && !isSAC {
+		return
+	}
+
+	token := i.token(strkey.MustEncode(strkey.VersionByteContract, contractData.Contract.ContractId[:]))
+	if hasMetadata {
+		token.Metadata, token.HasMetadata = metadata, true
+	}
+	if isSAC {
+		token.Asset = &asset
+	}
+}
+
+func (i *Indexer) contractBalance(entry xdr.LedgerEntry) (holderKey, *big.Int, bool) {
+	holder, amount, ok := sac.ContractBalanceFromContractData(entry, i.networkPassphrase)
+	if !ok {
+		return holderKey{}, nil, false
+	}
+	contractData := entry.Data.MustContractData()
+	if contractData.Contract.ContractId == nil {
+		return holderKey{}, nil, false
+	}
+	key := holderKey{
+		contractID: strkey.MustEncode(strkey.VersionByteContract, contractData.Contract.ContractId[:]),
+		holder:     strkey.MustEncode(strkey.VersionByteContract, holder[:]),
+	}
+	return key, amount, true
+}
+
+func (i *Indexer) balance(key holderKey) *big.Int {
+	if balance, ok := i.balances[key.contractID][key.holder]; ok {
+		return balance
+	}
+	return new(big.Int)
+}
+
+func (i *Indexer) setBalance(key holderKey, amount *big.Int) {
+	i.token(key.contractID)
+	holders, ok := i.balances[key.contractID]
+	if !ok {
+		holders = map[string]*big.Int{}
+		i.balances[key.contractID] = holders
+	}
+	holders[key.holder] = new(big.Int).Set(amount)
+	i.anchored[key] = true
+}
+
+func (i *Indexer) addBalance(key holderKey, delta *big.Int) {
+	if key.holder == "" {
+		return
+	}
+	holders, ok := i.balances[key.contractID]
+	if !ok {
+		holders = map[string]*big.Int{}
+		i.balances[key.contractID] = holders
+	}
+	balance, ok := holders[key.holder]
+	if !ok {
+		balance = new(big.Int)
+		holders[key.holder] = balance
+	}
+	balance.Add(balance, delta)
+}
@@ -0,0 +1,267 @@
+package sep41
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/ingest"
+	"github.com/stellar/go/ingest/sac"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/processors/token_transfer"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/support/contractevents"
+	"github.com/stellar/go/xdr"
+)
+
+var (
+	passphrase = network.TestNetworkPassphrase
+	issuer     = keypair.MustRandom().Address()
+	feePayer   = keypair.MustRandom().Address()
+	usdc       = xdr.MustNewCreditAsset("USDC", issuer)
+	holderA    = [32]byte{1}
+	holderB    = [32]byte{2}
+)
+
+func contractStrkey(id [32]byte) string {
+	return strkey.MustEncode(strkey.VersionByteContract, id[:])
+}
+
+func usdcContractID(t *testing.T) [32]byte {
+	contractID, err := usdc.ContractID(passphrase)
+	require.NoError(t, err)
+	return contractID
+}
+
+func instanceEntry(t *testing.T) xdr.LedgerEntry {
+	data, err := sac.AssetToContractData(false, "USDC", issuer, usdcContractID(t))
+	require.NoError(t, err)
+	return xdr.LedgerEntry{LastModifiedLedgerSeq: 10, Data: data}
+}
+
+func balanceEntry(t *testing.T, holder [32]byte, amount uint64) xdr.LedgerEntry {
+	return xdr.LedgerEntry{
+		LastModifiedLedgerSeq: 10,
+		Data:                  sac.BalanceToContractData(usdcContractID(t), holder, amount),
+	}
+}
+
+func i128(amount int64) xdr.ScVal {
+	return xdr.ScVal{Type: xdr.ScValTypeScvI128, I128: &xdr.Int128Parts{Lo: xdr.Uint64(amount)}}
+}
+
+func feeEvent(t *testing.T, amount int64) xdr.ContractEvent {
+	xlmContractID, err := xdr.MustNewNativeAsset().ContractID(passphrase)
+	require.NoError(t, err)
+	contractID := xdr.ContractId(xlmContractID)
+	fee := xdr.ScSymbol("fee")
+	from, err := xdr.AddressToAccountId(feePayer)
+	require.NoError(t, err)
+	return xdr.ContractEvent{
+		Type:       xdr.ContractEventTypeContract,
+		ContractId: &contractID,
+		Body: xdr.ContractEventBody{
+			V: 0,
+			V0: &xdr.ContractEventV0{
+				Topics: []xdr.ScVal{
+					{Type: xdr.ScValTypeScvSymbol, Sym: &fee},
+					{Type: xdr.ScValTypeScvAddress, Address: &xdr.ScAddress{
+						Type:      xdr.ScAddressTypeScAddressTypeAccount,
+						AccountId: &from,
+					}},
+				},
+				Data: i128(amount),
+			},
+		},
+	}
+}
+
+// transferTransaction builds a successful transaction moving amount USDC from
+// holderA to holderB with the given contract data changes.
+func transferTransaction(t *testing.T, amount int64, changes xdr.LedgerEntryChanges) ingest.LedgerTransaction {
+	transfer := contractevents.GenerateEvent(
+		contractevents.EventTypeTransfer,
+		contractStrkey(holderA), contractStrkey(holderB), "",
+		usdc, big.NewInt(amount), passphrase,
+	)
+	source := xdr.MustMuxedAddress(feePayer)
+	return ingest.LedgerTransaction{
+		Index: 1,
+		Envelope: xdr.TransactionEnvelope{
+			Type: xdr.EnvelopeTypeEnvelopeTypeTx,
+			V1: &xdr.TransactionV1Envelope{
+				Tx: xdr.Transaction{
+					SourceAccount: source,
+					Operations: []xdr.Operation{{
+						Body: xdr.OperationBody{
+							Type:                 xdr.OperationTypeInvokeHostFunction,
+							InvokeHostFunctionOp: &xdr.InvokeHostFunctionOp{},
+						},
+					}},
+				},
+			},
+		},
+		Result: xdr.TransactionResultPair{
+			Result: xdr.TransactionResult{
+				FeeCharged: 100,
+				Result: xdr.TransactionResultResult{
+					Code:    xdr.TransactionResultCodeTxSuccess,
+					Results: &[]xdr.OperationResult{},
+				},
+			},
+		},
+		UnsafeMeta: xdr.TransactionMeta{
+			V: 4,
+			V4: &xdr.TransactionMetaV4{
+				Operations: []xdr.OperationMetaV2{{
+					Changes: changes,
+					Events:  []xdr.ContractEvent{transfer},
+				}},
+				Events: []xdr.TransactionEvent{{
+					Stage: xdr.TransactionEventStageTransactionEventStageBeforeAllTxs,
+					Event: feeEvent(t, 100),
+				}},
+			},
+		},
+		LedgerVersion: 23,
+		Ledger: xdr.LedgerCloseMeta{
+			V: 1,
+			V1: &xdr.LedgerCloseMetaV1{
+				LedgerHeader: xdr.LedgerHeaderHistoryEntry{
+					Header: xdr.LedgerHeader{LedgerSeq: 11, LedgerVersion: 23},
+				},
+			},
+		},
+	}
+}
+
+func updated(pre, post xdr.LedgerEntry) xdr.LedgerEntryChanges {
+	return xdr.LedgerEntryChanges{
+		{Type: xdr.LedgerEntryChangeTypeLedgerEntryState, State: &pre},
+		{Type: xdr.LedgerEntryChangeTypeLedgerEntryUpdated, Updated: &post},
+	}
+}
+
+func TestMetadataFromContractData(t *testing.T) {
+	metadata, ok := sac.MetadataFromContractData(instanceEntry(t))
+	require.True(t, ok)
+	assert.Equal(t, sac.TokenMetadata{Decimal: 7, Name: "USDC:" + issuer, Symbol: "USDC"}, metadata)
+
+	_, ok = sac.MetadataFromContractData(balanceEntry(t, holderA, 1))
+	assert.False(t, ok)
+}
+
+func TestIndexerSeedsFromContractData(t *testing.T) {
+	indexer := NewIndexer(passphrase)
+	indexer.ProcessContractData(instanceEntry(t))
+	indexer.ProcessContractData(balanceEntry(t, holderA, 500))
+
+	token, ok := indexer.Token(contractStrkey(usdcContractID(t)))
+	require.True(t, ok)
+	assert.True(t, token.HasMetadata)
+	assert.Equal(t, "USDC", token.Metadata.Symbol)
+	require.NotNil(t, token.Asset)
+	assert.True(t, token.Asset.Equals(usdc))
+
+	assert.Equal(t, big.NewInt(500), indexer.Balance(token.ContractID, contractStrkey(holderA)))
+	assert.Equal(t, big.NewInt(0), indexer.Balance(token.ContractID, contractStrkey(holderB)))
+
+	// the native token is always known
+	xlmContractID, err := xdr.MustNewNativeAsset().ContractID(passphrase)
+	require.NoError(t, err)
+	native, ok := indexer.Token(contractStrkey(xlmContractID))
+	require.True(t, ok)
+	assert.Equal(t, uint32(7), native.Metadata.Decimal)
+	assert.Len(t, indexer.Tokens(), 2)
+}
+
+func TestIndexerProcessEvent(t *testing.T) {
+	indexer := NewIndexer(passphrase)
+	contractID := "CCUSTOMTOKEN"
+	meta := &token_transfer.EventMeta{ContractAddress: contractID}
+	alice, bob := keypair.MustRandom().Address(), keypair.MustRandom().Address()
+
+	for _, event := range []*token_transfer.TokenTransferEvent{
+		token_transfer.NewMintEvent(meta, alice, "1000", nil),
+		token_transfer.NewTransferEvent(meta, alice, bob, "300", nil),
+		token_transfer.NewBurnEvent(meta, bob, "100", nil),
+		token_transfer.NewClawbackEvent(meta, alice, "50", nil),
+	} {
+		require.NoError(t, indexer.ProcessEvent(event))
+	}
+
+	assert.Equal(t, map[string]*big.Int{
+		alice: big.NewInt(650),
+		bob:   big.NewInt(200),
+	}, indexer.Balances(contractID))
+
+	token, ok := indexer.Token(contractID)
+	require.True(t, ok)
+	assert.False(t, token.HasMetadata)
+	assert.Nil(t, token.Asset)
+
+	err := indexer.ProcessEvent(token_transfer.NewMintEvent(meta, alice, "invalid", nil))
+	assert.ErrorContains(t, err, "invalid amount")
+}
+
+func TestIndexerProcessTransaction(t *testing.T) {
+	indexer := NewIndexer(passphrase, token_transfer.WithUnifiedEventsStreamEnabled())
+	created := balanceEntry(t, holderB, 300)
+	changes := append(
+		updated(balanceEntry(t, holderA, 1000), balanceEntry(t, holderA, 700)),
+		xdr.LedgerEntryChange{Type: xdr.LedgerEntryChangeTypeLedgerEntryCreated, Created: &created},
+	)
+
+	mismatches, err := indexer.ProcessTransaction(transferTransaction(t, 300, changes))
+	require.NoError(t, err)
+	assert.Empty(t, mismatches)
+
+	contractID := contractStrkey(usdcContractID(t))
+	assert.Equal(t, map[string]*big.Int{
+		contractStrkey(holderA): big.NewInt(700),
+		contractStrkey(holderB): big.NewInt(300),
+	}, indexer.Balances(contractID))
+
+	token, ok := indexer.Token(contractID)
+	require.True(t, ok)
+	require.NotNil(t, token.Asset)
+	assert.True(t, token.Asset.Equals(usdc))
+
+	xlmContractID, err := xdr.MustNewNativeAsset().ContractID(passphrase)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(-100), indexer.Balance(contractStrkey(xlmContractID), feePayer))
+}
+
+func TestIndexerReportsMismatch(t *testing.T) {
+	indexer := NewIndexer(passphrase, token_transfer.WithUnifiedEventsStreamEnabled())
+	indexer.ProcessContractData(balanceEntry(t, holderA, 1000))
+	indexer.ProcessContractData(balanceEntry(t, holderB, 0))
+
+	// the ledger entries disagree with the transferred amount
+	changes := append(
+		updated(balanceEntry(t, holderA, 1000), balanceEntry(t, holderA, 600)),
+		updated(balanceEntry(t, holderB, 0), balanceEntry(t, holderB, 400))...,
+	)
+	mismatches, err := indexer.ProcessTransaction(transferTransaction(t, 300, changes))
+	require.NoError(t, err)
+	require.Len(t, mismatches, 2)
+
+	contractID := contractStrkey(usdcContractID(t))
+	assert.Equal(t, BalanceMismatch{
+		Ledger:     11,
+		TxHash:     xdr.Hash{}.HexString(),
+		ContractID: contractID,
+		Holder:     contractStrkey(holderA),
+		Expected:   big.NewInt(600),
+		Indexed:    big.NewInt(700),
+	}, mismatches[0])
+	assert.Equal(t, big.NewInt(400), mismatches[1].Expected)
+	assert.Equal(t, big.NewInt(300), mismatches[1].Indexed)
+
+	// balances are reset to the ledger entries
+	assert.Equal(t, big.NewInt(600), indexer.Balance(contractID, contractStrkey(holderA)))
+	assert.Equal(t, big.NewInt(400), indexer.Balance(contractID, contractStrkey(holderB)))
+}
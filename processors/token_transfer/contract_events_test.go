@@ -5,6 +5,8 @@ import (
 	"github.com/stellar/go/keypair"
 	"github.com/stellar/go/strkey"
 	"google.golang.org/protobuf/proto"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -2255,3 +2257,32 @@ func TestNoMuxedInfoInPreProtocol23SorobanTxEvents(t *testing.T) {
 	// there shudnt be muxedInfo here.
 	assert.Nil(t, event.GetMeta().ToMuxedInfo)
 }
+
+// TestSACEventFixturesMuxedData checks that the generated protocol 23 SAC
+// events of support/contractevents, which carry a to_muxed_id in their data,
+// are read the same way by the parser of this package.
+func TestSACEventFixturesMuxedData(t *testing.T) {
+	for _, testCase := range []struct {
+		fixture string
+		amount  uint64
+		muxed   *MuxedInfo
+	}{
+		{"mint_muxed_event_xdr.bin", 777, NewMuxedInfoFromId(42)},
+		{"transfer_muxed_event_xdr.bin", 100, &MuxedInfo{Content: &MuxedInfo_Text{Text: "invoice-7"}}},
+	} {
+		t.Run(testCase.fixture, func(t *testing.T) {
+			raw, err := os.ReadFile(filepath.Join("..", "..", "support", "contractevents", "fixtures", testCase.fixture))
+			require.NoError(t, err)
+			var event xdr.ContractEvent
+			require.NoError(t, event.UnmarshalBinary(raw))
+
+			data, ok := event.Body.MustV0().Data.GetMap()
+			require.True(t, ok)
+			require.NotNil(t, data)
+			amt, muxed, err := parseV4MapDataForTokenEvents(*data)
+			require.NoError(t, err)
+			assert.Equal(t, xdr.Int128Parts{Lo: xdr.Uint64(testCase.amount)}, amt)
+			assert.True(t, proto.Equal(testCase.muxed, muxed))
+		})
+	}
+}
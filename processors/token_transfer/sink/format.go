@@ -0,0 +1,190 @@
+package sink
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/stellar/go/processors/token_transfer"
+)
+
+// Format is the encoding used to write token transfer events.
+type Format int
+
+const (
+	// FormatProtobuf writes length-delimited protobuf messages, which can be
+	// read back with protodelim.UnmarshalFrom.
+	FormatProtobuf Format = iota
+	// FormatJSONL writes one protojson-encoded event per line, using the
+	// protobuf field names.
+	FormatJSONL
+	// FormatCSV writes one row per event using the flattened schema described
+	// by CSVHeader.
+	FormatCSV
+)
+
+// CSVHeader is the header row of files written with FormatCSV.
+var CSVHeader = []string{
+	"ledger_sequence",
+	"closed_at",
+	"tx_hash",
+	"transaction_index",
+	"operation_index",
+	"contract_address",
+	"event_type",
+	"from",
+	"to",
+	"asset",
+	"amount",
+	"to_muxed_type",
+	"to_muxed_id",
+}
+
+// Extension returns the file extension used for the format.
+func (f Format) Extension() string {
+	switch f {
+	case FormatProtobuf:
+		return "pb"
+	case FormatJSONL:
+		return "jsonl"
+	case FormatCSV:
+		return "csv"
+	default:
+		return ""
+	}
+}
+
+func (f Format) String() string {
+	switch f {
+	case FormatProtobuf:
+		return "protobuf"
+	case FormatJSONL:
+		return "jsonl"
+	case FormatCSV:
+		return "csv"
+	default:
+		return fmt.Sprintf("Format(%d)", int(f))
+	}
+}
+
+// encoder writes events to an underlying writer in a given format.
+type encoder interface {
+	Encode(event *token_transfer.TokenTransferEvent) error
+	Flush() error
+}
+
+func newEncoder(format Format, w io.Writer) (encoder, error) {
+	switch format {
+	case FormatProtobuf:
+		return &protobufEncoder{w: w}, nil
+	case FormatJSONL:
+		return &jsonlEncoder{w: w, options: protojson.MarshalOptions{UseProtoNames: true}}, nil
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(CSVHeader); err != nil {
+			return nil, err
+		}
+		return &csvEncoder{w: writer}, nil
+	default:
+		return nil, fmt.Errorf("unsupported format %v", format)
+	}
+}
+
+type protobufEncoder struct {
+	w io.Writer
+}
+
+func (e *protobufEncoder) Encode(event *token_transfer.TokenTransferEvent) error {
+	_, err := protodelim.MarshalTo(e.w, event)
+	return err
+}
+
+func (e *protobufEncoder) Flush() error {
+	return nil
+}
+
+type jsonlEncoder struct {
+	w       io.Writer
+	options protojson.MarshalOptions
+}
+
+func (e *jsonlEncoder) Encode(event *token_transfer.TokenTransferEvent) error {
+	line, err := e.options.Marshal(event)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	_, err = e.w.Write(line)
+	return err
+}
+
+func (e *jsonlEncoder) Flush() error {
+	return nil
+}
+
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func (e *csvEncoder) Encode(event *token_transfer.TokenTransferEvent) error {
+	return e.w.Write(csvRecord(event))
+}
+
+func (e *csvEncoder) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func csvRecord(event *token_transfer.TokenTransferEvent) []string {
+	meta := event.GetMeta()
+
+	var closedAt, operationIndex string
+	if meta.GetClosedAt() != nil {
+		closedAt = meta.GetClosedAt().AsTime().UTC().Format(time.RFC3339)
+	}
+	if meta.OperationIndex != nil {
+		operationIndex = strconv.FormatUint(uint64(meta.GetOperationIndex()), 10)
+	}
+
+	// the addresses of events of unknown types are left empty
+	from, to, amount, _ := event.GetMovement()
+
+	var asset string
+	if a := event.GetAsset(); a.GetNative() {
+		asset = "native"
+	} else if issued := a.GetIssuedAsset(); issued != nil {
+		asset = issued.GetAssetCode() + ":" + issued.GetIssuer()
+	}
+
+	var muxedType, muxedID string
+	switch muxed := meta.GetToMuxedInfo(); muxed.GetContent().(type) {
+	case *token_transfer.MuxedInfo_Text:
+		muxedType, muxedID = "text", muxed.GetText()
+	case *token_transfer.MuxedInfo_Id:
+		muxedType, muxedID = "id", strconv.FormatUint(muxed.GetId(), 10)
+	case *token_transfer.MuxedInfo_Hash:
+		muxedType, muxedID = "hash", hex.EncodeToString(muxed.GetHash())
+	}
+
+	return []string{
+		strconv.FormatUint(uint64(meta.GetLedgerSequence()), 10),
+		closedAt,
+		meta.GetTxHash(),
+		strconv.FormatUint(uint64(meta.GetTransactionIndex()), 10),
+		operationIndex,
+		meta.GetContractAddress(),
+		event.GetEventType(),
+		from,
+		to,
+		asset,
+		amount,
+		muxedType,
+		muxedID,
+	}
+}
@@ -0,0 +1,221 @@
+// Package sink persists token transfer events into a datastore, rotating to a
+// new file every N ledgers.
+package sink
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"strconv"
+
+	"github.com/stellar/go/processors/token_transfer"
+	"github.com/stellar/go/support/compressxdr"
+	"github.com/stellar/go/support/datastore"
+	"github.com/stellar/go/xdr"
+)
+
+// Config configures a Sink.
+type Config struct {
+	// Format is the encoding of the events.
+	Format Format
+	// LedgersPerFile is the number of ledgers covered by each file. Files are
+	// aligned to multiples of LedgersPerFile, like the files written by the
+	// ledger exporter.
+	LedgersPerFile uint32
+	// Prefix is prepended to the path of every file.
+	Prefix string
+	// Compressor, if set, compresses every file. Its name is appended to the
+	// file extension.
+	Compressor compressxdr.Compressor
+}
+
+// Sink encodes token transfer events and writes them to a DataStore. Events
+// must be written in ledger order. A file is uploaded once every ledger in its
+// range was written or when the Sink is closed.
+//
+// A Sink which failed to encode an event or to upload a file cannot be used
+// anymore, as the file being written is incomplete: writing must restart from
+// the start of the file with a new Sink.
+type Sink struct {
+	store     datastore.DataStore
+	processor *token_transfer.EventsProcessor
+	config    Config
+
+	buffer     bytes.Buffer
+	output     io.WriteCloser
+	encoder    encoder
+	open       bool
+	fileStart  uint32
+	lastLedger uint32
+	events     int
+	// written is true once a ledger was written, at which point lastLedger
+	// is set.
+	written bool
+	closed  bool
+	// err is the error which made the Sink unusable, if any.
+	err error
+}
+
+// New creates a Sink writing to store. The processor is used by
+// ProcessLedger to extract the events of a ledger.
+func New(store datastore.DataStore, processor *token_transfer.EventsProcessor, config Config) (*Sink, error) {
+	if config.LedgersPerFile == 0 {
+		return nil, fmt.Errorf("ledgers per file must be greater than zero")
+	}
+	if config.Format.Extension() == "" {
+		return nil, fmt.Errorf("unsupported format %v", config.Format)
+	}
+	return &Sink{store: store, processor: processor, config: config}, nil
+}
+
+// ProcessLedger extracts the token transfer events of the ledger and writes
+// them.
+func (s *Sink) ProcessLedger(ctx context.Context, lcm xdr.LedgerCloseMeta) error {
+	events, err := s.processor.EventsFromLedger(lcm)
+	if err != nil {
+		return fmt.Errorf("error extracting events from ledger %d: %w", lcm.LedgerSequence(), err)
+	}
+	return s.WriteLedger(ctx, lcm.LedgerSequence(), events)
+}
+
+// WriteLedger writes the events of a ledger. Ledgers must be written in
+// increasing order, including across files, so that no file is overwritten.
+// Ledgers without events are still recorded as covered by the current file.
+func (s *Sink) WriteLedger(ctx context.Context, ledgerSeq uint32, events []*token_transfer.TokenTransferEvent) error {
+	if s.err != nil {
+		return fmt.Errorf("sink failed: %w", s.err)
+	}
+	if s.closed {
+		return fmt.Errorf("sink is closed")
+	}
+	if s.written && ledgerSeq <= s.lastLedger {
+		return fmt.Errorf("ledger %d written out of order, last ledger written is %d", ledgerSeq, s.lastLedger)
+	}
+	if s.open && ledgerSeq > s.fileEnd() {
+		if err := s.flush(ctx); err != nil {
+			return err
+		}
+	}
+	if !s.open {
+		if err := s.openFile(ledgerSeq); err != nil {
+			return err
+		}
+	}
+
+	for _, event := range events {
+		if err := s.encoder.Encode(event); err != nil {
+			// the events encoded so far cannot be taken back
+			s.err = fmt.Errorf("error encoding event in ledger %d: %w", ledgerSeq, err)
+			return s.err
+		}
+	}
+	s.events += len(events)
+	s.lastLedger = ledgerSeq
+	s.written = true
+
+	if ledgerSeq == s.fileEnd() {
+		return s.flush(ctx)
+	}
+	return nil
+}
+
+// Close uploads the file being written, if any, even if it does not cover
+// its full ledger range. No ledger can be written afterwards.
+func (s *Sink) Close(ctx context.Context) error {
+	if s.err != nil {
+		return fmt.Errorf("sink failed: %w", s.err)
+	}
+	s.closed = true
+	if !s.open {
+		return nil
+	}
+	return s.flush(ctx)
+}
+
+// FileName returns the path of the file covering the ledgers from start to
+// end. Like the ledger exporter, the name starts with the inverted start
+// ledger so that the most recent files are listed first.
+func (s *Sink) FileName(start, end uint32) string {
+	name := fmt.Sprintf("%08X--%d", math.MaxUint32-start, start)
+	if start != end {
+		name += fmt.Sprintf("-%d", end)
+	}
+	name += "." + s.config.Format.Extension()
+	if s.config.Compressor != nil {
+		name += "." + s.config.Compressor.Name()
+	}
+	return path.Join(s.config.Prefix, name)
+}
+
+func (s *Sink) fileEnd() uint32 {
+	return s.fileStart + s.config.LedgersPerFile - 1
+}
+
+func (s *Sink) openFile(ledgerSeq uint32) error {
+	s.buffer.Reset()
+	s.output = nopWriteCloser{&s.buffer}
+	if s.config.Compressor != nil {
+		writer, err := s.config.Compressor.NewWriter(&s.buffer)
+		if err != nil {
+			return fmt.Errorf("error creating compressor: %w", err)
+		}
+		s.output = writer
+	}
+
+	enc, err := newEncoder(s.config.Format, s.output)
+	if err != nil {
+		return err
+	}
+	s.encoder = enc
+	s.fileStart = (ledgerSeq / s.config.LedgersPerFile) * s.config.LedgersPerFile
+	s.events = 0
+	s.open = true
+	return nil
+}
+
+// flush uploads the file being written. On failure the Sink keeps the error
+// and refuses any further write, as the file cannot be written again.
+func (s *Sink) flush(ctx context.Context) error {
+	s.open = false
+	if err := s.upload(ctx); err != nil {
+		s.err = err
+		return err
+	}
+	return nil
+}
+
+func (s *Sink) upload(ctx context.Context) error {
+	if err := s.encoder.Flush(); err != nil {
+		return fmt.Errorf("error flushing encoder: %w", err)
+	}
+	if err := s.output.Close(); err != nil {
+		return fmt.Errorf("error closing compressor: %w", err)
+	}
+
+	metadata := map[string]string{
+		"start-ledger": strconv.FormatUint(uint64(s.fileStart), 10),
+		"end-ledger":   strconv.FormatUint(uint64(s.lastLedger), 10),
+		"format":       s.config.Format.String(),
+		"event-count":  strconv.Itoa(s.events),
+	}
+	if s.config.Compressor != nil {
+		metadata["compression-type"] = s.config.Compressor.Name()
+	}
+
+	name := s.FileName(s.fileStart, s.lastLedger)
+	if err := s.store.PutFile(ctx, name, bytes.NewReader(s.buffer.Bytes()), metadata); err != nil {
+		return fmt.Errorf("error uploading %s: %w", name, err)
+	}
+	return nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
@@ -0,0 +1,200 @@
+package sink
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/stellar/go/asset"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/processors/token_transfer"
+	"github.com/stellar/go/support/compressxdr"
+	"github.com/stellar/go/support/datastore"
+)
+
+var (
+	alice = keypair.MustRandom().Address()
+	bob   = keypair.MustRandom().Address()
+)
+
+func eventMeta(ledger uint32) *token_transfer.EventMeta {
+	opIndex := uint32(1)
+	return &token_transfer.EventMeta{
+		LedgerSequence:   ledger,
+		ClosedAt:         timestamppb.New(time.Unix(1700000000, 0)),
+		TxHash:           "abcd",
+		TransactionIndex: 1,
+		OperationIndex:   &opIndex,
+		ContractAddress:  "CTOKEN",
+		ToMuxedInfo: &token_transfer.MuxedInfo{
+			Content: &token_transfer.MuxedInfo_Id{Id: 42},
+		},
+	}
+}
+
+func ledgerEvents(ledger uint32) []*token_transfer.TokenTransferEvent {
+	return []*token_transfer.TokenTransferEvent{
+		token_transfer.NewTransferEvent(eventMeta(ledger), alice, bob, "100", asset.NewNativeAsset()),
+		token_transfer.NewFeeEvent(&token_transfer.EventMeta{LedgerSequence: ledger}, alice, "10", asset.NewNativeAsset()),
+	}
+}
+
+// recordingStore returns a mock datastore recording the content of every
+// uploaded file.
+func recordingStore(t *testing.T) (*datastore.MockDataStore, map[string][]byte, map[string]map[string]string) {
+	store := &datastore.MockDataStore{}
+	files := map[string][]byte{}
+	metadata := map[string]map[string]string{}
+	store.On("PutFile", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			var buf bytes.Buffer
+			_, err := args.Get(2).(io.WriterTo).WriteTo(&buf)
+			require.NoError(t, err)
+			files[args.String(1)] = buf.Bytes()
+			metadata[args.String(1)] = args.Get(3).(map[string]string)
+		}).
+		Return(nil)
+	return store, files, metadata
+}
+
+func newSink(t *testing.T, store datastore.DataStore, config Config) *Sink {
+	s, err := New(store, token_transfer.NewEventsProcessor(network.TestNetworkPassphrase), config)
+	require.NoError(t, err)
+	return s
+}
+
+func TestSinkRotation(t *testing.T) {
+	store, files, metadata := recordingStore(t)
+	s := newSink(t, store, Config{Format: FormatJSONL, LedgersPerFile: 4, Prefix: "events"})
+	ctx := context.Background()
+
+	for ledger := uint32(2); ledger <= 9; ledger++ {
+		require.NoError(t, s.WriteLedger(ctx, ledger, ledgerEvents(ledger)))
+	}
+	// ledgers 2-3 and 4-7 are complete
+	require.Len(t, files, 2)
+	assert.Contains(t, files, "events/FFFFFFFF--0-3.jsonl")
+	assert.Contains(t, files, "events/FFFFFFFB--4-7.jsonl")
+	assert.Equal(t, map[string]string{
+		"start-ledger": "4",
+		"end-ledger":   "7",
+		"format":       "jsonl",
+		"event-count":  "8",
+	}, metadata["events/FFFFFFFB--4-7.jsonl"])
+
+	require.ErrorContains(t, s.WriteLedger(ctx, 9, nil), "out of order")
+
+	require.NoError(t, s.Close(ctx))
+	require.Len(t, files, 3)
+	assert.Equal(t, "9", metadata["events/FFFFFFF7--8-9.jsonl"]["end-ledger"])
+	require.NoError(t, s.Close(ctx))
+	store.AssertNumberOfCalls(t, "PutFile", 3)
+
+	scanner := bufio.NewScanner(bytes.NewReader(files["events/FFFFFFFB--4-7.jsonl"]))
+	var decoded []*token_transfer.TokenTransferEvent
+	for scanner.Scan() {
+		event := &token_transfer.TokenTransferEvent{}
+		require.NoError(t, protojson.Unmarshal(scanner.Bytes(), event))
+		decoded = append(decoded, event)
+	}
+	require.Len(t, decoded, 8)
+	assert.True(t, proto.Equal(ledgerEvents(4)[0], decoded[0]))
+	assert.True(t, proto.Equal(ledgerEvents(7)[1], decoded[7]))
+}
+
+func TestSinkOutOfOrderAfterUpload(t *testing.T) {
+	store, files, _ := recordingStore(t)
+	s := newSink(t, store, Config{Format: FormatJSONL, LedgersPerFile: 4})
+	ctx := context.Background()
+
+	// ledger 3 completes the file of ledgers 0-3, so no file is open
+	require.NoError(t, s.WriteLedger(ctx, 2, ledgerEvents(2)))
+	require.NoError(t, s.WriteLedger(ctx, 3, ledgerEvents(3)))
+	require.Len(t, files, 1)
+	assert.ErrorContains(t, s.WriteLedger(ctx, 3, nil), "out of order")
+	assert.ErrorContains(t, s.WriteLedger(ctx, 1, nil), "out of order")
+
+	require.NoError(t, s.WriteLedger(ctx, 5, ledgerEvents(5)))
+	require.NoError(t, s.Close(ctx))
+	assert.ErrorContains(t, s.WriteLedger(ctx, 5, nil), "closed")
+	assert.ErrorContains(t, s.WriteLedger(ctx, 6, nil), "closed")
+	store.AssertNumberOfCalls(t, "PutFile", 2)
+}
+
+func TestSinkUploadFailure(t *testing.T) {
+	store := &datastore.MockDataStore{}
+	store.On("PutFile", mock.Anything, "FFFFFFFF--0-1.jsonl", mock.Anything, mock.Anything).
+		Return(errors.New("unavailable")).Once()
+	s := newSink(t, store, Config{Format: FormatJSONL, LedgersPerFile: 2})
+	ctx := context.Background()
+
+	require.NoError(t, s.WriteLedger(ctx, 0, ledgerEvents(0)))
+	require.ErrorContains(t, s.WriteLedger(ctx, 1, ledgerEvents(1)), "unavailable")
+
+	// the events of ledgers 0-1 are gone, so nothing else can be uploaded
+	assert.ErrorContains(t, s.WriteLedger(ctx, 1, ledgerEvents(1)), "unavailable")
+	assert.ErrorContains(t, s.WriteLedger(ctx, 2, ledgerEvents(2)), "unavailable")
+	assert.ErrorContains(t, s.Close(ctx), "unavailable")
+	store.AssertNumberOfCalls(t, "PutFile", 1)
+}
+
+func TestSinkProtobuf(t *testing.T) {
+	store, files, _ := recordingStore(t)
+	s := newSink(t, store, Config{
+		Format:         FormatProtobuf,
+		LedgersPerFile: 1,
+		Compressor:     compressxdr.DefaultCompressor,
+	})
+	require.NoError(t, s.WriteLedger(context.Background(), 5, ledgerEvents(5)))
+
+	content, ok := files["FFFFFFFA--5.pb.zst"]
+	require.True(t, ok)
+	reader, err := compressxdr.DefaultCompressor.NewReader(bytes.NewReader(content))
+	require.NoError(t, err)
+	defer reader.Close()
+
+	buffered := bufio.NewReader(reader)
+	for _, expected := range ledgerEvents(5) {
+		event := &token_transfer.TokenTransferEvent{}
+		require.NoError(t, protodelim.UnmarshalFrom(buffered, event))
+		assert.True(t, proto.Equal(expected, event))
+	}
+	_, err = buffered.ReadByte()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestSinkCSV(t *testing.T) {
+	store, files, _ := recordingStore(t)
+	s := newSink(t, store, Config{Format: FormatCSV, LedgersPerFile: 1})
+	require.NoError(t, s.WriteLedger(context.Background(), 5, ledgerEvents(5)))
+
+	records, err := csv.NewReader(bytes.NewReader(files["FFFFFFFA--5.csv"])).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		CSVHeader,
+		{"5", "2023-11-14T22:13:20Z", "abcd", "1", "1", "CTOKEN", "transfer", alice, bob, "native", "100", "id", "42"},
+		{"5", "", "", "0", "", "", "fee", alice, "", "native", "10", "", ""},
+	}, records)
+}
+
+func TestSinkInvalidConfig(t *testing.T) {
+	processor := token_transfer.NewEventsProcessor(network.TestNetworkPassphrase)
+	_, err := New(&datastore.MockDataStore{}, processor, Config{Format: FormatCSV})
+	assert.Error(t, err)
+	_, err = New(&datastore.MockDataStore{}, processor, Config{Format: Format(10), LedgersPerFile: 1})
+	assert.Error(t, err)
+}
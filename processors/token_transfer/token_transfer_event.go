@@ -177,6 +177,27 @@ func (event *TokenTransferEvent) GetAmount() string {
 	return amount
 }
 
+// GetMovement returns the address debited by the event, the address credited
+// by it and the amount moved. Mints debit no address and burns, clawbacks and
+// fees credit none, in which case the address is empty. Fee refunds are fee
+// events with a negative amount.
+func (event *TokenTransferEvent) GetMovement() (from, to, amount string, err error) {
+	switch e := event.GetEvent().(type) {
+	case *TokenTransferEvent_Transfer:
+		return e.Transfer.GetFrom(), e.Transfer.GetTo(), e.Transfer.GetAmount(), nil
+	case *TokenTransferEvent_Mint:
+		return "", e.Mint.GetTo(), e.Mint.GetAmount(), nil
+	case *TokenTransferEvent_Burn:
+		return e.Burn.GetFrom(), "", e.Burn.GetAmount(), nil
+	case *TokenTransferEvent_Clawback:
+		return e.Clawback.GetFrom(), "", e.Clawback.GetAmount(), nil
+	case *TokenTransferEvent_Fee:
+		return e.Fee.GetFrom(), "", e.Fee.GetAmount(), nil
+	default:
+		return "", "", "", fmt.Errorf("unknown event type: %v", event)
+	}
+}
+
 func (e *TokenTransferEvent) setDestinationMuxedInfo(to string, tx ingest.LedgerTransaction) error {
 	// Destination Mux info needs to be set only for accountAddresses, and not for LPs or CBs.
 	// This is as per CAP-67
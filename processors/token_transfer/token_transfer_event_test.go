@@ -134,3 +134,30 @@ func TestEventSerialization(t *testing.T) {
 		})
 	}
 }
+
+func TestGetMovement(t *testing.T) {
+	meta := newTestEventMeta()
+	token := newTestAsset()
+	tests := []struct {
+		event            *TokenTransferEvent
+		from, to, amount string
+	}{
+		{NewTransferEvent(meta, "alice", "bob", "1000", token), "alice", "bob", "1000"},
+		{NewMintEvent(meta, "bob", "500", token), "", "bob", "500"},
+		{NewBurnEvent(meta, "alice", "200", token), "alice", "", "200"},
+		{NewClawbackEvent(meta, "alice", "300", token), "alice", "", "300"},
+		{NewFeeEvent(meta, "alice", "-50", xlmProtoAsset), "alice", "", "-50"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.event.GetEventType(), func(t *testing.T) {
+			from, to, amount, err := tt.event.GetMovement()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.from, from)
+			assert.Equal(t, tt.to, to)
+			assert.Equal(t, tt.amount, amount)
+		})
+	}
+
+	_, _, _, err := (&TokenTransferEvent{Meta: meta}).GetMovement()
+	assert.Error(t, err)
+}
@@ -0,0 +1,409 @@
+package token_transfer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stellar/go/ingest"
+	"github.com/stellar/go/ingest/ledgerbackend"
+	"github.com/stellar/go/ingest/sac"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/xdr"
+)
+
+// BalanceDiff is the difference between the balance delta of a holder derived
+// from token transfer events and the one derived from ledger entry changes.
+type BalanceDiff struct {
+	Holder       string
+	Asset        string
+	EventsDelta  int64
+	ChangesDelta int64
+}
+
+// Mismatch describes a transaction, or one of its operations, whose token
+// transfer events do not account for its ledger entry changes.
+type Mismatch struct {
+	LedgerSequence uint32
+	ClosedAt       time.Time
+	TxHash         string
+	// OperationIndex is the 0-indexed operation the mismatch was found in. It
+	// is nil for transaction-level changes, such as fees and fee refunds.
+	OperationIndex *uint32
+	// OperationType is the type of the operation. It is nil for
+	// transaction-level changes.
+	OperationType *xdr.OperationType
+	Diffs         []BalanceDiff
+}
+
+func (m Mismatch) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "balance delta mismatch between events and ledger changes for ledgerSequence: %v, closedAt: %v, txHash: %v",
+		m.LedgerSequence, m.ClosedAt, m.TxHash)
+	if m.OperationIndex != nil {
+		fmt.Fprintf(&b, ", operation: %d (%v)", *m.OperationIndex, *m.OperationType)
+	}
+	for _, diff := range m.Diffs {
+		fmt.Fprintf(&b, "\n  holder: %s, asset: %s, events delta: %d, changes delta: %d",
+			diff.Holder, diff.Asset, diff.EventsDelta, diff.ChangesDelta)
+	}
+	return b.String()
+}
+
+// VerificationStats counts the operations (or transactions) verified, the
+// events they emitted and the mismatches found.
+type VerificationStats struct {
+	Count      int
+	Events     int
+	Mismatches int
+}
+
+// VerificationReport is the outcome of verifying a range of ledgers.
+type VerificationReport struct {
+	Ledgers      int
+	Transactions int
+	Events       int
+	Mismatches   []Mismatch
+	// Fees holds the statistics of transaction-level verifications. Its
+	// Count is the number of transactions verified.
+	Fees VerificationStats
+	// Operations holds the statistics of every operation type verified.
+	Operations map[xdr.OperationType]*VerificationStats
+}
+
+func newVerificationReport() *VerificationReport {
+	return &VerificationReport{Operations: map[xdr.OperationType]*VerificationStats{}}
+}
+
+func (r *VerificationReport) operationStats(opType xdr.OperationType) *VerificationStats {
+	stats, ok := r.Operations[opType]
+	if !ok {
+		stats = &VerificationStats{}
+		r.Operations[opType] = stats
+	}
+	return stats
+}
+
+// RangeVerifier verifies the token transfer events of a range of ledgers
+// against their ledger entry changes. Unlike VerifyEvents it verifies every
+// operation separately and collects every mismatch instead of stopping at the
+// first one.
+type RangeVerifier struct {
+	backend           ledgerbackend.LedgerBackend
+	networkPassphrase string
+	processor         *EventsProcessor
+	contractHolders   bool
+	onMismatch        func(Mismatch)
+	onLedger          func(ledger uint32, report *VerificationReport)
+}
+
+type RangeVerifierOption func(*RangeVerifier)
+
+// WithUnifiedEvents reads the events from the unified events stream, see
+// NewEventsProcessorForUnifiedEvents.
+func WithUnifiedEvents() RangeVerifierOption {
+	return func(v *RangeVerifier) {
+		v.processor = NewEventsProcessorForUnifiedEvents(v.networkPassphrase)
+	}
+}
+
+// WithContractHolders also verifies the balances of contract holders of
+// Stellar Asset Contracts against the balance entries in contract storage.
+// Contract holders of the native asset are not verified since their balances
+// are not stored by the Stellar Asset Contract in the same way.
+func WithContractHolders() RangeVerifierOption {
+	return func(v *RangeVerifier) {
+		v.contractHolders = true
+	}
+}
+
+// WithMismatchHandler calls handler for every mismatch as soon as it is
+// found.
+func WithMismatchHandler(handler func(Mismatch)) RangeVerifierOption {
+	return func(v *RangeVerifier) {
+		v.onMismatch = handler
+	}
+}
+
+// WithProgressHandler calls handler after every ledger with the report so
+// far. The report must not be retained or modified.
+func WithProgressHandler(handler func(ledger uint32, report *VerificationReport)) RangeVerifierOption {
+	return func(v *RangeVerifier) {
+		v.onLedger = handler
+	}
+}
+
+func NewRangeVerifier(backend ledgerbackend.LedgerBackend, networkPassphrase string, options ...RangeVerifierOption) *RangeVerifier {
+	v := &RangeVerifier{
+		backend:           backend,
+		networkPassphrase: networkPassphrase,
+		processor:         NewEventsProcessor(networkPassphrase),
+	}
+	for _, opt := range options {
+		opt(v)
+	}
+	return v
+}
+
+// Verify verifies every ledger in ledgerRange. Unbounded ranges are verified
+// until ctx is cancelled, in which case the report so far is returned along
+// with the context error.
+func (v *RangeVerifier) Verify(ctx context.Context, ledgerRange ledgerbackend.Range) (*VerificationReport, error) {
+	report := newVerificationReport()
+
+	prepared, err := v.backend.IsPrepared(ctx, ledgerRange)
+	if err != nil {
+		return report, fmt.Errorf("error checking if range %v is prepared: %w", ledgerRange, err)
+	}
+	if !prepared {
+		if err = v.backend.PrepareRange(ctx, ledgerRange); err != nil {
+			return report, fmt.Errorf("error preparing range %v: %w", ledgerRange, err)
+		}
+	}
+
+	for seq := ledgerRange.From(); !ledgerRange.Bounded() || seq <= ledgerRange.To(); seq++ {
+		if err = ctx.Err(); err != nil {
+			return report, err
+		}
+		ledger, err := v.backend.GetLedger(ctx, seq)
+		if err != nil {
+			return report, fmt.Errorf("error getting ledger %d: %w", seq, err)
+		}
+		if err = v.VerifyLedger(ledger, report); err != nil {
+			return report, err
+		}
+		if v.onLedger != nil {
+			v.onLedger(seq, report)
+		}
+	}
+	return report, nil
+}
+
+// VerifyLedger verifies a single ledger and adds the outcome to report.
+func (v *RangeVerifier) VerifyLedger(ledger xdr.LedgerCloseMeta, report *VerificationReport) error {
+	if report.Operations == nil {
+		report.Operations = map[xdr.OperationType]*VerificationStats{}
+	}
+	txReader, err := ingest.NewLedgerTransactionReaderFromLedgerCloseMeta(v.networkPassphrase, ledger)
+	if err != nil {
+		return fmt.Errorf("error creating transaction reader: %w", err)
+	}
+	defer txReader.Close()
+
+	for {
+		tx, err := txReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("error reading transaction: %w", err)
+		}
+		if err = v.verifyTransaction(tx, report); err != nil {
+			return err
+		}
+	}
+	report.Ledgers++
+	return nil
+}
+
+func (v *RangeVerifier) verifyTransaction(tx ingest.LedgerTransaction, report *VerificationReport) error {
+	txEvents, err := v.processor.EventsFromTransaction(tx)
+	if err != nil {
+		return fmt.Errorf("error processing events for txHash: %v, ledgerSequence: %v: %w",
+			tx.Hash.HexString(), tx.Ledger.LedgerSequence(), err)
+	}
+	txChanges, err := tx.GetChanges()
+	if err != nil {
+		return fmt.Errorf("error reading changes for txHash: %v, ledgerSequence: %v: %w",
+			tx.Hash.HexString(), tx.Ledger.LedgerSequence(), err)
+	}
+
+	// Split events and changes between the transaction level (fees) and
+	// every operation.
+	operations := tx.Envelope.Operations()
+	opEvents := make([][]*TokenTransferEvent, len(operations))
+	opChanges := make([][]ingest.Change, len(operations))
+	var feeEvents []*TokenTransferEvent
+	feeChanges := append(tx.GetFeeChanges(), tx.GetPostApplyFeeChanges()...)
+
+	for _, events := range [][]*TokenTransferEvent{txEvents.FeeEvents, txEvents.OperationEvents} {
+		for _, event := range events {
+			index := event.GetMeta().OperationIndex
+			if index == nil || *index == 0 || int(*index) > len(operations) {
+				feeEvents = append(feeEvents, event)
+				continue
+			}
+			opEvents[*index-1] = append(opEvents[*index-1], event)
+		}
+	}
+	for _, change := range txChanges {
+		if change.Reason == ingest.LedgerEntryChangeReasonOperation && int(change.OperationIndex) < len(operations) {
+			opChanges[change.OperationIndex] = append(opChanges[change.OperationIndex], change)
+			continue
+		}
+		feeChanges = append(feeChanges, change)
+	}
+
+	report.Transactions++
+	report.Events += len(feeEvents)
+	report.Fees.Count++
+	report.Fees.Events += len(feeEvents)
+	if diffs := v.diff(feeEvents, feeChanges); len(diffs) > 0 {
+		report.Fees.Mismatches++
+		v.addMismatch(report, newMismatch(tx, nil, nil, diffs))
+	}
+
+	// Operations of failed transactions have no effect and are not verified.
+	if !tx.Result.Successful() {
+		return nil
+	}
+	for i, op := range operations {
+		opType := op.Body.Type
+		stats := report.operationStats(opType)
+		stats.Count++
+		stats.Events += len(opEvents[i])
+		report.Events += len(opEvents[i])
+		if diffs := v.diff(opEvents[i], opChanges[i]); len(diffs) > 0 {
+			stats.Mismatches++
+			opIndex := uint32(i)
+			v.addMismatch(report, newMismatch(tx, &opIndex, &opType, diffs))
+		}
+	}
+	return nil
+}
+
+func (v *RangeVerifier) addMismatch(report *VerificationReport, mismatch Mismatch) {
+	report.Mismatches = append(report.Mismatches, mismatch)
+	if v.onMismatch != nil {
+		v.onMismatch(mismatch)
+	}
+}
+
+func newMismatch(tx ingest.LedgerTransaction, opIndex *uint32, opType *xdr.OperationType, diffs []BalanceDiff) Mismatch {
+	return Mismatch{
+		LedgerSequence: tx.Ledger.LedgerSequence(),
+		ClosedAt:       tx.Ledger.ClosedAt(),
+		TxHash:         tx.Hash.HexString(),
+		OperationIndex: opIndex,
+		OperationType:  opType,
+		Diffs:          diffs,
+	}
+}
+
+// diff returns the holders whose balance deltas differ between events and
+// changes, sorted by holder and asset.
+func (v *RangeVerifier) diff(events []*TokenTransferEvent, changes []ingest.Change) []BalanceDiff {
+	eventDeltas := findBalanceDeltasFromEvents(events)
+	changeDeltas := findBalanceDeltasFromChanges(changes)
+	if v.contractHolders {
+		v.addContractDeltasFromEvents(events, eventDeltas)
+		v.addContractDeltasFromChanges(events, changes, changeDeltas)
+	}
+
+	var diffs []BalanceDiff
+	for key, delta := range eventDeltas {
+		if changeDeltas[key] != delta {
+			diffs = append(diffs, BalanceDiff{Holder: key.holder, Asset: key.asset, EventsDelta: delta, ChangesDelta: changeDeltas[key]})
+		}
+	}
+	for key, delta := range changeDeltas {
+		if _, ok := eventDeltas[key]; !ok {
+			diffs = append(diffs, BalanceDiff{Holder: key.holder, Asset: key.asset, ChangesDelta: delta})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		if diffs[i].Holder != diffs[j].Holder {
+			return diffs[i].Holder < diffs[j].Holder
+		}
+		return diffs[i].Asset < diffs[j].Asset
+	})
+	return diffs
+}
+
+// addContractDelta is the counterpart of updateBalanceMap for contract
+// holders, which updateBalanceMap ignores.
+func addContractDelta(m map[balanceKey]int64, key balanceKey, delta int64) {
+	if !strkey.IsValidContractAddress(key.holder) {
+		return
+	}
+	m[key] += delta
+	if m[key] == 0 {
+		delete(m, key)
+	}
+}
+
+func (v *RangeVerifier) addContractDeltasFromEvents(events []*TokenTransferEvent, m map[balanceKey]int64) {
+	for _, event := range events {
+		eventAsset := event.GetAsset()
+		if eventAsset == nil || eventAsset.GetNative() {
+			continue
+		}
+		asset := eventAsset.ToXdrAsset().StringCanonical()
+		from, to, value, err := event.GetMovement()
+		if err != nil {
+			continue
+		}
+		amt, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			continue
+		}
+		// the empty address of mints and burns is not a contract
+		addContractDelta(m, balanceKey{holder: from, asset: asset}, -amt)
+		addContractDelta(m, balanceKey{holder: to, asset: asset}, amt)
+	}
+}
+
+// addContractDeltasFromChanges adds the deltas of Stellar Asset Contract
+// balance entries. Balance entries are keyed by contract ID, which is mapped
+// back to the asset of the events. Entries of contracts without events are
+// keyed by contract ID so that they are reported as mismatches.
+func (v *RangeVerifier) addContractDeltasFromChanges(events []*TokenTransferEvent, changes []ingest.Change, m map[balanceKey]int64) {
+	assets := map[string]string{}
+	for _, event := range events {
+		if eventAsset := event.GetAsset(); eventAsset != nil {
+			assets[event.GetMeta().GetContractAddress()] = eventAsset.ToXdrAsset().StringCanonical()
+		}
+	}
+
+	for _, change := range changes {
+		if change.Type != xdr.LedgerEntryTypeContractData {
+			continue
+		}
+		var contractID, holder string
+		var pre, post int64
+		var found bool
+		for _, entry := range []*xdr.LedgerEntry{change.Pre, change.Post} {
+			if entry == nil {
+				continue
+			}
+			holderID, balance, ok := sac.ContractBalanceFromContractData(*entry, v.networkPassphrase)
+			if !ok || !balance.IsInt64() {
+				continue
+			}
+			contract := entry.Data.MustContractData().Contract.ContractId
+			if contract == nil {
+				continue
+			}
+			contractID = strkey.MustEncode(strkey.VersionByteContract, contract[:])
+			holder = strkey.MustEncode(strkey.VersionByteContract, holderID[:])
+			found = true
+			if entry == change.Pre {
+				pre = balance.Int64()
+			} else {
+				post = balance.Int64()
+			}
+		}
+		if !found {
+			continue
+		}
+		asset, ok := assets[contractID]
+		if !ok {
+			asset = contractID
+		}
+		addContractDelta(m, balanceKey{holder: holder, asset: asset}, post-pre)
+	}
+}
@@ -0,0 +1,170 @@
+package token_transfer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/ingest/ledgerbackend"
+	"github.com/stellar/go/ingest/sac"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/xdr"
+)
+
+// paymentLedger returns a ledger with a single transaction in which accountA
+// pays 70 XLM to accountB. The balance of accountB is credited with received
+// instead of 70 XLM.
+func paymentLedger(t *testing.T, seq uint32, received xdr.Int64, opChanges ...xdr.LedgerEntryChange) xdr.LedgerCloseMeta {
+	envelope := xdr.TransactionEnvelope{
+		Type: xdr.EnvelopeTypeEnvelopeTypeTx,
+		V1: &xdr.TransactionV1Envelope{
+			Tx: xdr.Transaction{
+				SourceAccount: accountA,
+				Fee:           100,
+				SeqNum:        xdr.SequenceNumber(seq),
+				Operations:    []xdr.Operation{paymentOp(nil, accountB, xlmAsset, 70*oneUnit)},
+			},
+		},
+	}
+	hash, err := network.HashTransactionInEnvelope(envelope, network.TestNetworkPassphrase)
+	require.NoError(t, err)
+
+	opChanges = append(xdr.LedgerEntryChanges{
+		generateAccountEntryChangState(accountEntry(accountA, 1000*oneUnit-100)),
+		generateAccountEntryUpdatedChange(accountEntry(accountA, 1000*oneUnit-100), 930*oneUnit-100),
+		generateAccountEntryChangState(accountEntry(accountB, 0)),
+		generateAccountEntryUpdatedChange(accountEntry(accountB, 0), received),
+	}, opChanges...)
+
+	return xdr.LedgerCloseMeta{
+		V: 0,
+		V0: &xdr.LedgerCloseMetaV0{
+			LedgerHeader: xdr.LedgerHeaderHistoryEntry{
+				Header: xdr.LedgerHeader{
+					LedgerVersion: 20,
+					LedgerSeq:     xdr.Uint32(seq),
+				},
+			},
+			TxSet: xdr.TransactionSet{Txs: []xdr.TransactionEnvelope{envelope}},
+			TxProcessing: []xdr.TransactionResultMeta{{
+				Result: xdr.TransactionResultPair{
+					TransactionHash: hash,
+					Result: xdr.TransactionResult{
+						FeeCharged: 100,
+						Result: xdr.TransactionResultResult{
+							Code: xdr.TransactionResultCodeTxSuccess,
+							Results: &[]xdr.OperationResult{{
+								Code: xdr.OperationResultCodeOpInner,
+								Tr: &xdr.OperationResultTr{
+									Type:          xdr.OperationTypePayment,
+									PaymentResult: &xdr.PaymentResult{Code: xdr.PaymentResultCodePaymentSuccess},
+								},
+							}},
+						},
+					},
+				},
+				FeeProcessing: xdr.LedgerEntryChanges{
+					generateAccountEntryChangState(accountEntry(accountA, 1000*oneUnit)),
+					generateAccountEntryUpdatedChange(accountEntry(accountA, 1000*oneUnit), 1000*oneUnit-100),
+				},
+				TxApplyProcessing: xdr.TransactionMeta{
+					V:  3,
+					V3: &xdr.TransactionMetaV3{Operations: []xdr.OperationMeta{{Changes: opChanges}}},
+				},
+			}},
+		},
+	}
+}
+
+func mockBackend(ledgers ...xdr.LedgerCloseMeta) *ledgerbackend.MockDatabaseBackend {
+	backend := &ledgerbackend.MockDatabaseBackend{}
+	backend.On("IsPrepared", mock.Anything, mock.Anything).Return(false, nil)
+	backend.On("PrepareRange", mock.Anything, mock.Anything).Return(nil)
+	for _, ledger := range ledgers {
+		backend.On("GetLedger", mock.Anything, ledger.LedgerSequence()).Return(ledger, nil)
+	}
+	return backend
+}
+
+func TestRangeVerifierCollectsMismatches(t *testing.T) {
+	backend := mockBackend(
+		paymentLedger(t, 10, 70*oneUnit),
+		paymentLedger(t, 11, 80*oneUnit),
+		paymentLedger(t, 12, 90*oneUnit),
+	)
+
+	var handled, progress []uint32
+	verifier := NewRangeVerifier(backend, network.TestNetworkPassphrase,
+		WithMismatchHandler(func(m Mismatch) { handled = append(handled, m.LedgerSequence) }),
+		WithProgressHandler(func(ledger uint32, _ *VerificationReport) { progress = append(progress, ledger) }),
+	)
+	report, err := verifier.Verify(context.Background(), ledgerbackend.BoundedRange(10, 12))
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, report.Ledgers)
+	assert.Equal(t, 3, report.Transactions)
+	assert.Equal(t, 6, report.Events)
+	assert.Equal(t, VerificationStats{Count: 3, Events: 3}, report.Fees)
+	assert.Equal(t, map[xdr.OperationType]*VerificationStats{
+		xdr.OperationTypePayment: {Count: 3, Events: 3, Mismatches: 2},
+	}, report.Operations)
+	assert.Equal(t, []uint32{11, 12}, handled)
+	assert.Equal(t, []uint32{10, 11, 12}, progress)
+
+	require.Len(t, report.Mismatches, 2)
+	mismatch := report.Mismatches[0]
+	assert.Equal(t, uint32(11), mismatch.LedgerSequence)
+	require.NotNil(t, mismatch.OperationIndex)
+	assert.Equal(t, uint32(0), *mismatch.OperationIndex)
+	assert.Equal(t, xdr.OperationTypePayment, *mismatch.OperationType)
+	assert.Equal(t, []BalanceDiff{{
+		Holder:       accountB.Address(),
+		Asset:        xlmAsset.StringCanonical(),
+		EventsDelta:  int64(70 * oneUnit),
+		ChangesDelta: int64(80 * oneUnit),
+	}}, mismatch.Diffs)
+	assert.Contains(t, mismatch.String(), "operation: 0 (OperationTypePayment)")
+}
+
+func TestRangeVerifierContractHolders(t *testing.T) {
+	usdcContractID, err := usdcAsset.ContractID(network.TestNetworkPassphrase)
+	require.NoError(t, err)
+	holder := [32]byte{7}
+
+	// A contract balance entry changes without any event accounting for it.
+	pre := xdr.LedgerEntry{Data: sac.BalanceToContractData(usdcContractID, holder, 10)}
+	post := xdr.LedgerEntry{Data: sac.BalanceToContractData(usdcContractID, holder, 25)}
+	ledger := paymentLedger(t, 10, 70*oneUnit,
+		xdr.LedgerEntryChange{Type: xdr.LedgerEntryChangeTypeLedgerEntryState, State: &pre},
+		xdr.LedgerEntryChange{Type: xdr.LedgerEntryChangeTypeLedgerEntryUpdated, Updated: &post},
+	)
+
+	report, err := NewRangeVerifier(mockBackend(ledger), network.TestNetworkPassphrase).
+		Verify(context.Background(), ledgerbackend.SingleLedgerRange(10))
+	require.NoError(t, err)
+	assert.Empty(t, report.Mismatches)
+
+	report, err = NewRangeVerifier(mockBackend(ledger), network.TestNetworkPassphrase, WithContractHolders()).
+		Verify(context.Background(), ledgerbackend.SingleLedgerRange(10))
+	require.NoError(t, err)
+	require.Len(t, report.Mismatches, 1)
+	assert.Equal(t, []BalanceDiff{{
+		Holder:       strkey.MustEncode(strkey.VersionByteContract, holder[:]),
+		Asset:        strkey.MustEncode(strkey.VersionByteContract, usdcContractID[:]),
+		ChangesDelta: 15,
+	}}, report.Mismatches[0].Diffs)
+}
+
+func TestRangeVerifierUnboundedStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	verifier := NewRangeVerifier(mockBackend(paymentLedger(t, 10, 70*oneUnit)), network.TestNetworkPassphrase,
+		WithProgressHandler(func(uint32, *VerificationReport) { cancel() }),
+	)
+	report, err := verifier.Verify(ctx, ledgerbackend.UnboundedRange(10))
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, report.Ledgers)
+}
@@ -0,0 +1,235 @@
+// Package trade_aggregation computes the OHLCV candles served by Horizon's
+// /trade_aggregations endpoint from trades extracted with processors/trades.
+package trade_aggregation
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/processors/trades"
+	"github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/xdr"
+)
+
+// The resolutions supported by Horizon.
+const (
+	MinuteResolution        = time.Minute
+	FiveMinuteResolution    = 5 * time.Minute
+	FifteenMinuteResolution = 15 * time.Minute
+	HourResolution          = time.Hour
+	DayResolution           = 24 * time.Hour
+	WeekResolution          = 7 * 24 * time.Hour
+)
+
+var (
+	ErrInvalidResolution = errors.New("resolution is not supported")
+	ErrInvalidOffset     = errors.New("offset must be a multiple of an hour, smaller than the resolution and less than 24 hours")
+	ErrSameAssets        = errors.New("base and counter assets must be different")
+	ErrFlushedBucket     = errors.New("trade belongs to a bucket which was already flushed")
+)
+
+func validResolution(resolution time.Duration) bool {
+	switch resolution {
+	case MinuteResolution, FiveMinuteResolution, FifteenMinuteResolution,
+		HourResolution, DayResolution, WeekResolution:
+		return true
+	default:
+		return false
+	}
+}
+
+// tradeKey orders the trades of a bucket to determine its open and close.
+type tradeKey struct {
+	operationID int64
+	order       uint32
+}
+
+func (k tradeKey) less(other tradeKey) bool {
+	if k.operationID != other.operationID {
+		return k.operationID < other.operationID
+	}
+	return k.order < other.order
+}
+
+type bucket struct {
+	timestamp     int64
+	count         int64
+	baseVolume    *big.Int
+	counterVolume *big.Int
+	high, low     *big.Rat
+	open, close   *big.Rat
+	first, last   tradeKey
+}
+
+// Aggregator folds the trades of an asset pair into the buckets of a
+// resolution.
+//
+// Trades can be added in any order, as the open and close of a bucket are
+// determined by the position of the trades in the ledger rather than the
+// order in which they are added, but each trade must be added only once.
+// Trades of buckets which were already flushed are rejected with
+// ErrFlushedBucket rather than starting a new partial bucket.
+type Aggregator struct {
+	base, counter xdr.Asset
+	resolution    int64
+	offset        int64
+	buckets       map[int64]*bucket
+	// flushedUntil is the latest time, in milliseconds since the Unix epoch,
+	// given to Flush.
+	flushedUntil int64
+}
+
+// NewAggregator returns an Aggregator for trades between base and counter
+// bucketed by resolution. Like in Horizon, buckets start at offset from the
+// Unix epoch, which is only allowed for resolutions larger than an hour.
+func NewAggregator(base, counter xdr.Asset, resolution, offset time.Duration) (*Aggregator, error) {
+	if base.Equals(counter) {
+		return nil, ErrSameAssets
+	}
+	if !validResolution(resolution) {
+		return nil, ErrInvalidResolution
+	}
+	if offset < 0 || offset%time.Hour != 0 || offset >= resolution || offset >= 24*time.Hour {
+		return nil, ErrInvalidOffset
+	}
+	return &Aggregator{
+		base:       base,
+		counter:    counter,
+		resolution: resolution.Milliseconds(),
+		offset:     offset.Milliseconds(),
+		buckets:    map[int64]*bucket{},
+		// no bucket ends before the smallest timestamp
+		flushedUntil: math.MinInt64,
+	}, nil
+}
+
+// BucketTimestamp returns the start of the bucket to which a trade closed at
+// the given time belongs, in milliseconds since the Unix epoch.
+func (a *Aggregator) BucketTimestamp(t time.Time) int64 {
+	ms := t.UnixMilli() - a.offset
+	bucket := ms / a.resolution
+	if ms < 0 && ms%a.resolution != 0 {
+		bucket--
+	}
+	return bucket*a.resolution + a.offset
+}
+
+// Add folds a trade into its bucket and returns the updated bucket, so live
+// ingestion can publish it right away. Trades of other asset pairs are
+// ignored, in which case false is returned. Trades of buckets which were
+// already flushed are rejected with ErrFlushedBucket.
+func (a *Aggregator) Add(trade trades.Trade) (horizon.TradeAggregation, bool, error) {
+	var baseAmount, counterAmount xdr.Int64
+	switch {
+	case trade.BaseAsset.Equals(a.base) && trade.CounterAsset.Equals(a.counter):
+		baseAmount, counterAmount = trade.BaseAmount, trade.CounterAmount
+	case trade.BaseAsset.Equals(a.counter) && trade.CounterAsset.Equals(a.base):
+		baseAmount, counterAmount = trade.CounterAmount, trade.BaseAmount
+	default:
+		return horizon.TradeAggregation{}, false, nil
+	}
+
+	timestamp := a.BucketTimestamp(trade.LedgerCloseTime)
+	if timestamp+a.resolution <= a.flushedUntil {
+		return horizon.TradeAggregation{}, false, ErrFlushedBucket
+	}
+
+	price := big.NewRat(int64(counterAmount), int64(baseAmount))
+	key := tradeKey{operationID: trade.OperationID, order: trade.Order}
+	b, ok := a.buckets[timestamp]
+	if !ok {
+		b = &bucket{
+			timestamp:     timestamp,
+			baseVolume:    new(big.Int),
+			counterVolume: new(big.Int),
+			high:          price,
+			low:           price,
+			open:          price,
+			close:         price,
+			first:         key,
+			last:          key,
+		}
+		a.buckets[timestamp] = b
+	}
+
+	b.count++
+	b.baseVolume.Add(b.baseVolume, big.NewInt(int64(baseAmount)))
+	b.counterVolume.Add(b.counterVolume, big.NewInt(int64(counterAmount)))
+	if price.Cmp(b.high) > 0 {
+		b.high = price
+	}
+	if price.Cmp(b.low) < 0 {
+		b.low = price
+	}
+	if key.less(b.first) {
+		b.first, b.open = key, price
+	}
+	if b.last.less(key) {
+		b.last, b.close = key, price
+	}
+	return b.aggregation(), true, nil
+}
+
+// Buckets returns the aggregation of every bucket with trades, in
+// chronological order.
+func (a *Aggregator) Buckets() []horizon.TradeAggregation {
+	timestamps := make([]int64, 0, len(a.buckets))
+	for timestamp := range a.buckets {
+		timestamps = append(timestamps, timestamp)
+	}
+	sort.Slice(timestamps, func(i, j int) bool { return timestamps[i] < timestamps[j] })
+
+	result := make([]horizon.TradeAggregation, 0, len(timestamps))
+	for _, timestamp := range timestamps {
+		result = append(result, a.buckets[timestamp].aggregation())
+	}
+	return result
+}
+
+// Flush returns, in chronological order, the buckets which end at or before
+// the given time and forgets them. Live ingestion can call it with the close
+// time of the latest ledger to emit the candles which cannot change anymore
+// and keep the memory usage bounded. Trades of the flushed buckets cannot be
+// added afterwards.
+func (a *Aggregator) Flush(until time.Time) []horizon.TradeAggregation {
+	a.flushedUntil = max(a.flushedUntil, until.UnixMilli())
+	var result []horizon.TradeAggregation
+	for _, aggregation := range a.Buckets() {
+		if aggregation.Timestamp+a.resolution > until.UnixMilli() {
+			break
+		}
+		result = append(result, aggregation)
+		delete(a.buckets, aggregation.Timestamp)
+	}
+	return result
+}
+
+func (b *bucket) aggregation() horizon.TradeAggregation {
+	// volumes are sums of amounts, which are valid amounts themselves
+	baseVolume, _ := amount.IntStringToAmount(b.baseVolume.String())
+	counterVolume, _ := amount.IntStringToAmount(b.counterVolume.String())
+	average := new(big.Rat).SetFrac(b.counterVolume, b.baseVolume)
+	return horizon.TradeAggregation{
+		Timestamp:     b.timestamp,
+		TradeCount:    b.count,
+		BaseVolume:    baseVolume,
+		CounterVolume: counterVolume,
+		Average:       average.FloatString(7),
+		High:          b.high.FloatString(7),
+		HighR:         tradePrice(b.high),
+		Low:           b.low.FloatString(7),
+		LowR:          tradePrice(b.low),
+		Open:          b.open.FloatString(7),
+		OpenR:         tradePrice(b.open),
+		Close:         b.close.FloatString(7),
+		CloseR:        tradePrice(b.close),
+	}
+}
+
+func tradePrice(price *big.Rat) horizon.TradePrice {
+	return horizon.TradePrice{N: price.Num().Int64(), D: price.Denom().Int64()}
+}
@@ -0,0 +1,164 @@
+package trade_aggregation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/processors/trades"
+	"github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/xdr"
+)
+
+var (
+	issuer = keypair.Root("issuer").Address()
+	xlm    = xdr.MustNewNativeAsset()
+	usdc   = xdr.MustNewCreditAsset("USDC", issuer)
+	eurc   = xdr.MustNewCreditAsset("EURC", issuer)
+	start  = time.Date(2023, 11, 14, 0, 0, 0, 0, time.UTC)
+)
+
+func trade(opID int64, closedAt time.Time, baseAmount, counterAmount xdr.Int64) trades.Trade {
+	return trades.Trade{
+		OperationID:     opID,
+		LedgerCloseTime: closedAt,
+		BaseAsset:       xlm,
+		BaseAmount:      baseAmount,
+		CounterAsset:    usdc,
+		CounterAmount:   counterAmount,
+	}
+}
+
+func TestNewAggregator(t *testing.T) {
+	_, err := NewAggregator(xlm, usdc, 2*time.Minute, 0)
+	assert.ErrorIs(t, err, ErrInvalidResolution)
+	_, err = NewAggregator(xlm, usdc, HourResolution, time.Hour)
+	assert.ErrorIs(t, err, ErrInvalidOffset)
+	_, err = NewAggregator(xlm, usdc, DayResolution, 90*time.Minute)
+	assert.ErrorIs(t, err, ErrInvalidOffset)
+	_, err = NewAggregator(xlm, usdc, WeekResolution, 24*time.Hour)
+	assert.ErrorIs(t, err, ErrInvalidOffset)
+	_, err = NewAggregator(xlm, xlm, DayResolution, 0)
+	assert.ErrorIs(t, err, ErrSameAssets)
+	_, err = NewAggregator(xlm, usdc, WeekResolution, 23*time.Hour)
+	assert.NoError(t, err)
+}
+
+func TestBucketTimestamp(t *testing.T) {
+	a, err := NewAggregator(xlm, usdc, DayResolution, 2*time.Hour)
+	require.NoError(t, err)
+
+	assert.Equal(t, start.Add(-22*time.Hour).UnixMilli(), a.BucketTimestamp(start.Add(time.Hour)))
+	assert.Equal(t, start.Add(2*time.Hour).UnixMilli(), a.BucketTimestamp(start.Add(2*time.Hour)))
+	assert.Equal(t, start.Add(2*time.Hour).UnixMilli(), a.BucketTimestamp(start.Add(25*time.Hour)))
+	assert.Equal(t, int64(-22*time.Hour/time.Millisecond), a.BucketTimestamp(time.UnixMilli(-1)))
+}
+
+func TestAggregation(t *testing.T) {
+	a, err := NewAggregator(xlm, usdc, FiveMinuteResolution, 0)
+	require.NoError(t, err)
+
+	// added out of order, the open and close follow the position in the ledger
+	_, ok, err := a.Add(trade(3, start.Add(2*time.Minute), 30_0000000, 4_0000000))
+	require.NoError(t, err)
+	require.True(t, ok)
+	_, ok, err = a.Add(trade(1, start.Add(time.Minute), 10_0000000, 1_0000000))
+	require.NoError(t, err)
+	require.True(t, ok)
+	updated, ok, err := a.Add(trade(2, start.Add(time.Minute), 20_0000000, 6_0000000))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	expected := horizon.TradeAggregation{
+		Timestamp:     start.UnixMilli(),
+		TradeCount:    3,
+		BaseVolume:    "60.0000000",
+		CounterVolume: "11.0000000",
+		Average:       "0.1833333",
+		High:          "0.3000000",
+		HighR:         horizon.TradePrice{N: 3, D: 10},
+		Low:           "0.1000000",
+		LowR:          horizon.TradePrice{N: 1, D: 10},
+		Open:          "0.1000000",
+		OpenR:         horizon.TradePrice{N: 1, D: 10},
+		Close:         "0.1333333",
+		CloseR:        horizon.TradePrice{N: 2, D: 15},
+	}
+	assert.Equal(t, expected, updated)
+
+	// trades of the opposite pair are inverted, others are ignored
+	inverted := trade(4, start.Add(7*time.Minute), 1_0000000, 5_0000000)
+	inverted.BaseAsset, inverted.CounterAsset = usdc, xlm
+	_, ok, err = a.Add(inverted)
+	require.NoError(t, err)
+	require.True(t, ok)
+	other := trade(5, start.Add(7*time.Minute), 1_0000000, 1_0000000)
+	other.CounterAsset = eurc
+	_, ok, err = a.Add(other)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	buckets := a.Buckets()
+	require.Len(t, buckets, 2)
+	assert.Equal(t, expected, buckets[0])
+	assert.Equal(t, start.Add(5*time.Minute).UnixMilli(), buckets[1].Timestamp)
+	assert.Equal(t, "5.0000000", buckets[1].BaseVolume)
+	assert.Equal(t, "1.0000000", buckets[1].CounterVolume)
+	assert.Equal(t, horizon.TradePrice{N: 1, D: 5}, buckets[1].OpenR)
+
+	// only complete buckets are flushed
+	assert.Empty(t, a.Flush(start.Add(4*time.Minute)))
+	assert.Equal(t, []horizon.TradeAggregation{expected}, a.Flush(start.Add(9*time.Minute)))
+	assert.Len(t, a.Buckets(), 1)
+}
+
+func TestAggregationLargeVolumes(t *testing.T) {
+	a, err := NewAggregator(xlm, usdc, MinuteResolution, 0)
+	require.NoError(t, err)
+
+	maxInt64 := xdr.Int64(1<<63 - 1)
+	a.Add(trade(1, start, maxInt64, maxInt64))
+	aggregation, _, _ := a.Add(trade(2, start, maxInt64, maxInt64))
+	assert.Equal(t, "1844674407370.9551614", aggregation.BaseVolume)
+	assert.Equal(t, "1.0000000", aggregation.Average)
+}
+
+func TestAggregationExactPrices(t *testing.T) {
+	a, err := NewAggregator(usdc, xlm, MinuteResolution, 0)
+	require.NoError(t, err)
+
+	// 2^63-1 and 10^8 are coprime so neither price can be reduced
+	maxInt64 := xdr.Int64(1<<63 - 1)
+	a.Add(trade(1, start, 10_0000000, maxInt64))
+	aggregation, _, _ := a.Add(trade(2, start, maxInt64, 10_0000000))
+	assert.Equal(t, horizon.TradePrice{N: 10_0000000, D: int64(maxInt64)}, aggregation.OpenR)
+	assert.Equal(t, horizon.TradePrice{N: int64(maxInt64), D: 10_0000000}, aggregation.CloseR)
+	assert.Equal(t, aggregation.CloseR, aggregation.HighR)
+	assert.Equal(t, aggregation.OpenR, aggregation.LowR)
+}
+
+func TestAggregationFlushedBucket(t *testing.T) {
+	a, err := NewAggregator(xlm, usdc, MinuteResolution, 0)
+	require.NoError(t, err)
+
+	_, _, err = a.Add(trade(1, start, 1_0000000, 1_0000000))
+	require.NoError(t, err)
+	require.Len(t, a.Flush(start.Add(time.Minute)), 1)
+
+	_, ok, err := a.Add(trade(2, start.Add(59*time.Second), 1_0000000, 1_0000000))
+	assert.ErrorIs(t, err, ErrFlushedBucket)
+	assert.False(t, ok)
+	assert.Empty(t, a.Buckets())
+
+	// an earlier flush does not reopen the buckets
+	assert.Empty(t, a.Flush(start))
+	_, _, err = a.Add(trade(3, start.Add(-time.Minute), 1_0000000, 1_0000000))
+	assert.ErrorIs(t, err, ErrFlushedBucket)
+
+	_, ok, err = a.Add(trade(4, start.Add(time.Minute), 1_0000000, 1_0000000))
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
@@ -0,0 +1,349 @@
+// Package trades extracts the trades executed by offers and path payments,
+// as served by Horizon's /trades endpoints, directly from ledger data.
+package trades
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/big"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/ingest"
+	"github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/support/render/hal"
+	"github.com/stellar/go/toid"
+	"github.com/stellar/go/xdr"
+)
+
+// TradeType tells whether the seller of a trade is an offer of the order book
+// or a liquidity pool.
+type TradeType string
+
+const (
+	TradeTypeOrderBook     TradeType = "orderbook"
+	TradeTypeLiquidityPool TradeType = "liquidity_pool"
+)
+
+// Trade is the exchange of assets recorded by a single claim atom.
+//
+// Horizon orders the assets of a trade by their internal database ids, which
+// depend on the order in which assets were first seen. Trades here use the
+// canonical order of xdr.Asset.LessThan instead, so the base asset is always
+// the lesser one and a given pair of assets is always reported the same way.
+type Trade struct {
+	// OperationID is the TOID of the operation which executed the trade.
+	OperationID int64
+	// Order is the 0-indexed position of the claim atom in the operation
+	// result. Claim atoms of offers garbage collected by stellar-core, which
+	// have zero amounts, produce no trade but keep their position.
+	Order           uint32
+	LedgerCloseTime time.Time
+	Type            TradeType
+
+	BaseAsset     xdr.Asset
+	BaseAmount    xdr.Int64
+	BaseAccount   string
+	BaseOfferID   int64
+	BasePoolID    *xdr.PoolId
+	CounterAsset  xdr.Asset
+	CounterAmount xdr.Int64
+	// CounterAccount, CounterOfferID and CounterPoolID are only set when base
+	// is the buyer, BaseAccount, BaseOfferID and BasePoolID otherwise.
+	CounterAccount string
+	CounterOfferID int64
+	CounterPoolID  *xdr.PoolId
+
+	// BaseIsSeller is true when the base asset was sold by the offer or pool
+	// which was crossed.
+	BaseIsSeller bool
+	// LiquidityPoolFeeBP is the fee of the pool of liquidity pool trades, in
+	// basis points.
+	LiquidityPoolFeeBP uint32
+	// Price is the amount of counter asset paid for a unit of base asset,
+	// reduced to lowest terms.
+	Price horizon.TradePrice
+}
+
+// PagingToken returns the identifier Horizon uses for the trade.
+func (t Trade) PagingToken() string {
+	return fmt.Sprintf("%d-%d", t.OperationID, t.Order)
+}
+
+// Processor extracts trades from transactions.
+type Processor struct {
+	networkPassphrase string
+	links             hal.LinkBuilder
+}
+
+type Option func(*Processor)
+
+// WithBaseURL sets the Horizon URL against which the links of the resources
+// returned by HorizonTrade are resolved. By default links are relative, e.g.
+// "/operations/123".
+func WithBaseURL(baseURL *url.URL) Option {
+	return func(p *Processor) {
+		p.links.Base = baseURL
+	}
+}
+
+func NewProcessor(networkPassphrase string, options ...Option) *Processor {
+	p := &Processor{networkPassphrase: networkPassphrase}
+	for _, opt := range options {
+		opt(p)
+	}
+	return p
+}
+
+// TradesFromLedger returns the trades of all the transactions of a ledger.
+func (p *Processor) TradesFromLedger(lcm xdr.LedgerCloseMeta) ([]Trade, error) {
+	reader, err := ingest.NewLedgerTransactionReaderFromLedgerCloseMeta(p.networkPassphrase, lcm)
+	if err != nil {
+		return nil, fmt.Errorf("error creating transaction reader: %w", err)
+	}
+	defer reader.Close()
+
+	var result []Trade
+	for {
+		tx, err := reader.Read()
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading transaction: %w", err)
+		}
+		txTrades, err := p.TradesFromTransaction(tx)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, txTrades...)
+	}
+}
+
+// TradesFromTransaction returns the trades of every operation of a
+// transaction. Failed transactions have no trades.
+func (p *Processor) TradesFromTransaction(tx ingest.LedgerTransaction) ([]Trade, error) {
+	if !tx.Successful() {
+		return nil, nil
+	}
+	var result []Trade
+	for i := range tx.Envelope.Operations() {
+		opTrades, err := p.TradesFromOperation(tx, uint32(i))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, opTrades...)
+	}
+	return result, nil
+}
+
+// TradesFromOperation returns the trades of the operation at the given
+// 0-indexed position of a transaction.
+func (p *Processor) TradesFromOperation(tx ingest.LedgerTransaction, opIndex uint32) ([]Trade, error) {
+	if !tx.Successful() {
+		return nil, nil
+	}
+	op, ok := tx.GetOperation(opIndex)
+	if !ok {
+		return nil, fmt.Errorf("operation %d not found in transaction %s", opIndex, tx.Hash.HexString())
+	}
+	results, ok := tx.Result.OperationResults()
+	if !ok || int(opIndex) >= len(results) {
+		return nil, fmt.Errorf("operation result %d not found in transaction %s", opIndex, tx.Hash.HexString())
+	}
+	claims, buyOffer := claimsAndBuyOffer(results[opIndex])
+	if len(claims) == 0 {
+		return nil, nil
+	}
+
+	opID := toid.New(int32(tx.Ledger.LedgerSequence()), int32(tx.Index), int32(opIndex+1)).ToInt64()
+	// Like Horizon, a synthetic offer id derived from the operation id
+	// identifies the buyer when its offer did not remain on the book.
+	buyOfferID := toid.EncodeOfferId(uint64(opID), toid.TOIDType)
+	if buyOffer != nil {
+		buyOfferID = int64(buyOffer.OfferId)
+	}
+	source := op.SourceAccount
+	if source == nil {
+		envelopeSource := tx.Envelope.SourceAccount()
+		source = &envelopeSource
+	}
+	buyer := source.ToAccountId().Address()
+
+	var (
+		changes []ingest.Change
+		trades  []Trade
+	)
+	for order, claim := range claims {
+		if claim.AmountSold() == 0 && claim.AmountBought() == 0 {
+			continue
+		}
+		if claim.AmountSold() == 0 || claim.AmountBought() == 0 {
+			return nil, fmt.Errorf("claim atom %d of operation %d in transaction %s has a zero amount",
+				order, opIndex, tx.Hash.HexString())
+		}
+
+		// the seller is the offer or the pool which was crossed
+		trade := Trade{
+			OperationID:     opID,
+			Order:           uint32(order),
+			LedgerCloseTime: tx.Ledger.ClosedAt(),
+			Type:            TradeTypeOrderBook,
+			BaseAsset:       claim.AssetSold(),
+			BaseAmount:      claim.AmountSold(),
+			BaseIsSeller:    true,
+			CounterAsset:    claim.AssetBought(),
+			CounterAmount:   claim.AmountBought(),
+			CounterAccount:  buyer,
+			CounterOfferID:  buyOfferID,
+		}
+		if claim.Type == xdr.ClaimAtomTypeClaimAtomTypeLiquidityPool {
+			if changes == nil {
+				var err error
+				if changes, err = tx.GetOperationChanges(opIndex); err != nil {
+					return nil, fmt.Errorf("error reading changes of operation %d in transaction %s: %w",
+						opIndex, tx.Hash.HexString(), err)
+				}
+			}
+			poolID := claim.MustLiquidityPool().LiquidityPoolId
+			fee, err := liquidityPoolFee(changes, poolID)
+			if err != nil {
+				return nil, fmt.Errorf("error processing claim atom %d of operation %d in transaction %s: %w",
+					order, opIndex, tx.Hash.HexString(), err)
+			}
+			trade.Type = TradeTypeLiquidityPool
+			trade.BasePoolID = &poolID
+			trade.LiquidityPoolFeeBP = fee
+		} else {
+			seller := claim.SellerId()
+			trade.BaseAccount = seller.Address()
+			trade.BaseOfferID = int64(claim.OfferId())
+		}
+		if trade.CounterAsset.LessThan(trade.BaseAsset) {
+			trade = trade.invert()
+		}
+		price := big.NewRat(int64(trade.CounterAmount), int64(trade.BaseAmount))
+		trade.Price = horizon.TradePrice{N: price.Num().Int64(), D: price.Denom().Int64()}
+		trades = append(trades, trade)
+	}
+	return trades, nil
+}
+
+// claimsAndBuyOffer returns the claim atoms of an operation result, and the
+// offer of the operation source which remained on the book, if any.
+func claimsAndBuyOffer(result xdr.OperationResult) ([]xdr.ClaimAtom, *xdr.OfferEntry) {
+	tr, ok := result.GetTr()
+	if !ok {
+		return nil, nil
+	}
+
+	var success *xdr.ManageOfferSuccessResult
+	switch tr.Type {
+	case xdr.OperationTypeManageSellOffer:
+		success = tr.MustManageSellOfferResult().Success
+	case xdr.OperationTypeManageBuyOffer:
+		success = tr.MustManageBuyOfferResult().Success
+	case xdr.OperationTypeCreatePassiveSellOffer:
+		success = tr.MustCreatePassiveSellOfferResult().Success
+	case xdr.OperationTypePathPaymentStrictReceive:
+		if s := tr.MustPathPaymentStrictReceiveResult().Success; s != nil {
+			return s.Offers, nil
+		}
+		return nil, nil
+	case xdr.OperationTypePathPaymentStrictSend:
+		if s := tr.MustPathPaymentStrictSendResult().Success; s != nil {
+			return s.Offers, nil
+		}
+		return nil, nil
+	default:
+		return nil, nil
+	}
+	if success == nil {
+		return nil, nil
+	}
+	switch success.Offer.Effect {
+	case xdr.ManageOfferEffectManageOfferCreated, xdr.ManageOfferEffectManageOfferUpdated:
+		return success.OffersClaimed, success.Offer.Offer
+	default:
+		return success.OffersClaimed, nil
+	}
+}
+
+func liquidityPoolFee(changes []ingest.Change, poolID xdr.PoolId) (uint32, error) {
+	for _, change := range changes {
+		if change.Type != xdr.LedgerEntryTypeLiquidityPool {
+			continue
+		}
+		entry := change.Post
+		if entry == nil {
+			entry = change.Pre
+		}
+		lp := entry.Data.MustLiquidityPool()
+		if lp.LiquidityPoolId == poolID {
+			return uint32(lp.Body.MustConstantProduct().Params.Fee), nil
+		}
+	}
+	return 0, fmt.Errorf("liquidity pool %s change not found", hex.EncodeToString(poolID[:]))
+}
+
+// invert swaps the base and counter sides of the trade.
+func (t Trade) invert() Trade {
+	t.BaseAsset, t.CounterAsset = t.CounterAsset, t.BaseAsset
+	t.BaseAmount, t.CounterAmount = t.CounterAmount, t.BaseAmount
+	t.BaseAccount, t.CounterAccount = t.CounterAccount, t.BaseAccount
+	t.BaseOfferID, t.CounterOfferID = t.CounterOfferID, t.BaseOfferID
+	t.BasePoolID, t.CounterPoolID = t.CounterPoolID, t.BasePoolID
+	t.BaseIsSeller = !t.BaseIsSeller
+	return t
+}
+
+// HorizonTrade returns the trade as served by Horizon's /trades endpoints.
+func (p *Processor) HorizonTrade(t Trade) horizon.Trade {
+	var result horizon.Trade
+	result.ID = t.PagingToken()
+	result.PT = t.PagingToken()
+	result.LedgerCloseTime = t.LedgerCloseTime
+	result.TradeType = string(t.Type)
+	result.LiquidityPoolFeeBP = t.LiquidityPoolFeeBP
+	result.BaseIsSeller = t.BaseIsSeller
+	result.Price = t.Price
+
+	result.BaseAmount = amount.String(t.BaseAmount)
+	// Extract cannot fail with string arguments
+	_ = t.BaseAsset.Extract(&result.BaseAssetType, &result.BaseAssetCode, &result.BaseAssetIssuer)
+	result.BaseAccount = t.BaseAccount
+	result.CounterAmount = amount.String(t.CounterAmount)
+	_ = t.CounterAsset.Extract(&result.CounterAssetType, &result.CounterAssetCode, &result.CounterAssetIssuer)
+	result.CounterAccount = t.CounterAccount
+
+	if t.BaseOfferID != 0 {
+		result.BaseOfferID = strconv.FormatInt(t.BaseOfferID, 10)
+	}
+	if t.CounterOfferID != 0 {
+		result.CounterOfferID = strconv.FormatInt(t.CounterOfferID, 10)
+	}
+	// the deprecated offer_id is the id of the offer which was crossed
+	if t.BaseIsSeller {
+		result.OfferID = result.BaseOfferID
+	} else {
+		result.OfferID = result.CounterOfferID
+	}
+
+	if t.BasePoolID != nil {
+		result.BaseLiquidityPoolID = hex.EncodeToString(t.BasePoolID[:])
+		result.Links.Base = p.links.Link("/liquidity_pools", result.BaseLiquidityPoolID)
+	} else {
+		result.Links.Base = p.links.Link("/accounts", t.BaseAccount)
+	}
+	if t.CounterPoolID != nil {
+		result.CounterLiquidityPoolID = hex.EncodeToString(t.CounterPoolID[:])
+		result.Links.Counter = p.links.Link("/liquidity_pools", result.CounterLiquidityPoolID)
+	} else {
+		result.Links.Counter = p.links.Link("/accounts", t.CounterAccount)
+	}
+	result.Links.Operation = p.links.Link("/operations", strconv.FormatInt(t.OperationID, 10))
+	return result
+}
@@ -0,0 +1,243 @@
+package trades
+
+import (
+	"encoding/hex"
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/ingest"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/processors/internal/processorstest"
+	"github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/toid"
+	"github.com/stellar/go/xdr"
+)
+
+var (
+	passphrase = network.TestNetworkPassphrase
+	alice      = keypair.Root("alice").Address()
+	bob        = keypair.Root("bob").Address()
+	carol      = keypair.Root("carol").Address()
+	issuer     = keypair.Root("issuer").Address()
+	xlm        = xdr.MustNewNativeAsset()
+	usdc       = xdr.MustNewCreditAsset("USDC", issuer)
+	eurc       = xdr.MustNewCreditAsset("EURC", issuer)
+	poolID, _  = xdr.NewPoolId(xlm, usdc, xdr.LiquidityPoolFeeV18)
+	opID       = toid.New(processorstest.LedgerSequence, 1, 1).ToInt64()
+)
+
+// manageSellOffer sells EURC for USDC, crossing an offer of bob, skipping an
+// offer of carol garbage collected by stellar-core and leaving the rest of
+// the offer on the book.
+func manageSellOffer() ingest.LedgerTransaction {
+	op := xdr.Operation{Body: xdr.OperationBody{
+		Type: xdr.OperationTypeManageSellOffer,
+		ManageSellOfferOp: &xdr.ManageSellOfferOp{
+			Selling: eurc,
+			Buying:  usdc,
+			Amount:  20_0000000,
+			Price:   xdr.Price{N: 1, D: 1},
+		},
+	}}
+	result := xdr.OperationResultTr{
+		Type: xdr.OperationTypeManageSellOffer,
+		ManageSellOfferResult: &xdr.ManageSellOfferResult{
+			Code: xdr.ManageSellOfferResultCodeManageSellOfferSuccess,
+			Success: &xdr.ManageOfferSuccessResult{
+				OffersClaimed: []xdr.ClaimAtom{
+					processorstest.OfferAtom(carol, 7, usdc, 0, eurc, 0),
+					processorstest.OfferAtom(bob, 8, usdc, 12_0000000, eurc, 10_0000000),
+				},
+				Offer: xdr.ManageOfferSuccessResultOffer{
+					Effect: xdr.ManageOfferEffectManageOfferCreated,
+					Offer: &xdr.OfferEntry{
+						SellerId: xdr.MustAddress(alice),
+						OfferId:  9,
+						Selling:  eurc,
+						Buying:   usdc,
+						Amount:   10_0000000,
+						Price:    xdr.Price{N: 1, D: 1},
+					},
+				},
+			},
+		},
+	}
+	return processorstest.Transaction(alice, processorstest.Operation{Op: op, Result: result})
+}
+
+// pathPaymentStrictSend sends XLM to bob, buying USDC from the XLM/USDC pool.
+func pathPaymentStrictSend() ingest.LedgerTransaction {
+	op := xdr.Operation{Body: xdr.OperationBody{
+		Type: xdr.OperationTypePathPaymentStrictSend,
+		PathPaymentStrictSendOp: &xdr.PathPaymentStrictSendOp{
+			SendAsset:   xlm,
+			SendAmount:  20_0000000,
+			Destination: xdr.MustMuxedAddress(bob),
+			DestAsset:   usdc,
+			DestMin:     9_0000000,
+		},
+	}}
+	result := xdr.OperationResultTr{
+		Type: xdr.OperationTypePathPaymentStrictSend,
+		PathPaymentStrictSendResult: &xdr.PathPaymentStrictSendResult{
+			Code: xdr.PathPaymentStrictSendResultCodePathPaymentStrictSendSuccess,
+			Success: &xdr.PathPaymentStrictSendResultSuccess{
+				Offers: []xdr.ClaimAtom{{
+					Type: xdr.ClaimAtomTypeClaimAtomTypeLiquidityPool,
+					LiquidityPool: &xdr.ClaimLiquidityAtom{
+						LiquidityPoolId: poolID,
+						AssetSold:       usdc,
+						AmountSold:      9_5000000,
+						AssetBought:     xlm,
+						AmountBought:    20_0000000,
+					},
+				}},
+				Last: xdr.SimplePaymentResult{
+					Destination: xdr.MustAddress(bob),
+					Asset:       usdc,
+					Amount:      9_5000000,
+				},
+			},
+		},
+	}
+	changes := processorstest.Updated(
+		processorstest.PoolEntry(xlm, usdc, 100_0000000, 50_0000000, 1000_0000000),
+		processorstest.PoolEntry(xlm, usdc, 120_0000000, 40_5000000, 1000_0000000),
+	)
+	return processorstest.Transaction(alice, processorstest.Operation{
+		Op:      op,
+		Result:  result,
+		Changes: []xdr.LedgerEntryChanges{changes},
+	})
+}
+
+func TestOfferTrades(t *testing.T) {
+	trades, err := NewProcessor(passphrase).TradesFromTransaction(manageSellOffer())
+	require.NoError(t, err)
+	require.Len(t, trades, 1)
+
+	// EURC is less than USDC so the seller, which sold USDC, is the counter
+	assert.Equal(t, Trade{
+		OperationID:     opID,
+		Order:           1,
+		LedgerCloseTime: processorstest.CloseTime,
+		Type:            TradeTypeOrderBook,
+		BaseAsset:       eurc,
+		BaseAmount:      10_0000000,
+		BaseAccount:     alice,
+		BaseOfferID:     9,
+		CounterAsset:    usdc,
+		CounterAmount:   12_0000000,
+		CounterAccount:  bob,
+		CounterOfferID:  8,
+		BaseIsSeller:    false,
+		Price:           horizon.TradePrice{N: 6, D: 5},
+	}, trades[0])
+	assert.Equal(t, "429496733697-1", trades[0].PagingToken())
+}
+
+func TestOfferTradesSyntheticBuyOfferID(t *testing.T) {
+	tx := manageSellOffer()
+	success := (*tx.Result.Result.Result.Results)[0].Tr.ManageSellOfferResult.Success
+	success.Offer = xdr.ManageOfferSuccessResultOffer{Effect: xdr.ManageOfferEffectManageOfferDeleted}
+
+	trades, err := NewProcessor(passphrase).TradesFromTransaction(tx)
+	require.NoError(t, err)
+	require.Len(t, trades, 1)
+	assert.Equal(t, toid.EncodeOfferId(uint64(opID), toid.TOIDType), trades[0].BaseOfferID)
+}
+
+func TestLiquidityPoolTrades(t *testing.T) {
+	trades, err := NewProcessor(passphrase).TradesFromTransaction(pathPaymentStrictSend())
+	require.NoError(t, err)
+	require.Len(t, trades, 1)
+
+	trade := trades[0]
+	assert.Equal(t, TradeTypeLiquidityPool, trade.Type)
+	assert.Equal(t, xlm, trade.BaseAsset)
+	assert.Equal(t, xdr.Int64(20_0000000), trade.BaseAmount)
+	assert.Equal(t, alice, trade.BaseAccount)
+	assert.False(t, trade.BaseIsSeller)
+	assert.Nil(t, trade.BasePoolID)
+	require.NotNil(t, trade.CounterPoolID)
+	assert.Equal(t, poolID, *trade.CounterPoolID)
+	assert.Empty(t, trade.CounterAccount)
+	assert.Zero(t, trade.CounterOfferID)
+	assert.Equal(t, uint32(xdr.LiquidityPoolFeeV18), trade.LiquidityPoolFeeBP)
+	assert.Equal(t, horizon.TradePrice{N: 19, D: 40}, trade.Price)
+}
+
+func TestLiquidityPoolTradesMissingChanges(t *testing.T) {
+	tx := pathPaymentStrictSend()
+	tx.UnsafeMeta.V4.Operations[0].Changes = nil
+	_, err := NewProcessor(passphrase).TradesFromTransaction(tx)
+	assert.ErrorContains(t, err, "change not found")
+}
+
+func TestFailedTransactionTrades(t *testing.T) {
+	tx := manageSellOffer()
+	tx.Result.Result.Result.Code = xdr.TransactionResultCodeTxFailed
+	trades, err := NewProcessor(passphrase).TradesFromTransaction(tx)
+	require.NoError(t, err)
+	assert.Empty(t, trades)
+}
+
+func TestLargeTradePrice(t *testing.T) {
+	tx := manageSellOffer()
+	success := (*tx.Result.Result.Result.Results)[0].Tr.ManageSellOfferResult.Success
+	success.OffersClaimed[1].OrderBook.AmountSold = math.MaxInt64
+
+	trades, err := NewProcessor(passphrase).TradesFromTransaction(tx)
+	require.NoError(t, err)
+	require.Len(t, trades, 1)
+	// 2^63-1 is odd and not a multiple of 5 so the price cannot be reduced
+	assert.Equal(t, horizon.TradePrice{N: math.MaxInt64, D: 10_0000000}, trades[0].Price)
+}
+
+func TestHorizonTrade(t *testing.T) {
+	p := NewProcessor(passphrase)
+	trades, err := p.TradesFromTransaction(manageSellOffer())
+	require.NoError(t, err)
+	require.Len(t, trades, 1)
+
+	trade := p.HorizonTrade(trades[0])
+	assert.Equal(t, "429496733697-1", trade.ID)
+	assert.Equal(t, "429496733697-1", trade.PT)
+	assert.Equal(t, processorstest.CloseTime, trade.LedgerCloseTime)
+	assert.Equal(t, "orderbook", trade.TradeType)
+	assert.Equal(t, "EURC", trade.BaseAssetCode)
+	assert.Equal(t, "10.0000000", trade.BaseAmount)
+	assert.Equal(t, alice, trade.BaseAccount)
+	assert.Equal(t, "9", trade.BaseOfferID)
+	assert.Equal(t, "USDC", trade.CounterAssetCode)
+	assert.Equal(t, issuer, trade.CounterAssetIssuer)
+	assert.Equal(t, "12.0000000", trade.CounterAmount)
+	assert.Equal(t, bob, trade.CounterAccount)
+	assert.Equal(t, "8", trade.CounterOfferID)
+	// the offer crossed is the one of bob, on the counter side
+	assert.Equal(t, "8", trade.OfferID)
+	assert.False(t, trade.BaseIsSeller)
+	assert.Equal(t, horizon.TradePrice{N: 6, D: 5}, trade.Price)
+	assert.Equal(t, "/operations/429496733697", trade.Links.Operation.Href)
+
+	trades, err = p.TradesFromTransaction(pathPaymentStrictSend())
+	require.NoError(t, err)
+	require.Len(t, trades, 1)
+
+	trade = p.HorizonTrade(trades[0])
+	assert.Equal(t, "liquidity_pool", trade.TradeType)
+	assert.Equal(t, uint32(30), trade.LiquidityPoolFeeBP)
+	assert.Equal(t, "native", trade.BaseAssetType)
+	assert.Equal(t, "20.0000000", trade.BaseAmount)
+	// the buyer of a pool trade has a synthetic offer id
+	assert.Equal(t, strconv.FormatInt(toid.EncodeOfferId(uint64(opID), toid.TOIDType), 10), trade.BaseOfferID)
+	assert.Equal(t, hex.EncodeToString(poolID[:]), trade.CounterLiquidityPoolID)
+	assert.Empty(t, trade.CounterAccount)
+	assert.Equal(t, "9.5000000", trade.CounterAmount)
+	assert.Equal(t, horizon.TradePrice{N: 19, D: 40}, trade.Price)
+}
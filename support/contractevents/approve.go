@@ -0,0 +1,66 @@
+package contractevents
+
+import (
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/xdr"
+)
+
+var ErrNotApproveEvent = errors.New("event is not a valid 'approve' event")
+
+type ApproveEvent struct {
+	sacEvent
+
+	From    string
+	Spender string
+	Amount  xdr.Int128Parts
+	// LiveUntilLedger is the last ledger (inclusive) in which the allowance is
+	// valid.
+	LiveUntilLedger uint32
+}
+
+// parseApproveEvent tries to parse the given topics and value as a SAC
+// "approve" event.
+//
+// Internally, it assumes that the `topics` array has already validated both the
+// function name AND the asset <--> contract ID relationship. It will return a
+// best-effort parsing even in error cases.
+func (event *ApproveEvent) parse(topics xdr.ScVec, value xdr.ScVal) error {
+	//
+	// The approve event format is:
+	//
+	// 	"approve"  	Symbol
+	//  <from> 		Address
+	//  <spender> 	Address
+	// 	<asset>		Bytes
+	//
+	// 	[<amount> i128, <live_until_ledger> u32]	Vec
+	//
+	// Reference: https://github.com/stellar/rs-soroban-env/blob/main/soroban-env-host/src/builtin_contracts/stellar_asset_contract/event.rs
+	//
+	if len(topics) != 4 {
+		return ErrNotApproveEvent
+	}
+
+	var err error
+	if event.From, err = parseAddress(topics[1]); err != nil {
+		return ErrNotApproveEvent
+	}
+	if event.Spender, err = parseAddress(topics[2]); err != nil {
+		return ErrNotApproveEvent
+	}
+
+	data, ok := value.GetVec()
+	if !ok || data == nil || len(*data) != 2 {
+		return ErrNotApproveEvent
+	}
+	if event.Amount, ok = (*data)[0].GetI128(); !ok {
+		return ErrNotApproveEvent
+	}
+	liveUntil, ok := (*data)[1].GetU32()
+	if !ok {
+		return ErrNotApproveEvent
+	}
+	event.LiveUntilLedger = uint32(liveUntil)
+
+	return nil
+}
@@ -10,6 +10,7 @@ var ErrNotClawbackEvent = errors.New("event is not a valid 'clawback' event")
 type ClawbackEvent struct {
 	sacEvent
 
+	// Admin is only set before protocol 23, which removed it from the topics.
 	Admin  string
 	From   string
 	Amount xdr.Int128Parts
@@ -32,8 +33,27 @@ func (event *ClawbackEvent) parse(topics xdr.ScVec, value xdr.ScVal) error {
 	//
 	// 	<amount> 	i128
 	//
+	// From protocol 23 onwards (CAP-67), the admin is dropped from the topics:
+	//
+	// 	"clawback" 	Symbol
+	//  <from> 		Address
+	// 	<asset>		Bytes
+	//
+	// 	<amount> 	i128
+	//
+	if len(topics) == 3 {
+		var err error
+		if event.From, err = parseAddress(topics[1]); err != nil {
+			return ErrNotClawbackEvent
+		}
+		if event.Amount, _, err = parseAmountData(value); err != nil {
+			return ErrNotClawbackEvent
+		}
+		return nil
+	}
+
 	var err error
-	event.Admin, event.From, event.Amount, err = parseBalanceChangeEvent(topics, value)
+	event.Admin, event.From, event.Amount, _, err = parseBalanceChangeEvent(topics, value)
 	if err != nil {
 		return ErrNotClawbackEvent
 	}
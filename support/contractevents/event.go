@@ -15,24 +15,30 @@ type EventType int
 // nor the other *_from variants. This is intentional from the host environment.
 
 const (
-	// Implemented
 	EventTypeTransfer EventType = iota
 	EventTypeMint
 	EventTypeClawback
 	EventTypeBurn
-	// TODO: Not implemented
+	// Deprecated: the host no longer emits "incr_allow" events, allowance
+	// changes are reported as EventTypeApprove.
 	EventTypeIncrAllow
+	// Deprecated: the host no longer emits "decr_allow" events, allowance
+	// changes are reported as EventTypeApprove.
 	EventTypeDecrAllow
 	EventTypeSetAuthorized
 	EventTypeSetAdmin
+	EventTypeApprove
 )
 
 var (
 	STELLAR_ASSET_CONTRACT_TOPICS = map[xdr.ScSymbol]EventType{
-		xdr.ScSymbol("transfer"): EventTypeTransfer,
-		xdr.ScSymbol("mint"):     EventTypeMint,
-		xdr.ScSymbol("clawback"): EventTypeClawback,
-		xdr.ScSymbol("burn"):     EventTypeBurn,
+		xdr.ScSymbol("transfer"):       EventTypeTransfer,
+		xdr.ScSymbol("mint"):           EventTypeMint,
+		xdr.ScSymbol("clawback"):       EventTypeClawback,
+		xdr.ScSymbol("burn"):           EventTypeBurn,
+		xdr.ScSymbol("approve"):        EventTypeApprove,
+		xdr.ScSymbol("set_authorized"): EventTypeSetAuthorized,
+		xdr.ScSymbol("set_admin"):      EventTypeSetAdmin,
 	}
 
 	ErrNotStellarAssetContract = errors.New("event was not from a Stellar Asset Contract")
@@ -138,6 +144,18 @@ func NewStellarAssetContractEvent(event *Event, networkPassphrase string) (Stell
 		burnEvent := BurnEvent{sacEvent: *evt}
 		return &burnEvent, burnEvent.parse(topics, value)
 
+	case EventTypeApprove:
+		approveEvent := ApproveEvent{sacEvent: *evt}
+		return &approveEvent, approveEvent.parse(topics, value)
+
+	case EventTypeSetAuthorized:
+		setAuthorizedEvent := SetAuthorizedEvent{sacEvent: *evt}
+		return &setAuthorizedEvent, setAuthorizedEvent.parse(topics, value)
+
+	case EventTypeSetAdmin:
+		setAdminEvent := SetAdminEvent{sacEvent: *evt}
+		return &setAdminEvent, setAdminEvent.parse(topics, value)
+
 	default:
 		return evt, errors.Wrapf(ErrEventUnsupported,
 			"event type %d ('%s') unsupported", evt.Type, fn)
@@ -8,6 +8,7 @@ import (
 
 	"github.com/stellar/go/gxdr"
 	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/network"
 	"github.com/stellar/go/randxdr"
 	"github.com/stellar/go/strkey"
 	"github.com/stellar/go/xdr"
@@ -63,6 +64,9 @@ func TestEventGenerator(t *testing.T) {
 		EventTypeMint,
 		EventTypeClawback,
 		EventTypeBurn,
+		EventTypeApprove,
+		EventTypeSetAuthorized,
+		EventTypeSetAdmin,
 	} {
 		event := GenerateEvent(type_, from, to, admin, xdr.MustNewNativeAsset(), big.NewInt(12345), passphrase)
 		parsedEvent, err := NewStellarAssetContractEvent(&event, passphrase)
@@ -201,6 +205,142 @@ func TestSACBurnEvent(t *testing.T) {
 	require.EqualValues(t, 0, burnEvent.Amount.Hi)
 }
 
+func TestSACApproveEvent(t *testing.T) {
+	xdrEvent := GenerateEvent(EventTypeApprove, randomAccount, zeroContract, "", randomAsset, big.NewInt(10000), passphrase)
+
+	sacEvent, err := NewStellarAssetContractEvent(&xdrEvent, passphrase)
+	require.NoError(t, err)
+	require.Equal(t, EventTypeApprove, sacEvent.GetType())
+
+	approveEvent := sacEvent.(*ApproveEvent)
+	require.Equal(t, randomAccount, approveEvent.From)
+	require.Equal(t, zeroContract, approveEvent.Spender)
+	require.EqualValues(t, 10000, approveEvent.Amount.Lo)
+	require.EqualValues(t, 0, approveEvent.LiveUntilLedger)
+
+	// the allowance expiration is mandatory
+	xdrEvent = GenerateEvent(EventTypeApprove, randomAccount, zeroContract, "", randomAsset, big.NewInt(10000), passphrase)
+	xdrEvent.Body.V0.Data = makeAmount(10000)
+	_, err = NewStellarAssetContractEvent(&xdrEvent, passphrase)
+	require.ErrorIs(t, err, ErrNotApproveEvent)
+}
+
+func TestSACSetAuthorizedEvent(t *testing.T) {
+	xdrEvent := GenerateEvent(EventTypeSetAuthorized, "", randomAccount, randomIssuer.Address(), randomAsset, big.NewInt(0), passphrase)
+
+	sacEvent, err := NewStellarAssetContractEvent(&xdrEvent, passphrase)
+	require.NoError(t, err)
+	require.Equal(t, EventTypeSetAuthorized, sacEvent.GetType())
+
+	setAuthorizedEvent := sacEvent.(*SetAuthorizedEvent)
+	require.Equal(t, randomIssuer.Address(), setAuthorizedEvent.Admin)
+	require.Equal(t, randomAccount, setAuthorizedEvent.Address)
+	require.True(t, setAuthorizedEvent.Authorized)
+}
+
+func TestSACSetAdminEvent(t *testing.T) {
+	xdrEvent := GenerateEvent(EventTypeSetAdmin, "", randomAccount, randomIssuer.Address(), randomAsset, big.NewInt(0), passphrase)
+
+	sacEvent, err := NewStellarAssetContractEvent(&xdrEvent, passphrase)
+	require.NoError(t, err)
+	require.Equal(t, EventTypeSetAdmin, sacEvent.GetType())
+
+	setAdminEvent := sacEvent.(*SetAdminEvent)
+	require.Equal(t, randomIssuer.Address(), setAdminEvent.Admin)
+	require.Equal(t, randomAccount, setAdminEvent.NewAdmin)
+}
+
+// The fixtures below are XDR-encoded xdr.ContractEvents in the shapes the SAC
+// of USDC emits on the test network, where USDC is issued by
+// fixtureAccount(1), and fixtureAccount(2) and fixtureAccount(3) are regular
+// accounts. They are not recorded from the network but generated by
+// sacEventFixtures, in fixtures_test.go. Events without an admin in their
+// topics use the protocol 23 (CAP-67) shapes.
+var (
+	//go:embed fixtures/approve_event_xdr.bin
+	approveEventFixture []byte
+	//go:embed fixtures/set_authorized_event_xdr.bin
+	setAuthorizedEventFixture []byte
+	//go:embed fixtures/set_authorized_legacy_event_xdr.bin
+	setAuthorizedLegacyEventFixture []byte
+	//go:embed fixtures/set_admin_event_xdr.bin
+	setAdminEventFixture []byte
+	//go:embed fixtures/mint_muxed_event_xdr.bin
+	mintMuxedEventFixture []byte
+	//go:embed fixtures/transfer_muxed_event_xdr.bin
+	transferMuxedEventFixture []byte
+	//go:embed fixtures/clawback_event_xdr.bin
+	clawbackEventFixture []byte
+)
+
+func TestSACEventFixtures(t *testing.T) {
+	issuer, alice, bob := fixtureAccount(1), fixtureAccount(2), fixtureAccount(3)
+
+	parse := func(t *testing.T, fixture []byte) StellarAssetContractEvent {
+		var event xdr.ContractEvent
+		require.NoError(t, event.UnmarshalBinary(fixture))
+		sacEvent, err := NewStellarAssetContractEvent(&event, network.TestNetworkPassphrase)
+		require.NoError(t, err)
+		require.Equal(t, xdr.MustNewCreditAsset("USDC", issuer), sacEvent.GetAsset())
+		return sacEvent
+	}
+
+	t.Run("approve", func(t *testing.T) {
+		event := parse(t, approveEventFixture).(*ApproveEvent)
+		assert.Equal(t, alice, event.From)
+		assert.Equal(t, bob, event.Spender)
+		assert.EqualValues(t, 5000, event.Amount.Lo)
+		assert.EqualValues(t, 123456, event.LiveUntilLedger)
+	})
+
+	t.Run("set_authorized", func(t *testing.T) {
+		event := parse(t, setAuthorizedEventFixture).(*SetAuthorizedEvent)
+		assert.Empty(t, event.Admin)
+		assert.Equal(t, alice, event.Address)
+		assert.True(t, event.Authorized)
+	})
+
+	t.Run("set_authorized before protocol 23", func(t *testing.T) {
+		event := parse(t, setAuthorizedLegacyEventFixture).(*SetAuthorizedEvent)
+		assert.Equal(t, issuer, event.Admin)
+		assert.Equal(t, alice, event.Address)
+		assert.False(t, event.Authorized)
+	})
+
+	t.Run("set_admin", func(t *testing.T) {
+		event := parse(t, setAdminEventFixture).(*SetAdminEvent)
+		assert.Equal(t, issuer, event.Admin)
+		assert.Equal(t, bob, event.NewAdmin)
+	})
+
+	t.Run("mint to muxed id", func(t *testing.T) {
+		event := parse(t, mintMuxedEventFixture).(*MintEvent)
+		assert.Empty(t, event.Admin)
+		assert.Equal(t, alice, event.To)
+		assert.EqualValues(t, 777, event.Amount.Lo)
+		require.NotNil(t, event.ToMuxedID)
+		require.NotNil(t, event.ToMuxedID.ID)
+		assert.EqualValues(t, 42, *event.ToMuxedID.ID)
+	})
+
+	t.Run("transfer to muxed text", func(t *testing.T) {
+		event := parse(t, transferMuxedEventFixture).(*TransferEvent)
+		assert.Equal(t, alice, event.From)
+		assert.Equal(t, bob, event.To)
+		assert.EqualValues(t, 100, event.Amount.Lo)
+		require.NotNil(t, event.ToMuxedID)
+		require.NotNil(t, event.ToMuxedID.Text)
+		assert.Equal(t, "invoice-7", *event.ToMuxedID.Text)
+	})
+
+	t.Run("clawback", func(t *testing.T) {
+		event := parse(t, clawbackEventFixture).(*ClawbackEvent)
+		assert.Empty(t, event.Admin)
+		assert.Equal(t, alice, event.From)
+		assert.EqualValues(t, 250, event.Amount.Lo)
+	})
+}
+
 func TestFuzzingSACEventParser(t *testing.T) {
 	gen := randxdr.NewGenerator()
 	for i := 0; i < 100_000; i++ {
@@ -221,6 +361,18 @@ func TestFuzzingSACEventParser(t *testing.T) {
 // Test suite helpers below
 //
 
+func fixtureAccount(b byte) string {
+	var seed [32]byte
+	for i := range seed {
+		seed[i] = b
+	}
+	kp, err := keypair.FromRawSeed(seed)
+	if err != nil {
+		panic(err)
+	}
+	return kp.Address()
+}
+
 func makeEvent() xdr.ContractEvent {
 	rawContractId, err := randomAsset.ContractID(passphrase)
 	if err != nil {
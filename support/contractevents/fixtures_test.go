@@ -0,0 +1,104 @@
+package contractevents
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/require"
+)
+
+var updateFixtures = flag.Bool("update", false, "regenerate the event fixtures")
+
+// sacEventFixtures generates the fixtures of TestSACEventFixtures, keyed by
+// file name. They are the events a SAC of USDC, issued by fixtureAccount(1),
+// emits on the test network. Run
+//
+//	go test ./support/contractevents -run TestSACEventFixturesGenerated -update
+//
+// to regenerate the files after changing them here.
+func sacEventFixtures() map[string]xdr.ContractEvent {
+	issuer, alice, bob := fixtureAccount(1), fixtureAccount(2), fixtureAccount(3)
+	asset := makeAsset(xdr.MustNewCreditAsset("USDC", issuer))
+	amount := func(lo uint64) xdr.ScVal {
+		return xdr.ScVal{Type: xdr.ScValTypeScvI128, I128: &xdr.Int128Parts{Lo: xdr.Uint64(lo)}}
+	}
+	amountMap := func(lo uint64, muxedID xdr.ScVal) xdr.ScVal {
+		entries := &xdr.ScMap{
+			{Key: makeSymbol("amount"), Val: amount(lo)},
+			{Key: makeSymbol("to_muxed_id"), Val: muxedID},
+		}
+		return xdr.ScVal{Type: xdr.ScValTypeScvMap, Map: &entries}
+	}
+	id, text := xdr.Uint64(42), xdr.ScString("invoice-7")
+
+	return map[string]xdr.ContractEvent{
+		"approve_event_xdr.bin": fixtureEvent(
+			makeVec(amount(5000), makeU32(123456)),
+			makeSymbol("approve"), makeAddress(alice), makeAddress(bob), asset,
+		),
+		// since protocol 23 (CAP-67), events have no admin in their topics
+		"set_authorized_event_xdr.bin": fixtureEvent(
+			makeBool(true),
+			makeSymbol("set_authorized"), makeAddress(alice), asset,
+		),
+		"set_authorized_legacy_event_xdr.bin": fixtureEvent(
+			makeBool(false),
+			makeSymbol("set_authorized"), makeAddress(issuer), makeAddress(alice), asset,
+		),
+		"set_admin_event_xdr.bin": fixtureEvent(
+			makeAddress(bob),
+			makeSymbol("set_admin"), makeAddress(issuer), asset,
+		),
+		"mint_muxed_event_xdr.bin": fixtureEvent(
+			amountMap(777, xdr.ScVal{Type: xdr.ScValTypeScvU64, U64: &id}),
+			makeSymbol("mint"), makeAddress(alice), asset,
+		),
+		"transfer_muxed_event_xdr.bin": fixtureEvent(
+			amountMap(100, xdr.ScVal{Type: xdr.ScValTypeScvString, Str: &text}),
+			makeSymbol("transfer"), makeAddress(alice), makeAddress(bob), asset,
+		),
+		"clawback_event_xdr.bin": fixtureEvent(
+			amount(250),
+			makeSymbol("clawback"), makeAddress(alice), asset,
+		),
+	}
+}
+
+func fixtureEvent(data xdr.ScVal, topics ...xdr.ScVal) xdr.ContractEvent {
+	rawContractID, err := xdr.MustNewCreditAsset("USDC", fixtureAccount(1)).ContractID(network.TestNetworkPassphrase)
+	if err != nil {
+		panic(err)
+	}
+	contractID := xdr.ContractId(rawContractID)
+	return xdr.ContractEvent{
+		Type:       xdr.ContractEventTypeContract,
+		ContractId: &contractID,
+		Body: xdr.ContractEventBody{
+			V: 0,
+			V0: &xdr.ContractEventV0{
+				Topics: xdr.ScVec(topics),
+				Data:   data,
+			},
+		},
+	}
+}
+
+func TestSACEventFixturesGenerated(t *testing.T) {
+	for name, event := range sacEventFixtures() {
+		encoded, err := event.MarshalBinary()
+		require.NoError(t, err)
+		path := filepath.Join("fixtures", name)
+		if *updateFixtures {
+			require.NoError(t, os.WriteFile(path, encoded, 0644))
+			continue
+		}
+		fixture, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Truef(t, bytes.Equal(encoded, fixture), "%s is not generated by sacEventFixtures", name)
+	}
+}
@@ -19,6 +19,10 @@ import (
 // transfer events have no admin, so it will be ignored). This means you can
 // always pass your set of testing parameters, modify the type, and get the
 // event filled out with the details you expect.
+//
+// For approvals, `to` is the spender and the allowance never expires (its live
+// until ledger is zero). For authorization changes, `to` is the address being
+// authorized. For admin changes, `to` is the new admin.
 func GenerateEvent(
 	type_ EventType,
 	from, to, admin string,
@@ -61,6 +65,32 @@ func GenerateEvent(
 			makeAsset(asset),
 		}
 
+	case EventTypeApprove:
+		topics = []xdr.ScVal{
+			makeSymbol("approve"),
+			makeAddress(from),
+			makeAddress(to),
+			makeAsset(asset),
+		}
+		data = makeVec(data, makeU32(0))
+
+	case EventTypeSetAuthorized:
+		topics = []xdr.ScVal{
+			makeSymbol("set_authorized"),
+			makeAddress(admin),
+			makeAddress(to),
+			makeAsset(asset),
+		}
+		data = makeBool(true)
+
+	case EventTypeSetAdmin:
+		topics = []xdr.ScVal{
+			makeSymbol("set_admin"),
+			makeAddress(admin),
+			makeAsset(asset),
+		}
+		data = makeAddress(to)
+
 	default:
 		panic(fmt.Errorf("event type %v unsupported", type_))
 	}
@@ -108,6 +138,30 @@ func makeSymbol(sym string) xdr.ScVal {
 	}
 }
 
+func makeU32(value uint32) xdr.ScVal {
+	u32 := xdr.Uint32(value)
+	return xdr.ScVal{
+		Type: xdr.ScValTypeScvU32,
+		U32:  &u32,
+	}
+}
+
+func makeBool(value bool) xdr.ScVal {
+	return xdr.ScVal{
+		Type: xdr.ScValTypeScvBool,
+		B:    &value,
+	}
+}
+
+func makeVec(values ...xdr.ScVal) xdr.ScVal {
+	vec := xdr.ScVec(values)
+	vecPtr := &vec
+	return xdr.ScVal{
+		Type: xdr.ScValTypeScvVec,
+		Vec:  &vecPtr,
+	}
+}
+
 func makeBigAmount(amount *big.Int) xdr.ScVal {
 	// TODO: Better check, as MaxUint128 shouldn't be allowed
 	if amount.BitLen() > 128 {
@@ -10,9 +10,13 @@ var ErrNotMintEvent = errors.New("event is not a valid 'mint' event")
 type MintEvent struct {
 	sacEvent
 
+	// Admin is only set before protocol 23, which removed it from the topics.
 	Admin  string
 	To     string
 	Amount xdr.Int128Parts
+	// ToMuxedID is only set from protocol 23 onwards, when the mint was made
+	// to a muxed account.
+	ToMuxedID *MuxedID
 }
 
 // parseMintEvent tries to parse the given topics and value as a SAC "mint"
@@ -32,8 +36,28 @@ func (event *MintEvent) parse(topics xdr.ScVec, value xdr.ScVal) error {
 	//
 	// 	<amount> 	i128
 	//
+	// From protocol 23 onwards (CAP-67), the admin is dropped from the topics
+	// and mints to muxed accounts carry a map as their data:
+	//
+	// 	"mint"  	Symbol
+	//  <to> 		Address
+	// 	<asset>		Bytes
+	//
+	// 	<amount> 	i128 | {amount: i128, to_muxed_id: u64 | String | Bytes}
+	//
+	if len(topics) == 3 {
+		var err error
+		if event.To, err = parseAddress(topics[1]); err != nil {
+			return ErrNotMintEvent
+		}
+		if event.Amount, event.ToMuxedID, err = parseAmountData(value); err != nil {
+			return ErrNotMintEvent
+		}
+		return nil
+	}
+
 	var err error
-	event.Admin, event.To, event.Amount, err = parseBalanceChangeEvent(topics, value)
+	event.Admin, event.To, event.Amount, event.ToMuxedID, err = parseBalanceChangeEvent(topics, value)
 	if err != nil {
 		return ErrNotMintEvent
 	}
@@ -0,0 +1,68 @@
+package contractevents
+
+import (
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/xdr"
+)
+
+var ErrNotSetAuthorizedEvent = errors.New("event is not a valid 'set_authorized' event")
+
+type SetAuthorizedEvent struct {
+	sacEvent
+
+	// Admin is only set before protocol 23, which removed it from the topics.
+	Admin      string
+	Address    string
+	Authorized bool
+}
+
+// parseSetAuthorizedEvent tries to parse the given topics and value as a SAC
+// "set_authorized" event.
+//
+// Internally, it assumes that the `topics` array has already validated both the
+// function name AND the asset <--> contract ID relationship. It will return a
+// best-effort parsing even in error cases.
+func (event *SetAuthorizedEvent) parse(topics xdr.ScVec, value xdr.ScVal) error {
+	//
+	// The set_authorized event format is:
+	//
+	// 	"set_authorized"	Symbol
+	//  <admin>				Address
+	//  <id> 				Address
+	// 	<asset>				Bytes
+	//
+	// 	<authorize> 		Bool
+	//
+	// From protocol 23 onwards (CAP-67), the admin is dropped from the topics:
+	//
+	// 	"set_authorized"	Symbol
+	//  <id> 				Address
+	// 	<asset>				Bytes
+	//
+	// 	<authorize> 		Bool
+	//
+	var err error
+	switch len(topics) {
+	case 3:
+		if event.Address, err = parseAddress(topics[1]); err != nil {
+			return ErrNotSetAuthorizedEvent
+		}
+	case 4:
+		if event.Admin, err = parseAddress(topics[1]); err != nil {
+			return ErrNotSetAuthorizedEvent
+		}
+		if event.Address, err = parseAddress(topics[2]); err != nil {
+			return ErrNotSetAuthorizedEvent
+		}
+	default:
+		return ErrNotSetAuthorizedEvent
+	}
+
+	authorized, ok := value.GetB()
+	if !ok {
+		return ErrNotSetAuthorizedEvent
+	}
+	event.Authorized = authorized
+
+	return nil
+}
@@ -13,6 +13,9 @@ type TransferEvent struct {
 	From   string
 	To     string
 	Amount xdr.Int128Parts
+	// ToMuxedID is only set from protocol 23 onwards, when the transfer was
+	// made to a muxed account.
+	ToMuxedID *MuxedID
 }
 
 // parseTransferEvent tries to parse the given topics and value as a SAC
@@ -32,8 +35,12 @@ func (event *TransferEvent) parse(topics xdr.ScVec, value xdr.ScVal) error {
 	//
 	// 	<amount> 	i128
 	//
+	// From protocol 23 onwards, transfers to muxed accounts instead carry
+	//
+	// 	{amount: i128, to_muxed_id: u64 | String | Bytes}	Map
+	//
 	var err error
-	event.From, event.To, event.Amount, err = parseBalanceChangeEvent(topics, value)
+	event.From, event.To, event.Amount, event.ToMuxedID, err = parseBalanceChangeEvent(topics, value)
 	if err != nil {
 		return ErrNotTransferEvent
 	}
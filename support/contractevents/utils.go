@@ -16,6 +16,7 @@ func parseBalanceChangeEvent(topics xdr.ScVec, value xdr.ScVal) (
 	first string,
 	second string,
 	amount xdr.Int128Parts,
+	muxedID *MuxedID,
 	err error,
 ) {
 	err = ErrNotBalanceChangeEvent
@@ -23,30 +24,98 @@ func parseBalanceChangeEvent(topics xdr.ScVec, value xdr.ScVal) (
 		return
 	}
 
-	firstSc, ok := topics[1].GetAddress()
-	if !ok {
-		return
-	}
-	first, err = firstSc.String()
+	first, err = parseAddress(topics[1])
 	if err != nil {
 		err = errors.Wrap(err, ErrNotBalanceChangeEvent.Error())
 		return
 	}
 
-	secondSc, ok := topics[2].GetAddress()
-	if !ok {
-		return
-	}
-	second, err = secondSc.String()
+	second, err = parseAddress(topics[2])
 	if err != nil {
 		err = errors.Wrap(err, ErrNotBalanceChangeEvent.Error())
 		return
 	}
 
-	amount, ok = value.GetI128()
-	if !ok {
+	amount, muxedID, err = parseAmountData(value)
+	if err != nil {
 		return
 	}
 
-	return first, second, amount, nil
+	return first, second, amount, muxedID, nil
+}
+
+// MuxedID is the destination multiplexing ID which, from protocol 23 onwards,
+// may accompany the amount of "transfer" and "mint" events. Exactly one of
+// the fields is set.
+type MuxedID struct {
+	ID   *uint64
+	Text *string
+	Hash *xdr.Hash
+}
+
+// parseAmountData extracts the amount from the data of a balance change event.
+// Before protocol 23 the data is always an i128. From protocol 23 onwards it
+// may instead be a map holding the "amount" and an optional "to_muxed_id".
+func parseAmountData(value xdr.ScVal) (xdr.Int128Parts, *MuxedID, error) {
+	if amount, ok := value.GetI128(); ok {
+		return amount, nil, nil
+	}
+
+	entries, ok := value.GetMap()
+	if !ok || entries == nil {
+		return xdr.Int128Parts{}, nil, ErrNotBalanceChangeEvent
+	}
+
+	var (
+		amount      xdr.Int128Parts
+		foundAmount bool
+		muxedID     *MuxedID
+	)
+	for _, entry := range *entries {
+		key, ok := entry.Key.GetSym()
+		if !ok {
+			return xdr.Int128Parts{}, nil, ErrNotBalanceChangeEvent
+		}
+		switch key {
+		case "amount":
+			if amount, ok = entry.Val.GetI128(); !ok {
+				return xdr.Int128Parts{}, nil, ErrNotBalanceChangeEvent
+			}
+			foundAmount = true
+		case "to_muxed_id":
+			switch entry.Val.Type {
+			case xdr.ScValTypeScvU64:
+				id := uint64(entry.Val.MustU64())
+				muxedID = &MuxedID{ID: &id}
+			case xdr.ScValTypeScvString:
+				text := string(entry.Val.MustStr())
+				muxedID = &MuxedID{Text: &text}
+			case xdr.ScValTypeScvBytes:
+				raw := entry.Val.MustBytes()
+				if len(raw) != len(xdr.Hash{}) {
+					return xdr.Int128Parts{}, nil, ErrNotBalanceChangeEvent
+				}
+				var hash xdr.Hash
+				copy(hash[:], raw)
+				muxedID = &MuxedID{Hash: &hash}
+			default:
+				return xdr.Int128Parts{}, nil, ErrNotBalanceChangeEvent
+			}
+		default:
+			return xdr.Int128Parts{}, nil, ErrNotBalanceChangeEvent
+		}
+	}
+	if !foundAmount {
+		return xdr.Int128Parts{}, nil, ErrNotBalanceChangeEvent
+	}
+	return amount, muxedID, nil
+}
+
+// parseAddress extracts the address stored in an ScVal as a strkey.
+func parseAddress(value xdr.ScVal) (string, error) {
+	address, ok := value.GetAddress()
+	if !ok {
+		return "", ErrNotBalanceChangeEvent
+	}
+	return address.String()
 }
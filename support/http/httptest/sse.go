@@ -0,0 +1,157 @@
+package httptest
+
+import (
+	"fmt"
+	"net/http"
+	stdtest "net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SSEEvent is an event sent by an SSEServer.
+type SSEEvent struct {
+	ID    string
+	Event string
+	Data  string
+	// Retry, if positive, is sent as the reconnection delay of the stream.
+	Retry time.Duration
+}
+
+// SSEWriter writes server-sent events to a connection of an SSEServer.
+type SSEWriter struct {
+	http.ResponseWriter
+}
+
+// Send writes an event and flushes it to the client.
+func (w SSEWriter) Send(event SSEEvent) {
+	var b strings.Builder
+	if event.Retry > 0 {
+		fmt.Fprintf(&b, "retry: %d\n", event.Retry.Milliseconds())
+	}
+	if event.ID != "" {
+		fmt.Fprintf(&b, "id: %s\n", event.ID)
+	}
+	if event.Event != "" {
+		fmt.Fprintf(&b, "event: %s\n", event.Event)
+	}
+	for _, line := range strings.Split(event.Data, "\n") {
+		fmt.Fprintf(&b, "data: %s\n", line)
+	}
+	b.WriteString("\n")
+	w.write(b.String())
+}
+
+// Comment writes a comment, which clients ignore, e.g. as a heartbeat.
+func (w SSEWriter) Comment(text string) {
+	w.write(": " + text + "\n")
+}
+
+func (w SSEWriter) write(s string) {
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "text/event-stream")
+	}
+	w.Write([]byte(s))
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// SSEConnection scripts the response to a single request of an SSEServer. The
+// connection is closed when it returns.
+type SSEConnection func(w SSEWriter, r *http.Request)
+
+// SSEServer is a stand-in server-sent events server, answering its requests
+// with the scripted connections, in order. Requests received once the
+// connections are exhausted are left open without sending anything, like a
+// stalled stream.
+type SSEServer struct {
+	*stdtest.Server
+
+	lock        sync.Mutex
+	connections []SSEConnection
+	requests    []*http.Request
+	done        chan struct{}
+}
+
+// NewSSEServer starts an SSEServer answering with the given connections.
+func NewSSEServer(connections ...SSEConnection) *SSEServer {
+	s := &SSEServer{
+		connections: connections,
+		done:        make(chan struct{}),
+	}
+	s.Server = stdtest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+func (s *SSEServer) serve(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	s.requests = append(s.requests, r.Clone(r.Context()))
+	var connection SSEConnection
+	if len(s.connections) > 0 {
+		connection = s.connections[0]
+		s.connections = s.connections[1:]
+	}
+	s.lock.Unlock()
+
+	if connection == nil {
+		connection = SSEStall()
+	}
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+	connection(SSEWriter{w}, r)
+}
+
+// Push appends connections to the script of the server.
+func (s *SSEServer) Push(connections ...SSEConnection) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.connections = append(s.connections, connections...)
+}
+
+// Requests returns the requests received by the server so far.
+func (s *SSEServer) Requests() []*http.Request {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]*http.Request(nil), s.requests...)
+}
+
+// Close ends the stalled connections and shuts down the server.
+func (s *SSEServer) Close() {
+	close(s.done)
+	s.Server.CloseClientConnections()
+	s.Server.Close()
+}
+
+// SSEEvents returns a connection sending the events and closing the stream.
+func SSEEvents(events ...SSEEvent) SSEConnection {
+	return func(w SSEWriter, r *http.Request) {
+		for _, event := range events {
+			w.Send(event)
+		}
+	}
+}
+
+// SSEStall returns a connection sending the events and then nothing, until the
+// client disconnects.
+func SSEStall(events ...SSEEvent) SSEConnection {
+	return func(w SSEWriter, r *http.Request) {
+		for _, event := range events {
+			w.Send(event)
+		}
+		// send the headers if there was no event
+		w.write("")
+		<-r.Context().Done()
+	}
+}
+
+// SSEStatus returns a connection answering with an error status.
+func SSEStatus(status int, body string) SSEConnection {
+	return func(w SSEWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}
+}
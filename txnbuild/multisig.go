@@ -0,0 +1,372 @@
+package txnbuild
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/xdr"
+)
+
+// ThresholdLevel is the threshold of an account which the weight of the
+// signatures of a transaction must meet, for the account to be the source of
+// an operation or of the transaction.
+type ThresholdLevel int
+
+const (
+	ThresholdLevelLow ThresholdLevel = iota + 1
+	ThresholdLevelMedium
+	ThresholdLevelHigh
+)
+
+func (l ThresholdLevel) String() string {
+	switch l {
+	case ThresholdLevelLow:
+		return "low"
+	case ThresholdLevelMedium:
+		return "medium"
+	case ThresholdLevelHigh:
+		return "high"
+	default:
+		return fmt.Sprintf("ThresholdLevel(%d)", int(l))
+	}
+}
+
+// OperationThresholdLevel returns the threshold level an operation requires
+// from its source account, as stellar-core does.
+func OperationThresholdLevel(op Operation) ThresholdLevel {
+	switch op := op.(type) {
+	case *AllowTrust, *SetTrustLineFlags, *BumpSequence, *ClaimClaimableBalance,
+		*Inflation, *ExtendFootprintTtl, *RestoreFootprint:
+		return ThresholdLevelLow
+	case *AccountMerge:
+		return ThresholdLevelHigh
+	case *SetOptions:
+		if op.MasterWeight != nil || op.LowThreshold != nil || op.MediumThreshold != nil ||
+			op.HighThreshold != nil || op.Signer != nil {
+			return ThresholdLevelHigh
+		}
+		return ThresholdLevelMedium
+	default:
+		return ThresholdLevelMedium
+	}
+}
+
+// AccountSigners are the signers of an account and its thresholds, as found
+// in its account entry. The signers are addressed by their strkey: ed25519
+// public keys (G...), pre-authorized transaction hashes (T...), hash-x
+// signers (X...) and signed payload signers (P...). The master key must be
+// included with its weight, unless the weight is 0.
+type AccountSigners struct {
+	Signers         SignerSummary
+	LowThreshold    Threshold
+	MediumThreshold Threshold
+	HighThreshold   Threshold
+}
+
+// required returns the weight required for a threshold level. A signature is
+// always required, even when the threshold is 0.
+func (a AccountSigners) required(level ThresholdLevel) int32 {
+	switch level {
+	case ThresholdLevelLow:
+		return max(int32(a.LowThreshold), 1)
+	case ThresholdLevelMedium:
+		return max(int32(a.MediumThreshold), 1)
+	default:
+		return max(int32(a.HighThreshold), 1)
+	}
+}
+
+// AccountSignatures is the weight of the signatures of a transaction for one
+// of its source accounts, compared to the weight the account requires.
+type AccountSignatures struct {
+	Account string
+	// Level is the highest threshold level the operations of the account
+	// require.
+	Level ThresholdLevel
+	// Required is the weight of the threshold of Level.
+	Required int32
+	// Collected is the weight of the signers which signed the transaction.
+	Collected int32
+	// Signed are the signers which signed the transaction, or pre-authorized
+	// it, by decreasing weight.
+	Signed []string
+	// Missing are the signers which did not sign the transaction, by
+	// decreasing weight.
+	Missing []string
+}
+
+// Met reports whether the signatures meet the threshold of the account.
+func (a AccountSignatures) Met() bool {
+	return a.Collected >= a.Required
+}
+
+// SignatureAnalysis is the analysis of the signatures of a transaction.
+type SignatureAnalysis struct {
+	// Accounts are the source accounts of the transaction and its operations,
+	// the source account of the transaction first.
+	Accounts []AccountSignatures
+	// Unused are the signatures which match none of the signers, which
+	// stellar-core rejects with tx_bad_auth_extra.
+	Unused []xdr.DecoratedSignature
+}
+
+// Met reports whether the signatures meet the thresholds of every account,
+// and the transaction has no signature unused.
+func (a SignatureAnalysis) Met() bool {
+	for _, account := range a.Accounts {
+		if !account.Met() {
+			return false
+		}
+	}
+	return len(a.Unused) == 0
+}
+
+// AnalyzeSignatures analyzes whether the signatures of a transaction meet the
+// thresholds of its source accounts: the source account of the transaction
+// requires the low threshold, and the source account of every operation the
+// threshold level of the operation. accounts holds the signers and
+// thresholds of every source account.
+func AnalyzeSignatures(tx *Transaction, network string, accounts map[string]AccountSigners) (SignatureAnalysis, error) {
+	source, err := accountAddress(tx.SourceAccount().AccountID)
+	if err != nil {
+		return SignatureAnalysis{}, errors.Wrap(err, "invalid source account")
+	}
+	order := []string{source}
+	levels := map[string]ThresholdLevel{source: ThresholdLevelLow}
+	for i, op := range tx.Operations() {
+		opSource := source
+		if op.GetSourceAccount() != "" {
+			if opSource, err = accountAddress(op.GetSourceAccount()); err != nil {
+				return SignatureAnalysis{}, errors.Wrapf(err, "invalid source account of operation %d", i)
+			}
+		}
+		if _, ok := levels[opSource]; !ok {
+			order = append(order, opSource)
+		}
+		levels[opSource] = max(levels[opSource], OperationThresholdLevel(op))
+	}
+
+	hash, err := tx.Hash(network)
+	if err != nil {
+		return SignatureAnalysis{}, err
+	}
+	return analyzeSignatures(hash, tx.Signatures(), order, levels, accounts)
+}
+
+// AnalyzeFeeBumpSignatures analyzes whether the signatures of a fee bump
+// transaction meet the low threshold of its fee account. The signatures of
+// the inner transaction are analyzed with AnalyzeSignatures.
+func AnalyzeFeeBumpSignatures(tx *FeeBumpTransaction, network string, accounts map[string]AccountSigners) (SignatureAnalysis, error) {
+	feeAccount, err := accountAddress(tx.FeeAccount())
+	if err != nil {
+		return SignatureAnalysis{}, errors.Wrap(err, "invalid fee account")
+	}
+	hash, err := tx.Hash(network)
+	if err != nil {
+		return SignatureAnalysis{}, err
+	}
+	levels := map[string]ThresholdLevel{feeAccount: ThresholdLevelLow}
+	return analyzeSignatures(hash, tx.Signatures(), []string{feeAccount}, levels, accounts)
+}
+
+func analyzeSignatures(
+	hash [32]byte,
+	signatures []xdr.DecoratedSignature,
+	order []string,
+	levels map[string]ThresholdLevel,
+	accounts map[string]AccountSigners,
+) (SignatureAnalysis, error) {
+	used := make([]bool, len(signatures))
+	analysis := SignatureAnalysis{}
+	for _, account := range order {
+		signers, ok := accounts[account]
+		if !ok {
+			return SignatureAnalysis{}, errors.Errorf("signers of account %s are missing", account)
+		}
+		result := AccountSignatures{
+			Account:  account,
+			Level:    levels[account],
+			Required: signers.required(levels[account]),
+		}
+
+		for signer, weight := range signers.Signers {
+			if weight <= 0 {
+				continue
+			}
+			signed, err := signerSigned(signer, hash, signatures, used)
+			if err != nil {
+				return SignatureAnalysis{}, err
+			}
+			if signed {
+				// stellar-core caps the weight of signers to 255
+				result.Collected += min(weight, math.MaxUint8)
+				result.Signed = append(result.Signed, signer)
+			} else {
+				result.Missing = append(result.Missing, signer)
+			}
+		}
+		byWeight := func(a, b string) int {
+			return cmp.Or(cmp.Compare(signers.Signers[b], signers.Signers[a]), cmp.Compare(a, b))
+		}
+		slices.SortFunc(result.Signed, byWeight)
+		slices.SortFunc(result.Missing, byWeight)
+		analysis.Accounts = append(analysis.Accounts, result)
+	}
+
+	for i, signature := range signatures {
+		if !used[i] {
+			analysis.Unused = append(analysis.Unused, signature)
+		}
+	}
+	return analysis, nil
+}
+
+// signerSigned reports whether a signer signed the transaction of a hash,
+// marking the signatures of the signer as used.
+func signerSigned(signer string, hash [32]byte, signatures []xdr.DecoratedSignature, used []bool) (bool, error) {
+	version, err := strkey.Version(signer)
+	if err != nil {
+		return false, errors.Wrapf(err, "invalid signer %s", signer)
+	}
+
+	var matches func(xdr.DecoratedSignature) bool
+	switch version {
+	case strkey.VersionByteAccountID:
+		kp, err := keypair.ParseAddress(signer)
+		if err != nil {
+			return false, errors.Wrapf(err, "invalid signer %s", signer)
+		}
+		matches = func(signature xdr.DecoratedSignature) bool {
+			return signature.Hint == xdr.SignatureHint(kp.Hint()) && kp.Verify(hash[:], signature.Signature) == nil
+		}
+	case strkey.VersionByteHashTx:
+		// a pre-authorized transaction requires no signature
+		preAuthHash, err := strkey.Decode(strkey.VersionByteHashTx, signer)
+		if err != nil {
+			return false, errors.Wrapf(err, "invalid signer %s", signer)
+		}
+		return bytes.Equal(preAuthHash, hash[:]), nil
+	case strkey.VersionByteHashX:
+		hashX, err := strkey.Decode(strkey.VersionByteHashX, signer)
+		if err != nil {
+			return false, errors.Wrapf(err, "invalid signer %s", signer)
+		}
+		var hint xdr.SignatureHint
+		copy(hint[:], hashX[len(hashX)-len(hint):])
+		matches = func(signature xdr.DecoratedSignature) bool {
+			preimageHash := sha256.Sum256(signature.Signature)
+			return signature.Hint == hint && bytes.Equal(preimageHash[:], hashX)
+		}
+	case strkey.VersionByteSignedPayload:
+		signedPayload, err := strkey.DecodeSignedPayload(signer)
+		if err != nil {
+			return false, errors.Wrapf(err, "invalid signer %s", signer)
+		}
+		kp, err := keypair.ParseAddress(signedPayload.Signer())
+		if err != nil {
+			return false, errors.Wrapf(err, "invalid signer %s", signer)
+		}
+		payload := signedPayload.Payload()
+		hint := xdr.NewDecoratedSignatureForPayload(nil, kp.Hint(), payload).Hint
+		matches = func(signature xdr.DecoratedSignature) bool {
+			return signature.Hint == hint && kp.Verify(payload, signature.Signature) == nil
+		}
+	default:
+		return false, errors.Errorf("invalid signer %s", signer)
+	}
+
+	signed := false
+	for i, signature := range signatures {
+		if matches(signature) {
+			used[i], signed = true, true
+		}
+	}
+	return signed, nil
+}
+
+// accountAddress returns the address (G...) of an account, or of the
+// underlying account of a muxed account.
+func accountAddress(address string) (string, error) {
+	account, err := xdr.AddressToMuxedAccount(address)
+	if err != nil {
+		return "", err
+	}
+	accountID := account.ToAccountId()
+	return accountID.Address(), nil
+}
+
+// MergeSignatures returns a transaction with the signatures of all the
+// transactions, which must be copies of the same transaction signed by
+// different parties. Signatures found in several copies are only kept once.
+func MergeSignatures(txs ...*Transaction) (*Transaction, error) {
+	if len(txs) == 0 {
+		return nil, errors.New("no transaction to merge")
+	}
+	envelopes := make([]xdr.TransactionEnvelope, len(txs))
+	for i, tx := range txs {
+		envelopes[i] = tx.clone(nil).ToXDR()
+	}
+	signatures, err := mergeSignatures(envelopes, func(i int) []xdr.DecoratedSignature {
+		return txs[i].Signatures()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return txs[0].clone(signatures), nil
+}
+
+// MergeFeeBumpSignatures returns a fee bump transaction with the signatures
+// of all the fee bump transactions, which must be copies of the same fee bump
+// transaction signed by different parties. Signatures found in several
+// copies are only kept once.
+func MergeFeeBumpSignatures(txs ...*FeeBumpTransaction) (*FeeBumpTransaction, error) {
+	if len(txs) == 0 {
+		return nil, errors.New("no transaction to merge")
+	}
+	envelopes := make([]xdr.TransactionEnvelope, len(txs))
+	for i, tx := range txs {
+		envelopes[i] = tx.clone(nil).ToXDR()
+	}
+	signatures, err := mergeSignatures(envelopes, func(i int) []xdr.DecoratedSignature {
+		return txs[i].Signatures()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return txs[0].clone(signatures), nil
+}
+
+// mergeSignatures checks that the unsigned envelopes are the same, and
+// returns the union of the signatures of the transactions.
+func mergeSignatures(unsigned []xdr.TransactionEnvelope, signatures func(int) []xdr.DecoratedSignature) ([]xdr.DecoratedSignature, error) {
+	var first []byte
+	var merged []xdr.DecoratedSignature
+	seen := map[string]bool{}
+	for i, envelope := range unsigned {
+		encoded, err := envelope.MarshalBinary()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to encode transaction")
+		}
+		if i == 0 {
+			first = encoded
+		} else if !bytes.Equal(encoded, first) {
+			return nil, errors.Errorf("transaction %d differs from the first transaction", i)
+		}
+
+		for _, signature := range signatures(i) {
+			key := string(signature.Hint[:]) + string(signature.Signature)
+			if !seen[key] {
+				seen[key] = true
+				merged = append(merged, signature)
+			}
+		}
+	}
+	return merged, nil
+}
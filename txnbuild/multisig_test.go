@@ -0,0 +1,201 @@
+package txnbuild
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/strkey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOperationThresholdLevel(t *testing.T) {
+	assert.Equal(t, ThresholdLevelLow, OperationThresholdLevel(&BumpSequence{}))
+	assert.Equal(t, ThresholdLevelLow, OperationThresholdLevel(&SetTrustLineFlags{}))
+	assert.Equal(t, ThresholdLevelMedium, OperationThresholdLevel(&Payment{}))
+	assert.Equal(t, ThresholdLevelMedium, OperationThresholdLevel(&SetOptions{HomeDomain: NewHomeDomain("example.com")}))
+	assert.Equal(t, ThresholdLevelHigh, OperationThresholdLevel(&SetOptions{MasterWeight: NewThreshold(0)}))
+	assert.Equal(t, ThresholdLevelHigh, OperationThresholdLevel(&AccountMerge{}))
+	assert.Equal(t, "high", ThresholdLevelHigh.String())
+}
+
+func multisigTransaction(t *testing.T, source, opSource string) *Transaction {
+	sourceAccount := NewSimpleAccount(source, 1)
+	tx, err := NewTransaction(
+		TransactionParams{
+			SourceAccount: &sourceAccount,
+			Operations: []Operation{
+				&Payment{Destination: opSource, Amount: "10", Asset: NativeAsset{}},
+				&SetOptions{HomeDomain: NewHomeDomain("example.com"), SourceAccount: opSource},
+				&SetOptions{MasterWeight: NewThreshold(1), SourceAccount: opSource},
+			},
+			BaseFee:       MinBaseFee,
+			Preconditions: Preconditions{TimeBounds: NewInfiniteTimeout()},
+		},
+	)
+	require.NoError(t, err)
+	return tx
+}
+
+func TestAnalyzeSignatures(t *testing.T) {
+	kp0, kp1, kp2 := newKeypair0(), newKeypair1(), newKeypair2()
+	tx := multisigTransaction(t, kp0.Address(), kp1.Address())
+	accounts := map[string]AccountSigners{
+		kp0.Address(): {
+			Signers:         SignerSummary{kp0.Address(): 1},
+			MediumThreshold: 1,
+			HighThreshold:   1,
+		},
+		kp1.Address(): {
+			Signers:         SignerSummary{kp1.Address(): 1, kp2.Address(): 2},
+			LowThreshold:    1,
+			MediumThreshold: 2,
+			HighThreshold:   3,
+		},
+	}
+
+	analysis, err := AnalyzeSignatures(tx, network.TestNetworkPassphrase, accounts)
+	require.NoError(t, err)
+	assert.False(t, analysis.Met())
+	assert.Equal(t, []AccountSignatures{
+		{
+			Account:  kp0.Address(),
+			Level:    ThresholdLevelMedium,
+			Required: 1,
+			Missing:  []string{kp0.Address()},
+		},
+		{
+			Account:  kp1.Address(),
+			Level:    ThresholdLevelHigh,
+			Required: 3,
+			Missing:  []string{kp2.Address(), kp1.Address()},
+		},
+	}, analysis.Accounts)
+
+	// the signers sign their own copy of the transaction
+	signed0, err := tx.Sign(network.TestNetworkPassphrase, kp0)
+	require.NoError(t, err)
+	signed1, err := tx.Sign(network.TestNetworkPassphrase, kp1)
+	require.NoError(t, err)
+	analysis, err = AnalyzeSignatures(signed1, network.TestNetworkPassphrase, accounts)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), analysis.Accounts[1].Collected)
+	assert.False(t, analysis.Accounts[1].Met())
+
+	signed2, err := tx.Sign(network.TestNetworkPassphrase, kp2, kp0)
+	require.NoError(t, err)
+	merged, err := MergeSignatures(signed0, signed1, signed2)
+	require.NoError(t, err)
+	assert.Len(t, merged.Signatures(), 3)
+	analysis, err = AnalyzeSignatures(merged, network.TestNetworkPassphrase, accounts)
+	require.NoError(t, err)
+	assert.True(t, analysis.Met())
+	assert.Equal(t, []string{kp2.Address(), kp1.Address()}, analysis.Accounts[1].Signed)
+	assert.Equal(t, int32(3), analysis.Accounts[1].Collected)
+
+	// a signature of a signer of no account is extra
+	extra, err := merged.Sign(network.TestNetworkPassphrase, keypair.MustRandom())
+	require.NoError(t, err)
+	analysis, err = AnalyzeSignatures(extra, network.TestNetworkPassphrase, accounts)
+	require.NoError(t, err)
+	assert.False(t, analysis.Met())
+	assert.Len(t, analysis.Unused, 1)
+
+	delete(accounts, kp1.Address())
+	_, err = AnalyzeSignatures(merged, network.TestNetworkPassphrase, accounts)
+	assert.EqualError(t, err, "signers of account "+kp1.Address()+" are missing")
+}
+
+func TestAnalyzeSignaturesSignerTypes(t *testing.T) {
+	kp0, kp1 := newKeypair0(), newKeypair1()
+	tx := multisigTransaction(t, kp0.Address(), kp0.Address())
+	hash, err := tx.Hash(network.TestNetworkPassphrase)
+	require.NoError(t, err)
+
+	preAuthTx, err := strkey.Encode(strkey.VersionByteHashTx, hash[:])
+	require.NoError(t, err)
+	preimage := []byte("preimage")
+	preimageHash := sha256.Sum256(preimage)
+	hashX, err := strkey.Encode(strkey.VersionByteHashX, preimageHash[:])
+	require.NoError(t, err)
+	payload := []byte("payload")
+	signedPayload, err := strkey.NewSignedPayload(kp1.Address(), payload)
+	require.NoError(t, err)
+	signedPayloadSigner, err := signedPayload.Encode()
+	require.NoError(t, err)
+
+	for _, testCase := range []struct {
+		name   string
+		signer string
+		sign   func(*Transaction) *Transaction
+	}{
+		{"pre-authorized transaction", preAuthTx, func(tx *Transaction) *Transaction { return tx }},
+		{"hash-x", hashX, func(tx *Transaction) *Transaction {
+			tx, err := tx.SignHashX(preimage)
+			require.NoError(t, err)
+			return tx
+		}},
+		{"signed payload", signedPayloadSigner, func(tx *Transaction) *Transaction {
+			signature, err := kp1.SignPayloadDecorated(payload)
+			require.NoError(t, err)
+			tx, err = tx.AddSignatureDecorated(signature)
+			require.NoError(t, err)
+			return tx
+		}},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			accounts := map[string]AccountSigners{
+				kp0.Address(): {
+					Signers:       SignerSummary{kp0.Address(): 1, testCase.signer: 10},
+					HighThreshold: 10,
+				},
+			}
+			analysis, err := AnalyzeSignatures(testCase.sign(tx), network.TestNetworkPassphrase, accounts)
+			require.NoError(t, err)
+			assert.True(t, analysis.Met())
+			assert.Equal(t, []string{testCase.signer}, analysis.Accounts[0].Signed)
+			assert.Equal(t, []string{kp0.Address()}, analysis.Accounts[0].Missing)
+		})
+	}
+}
+
+func TestAnalyzeFeeBumpSignatures(t *testing.T) {
+	kp0, kp1 := newKeypair0(), newKeypair1()
+	inner, err := multisigTransaction(t, kp0.Address(), kp0.Address()).Sign(network.TestNetworkPassphrase, kp0)
+	require.NoError(t, err)
+	feeBump, err := NewFeeBumpTransaction(FeeBumpTransactionParams{
+		Inner:      inner,
+		FeeAccount: kp1.Address(),
+		BaseFee:    MinBaseFee,
+	})
+	require.NoError(t, err)
+	accounts := map[string]AccountSigners{
+		kp1.Address(): {Signers: SignerSummary{kp1.Address(): 1}},
+	}
+
+	analysis, err := AnalyzeFeeBumpSignatures(feeBump, network.TestNetworkPassphrase, accounts)
+	require.NoError(t, err)
+	assert.False(t, analysis.Met())
+
+	signed, err := feeBump.Sign(network.TestNetworkPassphrase, kp1)
+	require.NoError(t, err)
+	merged, err := MergeFeeBumpSignatures(feeBump, signed, signed)
+	require.NoError(t, err)
+	assert.Len(t, merged.Signatures(), 1)
+	analysis, err = AnalyzeFeeBumpSignatures(merged, network.TestNetworkPassphrase, accounts)
+	require.NoError(t, err)
+	assert.True(t, analysis.Met())
+	assert.Equal(t, ThresholdLevelLow, analysis.Accounts[0].Level)
+}
+
+func TestMergeSignaturesDifferentTransactions(t *testing.T) {
+	kp0, kp1 := newKeypair0(), newKeypair1()
+	tx0 := multisigTransaction(t, kp0.Address(), kp1.Address())
+	tx1 := multisigTransaction(t, kp0.Address(), kp0.Address())
+	_, err := MergeSignatures(tx0, tx1)
+	assert.EqualError(t, err, "transaction 1 differs from the first transaction")
+	_, err = MergeSignatures()
+	assert.EqualError(t, err, "no transaction to merge")
+}
@@ -0,0 +1,76 @@
+package txnbuild
+
+import (
+	"context"
+	"crypto/sha256"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/xdr"
+)
+
+// SignAuthEntry returns a copy of a Soroban authorization entry signed by the
+// given signer, valid until the ledger validUntilLedger (inclusive). The
+// signature is the one expected by Stellar accounts: a vector holding a map of
+// the public key and signature of the signer. Entries with source account
+// credentials are authorized by the signature of the transaction, and are
+// returned unchanged.
+func SignAuthEntry(
+	ctx context.Context,
+	entry xdr.SorobanAuthorizationEntry,
+	validUntilLedger uint32,
+	signer keypair.Signer,
+	networkPassphrase string,
+) (xdr.SorobanAuthorizationEntry, error) {
+	if entry.Credentials.Type != xdr.SorobanCredentialsTypeSorobanCredentialsAddress {
+		return entry, nil
+	}
+	// copy the credentials, which the entry points to
+	credentials := *entry.Credentials.Address
+	credentials.SignatureExpirationLedger = xdr.Uint32(validUntilLedger)
+
+	preimage := xdr.HashIdPreimage{
+		Type: xdr.EnvelopeTypeEnvelopeTypeSorobanAuthorization,
+		SorobanAuthorization: &xdr.HashIdPreimageSorobanAuthorization{
+			NetworkId:                 network.ID(networkPassphrase),
+			Nonce:                     credentials.Nonce,
+			SignatureExpirationLedger: credentials.SignatureExpirationLedger,
+			Invocation:                entry.RootInvocation,
+		},
+	}
+	payload, err := preimage.MarshalBinary()
+	if err != nil {
+		return entry, errors.Wrap(err, "failed to encode authorization preimage")
+	}
+	hash := sha256.Sum256(payload)
+	signature, err := signer.SignDecorated(ctx, hash[:])
+	if err != nil {
+		return entry, errors.Wrap(err, "failed to sign authorization entry")
+	}
+	publicKey, err := strkey.Decode(strkey.VersionByteAccountID, signer.PublicKey())
+	if err != nil {
+		return entry, errors.Wrap(err, "invalid signer public key")
+	}
+
+	publicKeySym, signatureSym := xdr.ScSymbol("public_key"), xdr.ScSymbol("signature")
+	publicKeyBytes, signatureBytes := xdr.ScBytes(publicKey), xdr.ScBytes(signature.Signature)
+	signatureMap := &xdr.ScMap{
+		{
+			Key: xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &publicKeySym},
+			Val: xdr.ScVal{Type: xdr.ScValTypeScvBytes, Bytes: &publicKeyBytes},
+		},
+		{
+			Key: xdr.ScVal{Type: xdr.ScValTypeScvSymbol, Sym: &signatureSym},
+			Val: xdr.ScVal{Type: xdr.ScValTypeScvBytes, Bytes: &signatureBytes},
+		},
+	}
+	signatures := &xdr.ScVec{
+		xdr.ScVal{Type: xdr.ScValTypeScvMap, Map: &signatureMap},
+	}
+	credentials.Signature = xdr.ScVal{Type: xdr.ScValTypeScvVec, Vec: &signatures}
+
+	entry.Credentials.Address = &credentials
+	return entry, nil
+}
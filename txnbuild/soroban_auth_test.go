@@ -0,0 +1,125 @@
+package txnbuild
+
+import (
+	"context"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignWithSigners(t *testing.T) {
+	kp0, kp1 := newKeypair0(), newKeypair1()
+	sourceAccount := NewSimpleAccount(kp0.Address(), 1)
+	tx, err := NewTransaction(
+		TransactionParams{
+			SourceAccount: &sourceAccount,
+			Operations:    []Operation{&Inflation{}},
+			BaseFee:       MinBaseFee,
+			Preconditions: Preconditions{TimeBounds: NewInfiniteTimeout()},
+		},
+	)
+	require.NoError(t, err)
+
+	signed, err := tx.Sign(network.TestNetworkPassphrase, kp0)
+	require.NoError(t, err)
+	signedWithSigners, err := tx.SignWithSigners(context.Background(), network.TestNetworkPassphrase, kp0.Signer())
+	require.NoError(t, err)
+	assert.Equal(t, signed.Signatures(), signedWithSigners.Signatures())
+	assert.Empty(t, tx.Signatures())
+
+	feeBump, err := NewFeeBumpTransaction(FeeBumpTransactionParams{
+		Inner:      signed,
+		FeeAccount: kp1.Address(),
+		BaseFee:    MinBaseFee,
+	})
+	require.NoError(t, err)
+	signedFeeBump, err := feeBump.Sign(network.TestNetworkPassphrase, kp1)
+	require.NoError(t, err)
+	signedFeeBumpWithSigners, err := feeBump.SignWithSigners(context.Background(), network.TestNetworkPassphrase, kp1.Signer())
+	require.NoError(t, err)
+	assert.Equal(t, signedFeeBump.Signatures(), signedFeeBumpWithSigners.Signatures())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = tx.SignWithSigners(ctx, network.TestNetworkPassphrase, kp0.Signer())
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestSignAuthEntry(t *testing.T) {
+	kp := newKeypair0()
+	accountID := xdr.MustAddress(kp.Address())
+	contractID := xdr.ContractId{1}
+	invocation := xdr.SorobanAuthorizedInvocation{
+		Function: xdr.SorobanAuthorizedFunction{
+			Type: xdr.SorobanAuthorizedFunctionTypeSorobanAuthorizedFunctionTypeContractFn,
+			ContractFn: &xdr.InvokeContractArgs{
+				ContractAddress: xdr.ScAddress{
+					Type:       xdr.ScAddressTypeScAddressTypeContract,
+					ContractId: &contractID,
+				},
+				FunctionName: "transfer",
+			},
+		},
+	}
+	entry := xdr.SorobanAuthorizationEntry{
+		Credentials: xdr.SorobanCredentials{
+			Type: xdr.SorobanCredentialsTypeSorobanCredentialsAddress,
+			Address: &xdr.SorobanAddressCredentials{
+				Address: xdr.ScAddress{
+					Type:      xdr.ScAddressTypeScAddressTypeAccount,
+					AccountId: &accountID,
+				},
+				Nonce:     42,
+				Signature: xdr.ScVal{Type: xdr.ScValTypeScvVoid},
+			},
+		},
+		RootInvocation: invocation,
+	}
+
+	signed, err := SignAuthEntry(context.Background(), entry, 1000, kp.Signer(), network.TestNetworkPassphrase)
+	require.NoError(t, err)
+	// the entry signed is not modified
+	assert.Equal(t, xdr.Uint32(0), entry.Credentials.Address.SignatureExpirationLedger)
+	assert.Equal(t, xdr.ScValTypeScvVoid, entry.Credentials.Address.Signature.Type)
+
+	credentials := signed.Credentials.Address
+	assert.Equal(t, xdr.Uint32(1000), credentials.SignatureExpirationLedger)
+	signatures, ok := credentials.Signature.GetVec()
+	require.True(t, ok)
+	require.Len(t, *signatures, 1)
+	signature, ok := (*signatures)[0].GetMap()
+	require.True(t, ok)
+	require.Len(t, *signature, 2)
+	assert.Equal(t, xdr.ScSymbol("public_key"), *(*signature)[0].Key.Sym)
+	assert.Equal(t, xdr.ScSymbol("signature"), *(*signature)[1].Key.Sym)
+
+	preimage, err := xdr.HashIdPreimage{
+		Type: xdr.EnvelopeTypeEnvelopeTypeSorobanAuthorization,
+		SorobanAuthorization: &xdr.HashIdPreimageSorobanAuthorization{
+			NetworkId:                 network.ID(network.TestNetworkPassphrase),
+			Nonce:                     42,
+			SignatureExpirationLedger: 1000,
+			Invocation:                invocation,
+		},
+	}.MarshalBinary()
+	require.NoError(t, err)
+	hash := sha256.Sum256(preimage)
+	address, err := strkey.Encode(strkey.VersionByteAccountID, *(*signature)[0].Val.Bytes)
+	require.NoError(t, err)
+	assert.Equal(t, kp.Address(), address)
+	assert.NoError(t, kp.Verify(hash[:], *(*signature)[1].Val.Bytes))
+
+	// source account credentials are authorized by the transaction signature
+	sourceEntry := xdr.SorobanAuthorizationEntry{
+		Credentials:    xdr.SorobanCredentials{Type: xdr.SorobanCredentialsTypeSorobanCredentialsSourceAccount},
+		RootInvocation: invocation,
+	}
+	signed, err = SignAuthEntry(context.Background(), sourceEntry, 1000, kp.Signer(), network.TestNetworkPassphrase)
+	require.NoError(t, err)
+	assert.Equal(t, sourceEntry, signed)
+}
@@ -11,6 +11,7 @@ package txnbuild
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
@@ -53,6 +54,20 @@ func concatSignatures(
 	networkStr string,
 	signatures []xdr.DecoratedSignature,
 	kps ...*keypair.Full,
+) ([]xdr.DecoratedSignature, error) {
+	signers := make([]keypair.Signer, len(kps))
+	for i, kp := range kps {
+		signers[i] = kp.Signer()
+	}
+	return concatSigners(context.Background(), e, networkStr, signatures, signers...)
+}
+
+func concatSigners(
+	ctx context.Context,
+	e xdr.TransactionEnvelope,
+	networkStr string,
+	signatures []xdr.DecoratedSignature,
+	signers ...keypair.Signer,
 ) ([]xdr.DecoratedSignature, error) {
 	// Hash the transaction
 	h, err := network.HashTransactionInEnvelope(e, networkStr)
@@ -63,12 +78,12 @@ func concatSignatures(
 	extended := make(
 		[]xdr.DecoratedSignature,
 		len(signatures),
-		len(signatures)+len(kps),
+		len(signatures)+len(signers),
 	)
 	copy(extended, signatures)
 	// Sign the hash
-	for _, kp := range kps {
-		sig, err := kp.SignDecorated(h[:])
+	for _, signer := range signers {
+		sig, err := signer.SignDecorated(ctx, h[:])
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to sign transaction")
 		}
@@ -302,6 +317,18 @@ func (t *Transaction) Sign(network string, kps ...*keypair.Full) (*Transaction,
 	return t.clone(extendedSignatures), nil
 }
 
+// SignWithSigners returns a new Transaction instance which extends the current
+// instance with additional signatures made by the given signers, e.g. keys held
+// by a hardware security module or a remote signing service.
+func (t *Transaction) SignWithSigners(ctx context.Context, network string, signers ...keypair.Signer) (*Transaction, error) {
+	extendedSignatures, err := concatSigners(ctx, t.envelope, network, t.Signatures(), signers...)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.clone(extendedSignatures), nil
+}
+
 // SignWithKeyString returns a new Transaction instance which extends the current instance
 // with additional signatures derived from the given list of private key strings.
 func (t *Transaction) SignWithKeyString(network string, keys ...string) (*Transaction, error) {
@@ -504,6 +531,18 @@ func (t *FeeBumpTransaction) Sign(network string, kps ...*keypair.Full) (*FeeBum
 	return t.clone(extendedSignatures), nil
 }
 
+// SignWithSigners returns a new FeeBumpTransaction instance which extends the
+// current instance with additional signatures made by the given signers, e.g.
+// keys held by a hardware security module or a remote signing service.
+func (t *FeeBumpTransaction) SignWithSigners(ctx context.Context, network string, signers ...keypair.Signer) (*FeeBumpTransaction, error) {
+	extendedSignatures, err := concatSigners(ctx, t.envelope, network, t.Signatures(), signers...)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.clone(extendedSignatures), nil
+}
+
 // SignWithKeyString returns a new FeeBumpTransaction instance which extends the current instance
 // with additional signatures derived from the given list of private key strings.
 func (t *FeeBumpTransaction) SignWithKeyString(network string, keys ...string) (*FeeBumpTransaction, error) {